@@ -37,6 +37,111 @@ const (
 	JobStatusFailed    = "Failed"
 )
 
+// Failure reasons for WerfBundleStatus.FailureReason
+const (
+	// FailureReasonVerificationFailed means the bundle signature could not be verified,
+	// so no Job was created.
+	FailureReasonVerificationFailed = "VerificationFailed"
+	// FailureReasonAuthenticationFailed means the registry credentials are missing, invalid
+	// or rejected by the registry. Retried when the Secret or the spec changes.
+	FailureReasonAuthenticationFailed = "AuthenticationFailed"
+	// FailureReasonRepositoryNotFound means the registry has no repository at spec.registry.url.
+	FailureReasonRepositoryNotFound = "RepositoryNotFound"
+	// FailureReasonInvalidRepository means spec.registry.url (or a mirror URL) isn't a valid
+	// OCI repository reference. Retried when the spec changes.
+	FailureReasonInvalidRepository = "InvalidRepository"
+	// FailureReasonRegistryUnavailable means registry polls kept failing after
+	// spec.registry.retryPolicy.maxAttempts attempts.
+	FailureReasonRegistryUnavailable = "RegistryUnavailable"
+	// FailureReasonTooManyTags means the repository has more tags than the operator lists
+	// (--registry-max-tags).
+	FailureReasonTooManyTags = "TooManyTags"
+	// FailureReasonTLSFailed means the registry certificate could not be verified, or the
+	// certificates referenced by spec.registry.tls are missing or invalid.
+	FailureReasonTLSFailed = "TLSFailed"
+	// FailureReasonAttestationFailed means the bundle digest lacks a required attestation
+	// (spec.attestations), so no Job was created.
+	FailureReasonAttestationFailed = "AttestationFailed"
+	// FailureReasonInvalidBundle means the tag points to an artifact that isn't a werf bundle
+	// (a Helm chart in OCI layout), such as a container image, so no Job was created.
+	FailureReasonInvalidBundle = "InvalidBundle"
+	// FailureReasonPinnedVersionNotFound means the tag pinned by spec.version isn't in the
+	// repository.
+	FailureReasonPinnedVersionNotFound = "PinnedVersionNotFound"
+	// FailureReasonInvalidSchedule means spec.schedule has an invalid cron expression or time
+	// zone.
+	FailureReasonInvalidSchedule = "InvalidSchedule"
+	// FailureReasonInvalidMinTagAge means spec.registry.minTagAge isn't a valid duration
+	// (e.g., it overflows).
+	FailureReasonInvalidMinTagAge = "InvalidMinTagAge"
+	// FailureReasonRollbackFailed means a converge failed and so did the rollback to the last
+	// successfully deployed version (spec.rollback.onFailure).
+	FailureReasonRollbackFailed = "RollbackFailed"
+)
+
+// Condition types of WerfBundleStatus.Conditions
+const (
+	// ConditionUpdateAvailable is True when spec.version pins a version other than the one
+	// tag selection would deploy (status.latestAvailableTag). Only set while pinned.
+	ConditionUpdateAvailable = "UpdateAvailable"
+	// ConditionAwaitingApproval is True while a version waits for approval
+	// (spec.approval.mode Manual). Only set in Manual mode.
+	ConditionAwaitingApproval = "AwaitingApproval"
+	// ConditionDeploymentHeld is True while a version waits for a deployment window or the
+	// end of a change freeze (spec.schedule). Only set while spec.schedule is set.
+	ConditionDeploymentHeld = "DeploymentHeld"
+	// ConditionRolledBack is True while the deployed version is a rollback after a failed
+	// converge (spec.rollback.onFailure), and False if the rollback failed. Removed once a
+	// new version is deployed.
+	ConditionRolledBack = "RolledBack"
+)
+
+// Reasons of the RolledBack condition
+const (
+	// ReasonRollbackInProgress means the rollback Job is running.
+	ReasonRollbackInProgress = "RollbackInProgress"
+	// ReasonRollbackSucceeded means the last successfully deployed version was deployed again.
+	ReasonRollbackSucceeded = "RollbackSucceeded"
+	// ReasonRollbackFailed means the rollback Job failed too.
+	ReasonRollbackFailed = "RollbackFailed"
+)
+
+// Reasons of the DeploymentHeld condition
+const (
+	// ReasonOutsideWindow means status.heldTag waits for the next deployment window.
+	ReasonOutsideWindow = "OutsideWindow"
+	// ReasonChangeFreeze means status.heldTag waits for the end of a change freeze.
+	ReasonChangeFreeze = "ChangeFreeze"
+	// ReasonWindowOpen means the last held version was released for deployment.
+	ReasonWindowOpen = "WindowOpen"
+)
+
+// Reasons of the AwaitingApproval condition
+const (
+	// ReasonApprovalRequired means status.pendingTag waits for the werf.io/approve annotation.
+	ReasonApprovalRequired = "ApprovalRequired"
+	// ReasonApproved means the last pending version was approved (see status.lastApproval).
+	ReasonApproved = "Approved"
+)
+
+// Reasons of the UpdateAvailable condition
+const (
+	// ReasonNewerVersionAvailable means tag selection picks a tag other than the pinned version.
+	ReasonNewerVersionAvailable = "NewerVersionAvailable"
+	// ReasonPinnedVersionLatest means the pinned version is the one tag selection picks.
+	ReasonPinnedVersionLatest = "PinnedVersionLatest"
+	// ReasonNoVersionSelected means tag selection picks no tag (see status.selectionReason).
+	ReasonNoVersionSelected = "NoVersionSelected"
+)
+
+// Circuit breaker states of registry endpoints (status.endpoints)
+const (
+	// CircuitClosed means the endpoint is polled in its turn.
+	CircuitClosed = "Closed"
+	// CircuitOpen means the endpoint failed repeatedly and is skipped until its retry time.
+	CircuitOpen = "Open"
+)
+
 // WerfBundleSpec defines the desired state of WerfBundle.
 //
 // Example (same-namespace deployment):
@@ -78,26 +183,438 @@ type WerfBundleSpec struct {
 	// Converge contains configuration for deploying the bundle with werf converge.
 	// +kubebuilder:validation:Required
 	Converge ConvergeConfig `json:"converge"`
+
+	// Verify enables signature verification of the bundle before it is deployed.
+	// When set, a Job is only created for bundle digests signed by a trusted key.
+	// +kubebuilder:validation:Optional
+	Verify *VerifyConfig `json:"verify,omitempty"`
+
+	// Attestations are artifacts that must be attached to the bundle digest as OCI referrers
+	// (e.g., an SBOM, SLSA provenance or a vulnerability scan) before it is deployed.
+	// A Job is only created for digests meeting every requirement.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:MaxItems=10
+	Attestations []AttestationRequirement `json:"attestations,omitempty"`
+
+	// Version pins the bundle to an exact tag (e.g., v1.4.2) or manifest digest
+	// (e.g., sha256:abc...), bypassing tag selection. The registry is still polled: the tag
+	// selection would deploy is reported as status.latestAvailableTag, and the UpdateAvailable
+	// condition tells whether it differs from the pinned version. A pinned tag that is
+	// re-pushed is redeployed; a pinned digest never changes.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Pattern=`^([A-Za-z0-9_][A-Za-z0-9._-]{0,127}|sha256:[a-f0-9]{64})$`
+	Version string `json:"version,omitempty"`
+
+	// Approval controls whether new bundle versions are deployed automatically.
+	// If not set, they are.
+	// +kubebuilder:validation:Optional
+	Approval *ApprovalConfig `json:"approval,omitempty"`
+
+	// Schedule restricts converges to deployment windows and keeps them out of change
+	// freezes. A version found outside a window is held in status.heldTag until the next one
+	// opens. If not set, converges start at any time.
+	// +kubebuilder:validation:Optional
+	Schedule *ScheduleConfig `json:"schedule,omitempty"`
+
+	// Rollback configures what happens when a converge Job fails.
+	// If not set, the bundle is marked Failed and left as the Job left it.
+	// +kubebuilder:validation:Optional
+	Rollback *RollbackConfig `json:"rollback,omitempty"`
+}
+
+// RollbackConfig configures rollback after a failed converge.
+type RollbackConfig struct {
+	// OnFailure starts a converge of the last successfully deployed tag and digest
+	// (status.lastSuccessfulTag) when a converge Job fails. Whether or not it is set, the failed
+	// version is recorded in status.failedVersions and isn't deployed again until it is re-pushed
+	// or the spec changes.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default:=false
+	OnFailure bool `json:"onFailure,omitempty"`
+}
+
+// ScheduleConfig configures when converge Jobs may start. A Job that started in a window
+// runs to completion even if the window closes.
+type ScheduleConfig struct {
+	// TimeZone is the IANA time zone (e.g., Europe/Berlin) window start times are evaluated in,
+	// unless a window sets its own. Defaults to UTC.
+	// +kubebuilder:validation:Optional
+	TimeZone string `json:"timeZone,omitempty"`
+
+	// Windows are the deployment windows converges may start in. If empty, converges may
+	// start at any time outside Freezes.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:MaxItems=20
+	Windows []DeploymentWindow `json:"windows,omitempty"`
+
+	// Freezes are periods in which no converge starts, even inside a window.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:MaxItems=50
+	Freezes []FreezePeriod `json:"freezes,omitempty"`
+}
+
+// DeploymentWindow is a recurring period converges may start in.
+type DeploymentWindow struct {
+	// Start is a five-field cron expression (minute hour day-of-month month day-of-week) for
+	// when the window opens, e.g. "0 22 * * MON-FRI" for 22:00 on weekdays.
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	Start string `json:"start"`
+
+	// Duration is how long the window stays open after each start, e.g. 2h or 30m.
+	// +kubebuilder:validation:Required
+	Duration metav1.Duration `json:"duration"`
+
+	// TimeZone is the IANA time zone of Start, overriding the schedule's timeZone.
+	// +kubebuilder:validation:Optional
+	TimeZone string `json:"timeZone,omitempty"`
+}
+
+// FreezePeriod is a period in which no converge starts.
+type FreezePeriod struct {
+	// Start is when the freeze begins (RFC 3339, e.g. 2026-12-20T00:00:00+01:00).
+	// +kubebuilder:validation:Required
+	Start metav1.Time `json:"start"`
+
+	// End is when the freeze ends; converges may start again from this time.
+	// +kubebuilder:validation:Required
+	End metav1.Time `json:"end"`
+
+	// Reason is shown in status while the freeze holds a version, e.g. "year-end freeze".
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:MaxLength=256
+	Reason string `json:"reason,omitempty"`
+}
+
+// Approval modes for ApprovalConfig.Mode.
+const (
+	// ApprovalModeAutomatic deploys new versions as soon as they are found.
+	ApprovalModeAutomatic = "Automatic"
+	// ApprovalModeManual deploys a new version only once it is approved with ApprovalAnnotation.
+	ApprovalModeManual = "Manual"
+)
+
+// ApprovalAnnotation approves the version recorded in status.pendingTag and
+// status.pendingDigest for deployment. Its value is the pending digest, or the pending tag
+// and digest as <tag>@<digest>. The tag alone approves nothing: it may be re-pushed.
+const ApprovalAnnotation = "werf.io/approve"
+
+// ApprovalConfig configures approval of new bundle versions.
+type ApprovalConfig struct {
+	// Mode is Automatic (new versions are deployed as soon as they are found) or Manual
+	// (a new version is recorded in status.pendingTag and status.pendingDigest and only
+	// deployed once the werf.io/approve annotation names it).
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Enum=Automatic;Manual
+	// +kubebuilder:default:=Automatic
+	Mode string `json:"mode,omitempty"`
+}
+
+// AttestationRequirement requires a referrer of an artifact type attached to the bundle digest.
+// It is met by any referrer of ArtifactType that has the Annotations and satisfies the Predicates.
+type AttestationRequirement struct {
+	// ArtifactType is the artifact type of the referrer (e.g., application/spdx+json or
+	// application/vnd.in-toto+json).
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	ArtifactType string `json:"artifactType"`
+
+	// Annotations the referrer manifest must have, with these values.
+	// +kubebuilder:validation:Optional
+	Annotations map[string]string `json:"annotations,omitempty"`
+
+	// Predicates are conditions on the referrer's content, a JSON document in its first layer.
+	// In-toto attestations in DSSE envelopes are unwrapped, so paths start at the statement.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:MaxItems=20
+	Predicates []AttestationPredicate `json:"predicates,omitempty"`
+}
+
+// AttestationPredicate is a condition on a value in an attestation.
+// +kubebuilder:validation:XValidation:rule="(has(self.operator) && self.operator == 'Exists') || has(self.value)",message="value is required unless operator is Exists"
+type AttestationPredicate struct {
+	// Path is the dot-separated path of the value (e.g., predicate.buildDefinition.buildType).
+	// Numeric segments index arrays.
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	Path string `json:"path"`
+
+	// Operator compares the value with Value. Equals and NotEquals compare strings;
+	// LessThanOrEqual and GreaterThanOrEqual compare numbers, and the length of arrays and
+	// objects. Every operator fails if the value doesn't exist.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Enum=Exists;Equals;NotEquals;LessThanOrEqual;GreaterThanOrEqual
+	// +kubebuilder:default:=Equals
+	Operator string `json:"operator,omitempty"`
+
+	// Value is the expected value.
+	// +kubebuilder:validation:Optional
+	Value string `json:"value,omitempty"`
+}
+
+// Signature providers for VerifyConfig.Provider.
+const (
+	// VerifyProviderCosign verifies cosign signatures made with a key pair.
+	VerifyProviderCosign = "cosign"
+)
+
+// VerifyConfig configures bundle signature verification.
+type VerifyConfig struct {
+	// Provider is the signature format to verify. Only cosign key-pair signatures
+	// are supported.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Enum=cosign
+	// +kubebuilder:default:=cosign
+	Provider string `json:"provider,omitempty"`
+
+	// SecretRef references a Secret in the bundle namespace holding PEM-encoded public keys
+	// in data keys ending with .pub (e.g., cosign.pub). The bundle is deployed if it carries
+	// a signature made by any of the keys.
+	// Unlike registry credentials, keys are never read from the target namespace.
+	// +kubebuilder:validation:Required
+	SecretRef corev1.LocalObjectReference `json:"secretRef"`
 }
 
 // RegistryConfig contains configuration for accessing an OCI registry.
+// +kubebuilder:validation:XValidation:rule="!has(self.versionConstraint) || !has(self.tagFilter) || !has(self.tagFilter.sortPolicy) || self.tagFilter.sortPolicy == 'semver'",message="versionConstraint requires tagFilter.sortPolicy semver"
+// +kubebuilder:validation:XValidation:rule="!has(self.incrementalTagList) || !self.incrementalTagList || (has(self.tagFilter) && has(self.tagFilter.sortPolicy) && self.tagFilter.sortPolicy == 'lexical' && !has(self.tagFilter.extract) && !has(self.metadataSelection))",message="incrementalTagList requires tagFilter.sortPolicy lexical without extract or metadataSelection"
 type RegistryConfig struct {
 	// URL is the OCI registry URL (e.g., ghcr.io/org/bundle).
 	// +kubebuilder:validation:Required
 	// +kubebuilder:validation:MinLength=1
 	URL string `json:"url"`
 
+	// Mirrors are repositories serving the same bundle as URL (e.g., a replicated registry),
+	// polled in order when URL fails with a transient error (network errors, timeouts, server
+	// errors, rate limiting). The converge Job pulls the bundle from the endpoint that served
+	// the tags. SecretRef credentials are used for mirror hosts they have an entry for;
+	// other mirrors are accessed anonymously. TLS and Proxy apply to all endpoints.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:MaxItems=5
+	// +kubebuilder:validation:items:MinLength=1
+	Mirrors []string `json:"mirrors,omitempty"`
+
 	// SecretRef is an optional reference to a Secret containing registry credentials.
+	// The Secret is looked up in the bundle namespace first, then in the target namespace.
+	// Supports kubernetes.io/dockerconfigjson Secrets and basic Secrets with
+	// username/password or token keys.
 	// +kubebuilder:validation:Optional
 	SecretRef *corev1.LocalObjectReference `json:"secretRef,omitempty"`
 
 	// PollInterval is the interval at which to poll the registry for new tags (e.g., 15m, 1h).
-	// Currently not enforced; reconciliation happens on all events plus periodic resync.
-	// Proper interval enforcement will be added in a future release.
+	// Each poll is scheduled with ±10% jitter. Intervals below the operator-wide minimum
+	// (--min-poll-interval, 1m by default) are raised to the minimum.
 	// +kubebuilder:validation:Optional
 	// +kubebuilder:validation:Pattern=`^([0-9]+(ns|us|µs|ms|s|m|h))+$`
 	// +kubebuilder:default:="15m"
 	PollInterval string `json:"pollInterval,omitempty"`
+
+	// VersionConstraint restricts which tags are deployed using semver constraint syntax
+	// (e.g., ">=1.2.0 <2.0.0", "~1.4", "^1.0"). Tags are parsed as semver with an optional
+	// "v" prefix; tags that aren't valid semver (e.g., latest, sha-abc) are ignored.
+	// If empty, the highest semver tag is deployed.
+	// +kubebuilder:validation:Optional
+	VersionConstraint string `json:"versionConstraint,omitempty"`
+
+	// AllowPrerelease allows pre-release versions (e.g., 1.3.0-rc.1) to be selected.
+	// Pre-releases are skipped by default, even if versionConstraint mentions one.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default:=false
+	AllowPrerelease bool `json:"allowPrerelease,omitempty"`
+
+	// TagFilter narrows the tags considered for deployment with regular expressions
+	// and selects how they are ordered. If not set, tags are ordered by semver.
+	// +kubebuilder:validation:Optional
+	TagFilter *TagFilter `json:"tagFilter,omitempty"`
+
+	// MetadataSelection orders the tags by OCI metadata of their images (e.g., creation time)
+	// instead of by tag name, and can filter them by annotation. Tags are narrowed by
+	// TagFilter first. Can't be combined with VersionConstraint.
+	// +kubebuilder:validation:Optional
+	MetadataSelection *MetadataSelection `json:"metadataSelection,omitempty"`
+
+	// MinTagAge is how old a tag must be before it can be selected (e.g., 30m, 24h), so tags
+	// re-pushed or retracted shortly after they are pushed are never deployed. A tag's age is
+	// counted from its image creation time (OCI metadata), or, for bundles without one, from
+	// when the operator first saw its current digest (status.seenTags). Younger tags are
+	// skipped and selection falls back to the next tag; the bundle is polled again as soon as
+	// the skipped tag is old enough. Doesn't apply to spec.version.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Pattern=`^([0-9]+(ns|us|µs|ms|s|m|h))+$`
+	MinTagAge string `json:"minTagAge,omitempty"`
+
+	// RetryPolicy configures how failed registry polls are retried.
+	// If not set, retries start at 30s, double up to 8m, and the bundle is marked Failed
+	// after 5 failed attempts.
+	// +kubebuilder:validation:Optional
+	RetryPolicy *RetryPolicy `json:"retryPolicy,omitempty"`
+
+	// IncrementalTagList lists only the tags sorting after status.lastAppliedTag (the "last"
+	// parameter of the registry API) instead of all tags on every poll, for repositories with
+	// thousands of tags. Registries list tags in lexical order, so it requires
+	// tagFilter.sortPolicy lexical of whole tags (no extract, no metadataSelection), for tags
+	// whose lexical order is their release order (e.g., timestamps or zero-padded build numbers).
+	// The first poll after a spec change lists all tags.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default:=false
+	IncrementalTagList bool `json:"incrementalTagList,omitempty"`
+
+	// TLS configures the connection to registries using a private CA, requiring client
+	// certificates, or serving plain HTTP. Applies to polling and to the converge Job.
+	// +kubebuilder:validation:Optional
+	TLS *RegistryTLS `json:"tls,omitempty"`
+
+	// Proxy configures the HTTP(S) proxy for registry connections, replacing the operator-wide
+	// proxy settings (--registry-http-proxy and friends). An empty proxy ({}) connects directly.
+	// Applies to polling and to the converge Job.
+	// +kubebuilder:validation:Optional
+	Proxy *RegistryProxy `json:"proxy,omitempty"`
+}
+
+// RegistryProxy configures an HTTP(S) proxy, with the semantics of the HTTP_PROXY, HTTPS_PROXY
+// and NO_PROXY environment variables.
+type RegistryProxy struct {
+	// HTTPProxy is the proxy URL for plain HTTP registries (e.g., http://proxy.corp:3128).
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Pattern=`^(https?|socks5)://`
+	HTTPProxy string `json:"httpProxy,omitempty"`
+
+	// HTTPSProxy is the proxy URL for HTTPS registries.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Pattern=`^(https?|socks5)://`
+	HTTPSProxy string `json:"httpsProxy,omitempty"`
+
+	// NoProxy is a comma-separated list of hosts, domains (.corp.example) and CIDRs
+	// connected to directly.
+	// +kubebuilder:validation:Optional
+	NoProxy string `json:"noProxy,omitempty"`
+}
+
+// RegistryTLS configures TLS for connections to the registry.
+// The referenced ConfigMaps and Secrets are looked up in the bundle namespace only: whoever
+// can write to the target namespace must not be able to choose which certificates are trusted.
+// +kubebuilder:validation:XValidation:rule="!has(self.insecure) || !self.insecure || !has(self.caBundle)",message="caBundle can't be used with insecure"
+type RegistryTLS struct {
+	// CABundle references PEM-encoded CA certificates trusted in addition to the system roots,
+	// for registries with certificates issued by a private CA.
+	// +kubebuilder:validation:Optional
+	CABundle *CABundleSource `json:"caBundle,omitempty"`
+
+	// ClientCertSecretRef references a kubernetes.io/tls Secret (tls.crt and tls.key keys)
+	// holding the client certificate presented to registries that require mutual TLS.
+	// +kubebuilder:validation:Optional
+	ClientCertSecretRef *corev1.LocalObjectReference `json:"clientCertSecretRef,omitempty"`
+
+	// Insecure allows plain HTTP connections to the registry and skips verification of its
+	// certificate. Only meant for development clusters.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default:=false
+	Insecure bool `json:"insecure,omitempty"`
+}
+
+// CABundleSource references a ConfigMap or Secret key holding PEM-encoded CA certificates.
+// Exactly one of ConfigMapRef or SecretRef must be set.
+// +kubebuilder:validation:XValidation:rule="(has(self.configMapRef) && !has(self.secretRef)) || (!has(self.configMapRef) && has(self.secretRef))",message="exactly one of configMapRef or secretRef must be set"
+type CABundleSource struct {
+	// ConfigMapRef is a reference to a ConfigMap holding the CA certificates.
+	// +kubebuilder:validation:Optional
+	ConfigMapRef *corev1.LocalObjectReference `json:"configMapRef,omitempty"`
+
+	// SecretRef is a reference to a Secret holding the CA certificates.
+	// +kubebuilder:validation:Optional
+	SecretRef *corev1.LocalObjectReference `json:"secretRef,omitempty"`
+
+	// Key is the data key holding the certificates.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default:="ca.crt"
+	Key string `json:"key,omitempty"`
+}
+
+// RetryPolicy configures the exponential backoff of transient registry errors
+// (network errors, timeouts, server errors).
+//
+// Authentication and not-found errors aren't retried with backoff: the bundle is marked Failed
+// right away and polled again when its Secret or spec changes. Failed bundles keep polling
+// at a long interval (1h, or the poll interval if longer), so they recover on their own.
+type RetryPolicy struct {
+	// BaseDelay is the delay after the first failed poll; it doubles with each further failure.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Pattern=`^([0-9]+(ns|us|µs|ms|s|m|h))+$`
+	// +kubebuilder:default:="30s"
+	BaseDelay string `json:"baseDelay,omitempty"`
+
+	// MaxDelay caps the delay between retries.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Pattern=`^([0-9]+(ns|us|µs|ms|s|m|h))+$`
+	// +kubebuilder:default:="8m"
+	MaxDelay string `json:"maxDelay,omitempty"`
+
+	// MaxAttempts is the number of consecutive failed polls retried with backoff.
+	// The next failure marks the bundle Failed with reason RegistryUnavailable.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:default:=5
+	MaxAttempts int32 `json:"maxAttempts,omitempty"`
+}
+
+// Sort policies for TagFilter.SortPolicy.
+const (
+	// SortPolicyLexical orders tags (or extracted values) as plain strings.
+	SortPolicyLexical = "lexical"
+	// SortPolicyNumerical orders values as numbers (e.g., build-512 > build-99).
+	SortPolicyNumerical = "numerical"
+	// SortPolicySemver orders values by semantic version and applies VersionConstraint.
+	SortPolicySemver = "semver"
+	// SortPolicyCalver orders values by their numeric segments, left to right
+	// (e.g., calendar versions 2025.1.10 or timestamps 20250101.1234).
+	SortPolicyCalver = "calver"
+)
+
+// TagFilter filters registry tags with regular expressions and defines their sort order.
+// Tags that don't match Include, or that match Exclude, are never deployed.
+// +kubebuilder:validation:XValidation:rule="!has(self.extract) || has(self.include)",message="extract requires include"
+type TagFilter struct {
+	// Include is a regular expression (RE2 syntax) that tags must match to be considered.
+	// May contain a named capture group referenced by Extract,
+	// e.g. `^main-(?P<ts>[0-9]{8}\.[0-9]+)-[a-f0-9]+$`.
+	// +kubebuilder:validation:Optional
+	Include string `json:"include,omitempty"`
+
+	// Exclude is a regular expression (RE2 syntax); matching tags are ignored
+	// even if they match Include.
+	// +kubebuilder:validation:Optional
+	Exclude string `json:"exclude,omitempty"`
+
+	// Extract is the name of a capture group in Include whose value is sorted instead
+	// of the whole tag (e.g., "ts" for `(?P<ts>...)`).
+	// +kubebuilder:validation:Optional
+	Extract string `json:"extract,omitempty"`
+
+	// SortPolicy defines how tags (or extracted values) are ordered; the highest is deployed.
+	// One of lexical, numerical, semver, calver. Values that can't be parsed by the
+	// policy are ignored.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Enum=lexical;numerical;semver;calver
+	// +kubebuilder:default:=semver
+	SortPolicy string `json:"sortPolicy,omitempty"`
+}
+
+// MetadataSelection selects the bundle to deploy by the OCI metadata of the images: manifest
+// annotations, image config labels and creation time.
+type MetadataSelection struct {
+	// OrderBy is the annotation whose value orders the tags; the highest is deployed.
+	// Manifest annotations are looked up first, then image config labels. For
+	// org.opencontainers.image.created, the creation time in the image config is used when
+	// the manifest has no such annotation. RFC 3339 timestamps are ordered by time, other
+	// values by their numeric segments. Tags without the annotation are ignored.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default:="org.opencontainers.image.created"
+	OrderBy string `json:"orderBy,omitempty"`
+
+	// MatchAnnotations restricts the tags to images whose annotations (or config labels)
+	// have all the given values, e.g. {"env": "prod"}.
+	// +kubebuilder:validation:Optional
+	MatchAnnotations map[string]string `json:"matchAnnotations,omitempty"`
 }
 
 // ConvergeConfig contains configuration for deploying the bundle with werf converge.
@@ -182,6 +699,82 @@ type WerfBundleStatus struct {
 	// +kubebuilder:validation:Optional
 	LastAppliedTag string `json:"lastAppliedTag,omitempty"`
 
+	// LastAppliedDigest is the manifest digest LastAppliedTag resolved to when it was deployed
+	// (e.g., sha256:abc...). A new converge is triggered when the tag moves to another digest.
+	// +kubebuilder:validation:Optional
+	LastAppliedDigest string `json:"lastAppliedDigest,omitempty"`
+
+	// LastSuccessfulTag is the tag of the last converge Job that succeeded, the version
+	// spec.rollback.onFailure rolls back to.
+	// +kubebuilder:validation:Optional
+	LastSuccessfulTag string `json:"lastSuccessfulTag,omitempty"`
+
+	// LastSuccessfulDigest is the manifest digest LastSuccessfulTag was deployed from.
+	// +kubebuilder:validation:Optional
+	LastSuccessfulDigest string `json:"lastSuccessfulDigest,omitempty"`
+
+	// FailedVersions are the versions whose converge Job failed.
+	// They aren't deployed again until re-pushed (a new digest) or until the spec changes.
+	// Most recent last, at most 20.
+	// +kubebuilder:validation:Optional
+	// +listType=map
+	// +listMapKey=tag
+	// +listMapKey=digest
+	FailedVersions []FailedVersion `json:"failedVersions,omitempty"`
+
+	// LastRollback describes the last rollback after a failed converge, and its outcome.
+	// +kubebuilder:validation:Optional
+	LastRollback *RollbackStatus `json:"lastRollback,omitempty"`
+
+	// SelectedVersion is the version of the tag selected on the last registry poll:
+	// the semver version, or the value sorted by tagFilter.sortPolicy.
+	// Empty if no tag was selected.
+	// +kubebuilder:validation:Optional
+	SelectedVersion string `json:"selectedVersion,omitempty"`
+
+	// SelectionReason explains why the selected tag was chosen, or why no tag was.
+	// +kubebuilder:validation:Optional
+	SelectionReason string `json:"selectionReason,omitempty"`
+
+	// LatestAvailableTag is the tag selected on the last registry poll, i.e. the tag that is
+	// deployed unless spec.version pins another one. Empty if no tag was selected.
+	// +kubebuilder:validation:Optional
+	LatestAvailableTag string `json:"latestAvailableTag,omitempty"`
+
+	// SeenTags records when the operator first saw tags without an image creation time, to
+	// count their age for spec.registry.minTagAge across operator restarts. Only tags still in
+	// the repository are kept, at most 100.
+	// +kubebuilder:validation:Optional
+	// +listType=map
+	// +listMapKey=tag
+	SeenTags []SeenTag `json:"seenTags,omitempty"`
+
+	// PendingTag is the tag waiting for approval (spec.approval.mode Manual), empty if none.
+	// +kubebuilder:validation:Optional
+	PendingTag string `json:"pendingTag,omitempty"`
+
+	// PendingDigest is the manifest digest PendingTag resolved to.
+	// +kubebuilder:validation:Optional
+	PendingDigest string `json:"pendingDigest,omitempty"`
+
+	// LastApproval records the last version approved for deployment, and by whom.
+	// +kubebuilder:validation:Optional
+	LastApproval *ApprovalRecord `json:"lastApproval,omitempty"`
+
+	// HeldTag is the tag waiting for a deployment window or the end of a change freeze
+	// (spec.schedule), empty if none.
+	// +kubebuilder:validation:Optional
+	HeldTag string `json:"heldTag,omitempty"`
+
+	// HeldDigest is the manifest digest HeldTag resolved to.
+	// +kubebuilder:validation:Optional
+	HeldDigest string `json:"heldDigest,omitempty"`
+
+	// NextWindowTime is when HeldTag may be deployed: the start of the next deployment window
+	// outside change freezes. Nil if nothing is held, or if no window opens within five years.
+	// +kubebuilder:validation:Optional
+	NextWindowTime *metav1.Time `json:"nextWindowTime,omitempty"`
+
 	// LastSyncTime is the timestamp of the last successful sync (nil if not yet synced).
 	// +kubebuilder:validation:Optional
 	LastSyncTime *metav1.Time `json:"lastSyncTime,omitempty"`
@@ -190,6 +783,29 @@ type WerfBundleStatus struct {
 	// +kubebuilder:validation:Optional
 	LastErrorMessage string `json:"lastErrorMessage,omitempty"`
 
+	// FailureReason is a machine-readable reason for the Failed phase (e.g., VerificationFailed,
+	// AuthenticationFailed, RepositoryNotFound, InvalidRepository, RegistryUnavailable, TooManyTags,
+	// TLSFailed, AttestationFailed, InvalidBundle, PinnedVersionNotFound, InvalidSchedule,
+	// InvalidMinTagAge, RollbackFailed).
+	// Empty when the bundle isn't Failed or the failure has no specific reason.
+	// +kubebuilder:validation:Optional
+	FailureReason string `json:"failureReason,omitempty"`
+
+	// LastPollTime is when the registry was last polled for tags.
+	// +kubebuilder:validation:Optional
+	LastPollTime *metav1.Time `json:"lastPollTime,omitempty"`
+
+	// NextPollTime is when the registry will be polled next: the poll interval (with jitter)
+	// after LastPollTime, or the retry time while backing off from registry errors.
+	// Persisted so that restarting the operator doesn't make every bundle poll at once.
+	// +kubebuilder:validation:Optional
+	NextPollTime *metav1.Time `json:"nextPollTime,omitempty"`
+
+	// ObservedGeneration is the metadata.generation of the spec used for the last poll.
+	// A newer generation (the spec was edited) is polled immediately.
+	// +kubebuilder:validation:Optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
 	// LastETag is the ETag from the last successful registry response.
 	// Used for ETag-based caching to avoid re-downloading unchanged tag lists.
 	// +kubebuilder:validation:Optional
@@ -197,10 +813,10 @@ type WerfBundleStatus struct {
 
 	// ConsecutiveFailures is the number of consecutive registry polling failures.
 	// Used to calculate exponential backoff. Reset to 0 on success.
-	// Marked Failed if ConsecutiveFailures > 5 (after 6th consecutive error).
+	// Marked Failed if ConsecutiveFailures > spec.registry.retryPolicy.maxAttempts (5 by default),
+	// or right away for authentication and not-found errors. Failed bundles keep counting.
 	// +kubebuilder:validation:Optional
 	// +kubebuilder:validation:Minimum=0
-	// +kubebuilder:validation:Maximum=6
 	ConsecutiveFailures int32 `json:"consecutiveFailures,omitempty"`
 
 	// LastErrorTime is the timestamp of the last error encountered.
@@ -208,6 +824,16 @@ type WerfBundleStatus struct {
 	// +kubebuilder:validation:Optional
 	LastErrorTime *metav1.Time `json:"lastErrorTime,omitempty"`
 
+	// RegistryEndpoint is the repository that served the tags on the last successful poll:
+	// spec.registry.url or one of spec.registry.mirrors. Empty without mirrors.
+	// +kubebuilder:validation:Optional
+	RegistryEndpoint string `json:"registryEndpoint,omitempty"`
+
+	// Endpoints is the circuit breaker state of spec.registry.url and each mirror, in order.
+	// Empty without mirrors.
+	// +kubebuilder:validation:Optional
+	Endpoints []EndpointStatus `json:"endpoints,omitempty"`
+
 	// ActiveJobName is the name of the currently active job running werf converge.
 	// Set when a job is created, cleared when the job completes or fails.
 	// Used for deduplication to prevent multiple jobs for the same bundle version.
@@ -231,12 +857,117 @@ type WerfBundleStatus struct {
 	// Provides visibility for debugging cross-namespace deployments.
 	// +kubebuilder:validation:Optional
 	ResolvedTargetNamespace string `json:"resolvedTargetNamespace,omitempty"`
+
+	// Conditions are the latest observations of the bundle's state (e.g., UpdateAvailable,
+	// AwaitingApproval, DeploymentHeld, RolledBack).
+	// +kubebuilder:validation:Optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// FailedVersion is a tag and digest whose converge failed.
+type FailedVersion struct {
+	// Tag is the registry tag.
+	Tag string `json:"tag"`
+
+	// Digest is the manifest digest the tag was deployed from.
+	Digest string `json:"digest"`
+
+	// FailedAt is when the converge Job was found failed.
+	FailedAt metav1.Time `json:"failedAt"`
+}
+
+// RollbackStatus is a rollback to the last successfully deployed version after a failed
+// converge.
+type RollbackStatus struct {
+	// FailedTag and FailedDigest are the version whose converge failed.
+	FailedTag    string `json:"failedTag"`
+	FailedDigest string `json:"failedDigest,omitempty"`
+
+	// Tag and Digest are the version rolled back to.
+	Tag    string `json:"tag"`
+	Digest string `json:"digest,omitempty"`
+
+	// JobName is the name of the rollback converge Job.
+	// +kubebuilder:validation:Optional
+	JobName string `json:"jobName,omitempty"`
+
+	// Result is the status of the rollback Job (Running, Succeeded, Failed).
+	// +kubebuilder:validation:Enum=Succeeded;Failed;Running
+	Result string `json:"result"`
+
+	// StartTime is when the rollback started.
+	StartTime metav1.Time `json:"startTime"`
+
+	// CompletionTime is when the rollback Job finished, nil while it runs.
+	// +kubebuilder:validation:Optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+}
+
+// SeenTag is when the operator first saw a tag pointing to a digest.
+type SeenTag struct {
+	// Tag is the registry tag.
+	Tag string `json:"tag"`
+
+	// Digest is the manifest digest the tag pointed to; re-pushing the tag restarts its age.
+	Digest string `json:"digest"`
+
+	// FirstSeen is when the operator first saw the tag point to Digest.
+	FirstSeen metav1.Time `json:"firstSeen"`
+}
+
+// ApprovalRecord is a version approved for deployment with the werf.io/approve annotation.
+type ApprovalRecord struct {
+	// Tag is the approved tag.
+	Tag string `json:"tag"`
+
+	// Digest is the approved manifest digest.
+	Digest string `json:"digest"`
+
+	// ApprovedBy is the field manager that last set the werf.io/approve annotation, from
+	// metadata.managedFields (e.g., kubectl-annotate, or the name given with --field-manager).
+	// +kubebuilder:validation:Optional
+	ApprovedBy string `json:"approvedBy,omitempty"`
+
+	// ApprovedAt is when the annotation was last set, from metadata.managedFields, or when the
+	// operator saw the approval if no managedFields entry owns the annotation.
+	// +kubebuilder:validation:Optional
+	ApprovedAt *metav1.Time `json:"approvedAt,omitempty"`
+}
+
+// EndpointStatus is the circuit breaker state of a registry endpoint. An endpoint failing
+// several polls in a row is opened and skipped until RetryTime; it's then tried again,
+// closed by a success or opened again by a single failure.
+type EndpointStatus struct {
+	// URL is the repository URL of the endpoint.
+	URL string `json:"url"`
+
+	// State is Closed (polled in its turn) or Open (skipped until RetryTime).
+	// +kubebuilder:validation:Enum=Closed;Open
+	State string `json:"state"`
+
+	// ConsecutiveFailures is the number of consecutive failed polls of the endpoint.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=0
+	ConsecutiveFailures int32 `json:"consecutiveFailures,omitempty"`
+
+	// LastError is the error of the last failed poll, or empty after a success.
+	// +kubebuilder:validation:Optional
+	LastError string `json:"lastError,omitempty"`
+
+	// RetryTime is when an Open endpoint is tried again.
+	// +kubebuilder:validation:Optional
+	RetryTime *metav1.Time `json:"retryTime,omitempty"`
 }
 
 // +kubebuilder:object:root=true
 // +kubebuilder:subresource:status
 // +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
 // +kubebuilder:printcolumn:name="LastAppliedTag",type=string,JSONPath=`.status.lastAppliedTag`
+// +kubebuilder:printcolumn:name="Latest",type=string,JSONPath=`.status.latestAvailableTag`,priority=1
+// +kubebuilder:printcolumn:name="Pending",type=string,JSONPath=`.status.pendingTag`,priority=1
+// +kubebuilder:printcolumn:name="Held",type=string,JSONPath=`.status.heldTag`,priority=1
 // +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
 // +kubebuilder:resource:shortName=wb;wbs
 
@@ -17,19 +17,24 @@ limitations under the License.
 package main
 
 import (
+	"bytes"
 	"crypto/tls"
 	"flag"
+	"fmt"
 	"os"
+	"time"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
 	// to ensure that exec-entrypoint and run can make use of them.
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/client-go/kubernetes"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 	"sigs.k8s.io/controller-runtime/pkg/metrics/filters"
@@ -38,6 +43,7 @@ import (
 
 	werfv1alpha1 "github.com/werf/k8s-werf-operator-go/api/v1alpha1"
 	"github.com/werf/k8s-werf-operator-go/controllers"
+	"github.com/werf/k8s-werf-operator-go/internal/receiver"
 	"github.com/werf/k8s-werf-operator-go/internal/registry"
 	// +kubebuilder:scaffold:imports
 )
@@ -63,6 +69,12 @@ func main() {
 	var probeAddr string
 	var secureMetrics bool
 	var enableHTTP2 bool
+	var receiverAddr, receiverSecretFile string
+	var minPollInterval, registryTimeout time.Duration
+	var registryQPS float64
+	var registryBurst, registryMaxTags int
+	var registryProxy registry.ProxyConfig
+	var credentialProviderConfig, credentialProviderBinDir string
 	var tlsOpts []func(*tls.Config)
 	flag.StringVar(&metricsAddr, "metrics-bind-address", "0", "The address the metrics endpoint binds to. "+
 		"Use :8443 for HTTPS or :8080 for HTTP, or leave as 0 to disable the metrics service.")
@@ -81,6 +93,36 @@ func main() {
 	flag.StringVar(&metricsCertKey, "metrics-cert-key", "tls.key", "The name of the metrics server key file.")
 	flag.BoolVar(&enableHTTP2, "enable-http2", false,
 		"If set, HTTP/2 will be enabled for the metrics and webhook servers")
+	flag.StringVar(&receiverAddr, "receiver-bind-address", "0",
+		"The address the registry webhook receiver binds to, e.g. :9443. Leave as 0 to disable the receiver.")
+	flag.StringVar(&receiverSecretFile, "receiver-secret-file", "",
+		"Path to a file with the shared secret that registry webhooks must sign or send as a token. "+
+			"Required when the receiver is enabled.")
+	flag.DurationVar(&minPollInterval, "min-poll-interval", time.Minute,
+		"The minimum registry poll interval. WerfBundles with a shorter spec.registry.pollInterval use this instead.")
+	flag.DurationVar(&registryTimeout, "registry-timeout", registry.DefaultTimeout,
+		"The time limit for each registry operation (listing tags, resolving a digest), including authentication.")
+	flag.Float64Var(&registryQPS, "registry-qps", registry.DefaultQPS,
+		"The maximum requests per second to each registry host, shared by all WerfBundles.")
+	flag.IntVar(&registryBurst, "registry-burst", registry.DefaultBurst,
+		"The maximum burst of requests to each registry host above --registry-qps.")
+	flag.IntVar(&registryMaxTags, "registry-max-tags", registry.DefaultMaxTags,
+		"The maximum number of tags listed from one repository. WerfBundles of larger repositories are marked Failed.")
+	envProxy := registry.ProxyFromEnvironment()
+	flag.StringVar(&registryProxy.HTTPProxy, "registry-http-proxy", envProxy.HTTPProxy,
+		"The proxy URL for plain HTTP registries, used for polling and passed to converge Jobs. "+
+			"Defaults to the HTTP_PROXY environment variable.")
+	flag.StringVar(&registryProxy.HTTPSProxy, "registry-https-proxy", envProxy.HTTPSProxy,
+		"The proxy URL for HTTPS registries, used for polling and passed to converge Jobs. "+
+			"Defaults to the HTTPS_PROXY environment variable.")
+	flag.StringVar(&registryProxy.NoProxy, "registry-no-proxy", envProxy.NoProxy,
+		"Comma-separated hosts, domains and CIDRs connected to without the registry proxy. "+
+			"Defaults to the NO_PROXY environment variable.")
+	flag.StringVar(&credentialProviderConfig, "image-credential-provider-config", "",
+		"Path to a kubelet CredentialProviderConfig file. Its exec plugins provide registry credentials "+
+			"for WerfBundles without spec.registry.secretRef.")
+	flag.StringVar(&credentialProviderBinDir, "image-credential-provider-bin-dir", "",
+		"The directory with the plugins of --image-credential-provider-config.")
 	opts := zap.Options{
 		Development: true,
 	}
@@ -163,6 +205,11 @@ func main() {
 		HealthProbeBindAddress: probeAddr,
 		LeaderElection:         enableLeaderElection,
 		LeaderElectionID:       "247e2318.werf.io",
+		// Read Secrets straight from the API server: a cached read would keep every Secret
+		// in the cluster in memory. The controller only watches Secret metadata
+		Client: client.Options{
+			Cache: &client.CacheOptions{DisableFor: []client.Object{&corev1.Secret{}}},
+		},
 		// LeaderElectionReleaseOnCancel defines if the leader should step down voluntarily
 		// when the Manager ends. This requires the binary to immediately end when the
 		// Manager is stopped, otherwise, this setting is unsafe. Setting this significantly
@@ -187,16 +234,70 @@ func main() {
 		os.Exit(1)
 	}
 
+	// The operator-wide registry proxy; WerfBundles may replace it with spec.registry.proxy
+	var proxy *registry.ProxyConfig
+	if registryProxy != (registry.ProxyConfig{}) {
+		if err := registryProxy.Validate(); err != nil {
+			setupLog.Error(err, "invalid registry proxy configuration")
+			os.Exit(1)
+		}
+		proxy = &registryProxy
+	}
+
+	// Exec credential provider plugins, e.g. for short-lived cloud registry tokens
+	var credentialProviders *registry.CredentialProviders
+	if credentialProviderConfig != "" {
+		credentialProviders, err = registry.LoadCredentialProviders(credentialProviderConfig, credentialProviderBinDir)
+		if err != nil {
+			setupLog.Error(err, "unable to load credential providers")
+			os.Exit(1)
+		}
+	}
+
+	// Tag lists are shared between bundles polling the same repository
+	ociClient := registry.NewOCIClientWithOptions(registry.OCIClientOptions{
+		Timeout: registryTimeout,
+		QPS:     registryQPS,
+		Burst:   registryBurst,
+		MaxTags: registryMaxTags,
+	})
+	registryClient := registry.NewSharedClient(ociClient, minPollInterval)
+
+	// Registry webhook receiver triggers reconciles on push notifications
+	var triggers chan receiver.BundleEvent
+	if receiverAddr != "" && receiverAddr != "0" {
+		secret, err := readReceiverSecret(receiverSecretFile)
+		if err != nil {
+			setupLog.Error(err, "unable to configure registry webhook receiver")
+			os.Exit(1)
+		}
+		triggers = make(chan receiver.BundleEvent, 100)
+		receiverServer := receiver.NewServer(receiverAddr, secret, mgr.GetClient(), triggers)
+		receiverServer.OnPush = registryClient.Invalidate
+		receiverServer.Elected = mgr.Elected()
+		if err := mgr.Add(receiverServer); err != nil {
+			setupLog.Error(err, "unable to add registry webhook receiver")
+			os.Exit(1)
+		}
+	}
+
 	// Register WerfBundle controller
-	if err = (&controllers.WerfBundleReconciler{
-		Client:         mgr.GetClient(),
-		Scheme:         mgr.GetScheme(),
-		RegistryClient: registry.NewOCIClient(),
-		Clientset:      clientset,
-	}).SetupWithManager(mgr); err != nil {
+	reconciler := &controllers.WerfBundleReconciler{
+		Client:              mgr.GetClient(),
+		Scheme:              mgr.GetScheme(),
+		RegistryClient:      registryClient,
+		Clientset:           clientset,
+		Triggers:            triggers,
+		MinPollInterval:     minPollInterval,
+		Proxy:               proxy,
+		CredentialProviders: credentialProviders,
+	}
+	if err = reconciler.SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "WerfBundle")
 		os.Exit(1)
 	}
+	// A tag list change seen by one bundle is fanned out to all bundles tracking the repository
+	registryClient.OnChange = reconciler.PollRepository
 
 	// +kubebuilder:scaffold:builder
 
@@ -215,3 +316,19 @@ func main() {
 		os.Exit(1)
 	}
 }
+
+// readReceiverSecret reads the registry webhook secret, ignoring surrounding whitespace.
+func readReceiverSecret(path string) ([]byte, error) {
+	if path == "" {
+		return nil, fmt.Errorf("--receiver-secret-file is required when --receiver-bind-address is set")
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read receiver secret: %w", err)
+	}
+	secret := bytes.TrimSpace(data)
+	if len(secret) == 0 {
+		return nil, fmt.Errorf("receiver secret file %s is empty", path)
+	}
+	return secret, nil
+}
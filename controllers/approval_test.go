@@ -0,0 +1,219 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	werfv1alpha1 "github.com/werf/k8s-werf-operator-go/api/v1alpha1"
+)
+
+// newApprovalTestReconciler creates a bundle in Manual approval mode and a reconciler backed
+// by a fake registry serving tags.
+func newApprovalTestReconciler(
+	t *testing.T,
+	ctx context.Context,
+	bundleName, repoURL string,
+	tags []string,
+) (*WerfBundleReconciler, *FakeRegistry, reconcile.Request) {
+	t.Helper()
+
+	bundle := &werfv1alpha1.WerfBundle{
+		ObjectMeta: metav1.ObjectMeta{Name: bundleName, Namespace: "default"},
+		Spec: werfv1alpha1.WerfBundleSpec{
+			Registry: werfv1alpha1.RegistryConfig{URL: repoURL},
+			Converge: werfv1alpha1.ConvergeConfig{ServiceAccountName: "default"},
+			Approval: &werfv1alpha1.ApprovalConfig{Mode: werfv1alpha1.ApprovalModeManual},
+		},
+	}
+	if err := testk8sClient.Create(ctx, bundle); err != nil {
+		t.Fatalf("failed to create WerfBundle: %v", err)
+	}
+
+	fakeReg := NewFakeRegistry()
+	fakeReg.SetTags(repoURL, tags)
+	reconciler := &WerfBundleReconciler{
+		Client:         testk8sClient,
+		Scheme:         testk8sClient.Scheme(),
+		RegistryClient: fakeReg,
+		Clientset:      testK8sClientset,
+	}
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: bundleName, Namespace: "default"}}
+	return reconciler, fakeReg, req
+}
+
+// approve sets the werf.io/approve annotation of the bundle as field manager approver.
+func approve(t *testing.T, ctx context.Context, req reconcile.Request, value, approver string) {
+	t.Helper()
+
+	bundle := getWerfBundle(t, ctx, req.Name, req.Namespace)
+	patch := client.MergeFrom(bundle.DeepCopy())
+	if bundle.Annotations == nil {
+		bundle.Annotations = map[string]string{}
+	}
+	bundle.Annotations[werfv1alpha1.ApprovalAnnotation] = value
+	if err := testk8sClient.Patch(ctx, bundle, patch, client.FieldOwner(approver)); err != nil {
+		t.Fatalf("failed to annotate WerfBundle: %v", err)
+	}
+}
+
+// expectPending checks that tag waits for approval and no Job was created for it.
+func expectPending(t *testing.T, bundle *werfv1alpha1.WerfBundle, tag, digest string) {
+	t.Helper()
+
+	if bundle.Status.PendingTag != tag || bundle.Status.PendingDigest != digest {
+		t.Errorf("expected %s (%s) to be pending, got %q (%q)",
+			tag, digest, bundle.Status.PendingTag, bundle.Status.PendingDigest)
+	}
+	if bundle.Status.ActiveJobName != "" {
+		t.Errorf("expected no Job before approval, got %q", bundle.Status.ActiveJobName)
+	}
+	condition := meta.FindStatusCondition(bundle.Status.Conditions, werfv1alpha1.ConditionAwaitingApproval)
+	if condition == nil || condition.Status != metav1.ConditionTrue ||
+		condition.Reason != werfv1alpha1.ReasonApprovalRequired {
+		t.Errorf("expected AwaitingApproval True with reason %s, got %+v",
+			werfv1alpha1.ReasonApprovalRequired, condition)
+	}
+}
+
+func TestReconcile_ManualApproval(t *testing.T) {
+	ctx := context.Background()
+	bundleName := testBundleNameForStep("manual-approval")
+	repoURL := "ghcr.io/test/manual-approval"
+	reconciler, fakeReg, req := newApprovalTestReconciler(t, ctx, bundleName, repoURL, []string{"v1.0.0"})
+	digest := FakeDigest(repoURL, "v1.0.0")
+
+	// A new tag waits for approval
+	result, err := reconciler.Reconcile(ctx, req)
+	if err != nil {
+		t.Fatalf("reconcile failed: %v", err)
+	}
+	if result.RequeueAfter == 0 {
+		t.Error("expected requeue while waiting for approval")
+	}
+	expectPending(t, getWerfBundle(t, ctx, bundleName, "default"), "v1.0.0", digest)
+
+	// Naming only the tag doesn't deploy the pending version: the tag may be re-pushed
+	approve(t, ctx, req, "v1.0.0", "release-manager")
+	reconciler.requestPoll(req.NamespacedName)
+	if _, err := reconciler.Reconcile(ctx, req); err != nil {
+		t.Fatalf("reconcile failed: %v", err)
+	}
+	expectPending(t, getWerfBundle(t, ctx, bundleName, "default"), "v1.0.0", digest)
+
+	// Approving the pending digest deploys it without waiting for the next poll
+	approve(t, ctx, req, digest, "release-manager")
+	if _, err := reconciler.Reconcile(ctx, req); err != nil {
+		t.Fatalf("reconcile after approval failed: %v", err)
+	}
+	updated := getWerfBundle(t, ctx, bundleName, "default")
+	if updated.Status.ActiveJobName == "" || updated.Status.LastAppliedTag != "v1.0.0" {
+		t.Fatalf("expected a Job for the approved v1.0.0, got job %q tag %q",
+			updated.Status.ActiveJobName, updated.Status.LastAppliedTag)
+	}
+	if updated.Status.PendingTag != "" || updated.Status.PendingDigest != "" {
+		t.Errorf("expected no pending version after approval, got %q (%q)",
+			updated.Status.PendingTag, updated.Status.PendingDigest)
+	}
+	approval := updated.Status.LastApproval
+	if approval == nil || approval.Tag != "v1.0.0" || approval.Digest != digest {
+		t.Fatalf("expected the approval of v1.0.0 (%s) to be recorded, got %+v", digest, approval)
+	}
+	if approval.ApprovedBy != "release-manager" || approval.ApprovedAt == nil {
+		t.Errorf("expected approval by release-manager with a time, got %q at %v",
+			approval.ApprovedBy, approval.ApprovedAt)
+	}
+	condition := meta.FindStatusCondition(updated.Status.Conditions, werfv1alpha1.ConditionAwaitingApproval)
+	if condition == nil || condition.Status != metav1.ConditionFalse || condition.Reason != werfv1alpha1.ReasonApproved {
+		t.Errorf("expected AwaitingApproval False with reason %s, got %+v", werfv1alpha1.ReasonApproved, condition)
+	}
+	completeActiveJob(t, ctx, reconciler, req)
+
+	// The next tag waits again, keeping the approved one deployed
+	fakeReg.SetTags(repoURL, []string{"v1.0.0", "v1.1.0"})
+	reconciler.requestPoll(req.NamespacedName)
+	if _, err := reconciler.Reconcile(ctx, req); err != nil {
+		t.Fatalf("reconcile after push failed: %v", err)
+	}
+	updated = getWerfBundle(t, ctx, bundleName, "default")
+	expectPending(t, updated, "v1.1.0", FakeDigest(repoURL, "v1.1.0"))
+	if updated.Status.LastAppliedTag != "v1.0.0" {
+		t.Errorf("expected v1.0.0 to stay deployed, got %q", updated.Status.LastAppliedTag)
+	}
+
+	// Switching to Automatic deploys it and drops the pending version
+	updated.Spec.Approval.Mode = werfv1alpha1.ApprovalModeAutomatic
+	if err := testk8sClient.Update(ctx, updated); err != nil {
+		t.Fatalf("failed to update spec.approval: %v", err)
+	}
+	if _, err := reconciler.Reconcile(ctx, req); err != nil {
+		t.Fatalf("reconcile after switching to Automatic failed: %v", err)
+	}
+	updated = getWerfBundle(t, ctx, bundleName, "default")
+	if updated.Status.LastAppliedTag != "v1.1.0" || updated.Status.ActiveJobName == "" {
+		t.Errorf("expected a Job for v1.1.0, got job %q tag %q",
+			updated.Status.ActiveJobName, updated.Status.LastAppliedTag)
+	}
+	if updated.Status.PendingTag != "" ||
+		meta.FindStatusCondition(updated.Status.Conditions, werfv1alpha1.ConditionAwaitingApproval) != nil {
+		t.Errorf("expected no pending version in Automatic mode, got %q with %+v",
+			updated.Status.PendingTag, updated.Status.Conditions)
+	}
+}
+
+func TestApproves(t *testing.T) {
+	digest := "sha256:7777777777777777777777777777777777777777777777777777777777777777"
+	tests := []struct {
+		value string
+		want  bool
+	}{
+		{value: "v1.0.0", want: false},
+		{value: digest, want: true},
+		{value: "v1.0.0@" + digest, want: true},
+		{value: " " + digest + "\n", want: true},
+		{value: "", want: false},
+		{value: "v1.0", want: false},
+		{value: "v0.9.0@" + digest, want: false},
+	}
+	for _, tt := range tests {
+		if got := approves(tt.value, "v1.0.0", digest); got != tt.want {
+			t.Errorf("approves(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestApprovalManager(t *testing.T) {
+	earlier := metav1.NewTime(time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC))
+	later := metav1.NewTime(earlier.Add(time.Hour))
+	annotationFields := &metav1.FieldsV1{
+		Raw: []byte(`{"f:metadata":{"f:annotations":{".":{},"f:werf.io/approve":{}}}}`),
+	}
+	bundle := &werfv1alpha1.WerfBundle{
+		ObjectMeta: metav1.ObjectMeta{
+			ManagedFields: []metav1.ManagedFieldsEntry{
+				{Manager: "kubectl-client-side-apply", Time: &later,
+					FieldsV1: &metav1.FieldsV1{Raw: []byte(`{"f:spec":{"f:registry":{}}}`)}},
+				{Manager: "alice", Time: &earlier, FieldsV1: annotationFields},
+				{Manager: "kubectl-annotate", Time: &later, FieldsV1: annotationFields},
+				{Manager: "werf-operator", Time: &later, Subresource: "status",
+					FieldsV1: &metav1.FieldsV1{Raw: []byte(`{"f:status":{}}`)}},
+			},
+		},
+	}
+
+	manager, at := approvalManager(bundle)
+	if manager != "kubectl-annotate" || at == nil || !at.Equal(&later) {
+		t.Errorf("expected the latest owner kubectl-annotate at %v, got %q at %v", later, manager, at)
+	}
+
+	bundle.ManagedFields = bundle.ManagedFields[:1]
+	if manager, at := approvalManager(bundle); manager != "" || at != nil {
+		t.Errorf("expected no owner of the annotation, got %q at %v", manager, at)
+	}
+}
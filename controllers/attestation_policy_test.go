@@ -0,0 +1,102 @@
+package controllers
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	werfv1alpha1 "github.com/werf/k8s-werf-operator-go/api/v1alpha1"
+	"github.com/werf/k8s-werf-operator-go/internal/registry"
+	testingutil "github.com/werf/k8s-werf-operator-go/internal/testing"
+)
+
+func TestReconcile_Attestations_BlockJobUntilAttached(t *testing.T) {
+	ctx := context.Background()
+	bundleName := testBundleNameForStep("attestations")
+	repoURL := startAuthRegistry(t, "test/attested", "v1.0.0")
+	createRegistrySecret(t, ctx, bundleName+"-creds", "default", repoURL, testRegistryPassword)
+
+	bundle := &werfv1alpha1.WerfBundle{
+		ObjectMeta: metav1.ObjectMeta{Name: bundleName, Namespace: "default"},
+		Spec: werfv1alpha1.WerfBundleSpec{
+			Registry: werfv1alpha1.RegistryConfig{
+				URL:       repoURL,
+				SecretRef: &corev1.LocalObjectReference{Name: bundleName + "-creds"},
+			},
+			Converge: werfv1alpha1.ConvergeConfig{ServiceAccountName: "default"},
+			Attestations: []werfv1alpha1.AttestationRequirement{{
+				ArtifactType: "application/spdx+json",
+				Predicates: []werfv1alpha1.AttestationPredicate{
+					{Path: "spdxVersion", Operator: registry.PredicateEquals, Value: "SPDX-2.3"},
+				},
+			}},
+		},
+	}
+	if err := testk8sClient.Create(ctx, bundle); err != nil {
+		t.Fatalf("failed to create WerfBundle: %v", err)
+	}
+
+	client := registry.NewOCIClient()
+	digest, err := client.ResolveDigest(ctx, repoURL, "v1.0.0", testRegistryAuth())
+	if err != nil {
+		t.Fatalf("failed to resolve digest: %v", err)
+	}
+	reconciler := &WerfBundleReconciler{
+		Client:         testk8sClient,
+		Scheme:         testk8sClient.Scheme(),
+		RegistryClient: client,
+		Clientset:      testK8sClientset,
+	}
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: bundleName, Namespace: "default"}}
+
+	// An SBOM of the wrong version doesn't meet the requirement
+	sbom := []byte(`{"spdxVersion": "SPDX-2.2"}`)
+	if err := testingutil.AttachArtifact(ctx, repoURL, digest, "application/spdx+json", sbom, nil,
+		testRegistryAuth()); err != nil {
+		t.Fatalf("failed to attach SBOM: %v", err)
+	}
+	result, err := reconciler.Reconcile(ctx, req)
+	if err != nil {
+		t.Fatalf("reconcile failed: %v", err)
+	}
+	if result.RequeueAfter == 0 {
+		t.Error("expected requeue so a later attestation is picked up")
+	}
+	updated := getWerfBundle(t, ctx, bundleName, "default")
+	if updated.Status.Phase != werfv1alpha1.PhaseFailed ||
+		updated.Status.FailureReason != werfv1alpha1.FailureReasonAttestationFailed {
+		t.Errorf("expected phase Failed with reason %s, got %s with %q", werfv1alpha1.FailureReasonAttestationFailed,
+			updated.Status.Phase, updated.Status.FailureReason)
+	}
+	if !strings.Contains(updated.Status.LastErrorMessage, `spdxVersion is "SPDX-2.2", expected "SPDX-2.3"`) {
+		t.Errorf("expected the unmet predicate in the message, got %q", updated.Status.LastErrorMessage)
+	}
+	if updated.Status.ActiveJobName != "" || updated.Status.LastAppliedTag != "" {
+		t.Errorf("expected no Job and no applied tag, got job %q tag %q",
+			updated.Status.ActiveJobName, updated.Status.LastAppliedTag)
+	}
+
+	// Attaching a matching SBOM lets the next poll deploy the bundle
+	sbom = []byte(`{"spdxVersion": "SPDX-2.3"}`)
+	if err := testingutil.AttachArtifact(ctx, repoURL, digest, "application/spdx+json", sbom, nil,
+		testRegistryAuth()); err != nil {
+		t.Fatalf("failed to attach SBOM: %v", err)
+	}
+	reconciler.requestPoll(req.NamespacedName)
+	if _, err := reconciler.Reconcile(ctx, req); err != nil {
+		t.Fatalf("reconcile after attaching failed: %v", err)
+	}
+	updated = getWerfBundle(t, ctx, bundleName, "default")
+	if updated.Status.ActiveJobName == "" {
+		t.Errorf("expected a converge Job once the SBOM is attached, got phase %s: %s",
+			updated.Status.Phase, updated.Status.LastErrorMessage)
+	}
+	if updated.Status.FailureReason != "" {
+		t.Errorf("expected failure reason to be cleared, got %q", updated.Status.FailureReason)
+	}
+}
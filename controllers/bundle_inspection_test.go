@@ -0,0 +1,62 @@
+package controllers
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	werfv1alpha1 "github.com/werf/k8s-werf-operator-go/api/v1alpha1"
+)
+
+func TestReconcile_InvalidBundle_NoJobUntilRepushed(t *testing.T) {
+	ctx := context.Background()
+	bundleName := testBundleNameForStep("invalid-bundle")
+	repoURL := "ghcr.io/test/invalid-bundle"
+	reconciler, fakeReg, req := newDigestTestReconciler(t, ctx, bundleName, repoURL, []string{"v1.0.0"})
+	imageDigest := "sha256:4444444444444444444444444444444444444444444444444444444444444444"
+	bundleDigest := "sha256:5555555555555555555555555555555555555555555555555555555555555555"
+	fakeReg.SetDigest(repoURL, "v1.0.0", imageDigest)
+	fakeReg.SetInvalid(repoURL, imageDigest, "config media type is application/vnd.oci.image.config.v1+json")
+
+	// A container image pushed as the tag fails the bundle without a Job
+	result, err := reconciler.Reconcile(ctx, req)
+	if err != nil {
+		t.Fatalf("reconcile failed: %v", err)
+	}
+	if result.RequeueAfter == 0 {
+		t.Error("expected requeue so a re-pushed tag is picked up")
+	}
+	updated := getWerfBundle(t, ctx, bundleName, "default")
+	if updated.Status.Phase != werfv1alpha1.PhaseFailed ||
+		updated.Status.FailureReason != werfv1alpha1.FailureReasonInvalidBundle {
+		t.Errorf("expected phase Failed with reason %s, got %s with %q", werfv1alpha1.FailureReasonInvalidBundle,
+			updated.Status.Phase, updated.Status.FailureReason)
+	}
+	for _, want := range []string{"v1.0.0", imageDigest, "not a werf bundle"} {
+		if !strings.Contains(updated.Status.LastErrorMessage, want) {
+			t.Errorf("expected %q in the message, got %q", want, updated.Status.LastErrorMessage)
+		}
+	}
+	if updated.Status.ActiveJobName != "" || updated.Status.LastAppliedTag != "" {
+		t.Errorf("expected no Job and no applied tag, got job %q tag %q",
+			updated.Status.ActiveJobName, updated.Status.LastAppliedTag)
+	}
+
+	// Re-pushing the tag with a bundle lets the next poll deploy it
+	fakeReg.SetDigest(repoURL, "v1.0.0", bundleDigest)
+	reconciler.requestPoll(req.NamespacedName)
+	if _, err := reconciler.Reconcile(ctx, req); err != nil {
+		t.Fatalf("reconcile after re-push failed: %v", err)
+	}
+	updated = getWerfBundle(t, ctx, bundleName, "default")
+	if updated.Status.ActiveJobName == "" {
+		t.Errorf("expected a converge Job once the tag is a bundle, got phase %s: %s",
+			updated.Status.Phase, updated.Status.LastErrorMessage)
+	}
+	if updated.Status.LastAppliedDigest != bundleDigest {
+		t.Errorf("expected LastAppliedDigest %q, got %q", bundleDigest, updated.Status.LastAppliedDigest)
+	}
+	if updated.Status.FailureReason != "" {
+		t.Errorf("expected failure reason to be cleared, got %q", updated.Status.FailureReason)
+	}
+}
@@ -0,0 +1,221 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	batchv1 "k8s.io/api/batch/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	werfv1alpha1 "github.com/werf/k8s-werf-operator-go/api/v1alpha1"
+	"github.com/werf/k8s-werf-operator-go/internal/converge"
+)
+
+// newDigestTestReconciler creates a bundle polling repoURL and a reconciler backed by a
+// fake registry serving tags, so tests can move tags between reconciles.
+func newDigestTestReconciler(
+	t *testing.T,
+	ctx context.Context,
+	bundleName, repoURL string,
+	tags []string,
+) (*WerfBundleReconciler, *FakeRegistry, reconcile.Request) {
+	t.Helper()
+
+	bundle := &werfv1alpha1.WerfBundle{
+		ObjectMeta: metav1.ObjectMeta{Name: bundleName, Namespace: "default"},
+		Spec: werfv1alpha1.WerfBundleSpec{
+			Registry: werfv1alpha1.RegistryConfig{URL: repoURL},
+			Converge: werfv1alpha1.ConvergeConfig{ServiceAccountName: "default"},
+		},
+	}
+	if err := testk8sClient.Create(ctx, bundle); err != nil {
+		t.Fatalf("failed to create WerfBundle: %v", err)
+	}
+
+	fakeReg := NewFakeRegistry()
+	fakeReg.SetTags(repoURL, tags)
+	reconciler := &WerfBundleReconciler{
+		Client:         testk8sClient,
+		Scheme:         testk8sClient.Scheme(),
+		RegistryClient: fakeReg,
+		Clientset:      testK8sClientset,
+	}
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: bundleName, Namespace: "default"}}
+	return reconciler, fakeReg, req
+}
+
+// orderTagsLexically sets tagFilter.sortPolicy lexical on the bundle, so tests can track a
+// single mutable tag such as "main": without a sort policy, tags that aren't semver are ignored.
+func orderTagsLexically(t *testing.T, ctx context.Context, req reconcile.Request) {
+	t.Helper()
+
+	bundle := getWerfBundle(t, ctx, req.Name, req.Namespace)
+	bundle.Spec.Registry.TagFilter = &werfv1alpha1.TagFilter{SortPolicy: werfv1alpha1.SortPolicyLexical}
+	if err := testk8sClient.Update(ctx, bundle); err != nil {
+		t.Fatalf("failed to set tagFilter: %v", err)
+	}
+}
+
+// completeActiveJob marks the bundle's active Job as succeeded and reconciles so the
+// controller records the deployment.
+func completeActiveJob(t *testing.T, ctx context.Context, reconciler *WerfBundleReconciler, req reconcile.Request) {
+	t.Helper()
+
+	job := getJobInNamespace(t, ctx, req.Name, "default")
+	job.Status.Succeeded = 1
+	if err := testk8sClient.Status().Update(ctx, job); err != nil {
+		t.Fatalf("failed to update job status: %v", err)
+	}
+	if _, err := reconciler.Reconcile(ctx, req); err != nil {
+		t.Fatalf("reconcile after job completion failed: %v", err)
+	}
+
+	bundle := getWerfBundle(t, ctx, req.Name, "default")
+	if bundle.Status.Phase != werfv1alpha1.PhaseSynced {
+		t.Fatalf("expected phase Synced after job completion, got %s", bundle.Status.Phase)
+	}
+}
+
+func TestReconcile_Digest_JobPinnedToResolvedDigest(t *testing.T) {
+	ctx := context.Background()
+	bundleName := testBundleNameForStep("digest-pinned")
+	repoURL := "ghcr.io/test/digest-pinned"
+	reconciler, fakeReg, req := newDigestTestReconciler(t, ctx, bundleName, repoURL, []string{"main"})
+	orderTagsLexically(t, ctx, req)
+	digest := "sha256:1111111111111111111111111111111111111111111111111111111111111111"
+	fakeReg.SetDigest(repoURL, "main", digest)
+
+	if _, err := reconciler.Reconcile(ctx, req); err != nil {
+		t.Fatalf("reconcile failed: %v", err)
+	}
+
+	updated := getWerfBundle(t, ctx, bundleName, "default")
+	if updated.Status.LastAppliedTag != "main" {
+		t.Errorf("expected LastAppliedTag main, got %q", updated.Status.LastAppliedTag)
+	}
+	if updated.Status.LastAppliedDigest != digest {
+		t.Errorf("expected LastAppliedDigest %q, got %q", digest, updated.Status.LastAppliedDigest)
+	}
+
+	job := getJobInNamespace(t, ctx, bundleName, "default")
+	args := job.Spec.Template.Spec.Containers[0].Args
+	if !containsArg(args, repoURL+"@"+digest) {
+		t.Errorf("expected Job to deploy %s@%s, got args %v", repoURL, digest, args)
+	}
+	if job.Annotations[converge.DigestAnnotation] != digest {
+		t.Errorf("expected digest annotation %q, got %q", digest, job.Annotations[converge.DigestAnnotation])
+	}
+}
+
+func TestReconcile_Digest_MovedTagTriggersNewJob(t *testing.T) {
+	ctx := context.Background()
+	bundleName := testBundleNameForStep("digest-moved")
+	repoURL := "ghcr.io/test/digest-moved"
+	reconciler, fakeReg, req := newDigestTestReconciler(t, ctx, bundleName, repoURL, []string{"stable"})
+	orderTagsLexically(t, ctx, req)
+	oldDigest := "sha256:2222222222222222222222222222222222222222222222222222222222222222"
+	newDigest := "sha256:3333333333333333333333333333333333333333333333333333333333333333"
+	fakeReg.SetDigest(repoURL, "stable", oldDigest)
+
+	if _, err := reconciler.Reconcile(ctx, req); err != nil {
+		t.Fatalf("first reconcile failed: %v", err)
+	}
+	firstJob := getWerfBundle(t, ctx, bundleName, "default").Status.ActiveJobName
+	completeActiveJob(t, ctx, reconciler, req)
+
+	// Re-push the tag: the tag list (and its ETag) is unchanged, only the digest moves
+	fakeReg.SetDigest(repoURL, "stable", newDigest)
+	reconciler.requestPoll(req.NamespacedName)
+
+	result, err := reconciler.Reconcile(ctx, req)
+	if err != nil {
+		t.Fatalf("reconcile after tag move failed: %v", err)
+	}
+	if result.RequeueAfter == 0 {
+		t.Error("expected requeue to monitor the new Job")
+	}
+
+	updated := getWerfBundle(t, ctx, bundleName, "default")
+	if updated.Status.Phase != werfv1alpha1.PhaseSyncing {
+		t.Errorf("expected phase Syncing, got %s", updated.Status.Phase)
+	}
+	if updated.Status.ActiveJobName == "" || updated.Status.ActiveJobName == firstJob {
+		t.Fatalf("expected a new Job for the moved tag, got %q (first job %q)", updated.Status.ActiveJobName, firstJob)
+	}
+	if updated.Status.LastAppliedDigest != newDigest {
+		t.Errorf("expected LastAppliedDigest %q, got %q", newDigest, updated.Status.LastAppliedDigest)
+	}
+
+	job := &batchv1.Job{}
+	key := types.NamespacedName{Name: updated.Status.ActiveJobName, Namespace: "default"}
+	if err := testk8sClient.Get(ctx, key, job); err != nil {
+		t.Fatalf("failed to get new Job: %v", err)
+	}
+	args := job.Spec.Template.Spec.Containers[0].Args
+	if !containsArg(args, repoURL+"@"+newDigest) {
+		t.Errorf("expected new Job to deploy %s@%s, got args %v", repoURL, newDigest, args)
+	}
+}
+
+func TestReconcile_Digest_UnchangedDigestStaysSynced(t *testing.T) {
+	ctx := context.Background()
+	bundleName := testBundleNameForStep("digest-unchanged")
+	repoURL := "ghcr.io/test/digest-unchanged"
+	reconciler, _, req := newDigestTestReconciler(t, ctx, bundleName, repoURL, []string{"v1.0.0"})
+
+	if _, err := reconciler.Reconcile(ctx, req); err != nil {
+		t.Fatalf("first reconcile failed: %v", err)
+	}
+	completeActiveJob(t, ctx, reconciler, req)
+	reconciler.requestPoll(req.NamespacedName)
+
+	result, err := reconciler.Reconcile(ctx, req)
+	if err != nil {
+		t.Fatalf("reconcile failed: %v", err)
+	}
+	// Polling must continue so a later re-push is noticed
+	if result.RequeueAfter == 0 {
+		t.Error("expected requeue after poll interval")
+	}
+
+	updated := getWerfBundle(t, ctx, bundleName, "default")
+	if updated.Status.Phase != werfv1alpha1.PhaseSynced {
+		t.Errorf("expected phase Synced, got %s", updated.Status.Phase)
+	}
+	if updated.Status.ActiveJobName != "" {
+		t.Errorf("expected no new Job, got %q", updated.Status.ActiveJobName)
+	}
+	if updated.Status.LastAppliedDigest != FakeDigest(repoURL, "v1.0.0") {
+		t.Errorf("expected LastAppliedDigest %q, got %q",
+			FakeDigest(repoURL, "v1.0.0"), updated.Status.LastAppliedDigest)
+	}
+}
+
+func TestReconcile_Digest_AdoptedWithoutRedeploy(t *testing.T) {
+	ctx := context.Background()
+	bundleName := testBundleNameForStep("digest-adopt")
+	repoURL := "ghcr.io/test/digest-adopt"
+	reconciler, _, req := newDigestTestReconciler(t, ctx, bundleName, repoURL, []string{"v1.0.0"})
+
+	// Bundle deployed before digests were tracked
+	bundle := getWerfBundle(t, ctx, bundleName, "default")
+	bundle.Status.Phase = werfv1alpha1.PhaseSynced
+	bundle.Status.LastAppliedTag = "v1.0.0"
+	if err := testk8sClient.Status().Update(ctx, bundle); err != nil {
+		t.Fatalf("failed to set legacy status: %v", err)
+	}
+
+	if _, err := reconciler.Reconcile(ctx, req); err != nil {
+		t.Fatalf("reconcile failed: %v", err)
+	}
+
+	updated := getWerfBundle(t, ctx, bundleName, "default")
+	if updated.Status.ActiveJobName != "" {
+		t.Errorf("expected no Job for an already deployed tag, got %q", updated.Status.ActiveJobName)
+	}
+	if updated.Status.LastAppliedDigest != FakeDigest(repoURL, "v1.0.0") {
+		t.Errorf("expected digest to be adopted, got %q", updated.Status.LastAppliedDigest)
+	}
+}
@@ -3,6 +3,10 @@ package controllers
 
 import (
 	"context"
+	"crypto"
+	"crypto/sha256"
+	"errors"
+	"fmt"
 	"sort"
 
 	"github.com/google/go-containerregistry/pkg/authn"
@@ -16,13 +20,35 @@ type FakeRegistry struct {
 
 	// ErrorsByRepo maps repository URL to error that should be returned
 	ErrorsByRepo map[string]error
+
+	// DigestsByRef maps "repoURL:tag" to the manifest digest returned by ResolveDigest.
+	// Tags without an entry resolve to a digest derived from the reference.
+	DigestsByRef map[string]string
+
+	// MetadataByRef maps "repoURL:tag" to the image metadata returned by ImageMetadata.
+	// Tags without an entry have no annotations.
+	MetadataByRef map[string]*registry.ImageMetadata
+
+	// InvalidDigests maps "repoURL@digest" to the reason InspectBundle reports the artifact
+	// isn't a werf bundle. Digests without an entry are bundles.
+	InvalidDigests map[string]string
+
+	// Polls counts ListTagsWithETag calls, i.e. registry polls by the controller.
+	Polls int
+
+	// PollsByRepo counts ListTagsWithETag calls per repository URL.
+	PollsByRepo map[string]int
 }
 
 // NewFakeRegistry creates a new fake registry for testing.
 func NewFakeRegistry() *FakeRegistry {
 	return &FakeRegistry{
-		TagsByRepo:   make(map[string][]string),
-		ErrorsByRepo: make(map[string]error),
+		TagsByRepo:     make(map[string][]string),
+		ErrorsByRepo:   make(map[string]error),
+		DigestsByRef:   make(map[string]string),
+		MetadataByRef:  make(map[string]*registry.ImageMetadata),
+		InvalidDigests: make(map[string]string),
+		PollsByRepo:    make(map[string]int),
 	}
 }
 
@@ -40,6 +66,26 @@ func (f *FakeRegistry) SetError(repoURL string, err error) {
 	f.ErrorsByRepo[repoURL] = err
 }
 
+// SetDigest sets the digest that tag resolves to, e.g. to simulate a re-pushed tag.
+func (f *FakeRegistry) SetDigest(repoURL, tag, digest string) {
+	f.DigestsByRef[repoURL+":"+tag] = digest
+}
+
+// SetAnnotations sets the annotations of the image tag points to.
+func (f *FakeRegistry) SetAnnotations(repoURL, tag string, annotations map[string]string) {
+	f.MetadataByRef[repoURL+":"+tag] = &registry.ImageMetadata{Annotations: annotations}
+}
+
+// SetInvalid makes InspectBundle report that the artifact digest points to isn't a werf bundle.
+func (f *FakeRegistry) SetInvalid(repoURL, digest, reason string) {
+	f.InvalidDigests[repoURL+"@"+digest] = reason
+}
+
+// FakeDigest returns the default digest ResolveDigest reports for a tag without SetDigest.
+func FakeDigest(repoURL, tag string) string {
+	return fmt.Sprintf("sha256:%x", sha256.Sum256([]byte(repoURL+":"+tag)))
+}
+
 // ListTags returns predefined tags for testing.
 func (f *FakeRegistry) ListTags(ctx context.Context, repoURL string, auth authn.Authenticator) ([]string, error) {
 	if err, ok := f.ErrorsByRepo[repoURL]; ok {
@@ -75,6 +121,8 @@ func (f *FakeRegistry) ListTagsWithETag(
 	auth authn.Authenticator,
 	lastETag string,
 ) ([]string, string, error) {
+	f.Polls++
+	f.PollsByRepo[repoURL]++
 	tags, err := f.ListTags(ctx, repoURL, auth)
 	if err != nil {
 		return nil, "", err
@@ -91,5 +139,82 @@ func (f *FakeRegistry) ListTagsWithETag(
 	return tags, currentETag, nil
 }
 
+// ResolveDigest returns the digest set with SetDigest, or FakeDigest for the tag.
+func (f *FakeRegistry) ResolveDigest(
+	ctx context.Context,
+	repoURL, tag string,
+	auth authn.Authenticator,
+) (string, error) {
+	if err, ok := f.ErrorsByRepo[repoURL]; ok {
+		return "", err
+	}
+
+	if digest, ok := f.DigestsByRef[repoURL+":"+tag]; ok {
+		return digest, nil
+	}
+	return FakeDigest(repoURL, tag), nil
+}
+
+// ImageMetadata returns the metadata set with SetAnnotations, with the digest of the tag.
+func (f *FakeRegistry) ImageMetadata(
+	ctx context.Context,
+	repoURL, tag string,
+	auth authn.Authenticator,
+) (*registry.ImageMetadata, error) {
+	digest, err := f.ResolveDigest(ctx, repoURL, tag, auth)
+	if err != nil {
+		return nil, err
+	}
+
+	metadata := &registry.ImageMetadata{Digest: digest}
+	if set, ok := f.MetadataByRef[repoURL+":"+tag]; ok {
+		metadata.Annotations = set.Annotations
+	}
+	return metadata, nil
+}
+
+// InspectBundle returns an InvalidBundleError for digests set with SetInvalid.
+func (f *FakeRegistry) InspectBundle(
+	ctx context.Context,
+	repoURL, digest string,
+	auth authn.Authenticator,
+) error {
+	if err, ok := f.ErrorsByRepo[repoURL]; ok {
+		return err
+	}
+
+	if reason, ok := f.InvalidDigests[repoURL+"@"+digest]; ok {
+		return &registry.InvalidBundleError{Err: errors.New(reason)}
+	}
+	return nil
+}
+
+// VerifyCosignSignature accepts every digest; signature verification is tested against
+// a real registry.
+func (f *FakeRegistry) VerifyCosignSignature(
+	ctx context.Context,
+	repoURL, digest string,
+	auth authn.Authenticator,
+	keys []crypto.PublicKey,
+) error {
+	if err, ok := f.ErrorsByRepo[repoURL]; ok {
+		return err
+	}
+	return nil
+}
+
+// VerifyAttestations accepts every digest; attestations are tested against a real registry.
+func (f *FakeRegistry) VerifyAttestations(
+	ctx context.Context,
+	repoURL, digest string,
+	auth authn.Authenticator,
+	requirements []registry.AttestationRequirement,
+) error {
+	if err, ok := f.ErrorsByRepo[repoURL]; ok {
+		return err
+	}
+	return nil
+}
+
 // Verify that FakeRegistry implements registry.Client
 var _ registry.Client = (*FakeRegistry)(nil)
@@ -0,0 +1,120 @@
+package controllers
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	werfv1alpha1 "github.com/werf/k8s-werf-operator-go/api/v1alpha1"
+	"github.com/werf/k8s-werf-operator-go/internal/registry"
+)
+
+func TestReconcile_MinTagAge(t *testing.T) {
+	ctx := context.Background()
+	bundleName := testBundleNameForStep("min-tag-age")
+	repoURL := "ghcr.io/test/min-tag-age"
+	reconciler, fakeReg, req := newDigestTestReconciler(t, ctx, bundleName, repoURL, []string{"v1.0.0", "v1.1.0"})
+	bundle := getWerfBundle(t, ctx, bundleName, "default")
+	bundle.Spec.Registry.MinTagAge = "1h"
+	if err := testk8sClient.Update(ctx, bundle); err != nil {
+		t.Fatalf("failed to set minTagAge: %v", err)
+	}
+	// v1.0.0 was created two hours ago; v1.1.0 has no creation time and was just pushed
+	fakeReg.SetAnnotations(repoURL, "v1.0.0", map[string]string{
+		registry.AnnotationCreated: time.Now().Add(-2 * time.Hour).UTC().Format(time.RFC3339),
+	})
+
+	// The young tag is skipped in favor of the old enough one
+	start := time.Now()
+	if _, err := reconciler.Reconcile(ctx, req); err != nil {
+		t.Fatalf("reconcile failed: %v", err)
+	}
+	updated := getWerfBundle(t, ctx, bundleName, "default")
+	if updated.Status.LastAppliedTag != "v1.0.0" || updated.Status.ActiveJobName == "" {
+		t.Fatalf("expected a Job for v1.0.0, got job %q tag %q",
+			updated.Status.ActiveJobName, updated.Status.LastAppliedTag)
+	}
+	if !strings.Contains(updated.Status.SelectionReason, "younger than minTagAge 1h0m0s: v1.1.0") {
+		t.Errorf("expected the skipped tag in the selection reason, got %q", updated.Status.SelectionReason)
+	}
+	if len(updated.Status.SeenTags) != 1 || updated.Status.SeenTags[0].Tag != "v1.1.0" ||
+		updated.Status.SeenTags[0].Digest != FakeDigest(repoURL, "v1.1.0") {
+		t.Fatalf("expected v1.1.0 to be recorded as seen, got %+v", updated.Status.SeenTags)
+	}
+	if next := updated.Status.NextPollTime; next == nil || next.After(start.Add(time.Hour+time.Second)) {
+		t.Errorf("expected the next poll when v1.1.0 is old enough, got %v", next)
+	}
+	completeActiveJob(t, ctx, reconciler, req)
+
+	// Once its first-seen time is old enough, a restarted operator deploys it
+	updated = getWerfBundle(t, ctx, bundleName, "default")
+	updated.Status.SeenTags[0].FirstSeen = metav1.NewTime(time.Now().Add(-2 * time.Hour))
+	if err := testk8sClient.Status().Update(ctx, updated); err != nil {
+		t.Fatalf("failed to age v1.1.0: %v", err)
+	}
+	restarted := &WerfBundleReconciler{
+		Client:         testk8sClient,
+		Scheme:         testk8sClient.Scheme(),
+		RegistryClient: fakeReg,
+		Clientset:      testK8sClientset,
+	}
+	restarted.requestPoll(req.NamespacedName)
+	if _, err := restarted.Reconcile(ctx, req); err != nil {
+		t.Fatalf("reconcile after restart failed: %v", err)
+	}
+	updated = getWerfBundle(t, ctx, bundleName, "default")
+	if updated.Status.LastAppliedTag != "v1.1.0" || updated.Status.ActiveJobName == "" {
+		t.Fatalf("expected a Job for v1.1.0, got job %q tag %q (%s)",
+			updated.Status.ActiveJobName, updated.Status.LastAppliedTag, updated.Status.SelectionReason)
+	}
+	completeActiveJob(t, ctx, restarted, req)
+
+	// Re-pushing the applied tag restarts its age without falling back to v1.0.0
+	repushed := "sha256:8888888888888888888888888888888888888888888888888888888888888888"
+	fakeReg.SetDigest(repoURL, "v1.1.0", repushed)
+	restarted.requestPoll(req.NamespacedName)
+	if _, err := restarted.Reconcile(ctx, req); err != nil {
+		t.Fatalf("reconcile after re-push failed: %v", err)
+	}
+	updated = getWerfBundle(t, ctx, bundleName, "default")
+	if updated.Status.ActiveJobName != "" || updated.Status.LastAppliedTag != "v1.1.0" ||
+		updated.Status.LastAppliedDigest != FakeDigest(repoURL, "v1.1.0") {
+		t.Errorf("expected the previous v1.1.0 digest to stay deployed, got job %q tag %q digest %q",
+			updated.Status.ActiveJobName, updated.Status.LastAppliedTag, updated.Status.LastAppliedDigest)
+	}
+	if !strings.Contains(updated.Status.SelectionReason, "applied tag v1.1.0 was re-pushed") {
+		t.Errorf("expected the re-push in the selection reason, got %q", updated.Status.SelectionReason)
+	}
+	if len(updated.Status.SeenTags) != 1 || updated.Status.SeenTags[0].Digest != repushed {
+		t.Errorf("expected the re-pushed digest to be recorded, got %+v", updated.Status.SeenTags)
+	}
+}
+
+func TestReconcile_MinTagAge_Invalid(t *testing.T) {
+	ctx := context.Background()
+	bundleName := testBundleNameForStep("min-tag-age-invalid")
+	repoURL := "ghcr.io/test/min-tag-age-invalid"
+	reconciler, _, req := newDigestTestReconciler(t, ctx, bundleName, repoURL, []string{"v1.0.0"})
+	bundle := getWerfBundle(t, ctx, bundleName, "default")
+	// Matches the CRD pattern, but overflows
+	bundle.Spec.Registry.MinTagAge = "99999999999h"
+	if err := testk8sClient.Update(ctx, bundle); err != nil {
+		t.Fatalf("failed to set minTagAge: %v", err)
+	}
+
+	if _, err := reconciler.Reconcile(ctx, req); err != nil {
+		t.Fatalf("reconcile failed: %v", err)
+	}
+	updated := getWerfBundle(t, ctx, bundleName, "default")
+	if updated.Status.Phase != werfv1alpha1.PhaseFailed ||
+		updated.Status.FailureReason != werfv1alpha1.FailureReasonInvalidMinTagAge {
+		t.Errorf("expected phase Failed with reason %s, got %s with %q",
+			werfv1alpha1.FailureReasonInvalidMinTagAge, updated.Status.Phase, updated.Status.FailureReason)
+	}
+	if updated.Status.ActiveJobName != "" {
+		t.Errorf("expected no Job for an invalid minTagAge, got %q", updated.Status.ActiveJobName)
+	}
+}
@@ -0,0 +1,224 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	werfv1alpha1 "github.com/werf/k8s-werf-operator-go/api/v1alpha1"
+	"github.com/werf/k8s-werf-operator-go/internal/converge"
+)
+
+// newPinnedTestReconciler creates a bundle pinned to version and a reconciler backed by a
+// fake registry serving tags.
+func newPinnedTestReconciler(
+	t *testing.T,
+	ctx context.Context,
+	bundleName, repoURL, version string,
+	tags []string,
+) (*WerfBundleReconciler, *FakeRegistry, reconcile.Request) {
+	t.Helper()
+
+	bundle := &werfv1alpha1.WerfBundle{
+		ObjectMeta: metav1.ObjectMeta{Name: bundleName, Namespace: "default"},
+		Spec: werfv1alpha1.WerfBundleSpec{
+			Registry: werfv1alpha1.RegistryConfig{URL: repoURL},
+			Converge: werfv1alpha1.ConvergeConfig{ServiceAccountName: "default"},
+			Version:  version,
+		},
+	}
+	if err := testk8sClient.Create(ctx, bundle); err != nil {
+		t.Fatalf("failed to create WerfBundle: %v", err)
+	}
+
+	fakeReg := NewFakeRegistry()
+	fakeReg.SetTags(repoURL, tags)
+	reconciler := &WerfBundleReconciler{
+		Client:         testk8sClient,
+		Scheme:         testk8sClient.Scheme(),
+		RegistryClient: fakeReg,
+		Clientset:      testK8sClientset,
+	}
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: bundleName, Namespace: "default"}}
+	return reconciler, fakeReg, req
+}
+
+// setPinnedVersion changes spec.version of the bundle.
+func setPinnedVersion(t *testing.T, ctx context.Context, req reconcile.Request, version string) {
+	t.Helper()
+
+	bundle := getWerfBundle(t, ctx, req.Name, req.Namespace)
+	bundle.Spec.Version = version
+	if err := testk8sClient.Update(ctx, bundle); err != nil {
+		t.Fatalf("failed to update spec.version: %v", err)
+	}
+}
+
+// expectUpdateAvailable checks the UpdateAvailable condition of the bundle.
+func expectUpdateAvailable(
+	t *testing.T,
+	bundle *werfv1alpha1.WerfBundle,
+	status metav1.ConditionStatus,
+	reason string,
+) {
+	t.Helper()
+
+	condition := meta.FindStatusCondition(bundle.Status.Conditions, werfv1alpha1.ConditionUpdateAvailable)
+	if condition == nil {
+		t.Fatalf("expected an UpdateAvailable condition, got %+v", bundle.Status.Conditions)
+	}
+	if condition.Status != status || condition.Reason != reason {
+		t.Errorf("expected UpdateAvailable %s with reason %s, got %s with %s: %s",
+			status, reason, condition.Status, condition.Reason, condition.Message)
+	}
+}
+
+func TestReconcile_PinnedVersion_DeploysPinnedTagAndReportsLatest(t *testing.T) {
+	ctx := context.Background()
+	bundleName := testBundleNameForStep("pinned-tag")
+	repoURL := "ghcr.io/test/pinned-tag"
+	reconciler, _, req := newPinnedTestReconciler(t, ctx, bundleName, repoURL, "v1.0.0",
+		[]string{"v1.0.0", "v1.1.0"})
+
+	// The pinned tag is deployed, not the newest one
+	if _, err := reconciler.Reconcile(ctx, req); err != nil {
+		t.Fatalf("reconcile failed: %v", err)
+	}
+	updated := getWerfBundle(t, ctx, bundleName, "default")
+	if updated.Status.LastAppliedTag != "v1.0.0" {
+		t.Errorf("expected LastAppliedTag v1.0.0, got %q", updated.Status.LastAppliedTag)
+	}
+	if updated.Status.LatestAvailableTag != "v1.1.0" {
+		t.Errorf("expected LatestAvailableTag v1.1.0, got %q", updated.Status.LatestAvailableTag)
+	}
+	expectUpdateAvailable(t, updated, metav1.ConditionTrue, werfv1alpha1.ReasonNewerVersionAvailable)
+	job := getJobInNamespace(t, ctx, bundleName, "default")
+	if !containsArg(job.Spec.Template.Spec.Containers[0].Args, repoURL+"@"+FakeDigest(repoURL, "v1.0.0")) {
+		t.Errorf("expected Job to deploy v1.0.0, got args %v", job.Spec.Template.Spec.Containers[0].Args)
+	}
+	completeActiveJob(t, ctx, reconciler, req)
+
+	// Later polls keep the pin
+	reconciler.requestPoll(req.NamespacedName)
+	if _, err := reconciler.Reconcile(ctx, req); err != nil {
+		t.Fatalf("reconcile failed: %v", err)
+	}
+	updated = getWerfBundle(t, ctx, bundleName, "default")
+	if updated.Status.ActiveJobName != "" || updated.Status.LastAppliedTag != "v1.0.0" {
+		t.Errorf("expected v1.0.0 to stay deployed, got job %q tag %q",
+			updated.Status.ActiveJobName, updated.Status.LastAppliedTag)
+	}
+
+	// Pinning the newest tag deploys it and clears the drift
+	setPinnedVersion(t, ctx, req, "v1.1.0")
+	if _, err := reconciler.Reconcile(ctx, req); err != nil {
+		t.Fatalf("reconcile after pinning v1.1.0 failed: %v", err)
+	}
+	updated = getWerfBundle(t, ctx, bundleName, "default")
+	if updated.Status.LastAppliedTag != "v1.1.0" || updated.Status.ActiveJobName == "" {
+		t.Errorf("expected a Job for v1.1.0, got job %q tag %q",
+			updated.Status.ActiveJobName, updated.Status.LastAppliedTag)
+	}
+	expectUpdateAvailable(t, updated, metav1.ConditionFalse, werfv1alpha1.ReasonPinnedVersionLatest)
+	completeActiveJob(t, ctx, reconciler, req)
+
+	// Unpinning returns to tag selection and drops the condition
+	setPinnedVersion(t, ctx, req, "")
+	if _, err := reconciler.Reconcile(ctx, req); err != nil {
+		t.Fatalf("reconcile after unpinning failed: %v", err)
+	}
+	updated = getWerfBundle(t, ctx, bundleName, "default")
+	if meta.FindStatusCondition(updated.Status.Conditions, werfv1alpha1.ConditionUpdateAvailable) != nil {
+		t.Errorf("expected no UpdateAvailable condition without a pin, got %+v", updated.Status.Conditions)
+	}
+	if updated.Status.LatestAvailableTag != "v1.1.0" {
+		t.Errorf("expected LatestAvailableTag v1.1.0, got %q", updated.Status.LatestAvailableTag)
+	}
+}
+
+func TestReconcile_PinnedVersion_MissingTag(t *testing.T) {
+	ctx := context.Background()
+	bundleName := testBundleNameForStep("pinned-missing")
+	repoURL := "ghcr.io/test/pinned-missing"
+	reconciler, fakeReg, req := newPinnedTestReconciler(t, ctx, bundleName, repoURL, "v2.0.0",
+		[]string{"v1.0.0"})
+
+	result, err := reconciler.Reconcile(ctx, req)
+	if err != nil {
+		t.Fatalf("reconcile failed: %v", err)
+	}
+	if result.RequeueAfter == 0 {
+		t.Error("expected requeue so pushing the pinned tag is picked up")
+	}
+	updated := getWerfBundle(t, ctx, bundleName, "default")
+	if updated.Status.Phase != werfv1alpha1.PhaseFailed ||
+		updated.Status.FailureReason != werfv1alpha1.FailureReasonPinnedVersionNotFound {
+		t.Errorf("expected phase Failed with reason %s, got %s with %q",
+			werfv1alpha1.FailureReasonPinnedVersionNotFound, updated.Status.Phase, updated.Status.FailureReason)
+	}
+	if updated.Status.ActiveJobName != "" {
+		t.Errorf("expected no Job for a missing pinned tag, got %q", updated.Status.ActiveJobName)
+	}
+
+	// Pushing the pinned tag deploys it on the next poll
+	fakeReg.SetTags(repoURL, []string{"v1.0.0", "v2.0.0"})
+	reconciler.requestPoll(req.NamespacedName)
+	if _, err := reconciler.Reconcile(ctx, req); err != nil {
+		t.Fatalf("reconcile after push failed: %v", err)
+	}
+	updated = getWerfBundle(t, ctx, bundleName, "default")
+	if updated.Status.LastAppliedTag != "v2.0.0" || updated.Status.ActiveJobName == "" {
+		t.Errorf("expected a Job for v2.0.0, got job %q tag %q (%s)",
+			updated.Status.ActiveJobName, updated.Status.LastAppliedTag, updated.Status.LastErrorMessage)
+	}
+	if updated.Status.FailureReason != "" {
+		t.Errorf("expected failure reason to be cleared, got %q", updated.Status.FailureReason)
+	}
+}
+
+func TestReconcile_PinnedVersion_Digest(t *testing.T) {
+	ctx := context.Background()
+	bundleName := testBundleNameForStep("pinned-digest")
+	repoURL := "ghcr.io/test/pinned-digest"
+	pinned := "sha256:6666666666666666666666666666666666666666666666666666666666666666"
+	reconciler, fakeReg, req := newPinnedTestReconciler(t, ctx, bundleName, repoURL, pinned,
+		[]string{"main"})
+	orderTagsLexically(t, ctx, req)
+
+	if _, err := reconciler.Reconcile(ctx, req); err != nil {
+		t.Fatalf("reconcile failed: %v", err)
+	}
+	updated := getWerfBundle(t, ctx, bundleName, "default")
+	if updated.Status.LastAppliedTag != pinned || updated.Status.LastAppliedDigest != pinned {
+		t.Errorf("expected the pinned digest to be applied, got tag %q digest %q",
+			updated.Status.LastAppliedTag, updated.Status.LastAppliedDigest)
+	}
+	expectUpdateAvailable(t, updated, metav1.ConditionTrue, werfv1alpha1.ReasonNewerVersionAvailable)
+	job := getJobInNamespace(t, ctx, bundleName, "default")
+	if !containsArg(job.Spec.Template.Spec.Containers[0].Args, repoURL+"@"+pinned) {
+		t.Errorf("expected Job to deploy %s@%s, got args %v", repoURL, pinned, job.Spec.Template.Spec.Containers[0].Args)
+	}
+	if job.Annotations[converge.TagAnnotation] != pinned {
+		t.Errorf("expected tag annotation %q, got %q", pinned, job.Annotations[converge.TagAnnotation])
+	}
+	completeActiveJob(t, ctx, reconciler, req)
+	if tag := getWerfBundle(t, ctx, bundleName, "default").Status.LastAppliedTag; tag != pinned {
+		t.Errorf("expected the pinned digest as LastAppliedTag after the Job, got %q", tag)
+	}
+
+	// Once main points to the pinned digest, there is no newer version
+	fakeReg.SetDigest(repoURL, "main", pinned)
+	reconciler.requestPoll(req.NamespacedName)
+	if _, err := reconciler.Reconcile(ctx, req); err != nil {
+		t.Fatalf("reconcile failed: %v", err)
+	}
+	updated = getWerfBundle(t, ctx, bundleName, "default")
+	expectUpdateAvailable(t, updated, metav1.ConditionFalse, werfv1alpha1.ReasonPinnedVersionLatest)
+	if updated.Status.ActiveJobName != "" {
+		t.Errorf("expected no new Job for the same digest, got %q", updated.Status.ActiveJobName)
+	}
+}
@@ -0,0 +1,218 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	werfv1alpha1 "github.com/werf/k8s-werf-operator-go/api/v1alpha1"
+)
+
+// syncBundle creates a bundle tracking repoURL and deploys its only tag, leaving it Synced
+// after one registry poll.
+func syncBundle(
+	t *testing.T,
+	ctx context.Context,
+	bundleName, repoURL string,
+) (*WerfBundleReconciler, *FakeRegistry, reconcile.Request) {
+	t.Helper()
+
+	reconciler, fakeReg, req := newDigestTestReconciler(t, ctx, bundleName, repoURL, []string{"v1.0.0"})
+	if _, err := reconciler.Reconcile(ctx, req); err != nil {
+		t.Fatalf("first reconcile failed: %v", err)
+	}
+	completeActiveJob(t, ctx, reconciler, req)
+	if fakeReg.Polls != 1 {
+		t.Fatalf("expected 1 registry poll to deploy the bundle, got %d", fakeReg.Polls)
+	}
+	return reconciler, fakeReg, req
+}
+
+func TestReconcile_PollSchedule_SyncedBundleWaitsForNextPoll(t *testing.T) {
+	ctx := context.Background()
+	bundleName := testBundleNameForStep("poll-wait")
+	reconciler, fakeReg, req := syncBundle(t, ctx, bundleName, "ghcr.io/test/poll-wait")
+
+	bundle := getWerfBundle(t, ctx, bundleName, "default")
+	if bundle.Status.LastPollTime == nil || bundle.Status.NextPollTime == nil {
+		t.Fatalf("expected lastPollTime and nextPollTime in status, got %v and %v",
+			bundle.Status.LastPollTime, bundle.Status.NextPollTime)
+	}
+	// Default 15m interval with ±10% jitter (status times have second precision)
+	interval := bundle.Status.NextPollTime.Sub(bundle.Status.LastPollTime.Time)
+	if interval < 13*time.Minute || interval > 17*time.Minute {
+		t.Errorf("expected next poll about 15m after the last one, got %v", interval)
+	}
+
+	result, err := reconciler.Reconcile(ctx, req)
+	if err != nil {
+		t.Fatalf("reconcile failed: %v", err)
+	}
+	if fakeReg.Polls != 1 {
+		t.Errorf("expected no registry poll before nextPollTime, got %d polls", fakeReg.Polls)
+	}
+	if result.RequeueAfter < 13*time.Minute || result.RequeueAfter > 17*time.Minute {
+		t.Errorf("expected requeue at nextPollTime, got %v", result.RequeueAfter)
+	}
+}
+
+func TestReconcile_PollSchedule_PollsWhenDue(t *testing.T) {
+	ctx := context.Background()
+	bundleName := testBundleNameForStep("poll-due")
+	reconciler, fakeReg, req := syncBundle(t, ctx, bundleName, "ghcr.io/test/poll-due")
+
+	// As if the operator was down past the scheduled poll
+	bundle := getWerfBundle(t, ctx, bundleName, "default")
+	past := metav1.NewTime(time.Now().Add(-time.Minute))
+	bundle.Status.NextPollTime = &past
+	if err := testk8sClient.Status().Update(ctx, bundle); err != nil {
+		t.Fatalf("failed to update status: %v", err)
+	}
+
+	if _, err := reconciler.Reconcile(ctx, req); err != nil {
+		t.Fatalf("reconcile failed: %v", err)
+	}
+	if fakeReg.Polls != 2 {
+		t.Errorf("expected a registry poll once nextPollTime passed, got %d polls", fakeReg.Polls)
+	}
+	updated := getWerfBundle(t, ctx, bundleName, "default")
+	if updated.Status.NextPollTime == nil || !updated.Status.NextPollTime.After(time.Now()) {
+		t.Errorf("expected the next poll to be rescheduled, got %v", updated.Status.NextPollTime)
+	}
+}
+
+func TestReconcile_PollSchedule_RequestedPollIgnoresSchedule(t *testing.T) {
+	ctx := context.Background()
+	bundleName := testBundleNameForStep("poll-request")
+	reconciler, fakeReg, req := syncBundle(t, ctx, bundleName, "ghcr.io/test/poll-request")
+
+	reconciler.requestPoll(req.NamespacedName)
+	if _, err := reconciler.Reconcile(ctx, req); err != nil {
+		t.Fatalf("reconcile failed: %v", err)
+	}
+	if fakeReg.Polls != 2 {
+		t.Errorf("expected a registry poll on request, got %d polls", fakeReg.Polls)
+	}
+
+	// The request is used up
+	if _, err := reconciler.Reconcile(ctx, req); err != nil {
+		t.Fatalf("reconcile failed: %v", err)
+	}
+	if fakeReg.Polls != 2 {
+		t.Errorf("expected the poll request to be consumed, got %d polls", fakeReg.Polls)
+	}
+}
+
+func TestReconcile_PollSchedule_SpecChangePollsImmediately(t *testing.T) {
+	ctx := context.Background()
+	bundleName := testBundleNameForStep("poll-spec")
+	reconciler, fakeReg, req := syncBundle(t, ctx, bundleName, "ghcr.io/test/poll-spec")
+
+	bundle := getWerfBundle(t, ctx, bundleName, "default")
+	bundle.Spec.Registry.VersionConstraint = ">=1.0.0"
+	if err := testk8sClient.Update(ctx, bundle); err != nil {
+		t.Fatalf("failed to update WerfBundle: %v", err)
+	}
+
+	if _, err := reconciler.Reconcile(ctx, req); err != nil {
+		t.Fatalf("reconcile failed: %v", err)
+	}
+	if fakeReg.Polls != 2 {
+		t.Errorf("expected a registry poll after the spec changed, got %d polls", fakeReg.Polls)
+	}
+	updated := getWerfBundle(t, ctx, bundleName, "default")
+	if updated.Status.ObservedGeneration != updated.Generation {
+		t.Errorf("expected observedGeneration %d, got %d", updated.Generation, updated.Status.ObservedGeneration)
+	}
+}
+
+func TestReconcile_PollSchedule_FailedJobStaysFailed(t *testing.T) {
+	ctx := context.Background()
+	bundleName := testBundleNameForStep("poll-failed")
+	reconciler, fakeReg, req := newDigestTestReconciler(t, ctx, bundleName, "ghcr.io/test/poll-failed",
+		[]string{"v1.0.0"})
+	if _, err := reconciler.Reconcile(ctx, req); err != nil {
+		t.Fatalf("first reconcile failed: %v", err)
+	}
+	failActiveJob(t, ctx, reconciler, req)
+
+	// The next poll selects the failed tag again, which must not be reported Synced
+	reconciler.requestPoll(req.NamespacedName)
+	if _, err := reconciler.Reconcile(ctx, req); err != nil {
+		t.Fatalf("reconcile failed: %v", err)
+	}
+	if fakeReg.Polls != 2 {
+		t.Fatalf("expected a second registry poll, got %d polls", fakeReg.Polls)
+	}
+	updated := getWerfBundle(t, ctx, bundleName, "default")
+	if updated.Status.Phase != werfv1alpha1.PhaseFailed || updated.Status.LastSyncTime != nil {
+		t.Errorf("expected the failed deploy to stay Failed, got %s synced at %v",
+			updated.Status.Phase, updated.Status.LastSyncTime)
+	}
+	if updated.Status.ActiveJobName != "" {
+		t.Errorf("expected the failed tag not to be retried, got Job %q", updated.Status.ActiveJobName)
+	}
+
+	// A spec change may fix the converge, so it retries the tag
+	updated.Spec.Registry.VersionConstraint = ">=1.0.0"
+	if err := testk8sClient.Update(ctx, updated); err != nil {
+		t.Fatalf("failed to update WerfBundle: %v", err)
+	}
+	if _, err := reconciler.Reconcile(ctx, req); err != nil {
+		t.Fatalf("reconcile after spec change failed: %v", err)
+	}
+	updated = getWerfBundle(t, ctx, bundleName, "default")
+	if updated.Status.Phase != werfv1alpha1.PhaseSyncing || updated.Status.ActiveJobName == "" {
+		t.Errorf("expected a new Job for v1.0.0 after the spec changed, got %s with Job %q",
+			updated.Status.Phase, updated.Status.ActiveJobName)
+	}
+}
+
+func TestReconcile_PollSchedule_MinimumInterval(t *testing.T) {
+	ctx := context.Background()
+	bundleName := testBundleNameForStep("poll-min")
+	repoURL := "ghcr.io/test/poll-min"
+
+	bundle := &werfv1alpha1.WerfBundle{
+		ObjectMeta: metav1.ObjectMeta{Name: bundleName, Namespace: "default"},
+		Spec: werfv1alpha1.WerfBundleSpec{
+			Registry: werfv1alpha1.RegistryConfig{URL: repoURL, PollInterval: "5s"},
+			Converge: werfv1alpha1.ConvergeConfig{ServiceAccountName: "default"},
+		},
+	}
+	if err := testk8sClient.Create(ctx, bundle); err != nil {
+		t.Fatalf("failed to create WerfBundle: %v", err)
+	}
+
+	fakeReg := NewFakeRegistry()
+	reconciler := &WerfBundleReconciler{
+		Client:          testk8sClient,
+		Scheme:          testk8sClient.Scheme(),
+		RegistryClient:  fakeReg,
+		Clientset:       testK8sClientset,
+		MinPollInterval: 10 * time.Minute,
+	}
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: bundleName, Namespace: "default"}}
+
+	// No tags yet: the bundle waits for the next poll
+	result, err := reconciler.Reconcile(ctx, req)
+	if err != nil {
+		t.Fatalf("reconcile failed: %v", err)
+	}
+	if result.RequeueAfter < 8*time.Minute {
+		t.Errorf("expected requeue after at least the 10m minimum (with jitter), got %v", result.RequeueAfter)
+	}
+
+	updated := getWerfBundle(t, ctx, bundleName, "default")
+	if updated.Status.Phase != werfv1alpha1.PhaseSyncing {
+		t.Errorf("expected phase Syncing, got %s", updated.Status.Phase)
+	}
+	interval := updated.Status.NextPollTime.Sub(updated.Status.LastPollTime.Time)
+	if interval < 8*time.Minute {
+		t.Errorf("expected the 10m minimum interval to apply, got %v", interval)
+	}
+}
@@ -47,11 +47,11 @@ func TestRBACManifestGeneration(t *testing.T) {
 			resources: []string{"jobs"},
 			verbs:     []string{"create", "get", "list", "watch", "delete"},
 		},
-		// Secrets - only needs get
+		// Secrets - get for credentials/values, create/delete for Job registry credentials
 		{
 			apiGroup:  "",
 			resources: []string{"secrets"},
-			verbs:     []string{"get"},
+			verbs:     []string{"get", "create", "delete"},
 		},
 		// ServiceAccounts - needs list/watch for controller-runtime cache
 		{
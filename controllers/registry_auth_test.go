@@ -0,0 +1,446 @@
+package controllers
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	ggcrregistry "github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	werfv1alpha1 "github.com/werf/k8s-werf-operator-go/api/v1alpha1"
+	"github.com/werf/k8s-werf-operator-go/internal/converge"
+	"github.com/werf/k8s-werf-operator-go/internal/registry"
+	testingutil "github.com/werf/k8s-werf-operator-go/internal/testing"
+)
+
+const (
+	testRegistryUser     = "testuser"
+	testRegistryPassword = "testpass"
+)
+
+// startAuthRegistry starts an in-process OCI registry requiring HTTP Basic auth,
+// pushes one image per tag to repoName, and returns the full repository URL.
+func startAuthRegistry(t *testing.T, repoName string, tags ...string) string {
+	t.Helper()
+
+	handler := ggcrregistry.New(ggcrregistry.Logger(log.New(io.Discard, "", 0)))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, pass, ok := r.BasicAuth()
+		if !ok || user != testRegistryUser || pass != testRegistryPassword {
+			w.Header().Set("WWW-Authenticate", `Basic realm="test"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	repoURL := fmt.Sprintf("%s/%s", strings.TrimPrefix(server.URL, "http://"), repoName)
+	auth := &authn.Basic{Username: testRegistryUser, Password: testRegistryPassword}
+	for _, tag := range tags {
+		img, err := testingutil.BundleImage("app", tag)
+		if err != nil {
+			t.Fatalf("failed to build bundle: %v", err)
+		}
+		ref, err := name.ParseReference(fmt.Sprintf("%s:%s", repoURL, tag))
+		if err != nil {
+			t.Fatalf("failed to parse reference: %v", err)
+		}
+		if err := remote.Write(ref, img, remote.WithAuth(auth)); err != nil {
+			t.Fatalf("failed to push image: %v", err)
+		}
+	}
+
+	return repoURL
+}
+
+// createRegistrySecret creates a kubernetes.io/dockerconfigjson Secret with credentials for repoURL.
+func createRegistrySecret(t *testing.T, ctx context.Context, secretName, namespace, repoURL, password string) {
+	t.Helper()
+
+	host, _, _ := strings.Cut(repoURL, "/")
+	auth := base64.StdEncoding.EncodeToString([]byte(testRegistryUser + ":" + password))
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: secretName, Namespace: namespace},
+		Type:       corev1.SecretTypeDockerConfigJson,
+		Data: map[string][]byte{
+			corev1.DockerConfigJsonKey: []byte(fmt.Sprintf(`{"auths":{%q:{"auth":%q}}}`, host, auth)),
+		},
+	}
+	if err := testk8sClient.Create(ctx, secret); err != nil {
+		t.Fatalf("failed to create registry Secret: %v", err)
+	}
+	t.Cleanup(func() { _ = testk8sClient.Delete(context.Background(), secret) })
+}
+
+// createAuthTestBundle creates a WerfBundle in the default namespace referencing secretName.
+func createAuthTestBundle(t *testing.T, ctx context.Context, bundleName, repoURL, secretName string) {
+	t.Helper()
+
+	bundle := &werfv1alpha1.WerfBundle{
+		ObjectMeta: metav1.ObjectMeta{Name: bundleName, Namespace: "default"},
+		Spec: werfv1alpha1.WerfBundleSpec{
+			Registry: werfv1alpha1.RegistryConfig{
+				URL:       repoURL,
+				SecretRef: &corev1.LocalObjectReference{Name: secretName},
+			},
+			Converge: werfv1alpha1.ConvergeConfig{
+				ServiceAccountName: "default",
+			},
+		},
+	}
+	if err := testk8sClient.Create(ctx, bundle); err != nil {
+		t.Fatalf("failed to create WerfBundle: %v", err)
+	}
+}
+
+func TestReconcile_RegistrySecret_AuthenticatesAndCreatesJob(t *testing.T) {
+	ctx := context.Background()
+	bundleName := testBundleNameForStep("registry-auth")
+
+	repoURL := startAuthRegistry(t, "test/private", "v1.0.0")
+	createRegistrySecret(t, ctx, bundleName+"-creds", "default", repoURL, testRegistryPassword)
+	createAuthTestBundle(t, ctx, bundleName, repoURL, bundleName+"-creds")
+
+	reconciler := &WerfBundleReconciler{
+		Client:         testk8sClient,
+		Scheme:         testk8sClient.Scheme(),
+		RegistryClient: registry.NewOCIClient(),
+		Clientset:      testK8sClientset,
+	}
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: bundleName, Namespace: "default"}}
+
+	if _, err := reconciler.Reconcile(ctx, req); err != nil {
+		t.Fatalf("reconcile failed: %v", err)
+	}
+
+	updated := getWerfBundle(t, ctx, bundleName, "default")
+	if updated.Status.Phase != werfv1alpha1.PhaseSyncing {
+		t.Errorf("expected phase Syncing, got %s (error: %s)", updated.Status.Phase, updated.Status.LastErrorMessage)
+	}
+	if updated.Status.LastAppliedTag != "v1.0.0" {
+		t.Errorf("expected tag v1.0.0, got %q", updated.Status.LastAppliedTag)
+	}
+	if updated.Status.ActiveJobName == "" {
+		t.Fatal("expected a converge Job to be created")
+	}
+
+	// The Job mounts a copy of the credentials as DOCKER_CONFIG
+	job := &batchv1.Job{}
+	jobKey := types.NamespacedName{Name: updated.Status.ActiveJobName, Namespace: "default"}
+	if err := testk8sClient.Get(ctx, jobKey, job); err != nil {
+		t.Fatalf("failed to get Job: %v", err)
+	}
+	secretName := converge.RegistrySecretName(job.Name)
+	if len(job.Spec.Template.Spec.Volumes) != 1 || job.Spec.Template.Spec.Volumes[0].Secret.SecretName != secretName {
+		t.Errorf("expected Job to mount Secret %q, got %+v", secretName, job.Spec.Template.Spec.Volumes)
+	}
+
+	copied := &corev1.Secret{}
+	if err := testk8sClient.Get(ctx, types.NamespacedName{Name: secretName, Namespace: "default"}, copied); err != nil {
+		t.Fatalf("expected registry credentials Secret %q: %v", secretName, err)
+	}
+	if len(copied.OwnerReferences) != 1 || copied.OwnerReferences[0].UID != job.UID {
+		t.Errorf("expected Secret to be owned by Job %s, got %+v", job.UID, copied.OwnerReferences)
+	}
+
+	// The copy must still authenticate against the registry
+	auth, err := registry.AuthFromSecret(copied, repoURL)
+	if err != nil {
+		t.Fatalf("copied Secret is not usable: %v", err)
+	}
+	if _, _, err := registry.NewOCIClient().ListTagsWithETag(ctx, repoURL, auth, ""); err != nil {
+		t.Errorf("copied credentials rejected by registry: %v", err)
+	}
+}
+
+func TestReconcile_RegistrySecret_CopyDeletedWhenJobFinishes(t *testing.T) {
+	ctx := context.Background()
+	bundleName := testBundleNameForStep("registry-auth-cleanup")
+
+	repoURL := startAuthRegistry(t, "test/private", "v1.0.0")
+	createRegistrySecret(t, ctx, bundleName+"-creds", "default", repoURL, testRegistryPassword)
+	createAuthTestBundle(t, ctx, bundleName, repoURL, bundleName+"-creds")
+
+	reconciler := &WerfBundleReconciler{
+		Client:         testk8sClient,
+		Scheme:         testk8sClient.Scheme(),
+		RegistryClient: registry.NewOCIClient(),
+		Clientset:      testK8sClientset,
+	}
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: bundleName, Namespace: "default"}}
+
+	if _, err := reconciler.Reconcile(ctx, req); err != nil {
+		t.Fatalf("reconcile failed: %v", err)
+	}
+
+	bundle := getWerfBundle(t, ctx, bundleName, "default")
+	job := &batchv1.Job{}
+	jobKey := types.NamespacedName{Name: bundle.Status.ActiveJobName, Namespace: "default"}
+	if err := testk8sClient.Get(ctx, jobKey, job); err != nil {
+		t.Fatalf("failed to get Job: %v", err)
+	}
+	secretKey := types.NamespacedName{Name: converge.RegistrySecretName(job.Name), Namespace: "default"}
+	if err := testk8sClient.Get(ctx, secretKey, &corev1.Secret{}); err != nil {
+		t.Fatalf("expected registry credentials Secret before Job finishes: %v", err)
+	}
+
+	job.Status.Failed = 1
+	if _, err := reconciler.monitorJobCompletion(ctx, bundle, job, "v1.0.0"); err != nil {
+		t.Fatalf("monitorJobCompletion failed: %v", err)
+	}
+
+	err := testk8sClient.Get(ctx, secretKey, &corev1.Secret{})
+	if !apierrors.IsNotFound(err) {
+		t.Errorf("expected registry credentials Secret to be deleted after Job finished, got %v", err)
+	}
+}
+
+func TestReconcile_RegistrySecret_CopyDeletedWithBundle(t *testing.T) {
+	ctx := context.Background()
+	bundleName := testBundleNameForStep("registry-auth-delete")
+
+	repoURL := startAuthRegistry(t, "test/private", "v1.0.0")
+	createRegistrySecret(t, ctx, bundleName+"-creds", "default", repoURL, testRegistryPassword)
+	createAuthTestBundle(t, ctx, bundleName, repoURL, bundleName+"-creds")
+
+	reconciler := &WerfBundleReconciler{
+		Client:         testk8sClient,
+		Scheme:         testk8sClient.Scheme(),
+		RegistryClient: registry.NewOCIClient(),
+		Clientset:      testK8sClientset,
+	}
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: bundleName, Namespace: "default"}}
+
+	if _, err := reconciler.Reconcile(ctx, req); err != nil {
+		t.Fatalf("reconcile failed: %v", err)
+	}
+
+	bundle := getWerfBundle(t, ctx, bundleName, "default")
+	if bundle.Status.ActiveJobName == "" {
+		t.Fatal("expected a converge Job to be created")
+	}
+	secretKey := types.NamespacedName{
+		Name:      converge.RegistrySecretName(bundle.Status.ActiveJobName),
+		Namespace: "default",
+	}
+
+	// Delete the bundle while the Job is still running; the finalizer removes the copy
+	if err := testk8sClient.Delete(ctx, bundle); err != nil {
+		t.Fatalf("failed to delete WerfBundle: %v", err)
+	}
+	if _, err := reconciler.Reconcile(ctx, req); err != nil {
+		t.Fatalf("reconcile after deletion failed: %v", err)
+	}
+
+	err := testk8sClient.Get(ctx, secretKey, &corev1.Secret{})
+	if !apierrors.IsNotFound(err) {
+		t.Errorf("expected registry credentials Secret to be deleted with the bundle, got %v", err)
+	}
+}
+
+func TestReconcile_RegistrySecretMissing_MarksFailed(t *testing.T) {
+	ctx := context.Background()
+	bundleName := testBundleNameForStep("registry-auth-missing")
+
+	repoURL := startAuthRegistry(t, "test/private", "v1.0.0")
+	createAuthTestBundle(t, ctx, bundleName, repoURL, "does-not-exist")
+
+	reconciler := &WerfBundleReconciler{
+		Client:         testk8sClient,
+		Scheme:         testk8sClient.Scheme(),
+		RegistryClient: registry.NewOCIClient(),
+		Clientset:      testK8sClientset,
+	}
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: bundleName, Namespace: "default"}}
+
+	result, err := reconciler.Reconcile(ctx, req)
+	if err != nil {
+		t.Fatalf("reconcile failed: %v", err)
+	}
+	if result.RequeueAfter == 0 {
+		t.Error("expected requeue so a later-created Secret is picked up")
+	}
+
+	updated := getWerfBundle(t, ctx, bundleName, "default")
+	if updated.Status.Phase != werfv1alpha1.PhaseFailed {
+		t.Errorf("expected phase Failed, got %s", updated.Status.Phase)
+	}
+	if !strings.Contains(updated.Status.LastErrorMessage, "does-not-exist") {
+		t.Errorf("expected error message to name the missing Secret, got %q", updated.Status.LastErrorMessage)
+	}
+	if updated.Status.ActiveJobName != "" {
+		t.Errorf("expected no Job, got %q", updated.Status.ActiveJobName)
+	}
+}
+
+func TestReconcile_RegistrySecretMalformed_MarksFailed(t *testing.T) {
+	ctx := context.Background()
+	bundleName := testBundleNameForStep("registry-auth-malformed")
+
+	repoURL := startAuthRegistry(t, "test/private", "v1.0.0")
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: bundleName + "-creds", Namespace: "default"},
+		Data:       map[string][]byte{"unrelated": []byte("data")},
+	}
+	if err := testk8sClient.Create(ctx, secret); err != nil {
+		t.Fatalf("failed to create Secret: %v", err)
+	}
+	createAuthTestBundle(t, ctx, bundleName, repoURL, secret.Name)
+
+	reconciler := &WerfBundleReconciler{
+		Client:         testk8sClient,
+		Scheme:         testk8sClient.Scheme(),
+		RegistryClient: registry.NewOCIClient(),
+		Clientset:      testK8sClientset,
+	}
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: bundleName, Namespace: "default"}}
+
+	if _, err := reconciler.Reconcile(ctx, req); err != nil {
+		t.Fatalf("reconcile failed: %v", err)
+	}
+
+	updated := getWerfBundle(t, ctx, bundleName, "default")
+	if updated.Status.Phase != werfv1alpha1.PhaseFailed {
+		t.Errorf("expected phase Failed, got %s", updated.Status.Phase)
+	}
+	if !strings.Contains(updated.Status.LastErrorMessage, "authentication error") {
+		t.Errorf("expected authentication error in status, got %q", updated.Status.LastErrorMessage)
+	}
+}
+
+func TestReconcile_InvalidRepositoryURL_MarksFailedWithoutRequeue(t *testing.T) {
+	ctx := context.Background()
+	bundleName := testBundleNameForStep("registry-auth-invalid-url")
+
+	// Repository names are lowercase, so the URL can't be parsed
+	repoURL := "ghcr.io/Test/Private"
+	createRegistrySecret(t, ctx, bundleName+"-creds", "default", repoURL, testRegistryPassword)
+	createAuthTestBundle(t, ctx, bundleName, repoURL, bundleName+"-creds")
+
+	reconciler := &WerfBundleReconciler{
+		Client:         testk8sClient,
+		Scheme:         testk8sClient.Scheme(),
+		RegistryClient: registry.NewOCIClient(),
+		Clientset:      testK8sClientset,
+	}
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: bundleName, Namespace: "default"}}
+
+	result, err := reconciler.Reconcile(ctx, req)
+	if err != nil {
+		t.Fatalf("expected no error (retrying won't fix the URL), got %v", err)
+	}
+	if result.RequeueAfter != 0 {
+		t.Errorf("expected no requeue until the spec changes, got %v", result.RequeueAfter)
+	}
+
+	updated := getWerfBundle(t, ctx, bundleName, "default")
+	if updated.Status.Phase != werfv1alpha1.PhaseFailed {
+		t.Errorf("expected phase Failed, got %s", updated.Status.Phase)
+	}
+	if updated.Status.FailureReason != werfv1alpha1.FailureReasonInvalidRepository {
+		t.Errorf("expected failure reason %s, got %q",
+			werfv1alpha1.FailureReasonInvalidRepository, updated.Status.FailureReason)
+	}
+	if updated.Status.ConsecutiveFailures != 0 {
+		t.Errorf("expected no retry counted, got %d failures", updated.Status.ConsecutiveFailures)
+	}
+}
+
+func TestReconcile_RegistrySecretWrongPassword_FailsWithAuthError(t *testing.T) {
+	ctx := context.Background()
+	bundleName := testBundleNameForStep("registry-auth-wrong")
+
+	repoURL := startAuthRegistry(t, "test/private", "v1.0.0")
+	createRegistrySecret(t, ctx, bundleName+"-creds", "default", repoURL, "wrong-password")
+	createAuthTestBundle(t, ctx, bundleName, repoURL, bundleName+"-creds")
+
+	reconciler := &WerfBundleReconciler{
+		Client:         testk8sClient,
+		Scheme:         testk8sClient.Scheme(),
+		RegistryClient: registry.NewOCIClient(),
+		Clientset:      testK8sClientset,
+	}
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: bundleName, Namespace: "default"}}
+
+	if _, err := reconciler.Reconcile(ctx, req); err != nil {
+		t.Fatalf("reconcile failed: %v", err)
+	}
+
+	// Rejected credentials won't work on retry: the bundle fails right away
+	updated := getWerfBundle(t, ctx, bundleName, "default")
+	if updated.Status.Phase != werfv1alpha1.PhaseFailed {
+		t.Errorf("expected phase Failed, got %s", updated.Status.Phase)
+	}
+	if updated.Status.FailureReason != werfv1alpha1.FailureReasonAuthenticationFailed {
+		t.Errorf("expected failure reason %s, got %q",
+			werfv1alpha1.FailureReasonAuthenticationFailed, updated.Status.FailureReason)
+	}
+	if !strings.Contains(updated.Status.LastErrorMessage, "authentication error") {
+		t.Errorf("expected authentication error in status, got %q", updated.Status.LastErrorMessage)
+	}
+	if updated.Status.ActiveJobName != "" {
+		t.Errorf("expected no Job, got %q", updated.Status.ActiveJobName)
+	}
+}
+
+func TestReconcile_RegistrySecretCreated_RetriesFailedBundle(t *testing.T) {
+	ctx := context.Background()
+	bundleName := testBundleNameForStep("registry-auth-created")
+	secretName := bundleName + "-creds"
+
+	repoURL := startAuthRegistry(t, "test/private", "v1.0.0")
+	createAuthTestBundle(t, ctx, bundleName, repoURL, secretName)
+
+	reconciler := &WerfBundleReconciler{
+		Client:         testk8sClient,
+		Scheme:         testk8sClient.Scheme(),
+		RegistryClient: registry.NewOCIClient(),
+		Clientset:      testK8sClientset,
+	}
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: bundleName, Namespace: "default"}}
+
+	if _, err := reconciler.Reconcile(ctx, req); err != nil {
+		t.Fatalf("reconcile failed: %v", err)
+	}
+	if phase := getWerfBundle(t, ctx, bundleName, "default").Status.Phase; phase != werfv1alpha1.PhaseFailed {
+		t.Fatalf("expected phase Failed without the Secret, got %s", phase)
+	}
+
+	// Creating the Secret maps to the Failed bundle, which polls right away
+	createRegistrySecret(t, ctx, secretName, "default", repoURL, testRegistryPassword)
+	secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: secretName, Namespace: "default"}}
+	requests := reconciler.bundlesForSecret(ctx, secret)
+	if len(requests) != 1 || requests[0] != req {
+		t.Fatalf("expected the Secret to map to %v, got %v", req, requests)
+	}
+	if unrelated := reconciler.bundlesForSecret(ctx, &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: secretName, Namespace: "other"},
+	}); len(unrelated) != 0 {
+		t.Errorf("expected a Secret in another namespace not to match, got %v", unrelated)
+	}
+
+	if _, err := reconciler.Reconcile(ctx, req); err != nil {
+		t.Fatalf("reconcile failed: %v", err)
+	}
+	updated := getWerfBundle(t, ctx, bundleName, "default")
+	if updated.Status.ActiveJobName == "" {
+		t.Errorf("expected a converge Job once the Secret exists, got phase %s: %s",
+			updated.Status.Phase, updated.Status.LastErrorMessage)
+	}
+}
@@ -0,0 +1,118 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	werfv1alpha1 "github.com/werf/k8s-werf-operator-go/api/v1alpha1"
+	"github.com/werf/k8s-werf-operator-go/internal/converge"
+	"github.com/werf/k8s-werf-operator-go/internal/registry"
+	testingutil "github.com/werf/k8s-werf-operator-go/internal/testing"
+)
+
+func TestReconcile_CredentialProvider_AuthenticatesWithoutSecret(t *testing.T) {
+	ctx := context.Background()
+	repoURL := startAuthRegistry(t, "test/provider", "v1.0.0")
+	host, _, _ := strings.Cut(repoURL, "/")
+
+	tests := []struct {
+		name     string
+		response string
+		wantJob  bool
+	}{
+		{
+			name: "token",
+			response: fmt.Sprintf(`{"kind": "CredentialProviderResponse", "apiVersion": "credentialprovider.kubelet.k8s.io/v1",`+
+				` "cacheKeyType": "Registry", "cacheDuration": "1h", "auth": {%q: {"username": %q, "password": %q}}}`,
+				host, testRegistryUser, testRegistryPassword),
+			wantJob: true,
+		},
+		{
+			// A failing plugin is retried like an unavailable registry
+			name: "plugin-fails",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			configPath, err := testingutil.WriteFakeCredentialProvider(dir, "fake-provider", tt.response, host)
+			if err != nil {
+				t.Fatalf("failed to write plugin: %v", err)
+			}
+			providers, err := registry.LoadCredentialProviders(configPath, dir)
+			if err != nil {
+				t.Fatalf("failed to load credential providers: %v", err)
+			}
+			reconciler := &WerfBundleReconciler{
+				Client:              testk8sClient,
+				Scheme:              testk8sClient.Scheme(),
+				RegistryClient:      registry.NewOCIClient(),
+				Clientset:           testK8sClientset,
+				CredentialProviders: providers,
+			}
+
+			bundleName := testBundleNameForStep("provider-" + tt.name)
+			bundle := &werfv1alpha1.WerfBundle{
+				ObjectMeta: metav1.ObjectMeta{Name: bundleName, Namespace: "default"},
+				Spec: werfv1alpha1.WerfBundleSpec{
+					Registry: werfv1alpha1.RegistryConfig{URL: repoURL},
+					Converge: werfv1alpha1.ConvergeConfig{ServiceAccountName: "default"},
+				},
+			}
+			if err := testk8sClient.Create(ctx, bundle); err != nil {
+				t.Fatalf("failed to create WerfBundle: %v", err)
+			}
+			req := reconcile.Request{NamespacedName: types.NamespacedName{Name: bundleName, Namespace: "default"}}
+			if _, err := reconciler.Reconcile(ctx, req); err != nil {
+				t.Fatalf("reconcile failed: %v", err)
+			}
+
+			updated := getWerfBundle(t, ctx, bundleName, "default")
+			if !tt.wantJob {
+				if updated.Status.ConsecutiveFailures != 1 || updated.Status.FailureReason != "" ||
+					!strings.Contains(updated.Status.LastErrorMessage, "fake-provider") {
+					t.Errorf("expected a retried plugin failure, got %d failures, reason %q: %s",
+						updated.Status.ConsecutiveFailures, updated.Status.FailureReason, updated.Status.LastErrorMessage)
+				}
+				return
+			}
+			if updated.Status.ActiveJobName == "" {
+				t.Fatalf("expected a converge Job, got phase %s: %s", updated.Status.Phase, updated.Status.LastErrorMessage)
+			}
+
+			// The Job gets the plugin's token as DOCKER_CONFIG; polling and the Job share one invocation
+			job := &batchv1.Job{}
+			jobKey := types.NamespacedName{Name: updated.Status.ActiveJobName, Namespace: "default"}
+			if err := testk8sClient.Get(ctx, jobKey, job); err != nil {
+				t.Fatalf("failed to get Job: %v", err)
+			}
+			secret := &corev1.Secret{}
+			secretKey := types.NamespacedName{Name: converge.RegistrySecretName(job.Name), Namespace: "default"}
+			if err := testk8sClient.Get(ctx, secretKey, secret); err != nil {
+				t.Fatalf("expected registry credentials Secret: %v", err)
+			}
+			auth, err := registry.AuthFromSecret(secret, repoURL)
+			if err != nil {
+				t.Fatalf("registry credentials Secret is not usable: %v", err)
+			}
+			if _, err := registry.NewOCIClient().ListTags(ctx, repoURL, auth); err != nil {
+				t.Errorf("Job credentials rejected by registry: %v", err)
+			}
+			images, err := testingutil.CredentialProviderRequests(dir, "fake-provider")
+			if err != nil {
+				t.Fatalf("failed to read plugin requests: %v", err)
+			}
+			if len(images) != 1 || images[0] != repoURL {
+				t.Errorf("expected one plugin request for %s, got %v", repoURL, images)
+			}
+		})
+	}
+}
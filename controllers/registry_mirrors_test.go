@@ -0,0 +1,192 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	werfv1alpha1 "github.com/werf/k8s-werf-operator-go/api/v1alpha1"
+	"github.com/werf/k8s-werf-operator-go/internal/registry"
+)
+
+// createMirrorTestBundle creates a WerfBundle tracking repoURL with the given mirrors.
+func createMirrorTestBundle(
+	t *testing.T,
+	ctx context.Context,
+	name, repoURL string,
+	mirrors ...string,
+) reconcile.Request {
+	t.Helper()
+
+	bundle := &werfv1alpha1.WerfBundle{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		Spec: werfv1alpha1.WerfBundleSpec{
+			Registry: werfv1alpha1.RegistryConfig{URL: repoURL, Mirrors: mirrors},
+			Converge: werfv1alpha1.ConvergeConfig{ServiceAccountName: "default"},
+		},
+	}
+	if err := testk8sClient.Create(ctx, bundle); err != nil {
+		t.Fatalf("failed to create WerfBundle: %v", err)
+	}
+	return reconcile.Request{NamespacedName: types.NamespacedName{Name: name, Namespace: "default"}}
+}
+
+func TestReconcile_RegistryMirrors_FallBackAndOpenCircuit(t *testing.T) {
+	ctx := context.Background()
+	primary := "ghcr.io/test/mirrors-primary"
+	mirror := "mirror.example.com/test/mirrors"
+	req := createMirrorTestBundle(t, ctx, testBundleNameForStep("mirrors"), primary, mirror)
+
+	// Mirrors serve the same images, so the tag resolves to the same digest on both
+	digest := FakeDigest(primary, "v1.0.0")
+	fakeReg := NewFakeRegistry()
+	fakeReg.SetError(primary, &registry.NetworkError{Err: errors.New("connection refused")})
+	fakeReg.SetTags(mirror, []string{"v1.0.0"})
+	fakeReg.SetDigest(mirror, "v1.0.0", digest)
+	fakeReg.SetDigest(primary, "v1.0.0", digest)
+	reconciler := &WerfBundleReconciler{
+		Client:         testk8sClient,
+		Scheme:         testk8sClient.Scheme(),
+		RegistryClient: fakeReg,
+		Clientset:      testK8sClientset,
+	}
+
+	if _, err := reconciler.Reconcile(ctx, req); err != nil {
+		t.Fatalf("reconcile failed: %v", err)
+	}
+
+	// The mirror served the tags and the Job pulls from it; the bundle isn't failing
+	bundle := getWerfBundle(t, ctx, req.Name, "default")
+	if bundle.Status.RegistryEndpoint != mirror {
+		t.Errorf("expected registryEndpoint %s, got %q", mirror, bundle.Status.RegistryEndpoint)
+	}
+	if bundle.Status.ConsecutiveFailures != 0 || bundle.Status.ActiveJobName == "" {
+		t.Fatalf("expected a converge Job without failures, got %d failures, phase %s: %s",
+			bundle.Status.ConsecutiveFailures, bundle.Status.Phase, bundle.Status.LastErrorMessage)
+	}
+	job := &batchv1.Job{}
+	jobKey := types.NamespacedName{Name: bundle.Status.ActiveJobName, Namespace: "default"}
+	if err := testk8sClient.Get(ctx, jobKey, job); err != nil {
+		t.Fatalf("failed to get Job: %v", err)
+	}
+	if args := job.Spec.Template.Spec.Containers[0].Args; !slices.Contains(args, mirror+"@"+digest) {
+		t.Errorf("expected the Job to pull %s@%s, got args %v", mirror, digest, args)
+	}
+	if len(bundle.Status.Endpoints) != 2 || bundle.Status.Endpoints[0].ConsecutiveFailures != 1 ||
+		bundle.Status.Endpoints[0].State != werfv1alpha1.CircuitClosed ||
+		!strings.Contains(bundle.Status.Endpoints[0].LastError, "connection refused") {
+		t.Fatalf("expected one recorded failure of the primary endpoint, got %+v", bundle.Status.Endpoints)
+	}
+
+	// Failing again opens the primary's circuit: it's skipped until its retry time
+	for i := 0; i < endpointFailureThreshold-1; i++ {
+		reconciler.requestPoll(req.NamespacedName)
+		if _, err := reconciler.Reconcile(ctx, req); err != nil {
+			t.Fatalf("reconcile failed: %v", err)
+		}
+	}
+	bundle = getWerfBundle(t, ctx, req.Name, "default")
+	state := bundle.Status.Endpoints[0]
+	if state.State != werfv1alpha1.CircuitOpen || state.RetryTime == nil {
+		t.Fatalf("expected the primary circuit Open after %d failures, got %+v", endpointFailureThreshold, state)
+	}
+	primaryPolls := fakeReg.PollsByRepo[primary]
+	reconciler.requestPoll(req.NamespacedName)
+	if _, err := reconciler.Reconcile(ctx, req); err != nil {
+		t.Fatalf("reconcile failed: %v", err)
+	}
+	if fakeReg.PollsByRepo[primary] != primaryPolls {
+		t.Errorf("expected the open primary endpoint to be skipped")
+	}
+
+	// After the retry time, the recovered primary is tried again and closes its circuit
+	delete(fakeReg.ErrorsByRepo, primary)
+	fakeReg.SetTags(primary, []string{"v1.0.0"})
+	bundle = getWerfBundle(t, ctx, req.Name, "default")
+	past := metav1.NewTime(time.Now().Add(-time.Second))
+	bundle.Status.Endpoints[0].RetryTime = &past
+	if err := testk8sClient.Status().Update(ctx, bundle); err != nil {
+		t.Fatalf("failed to update status: %v", err)
+	}
+	reconciler.requestPoll(req.NamespacedName)
+	if _, err := reconciler.Reconcile(ctx, req); err != nil {
+		t.Fatalf("reconcile failed: %v", err)
+	}
+	bundle = getWerfBundle(t, ctx, req.Name, "default")
+	if bundle.Status.RegistryEndpoint != primary {
+		t.Errorf("expected registryEndpoint back on %s, got %q", primary, bundle.Status.RegistryEndpoint)
+	}
+	if state := bundle.Status.Endpoints[0]; state.State != werfv1alpha1.CircuitClosed || state.ConsecutiveFailures != 0 {
+		t.Errorf("expected the primary circuit Closed, got %+v", state)
+	}
+}
+
+func TestReconcile_RegistryMirrors_Errors(t *testing.T) {
+	tests := []struct {
+		name          string
+		primaryErr    error
+		wantFailures  int32
+		wantReason    string
+		wantMirrorTry bool
+	}{
+		{
+			// All endpoints down: one failed poll of the bundle, retried with backoff
+			name:          "all-transient",
+			primaryErr:    &registry.NetworkError{Err: errors.New("connection refused")},
+			wantFailures:  1,
+			wantMirrorTry: true,
+		},
+		{
+			// Configuration errors aren't hidden by falling back to a mirror
+			name:         "permanent",
+			primaryErr:   &registry.AuthError{Err: errors.New("HTTP 401: Unauthorized")},
+			wantFailures: 1,
+			wantReason:   werfv1alpha1.FailureReasonAuthenticationFailed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			primary := "ghcr.io/test/mirrors-" + tt.name
+			mirror := "mirror.example.com/test/mirrors-" + tt.name
+			req := createMirrorTestBundle(t, ctx, testBundleNameForStep("mirrors-"+tt.name), primary, mirror)
+
+			fakeReg := NewFakeRegistry()
+			fakeReg.SetError(primary, tt.primaryErr)
+			fakeReg.SetError(mirror, &registry.NetworkError{Err: errors.New("i/o timeout")})
+			reconciler := &WerfBundleReconciler{
+				Client:         testk8sClient,
+				Scheme:         testk8sClient.Scheme(),
+				RegistryClient: fakeReg,
+				Clientset:      testK8sClientset,
+			}
+			if _, err := reconciler.Reconcile(ctx, req); err != nil {
+				t.Fatalf("reconcile failed: %v", err)
+			}
+
+			bundle := getWerfBundle(t, ctx, req.Name, "default")
+			if bundle.Status.ConsecutiveFailures != tt.wantFailures || bundle.Status.FailureReason != tt.wantReason {
+				t.Errorf("expected %d failures with reason %q, got %d with %q", tt.wantFailures, tt.wantReason,
+					bundle.Status.ConsecutiveFailures, bundle.Status.FailureReason)
+			}
+			if tried := fakeReg.PollsByRepo[mirror] > 0; tried != tt.wantMirrorTry {
+				t.Errorf("mirror polled: got %v, want %v", tried, tt.wantMirrorTry)
+			}
+			if !strings.Contains(bundle.Status.LastErrorMessage, primary) {
+				t.Errorf("expected the error to name the failing endpoint, got %q", bundle.Status.LastErrorMessage)
+			}
+			if tt.wantMirrorTry && !strings.Contains(bundle.Status.LastErrorMessage, mirror) {
+				t.Errorf("expected the error to name the mirror, got %q", bundle.Status.LastErrorMessage)
+			}
+		})
+	}
+}
@@ -0,0 +1,143 @@
+package controllers
+
+import (
+	"context"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	ggcrregistry "github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	batchv1 "k8s.io/api/batch/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	werfv1alpha1 "github.com/werf/k8s-werf-operator-go/api/v1alpha1"
+	"github.com/werf/k8s-werf-operator-go/internal/registry"
+	testingutil "github.com/werf/k8s-werf-operator-go/internal/testing"
+)
+
+// startProxiedRegistry serves an in-process registry as a plain HTTP proxy for any host and
+// pushes the tags to repoName. Returns the proxy URL.
+func startProxiedRegistry(t *testing.T, repoName string, tags ...string) string {
+	t.Helper()
+
+	handler := ggcrregistry.New(ggcrregistry.Logger(log.New(io.Discard, "", 0)))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodConnect {
+			http.Error(w, "CONNECT not supported", http.StatusMethodNotAllowed)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	}))
+	t.Cleanup(server.Close)
+	for _, tag := range tags {
+		img, err := testingutil.BundleImage("app", tag)
+		if err != nil {
+			t.Fatalf("failed to build bundle: %v", err)
+		}
+		ref, err := name.ParseReference(strings.TrimPrefix(server.URL, "http://") + "/" + repoName + ":" + tag)
+		if err != nil {
+			t.Fatalf("failed to parse reference: %v", err)
+		}
+		if err := remote.Write(ref, img); err != nil {
+			t.Fatalf("failed to push image: %v", err)
+		}
+	}
+	return server.URL
+}
+
+func TestReconcile_RegistryProxy_UsesOperatorOrBundleProxy(t *testing.T) {
+	ctx := context.Background()
+	proxyURL := startProxiedRegistry(t, "test/proxy", "v1.0.0")
+
+	reconciler := &WerfBundleReconciler{
+		Client:         testk8sClient,
+		Scheme:         testk8sClient.Scheme(),
+		RegistryClient: registry.NewOCIClient(),
+		Clientset:      testK8sClientset,
+		Proxy:          &registry.ProxyConfig{HTTPProxy: proxyURL, NoProxy: ".corp.example"},
+	}
+
+	tests := []struct {
+		name        string
+		proxy       *werfv1alpha1.RegistryProxy
+		wantJob     bool
+		wantNoProxy string
+	}{
+		{
+			name:        "operator-proxy",
+			proxy:       nil,
+			wantJob:     true,
+			wantNoProxy: ".corp.example,$(KUBERNETES_SERVICE_HOST)",
+		},
+		{
+			// The bundle proxy replaces the operator settings entirely
+			name:        "bundle-proxy",
+			proxy:       &werfv1alpha1.RegistryProxy{HTTPProxy: proxyURL},
+			wantJob:     true,
+			wantNoProxy: "$(KUBERNETES_SERVICE_HOST)",
+		},
+		{
+			// An empty bundle proxy connects directly; the test registry host doesn't resolve
+			name:  "bundle-direct",
+			proxy: &werfv1alpha1.RegistryProxy{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bundleName := testBundleNameForStep("registry-" + tt.name)
+			bundle := &werfv1alpha1.WerfBundle{
+				ObjectMeta: metav1.ObjectMeta{Name: bundleName, Namespace: "default"},
+				Spec: werfv1alpha1.WerfBundleSpec{
+					Registry: werfv1alpha1.RegistryConfig{
+						URL:   "registry.proxy.test/test/proxy",
+						TLS:   &werfv1alpha1.RegistryTLS{Insecure: true},
+						Proxy: tt.proxy,
+					},
+					Converge: werfv1alpha1.ConvergeConfig{ServiceAccountName: "default"},
+				},
+			}
+			if err := testk8sClient.Create(ctx, bundle); err != nil {
+				t.Fatalf("failed to create WerfBundle: %v", err)
+			}
+
+			req := reconcile.Request{NamespacedName: types.NamespacedName{Name: bundleName, Namespace: "default"}}
+			if _, err := reconciler.Reconcile(ctx, req); err != nil {
+				t.Fatalf("reconcile failed: %v", err)
+			}
+
+			updated := getWerfBundle(t, ctx, bundleName, "default")
+			if !tt.wantJob {
+				if updated.Status.ActiveJobName != "" {
+					t.Errorf("expected no Job without the proxy, got %s", updated.Status.ActiveJobName)
+				}
+				return
+			}
+			if updated.Status.ActiveJobName == "" {
+				t.Fatalf("expected a converge Job, got phase %s: %s", updated.Status.Phase, updated.Status.LastErrorMessage)
+			}
+
+			job := &batchv1.Job{}
+			jobKey := types.NamespacedName{Name: updated.Status.ActiveJobName, Namespace: "default"}
+			if err := testk8sClient.Get(ctx, jobKey, job); err != nil {
+				t.Fatalf("failed to get Job: %v", err)
+			}
+			env := map[string]string{}
+			for _, e := range job.Spec.Template.Spec.Containers[0].Env {
+				env[e.Name] = e.Value
+			}
+			if env["HTTP_PROXY"] != proxyURL {
+				t.Errorf("HTTP_PROXY: got %q, want %q", env["HTTP_PROXY"], proxyURL)
+			}
+			if env["NO_PROXY"] != tt.wantNoProxy {
+				t.Errorf("NO_PROXY: got %q, want %q", env["NO_PROXY"], tt.wantNoProxy)
+			}
+		})
+	}
+}
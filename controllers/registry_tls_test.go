@@ -0,0 +1,225 @@
+package controllers
+
+import (
+	"context"
+	"io"
+	"log"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	ggcrregistry "github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	werfv1alpha1 "github.com/werf/k8s-werf-operator-go/api/v1alpha1"
+	"github.com/werf/k8s-werf-operator-go/internal/converge"
+	"github.com/werf/k8s-werf-operator-go/internal/registry"
+	testingutil "github.com/werf/k8s-werf-operator-go/internal/testing"
+)
+
+// startTLSRegistry serves an in-process registry over TLS with a certificate issued by ca,
+// requiring client certificates issued by ca, and pushes the tags to repoName.
+// Returns the repository URL.
+func startTLSRegistry(t *testing.T, ca *testingutil.CertificateAuthority, repoName string, tags ...string) string {
+	t.Helper()
+
+	handler := ggcrregistry.New(ggcrregistry.Logger(log.New(io.Discard, "", 0)))
+
+	// The same registry over plain HTTP, to push the tags
+	plain := httptest.NewServer(handler)
+	t.Cleanup(plain.Close)
+	for _, tag := range tags {
+		img, err := testingutil.BundleImage("app", tag)
+		if err != nil {
+			t.Fatalf("failed to build bundle: %v", err)
+		}
+		ref, err := name.ParseReference(strings.TrimPrefix(plain.URL, "http://") + "/" + repoName + ":" + tag)
+		if err != nil {
+			t.Fatalf("failed to parse reference: %v", err)
+		}
+		if err := remote.Write(ref, img); err != nil {
+			t.Fatalf("failed to push image: %v", err)
+		}
+	}
+
+	server := httptest.NewUnstartedServer(handler)
+	var err error
+	server.TLS, err = ca.ServerTLSConfig(true)
+	if err != nil {
+		t.Fatalf("failed to create server TLS config: %v", err)
+	}
+	server.StartTLS()
+	t.Cleanup(server.Close)
+	return strings.TrimPrefix(server.URL, "https://") + "/" + repoName
+}
+
+// createTLSTestBundle creates a WerfBundle trusting the CA in ConfigMap <name>-ca and
+// presenting the client certificate in Secret <name>-client.
+func createTLSTestBundle(t *testing.T, ctx context.Context, bundleName, repoURL string) {
+	t.Helper()
+
+	bundle := &werfv1alpha1.WerfBundle{
+		ObjectMeta: metav1.ObjectMeta{Name: bundleName, Namespace: "default"},
+		Spec: werfv1alpha1.WerfBundleSpec{
+			Registry: werfv1alpha1.RegistryConfig{
+				URL: repoURL,
+				TLS: &werfv1alpha1.RegistryTLS{
+					CABundle: &werfv1alpha1.CABundleSource{
+						ConfigMapRef: &corev1.LocalObjectReference{Name: bundleName + "-ca"},
+					},
+					ClientCertSecretRef: &corev1.LocalObjectReference{Name: bundleName + "-client"},
+				},
+			},
+			Converge: werfv1alpha1.ConvergeConfig{ServiceAccountName: "default"},
+		},
+	}
+	if err := testk8sClient.Create(ctx, bundle); err != nil {
+		t.Fatalf("failed to create WerfBundle: %v", err)
+	}
+}
+
+// createTLSObjects creates the CA ConfigMap and client certificate Secret of a TLS test bundle.
+// The ConfigMap is deleted on test cleanup.
+func createTLSObjects(t *testing.T, ctx context.Context, bundleName string, ca *testingutil.CertificateAuthority) {
+	t.Helper()
+
+	cert, key, err := ca.Issue("werf-operator", false)
+	if err != nil {
+		t.Fatalf("failed to issue client certificate: %v", err)
+	}
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: bundleName + "-ca", Namespace: "default"},
+		Data:       map[string]string{registry.DefaultCABundleKey: string(ca.CertPEM)},
+	}
+	if err := testk8sClient.Create(ctx, configMap); err != nil {
+		t.Fatalf("failed to create CA ConfigMap: %v", err)
+	}
+	// Other tests expect no ConfigMaps in the default namespace
+	t.Cleanup(func() { _ = testk8sClient.Delete(context.Background(), configMap) })
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: bundleName + "-client", Namespace: "default"},
+		Type:       corev1.SecretTypeTLS,
+		Data:       map[string][]byte{corev1.TLSCertKey: cert, corev1.TLSPrivateKeyKey: key},
+	}
+	if err := testk8sClient.Create(ctx, secret); err != nil {
+		t.Fatalf("failed to create client certificate Secret: %v", err)
+	}
+}
+
+func TestReconcile_RegistryTLS_ConnectsAndPassesCertificatesToJob(t *testing.T) {
+	ctx := context.Background()
+	bundleName := testBundleNameForStep("registry-tls")
+
+	ca, err := testingutil.NewCertificateAuthority()
+	if err != nil {
+		t.Fatalf("failed to create CA: %v", err)
+	}
+	repoURL := startTLSRegistry(t, ca, "test/tls", "v1.0.0")
+	createTLSObjects(t, ctx, bundleName, ca)
+	createTLSTestBundle(t, ctx, bundleName, repoURL)
+
+	reconciler := &WerfBundleReconciler{
+		Client:         testk8sClient,
+		Scheme:         testk8sClient.Scheme(),
+		RegistryClient: registry.NewOCIClient(),
+		Clientset:      testK8sClientset,
+	}
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: bundleName, Namespace: "default"}}
+	if _, err := reconciler.Reconcile(ctx, req); err != nil {
+		t.Fatalf("reconcile failed: %v", err)
+	}
+
+	updated := getWerfBundle(t, ctx, bundleName, "default")
+	if updated.Status.ActiveJobName == "" {
+		t.Fatalf("expected a converge Job, got phase %s: %s", updated.Status.Phase, updated.Status.LastErrorMessage)
+	}
+
+	// The Job gets a copy of the certificates in its registry Secret
+	job := &batchv1.Job{}
+	jobKey := types.NamespacedName{Name: updated.Status.ActiveJobName, Namespace: "default"}
+	if err := testk8sClient.Get(ctx, jobKey, job); err != nil {
+		t.Fatalf("failed to get Job: %v", err)
+	}
+	copied := &corev1.Secret{}
+	secretKey := types.NamespacedName{Name: converge.RegistrySecretName(job.Name), Namespace: "default"}
+	if err := testk8sClient.Get(ctx, secretKey, copied); err != nil {
+		t.Fatalf("expected registry Secret %q: %v", secretKey.Name, err)
+	}
+	if string(copied.Data["ca.crt"]) != string(ca.CertPEM) || len(copied.Data["client.cert"]) == 0 ||
+		len(copied.Data["client.key"]) == 0 {
+		t.Errorf("expected the CA and client certificate in the registry Secret, got keys %v", dataKeys(copied.Data))
+	}
+	var sslCertDir string
+	for _, env := range job.Spec.Template.Spec.Containers[0].Env {
+		if env.Name == "SSL_CERT_DIR" {
+			sslCertDir = env.Value
+		}
+	}
+	if sslCertDir == "" {
+		t.Error("expected SSL_CERT_DIR pointing werf at the registry CA")
+	}
+}
+
+func TestReconcile_RegistryTLS_MissingCertificatesFailBundle(t *testing.T) {
+	ctx := context.Background()
+	bundleName := testBundleNameForStep("registry-tls-missing")
+
+	ca, err := testingutil.NewCertificateAuthority()
+	if err != nil {
+		t.Fatalf("failed to create CA: %v", err)
+	}
+	repoURL := startTLSRegistry(t, ca, "test/tls", "v1.0.0")
+	createTLSTestBundle(t, ctx, bundleName, repoURL)
+
+	reconciler := &WerfBundleReconciler{
+		Client:         testk8sClient,
+		Scheme:         testk8sClient.Scheme(),
+		RegistryClient: registry.NewOCIClient(),
+		Clientset:      testK8sClientset,
+	}
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: bundleName, Namespace: "default"}}
+	if _, err := reconciler.Reconcile(ctx, req); err != nil {
+		t.Fatalf("reconcile failed: %v", err)
+	}
+
+	updated := getWerfBundle(t, ctx, bundleName, "default")
+	if updated.Status.Phase != werfv1alpha1.PhaseFailed ||
+		updated.Status.FailureReason != werfv1alpha1.FailureReasonTLSFailed {
+		t.Fatalf("expected phase Failed with reason %s, got %s/%s: %s", werfv1alpha1.FailureReasonTLSFailed,
+			updated.Status.Phase, updated.Status.FailureReason, updated.Status.LastErrorMessage)
+	}
+	if !strings.Contains(updated.Status.LastErrorMessage, bundleName+"-ca") {
+		t.Errorf("expected the error to name the missing ConfigMap, got %q", updated.Status.LastErrorMessage)
+	}
+
+	// Creating the client certificate Secret retries the Failed bundle
+	createTLSObjects(t, ctx, bundleName, ca)
+	secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: bundleName + "-client", Namespace: "default"}}
+	if requests := reconciler.bundlesForSecret(ctx, secret); len(requests) != 1 || requests[0] != req {
+		t.Fatalf("expected the Secret to map to %v, got %v", req, requests)
+	}
+
+	if _, err := reconciler.Reconcile(ctx, req); err != nil {
+		t.Fatalf("reconcile failed: %v", err)
+	}
+	updated = getWerfBundle(t, ctx, bundleName, "default")
+	if updated.Status.ActiveJobName == "" {
+		t.Errorf("expected a converge Job once the certificates exist, got phase %s: %s",
+			updated.Status.Phase, updated.Status.LastErrorMessage)
+	}
+}
+
+// dataKeys returns the keys of a Secret's data, for error messages.
+func dataKeys(data map[string][]byte) []string {
+	var names []string
+	for key := range data {
+		names = append(names, key)
+	}
+	return names
+}
@@ -0,0 +1,185 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	werfv1alpha1 "github.com/werf/k8s-werf-operator-go/api/v1alpha1"
+	"github.com/werf/k8s-werf-operator-go/internal/registry"
+)
+
+// createRetryTestBundle creates a WerfBundle tracking repoURL with the given retry policy.
+func createRetryTestBundle(
+	t *testing.T,
+	ctx context.Context,
+	name, repoURL string,
+	policy *werfv1alpha1.RetryPolicy,
+) reconcile.Request {
+	t.Helper()
+
+	bundle := &werfv1alpha1.WerfBundle{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		Spec: werfv1alpha1.WerfBundleSpec{
+			Registry: werfv1alpha1.RegistryConfig{URL: repoURL, RetryPolicy: policy},
+			Converge: werfv1alpha1.ConvergeConfig{ServiceAccountName: "default"},
+		},
+	}
+	if err := testk8sClient.Create(ctx, bundle); err != nil {
+		t.Fatalf("failed to create WerfBundle: %v", err)
+	}
+	return reconcile.Request{NamespacedName: types.NamespacedName{Name: name, Namespace: "default"}}
+}
+
+func TestReconcile_PermanentRegistryErrors_FailFast(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		reason string
+	}{
+		{
+			"auth",
+			&registry.AuthError{Err: errors.New("HTTP 401: Unauthorized")},
+			werfv1alpha1.FailureReasonAuthenticationFailed,
+		},
+		{
+			"not-found",
+			&registry.NotFoundError{Err: errors.New("HTTP 404: Not Found")},
+			werfv1alpha1.FailureReasonRepositoryNotFound,
+		},
+		{"too-many-tags", &registry.TooManyTagsError{Limit: 100}, werfv1alpha1.FailureReasonTooManyTags},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			repoURL := "ghcr.io/test/fail-fast-" + tt.name
+			req := createRetryTestBundle(t, ctx, testBundleNameForStep("fail-fast-"+tt.name), repoURL, nil)
+
+			fakeReg := NewFakeRegistry()
+			fakeReg.SetError(repoURL, tt.err)
+			reconciler := &WerfBundleReconciler{
+				Client:         testk8sClient,
+				Scheme:         testk8sClient.Scheme(),
+				RegistryClient: fakeReg,
+				Clientset:      testK8sClientset,
+			}
+
+			result, err := reconciler.Reconcile(ctx, req)
+			if err != nil {
+				t.Fatalf("reconcile failed: %v", err)
+			}
+
+			// No backoff retries: Failed on the first error, retried at the long interval
+			bundle := getWerfBundle(t, ctx, req.Name, "default")
+			if bundle.Status.Phase != werfv1alpha1.PhaseFailed {
+				t.Errorf("expected phase Failed after the first error, got %s", bundle.Status.Phase)
+			}
+			if bundle.Status.FailureReason != tt.reason {
+				t.Errorf("expected failure reason %s, got %q", tt.reason, bundle.Status.FailureReason)
+			}
+			if result.RequeueAfter < failedRetryInterval/2 {
+				t.Errorf("expected requeue at the Failed retry interval, got %v", result.RequeueAfter)
+			}
+		})
+	}
+}
+
+func TestReconcile_RetryPolicy_CustomBackoff(t *testing.T) {
+	ctx := context.Background()
+	repoURL := "ghcr.io/test/retry-policy"
+	req := createRetryTestBundle(t, ctx, testBundleNameForStep("retry-policy"), repoURL, &werfv1alpha1.RetryPolicy{
+		BaseDelay:   "10s",
+		MaxDelay:    "15s",
+		MaxAttempts: 3,
+	})
+
+	fakeReg := NewFakeRegistry()
+	fakeReg.SetError(repoURL, &registry.NetworkError{Err: fmt.Errorf("connection refused")})
+	reconciler := &WerfBundleReconciler{
+		Client:         testk8sClient,
+		Scheme:         testk8sClient.Scheme(),
+		RegistryClient: fakeReg,
+		Clientset:      testK8sClientset,
+	}
+
+	for i, want := range []time.Duration{10 * time.Second, 15 * time.Second, 15 * time.Second} {
+		reconciler.requestPoll(req.NamespacedName)
+		result, err := reconciler.Reconcile(ctx, req)
+		if err != nil {
+			t.Fatalf("reconcile %d failed: %v", i, err)
+		}
+		if result.RequeueAfter != want {
+			t.Errorf("reconcile %d: expected backoff %v, got %v", i, want, result.RequeueAfter)
+		}
+		if phase := getWerfBundle(t, ctx, req.Name, "default").Status.Phase; phase != werfv1alpha1.PhaseSyncing {
+			t.Errorf("reconcile %d: expected phase Syncing while retrying, got %s", i, phase)
+		}
+	}
+
+	// The attempts are used up
+	reconciler.requestPoll(req.NamespacedName)
+	result, err := reconciler.Reconcile(ctx, req)
+	if err != nil {
+		t.Fatalf("reconcile failed: %v", err)
+	}
+	bundle := getWerfBundle(t, ctx, req.Name, "default")
+	if bundle.Status.Phase != werfv1alpha1.PhaseFailed ||
+		bundle.Status.FailureReason != werfv1alpha1.FailureReasonRegistryUnavailable {
+		t.Errorf("expected Failed with reason %s, got %s/%q",
+			werfv1alpha1.FailureReasonRegistryUnavailable, bundle.Status.Phase, bundle.Status.FailureReason)
+	}
+	if result.RequeueAfter < failedRetryInterval/2 {
+		t.Errorf("expected requeue at the Failed retry interval, got %v", result.RequeueAfter)
+	}
+}
+
+func TestReconcile_FailedBundle_RecoversWhenRegistryDoes(t *testing.T) {
+	ctx := context.Background()
+	repoURL := "ghcr.io/test/failed-recovers"
+	req := createRetryTestBundle(t, ctx, testBundleNameForStep("failed-recovers"), repoURL,
+		&werfv1alpha1.RetryPolicy{MaxAttempts: 1})
+
+	fakeReg := NewFakeRegistry()
+	fakeReg.SetError(repoURL, &registry.NetworkError{Err: fmt.Errorf("connection refused")})
+	reconciler := &WerfBundleReconciler{
+		Client:         testk8sClient,
+		Scheme:         testk8sClient.Scheme(),
+		RegistryClient: fakeReg,
+		Clientset:      testK8sClientset,
+	}
+
+	for range 2 {
+		reconciler.requestPoll(req.NamespacedName)
+		if _, err := reconciler.Reconcile(ctx, req); err != nil {
+			t.Fatalf("reconcile failed: %v", err)
+		}
+	}
+	if phase := getWerfBundle(t, ctx, req.Name, "default").Status.Phase; phase != werfv1alpha1.PhaseFailed {
+		t.Fatalf("expected phase Failed, got %s", phase)
+	}
+
+	// The registry is back by the time the Failed bundle is retried
+	delete(fakeReg.ErrorsByRepo, repoURL)
+	fakeReg.SetTags(repoURL, []string{"v1.0.0"})
+	reconciler.requestPoll(req.NamespacedName)
+	if _, err := reconciler.Reconcile(ctx, req); err != nil {
+		t.Fatalf("reconcile failed: %v", err)
+	}
+
+	bundle := getWerfBundle(t, ctx, req.Name, "default")
+	if bundle.Status.Phase != werfv1alpha1.PhaseSyncing || bundle.Status.ActiveJobName == "" {
+		t.Errorf("expected a converge Job after recovery, got phase %s, job %q",
+			bundle.Status.Phase, bundle.Status.ActiveJobName)
+	}
+	if bundle.Status.ConsecutiveFailures != 0 || bundle.Status.FailureReason != "" {
+		t.Errorf("expected failures and reason reset, got %d/%q",
+			bundle.Status.ConsecutiveFailures, bundle.Status.FailureReason)
+	}
+}
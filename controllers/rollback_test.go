@@ -0,0 +1,200 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	werfv1alpha1 "github.com/werf/k8s-werf-operator-go/api/v1alpha1"
+)
+
+// newRollbackTestReconciler sets up a bundle with spec.rollback.onFailure that has v1.0.0
+// deployed, then starts a converge of v1.1.0.
+func newRollbackTestReconciler(
+	t *testing.T,
+	ctx context.Context,
+	name, repoURL string,
+) (*WerfBundleReconciler, *FakeRegistry, reconcile.Request) {
+	t.Helper()
+
+	reconciler, fakeReg, req := newDigestTestReconciler(t, ctx, name, repoURL, []string{"v1.0.0"})
+	bundle := getWerfBundle(t, ctx, name, "default")
+	bundle.Spec.Rollback = &werfv1alpha1.RollbackConfig{OnFailure: true}
+	if err := testk8sClient.Update(ctx, bundle); err != nil {
+		t.Fatalf("failed to set spec.rollback: %v", err)
+	}
+	if _, err := reconciler.Reconcile(ctx, req); err != nil {
+		t.Fatalf("reconcile failed: %v", err)
+	}
+	completeActiveJob(t, ctx, reconciler, req)
+
+	fakeReg.SetTags(repoURL, []string{"v1.0.0", "v1.1.0"})
+	reconciler.requestPoll(req.NamespacedName)
+	if _, err := reconciler.Reconcile(ctx, req); err != nil {
+		t.Fatalf("reconcile after push failed: %v", err)
+	}
+	if updated := getWerfBundle(t, ctx, name, "default"); updated.Status.LastAppliedTag != "v1.1.0" {
+		t.Fatalf("expected a Job for v1.1.0, got tag %q", updated.Status.LastAppliedTag)
+	}
+	return reconciler, fakeReg, req
+}
+
+// failActiveJob marks the active Job of the bundle failed and reconciles.
+func failActiveJob(t *testing.T, ctx context.Context, reconciler *WerfBundleReconciler, req reconcile.Request) {
+	t.Helper()
+
+	job := getJobInNamespace(t, ctx, req.Name, "default")
+	now := metav1.Now()
+	job.Status.Failed = 1
+	job.Status.StartTime = &now
+	if err := testk8sClient.Status().Update(ctx, job); err != nil {
+		t.Fatalf("failed to update job status: %v", err)
+	}
+	if _, err := reconciler.Reconcile(ctx, req); err != nil {
+		t.Fatalf("reconcile after job failure failed: %v", err)
+	}
+}
+
+func TestReconcile_Rollback_OnFailure(t *testing.T) {
+	ctx := context.Background()
+	bundleName := testBundleNameForStep("rollback")
+	repoURL := "ghcr.io/test/rollback"
+	reconciler, fakeReg, req := newRollbackTestReconciler(t, ctx, bundleName, repoURL)
+
+	// The failed converge starts a rollback to v1.0.0
+	failActiveJob(t, ctx, reconciler, req)
+	updated := getWerfBundle(t, ctx, bundleName, "default")
+	if updated.Status.ActiveJobName == "" || updated.Status.LastAppliedTag != "v1.0.0" {
+		t.Fatalf("expected a rollback Job for v1.0.0, got job %q tag %q",
+			updated.Status.ActiveJobName, updated.Status.LastAppliedTag)
+	}
+	job := getJobInNamespace(t, ctx, bundleName, "default")
+	if !containsArg(job.Spec.Template.Spec.Containers[0].Args, repoURL+"@"+FakeDigest(repoURL, "v1.0.0")) {
+		t.Errorf("expected the rollback Job pinned to the v1.0.0 digest, got args %v",
+			job.Spec.Template.Spec.Containers[0].Args)
+	}
+	rollback := updated.Status.LastRollback
+	if rollback == nil || rollback.FailedTag != "v1.1.0" || rollback.Tag != "v1.0.0" ||
+		rollback.JobName != job.Name || rollback.Result != werfv1alpha1.JobStatusRunning {
+		t.Fatalf("expected a running rollback from v1.1.0 to v1.0.0, got %+v", rollback)
+	}
+	if len(updated.Status.FailedVersions) != 1 || updated.Status.FailedVersions[0].Tag != "v1.1.0" ||
+		updated.Status.FailedVersions[0].Digest != FakeDigest(repoURL, "v1.1.0") {
+		t.Errorf("expected v1.1.0 to be recorded as failed, got %+v", updated.Status.FailedVersions)
+	}
+	condition := meta.FindStatusCondition(updated.Status.Conditions, werfv1alpha1.ConditionRolledBack)
+	if condition == nil || condition.Status != metav1.ConditionTrue ||
+		condition.Reason != werfv1alpha1.ReasonRollbackInProgress {
+		t.Errorf("expected RolledBack True with reason %s, got %+v", werfv1alpha1.ReasonRollbackInProgress, condition)
+	}
+
+	completeActiveJob(t, ctx, reconciler, req)
+	updated = getWerfBundle(t, ctx, bundleName, "default")
+	if rollback := updated.Status.LastRollback; rollback.Result != werfv1alpha1.JobStatusSucceeded ||
+		rollback.CompletionTime == nil {
+		t.Errorf("expected the rollback to succeed, got %+v", rollback)
+	}
+	condition = meta.FindStatusCondition(updated.Status.Conditions, werfv1alpha1.ConditionRolledBack)
+	if condition == nil || condition.Status != metav1.ConditionTrue ||
+		condition.Reason != werfv1alpha1.ReasonRollbackSucceeded {
+		t.Errorf("expected RolledBack True with reason %s, got %+v", werfv1alpha1.ReasonRollbackSucceeded, condition)
+	}
+
+	// The failed version isn't retried
+	reconciler.requestPoll(req.NamespacedName)
+	if _, err := reconciler.Reconcile(ctx, req); err != nil {
+		t.Fatalf("reconcile after rollback failed: %v", err)
+	}
+	updated = getWerfBundle(t, ctx, bundleName, "default")
+	if updated.Status.ActiveJobName != "" || updated.Status.LastAppliedTag != "v1.0.0" {
+		t.Fatalf("expected v1.1.0 not to be retried, got job %q tag %q",
+			updated.Status.ActiveJobName, updated.Status.LastAppliedTag)
+	}
+
+	// Until it's re-pushed
+	fakeReg.SetDigest(repoURL, "v1.1.0", "sha256:9999999999999999999999999999999999999999999999999999999999999999")
+	reconciler.requestPoll(req.NamespacedName)
+	if _, err := reconciler.Reconcile(ctx, req); err != nil {
+		t.Fatalf("reconcile after re-push failed: %v", err)
+	}
+	updated = getWerfBundle(t, ctx, bundleName, "default")
+	if updated.Status.ActiveJobName == "" || updated.Status.LastAppliedTag != "v1.1.0" {
+		t.Fatalf("expected a Job for the re-pushed v1.1.0, got job %q tag %q",
+			updated.Status.ActiveJobName, updated.Status.LastAppliedTag)
+	}
+	completeActiveJob(t, ctx, reconciler, req)
+	updated = getWerfBundle(t, ctx, bundleName, "default")
+	if updated.Status.LastSuccessfulTag != "v1.1.0" {
+		t.Errorf("expected LastSuccessfulTag v1.1.0, got %q", updated.Status.LastSuccessfulTag)
+	}
+	condition = meta.FindStatusCondition(updated.Status.Conditions, werfv1alpha1.ConditionRolledBack)
+	if condition != nil {
+		t.Errorf("expected no RolledBack condition once a new version is deployed, got %+v", condition)
+	}
+}
+
+func TestReconcile_Rollback_Fails(t *testing.T) {
+	ctx := context.Background()
+	bundleName := testBundleNameForStep("rollback-fails")
+	repoURL := "ghcr.io/test/rollback-fails"
+	reconciler, _, req := newRollbackTestReconciler(t, ctx, bundleName, repoURL)
+
+	failActiveJob(t, ctx, reconciler, req)
+	rollbackJob := getWerfBundle(t, ctx, bundleName, "default").Status.ActiveJobName
+	failActiveJob(t, ctx, reconciler, req)
+
+	updated := getWerfBundle(t, ctx, bundleName, "default")
+	if updated.Status.Phase != werfv1alpha1.PhaseFailed ||
+		updated.Status.FailureReason != werfv1alpha1.FailureReasonRollbackFailed {
+		t.Errorf("expected phase Failed with reason %s, got %s with %q",
+			werfv1alpha1.FailureReasonRollbackFailed, updated.Status.Phase, updated.Status.FailureReason)
+	}
+	if updated.Status.ActiveJobName != "" {
+		t.Errorf("expected no rollback of the failed rollback, got Job %q", updated.Status.ActiveJobName)
+	}
+	if rollback := updated.Status.LastRollback; rollback == nil || rollback.JobName != rollbackJob ||
+		rollback.Result != werfv1alpha1.JobStatusFailed {
+		t.Errorf("expected the rollback to fail, got %+v", rollback)
+	}
+	condition := meta.FindStatusCondition(updated.Status.Conditions, werfv1alpha1.ConditionRolledBack)
+	if condition == nil || condition.Status != metav1.ConditionFalse ||
+		condition.Reason != werfv1alpha1.ReasonRollbackFailed {
+		t.Errorf("expected RolledBack False with reason %s, got %+v", werfv1alpha1.ReasonRollbackFailed, condition)
+	}
+
+	// Nor is the failed version retried
+	reconciler.requestPoll(req.NamespacedName)
+	if _, err := reconciler.Reconcile(ctx, req); err != nil {
+		t.Fatalf("reconcile after failed rollback failed: %v", err)
+	}
+	if updated := getWerfBundle(t, ctx, bundleName, "default"); updated.Status.ActiveJobName != "" {
+		t.Errorf("expected v1.1.0 not to be retried, got Job %q", updated.Status.ActiveJobName)
+	}
+}
+
+func TestReconcile_FailedVersion_RecordedWithoutRollback(t *testing.T) {
+	ctx := context.Background()
+	bundleName := testBundleNameForStep("failed-no-rollback")
+	repoURL := "ghcr.io/test/failed-no-rollback"
+	reconciler, _, req := newDigestTestReconciler(t, ctx, bundleName, repoURL, []string{"v1.0.0"})
+	if _, err := reconciler.Reconcile(ctx, req); err != nil {
+		t.Fatalf("reconcile failed: %v", err)
+	}
+
+	failActiveJob(t, ctx, reconciler, req)
+	updated := getWerfBundle(t, ctx, bundleName, "default")
+	if updated.Status.Phase != werfv1alpha1.PhaseFailed || updated.Status.ActiveJobName != "" {
+		t.Fatalf("expected phase Failed without a rollback Job, got %s with job %q",
+			updated.Status.Phase, updated.Status.ActiveJobName)
+	}
+	if len(updated.Status.FailedVersions) != 1 || updated.Status.FailedVersions[0].Tag != "v1.0.0" ||
+		updated.Status.FailedVersions[0].Digest != FakeDigest(repoURL, "v1.0.0") {
+		t.Errorf("expected v1.0.0 to be recorded as failed, got %+v", updated.Status.FailedVersions)
+	}
+	if updated.Status.LastRollback != nil {
+		t.Errorf("expected no rollback without spec.rollback.onFailure, got %+v", updated.Status.LastRollback)
+	}
+}
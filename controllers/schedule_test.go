@@ -0,0 +1,121 @@
+package controllers
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	werfv1alpha1 "github.com/werf/k8s-werf-operator-go/api/v1alpha1"
+)
+
+// setSchedule changes spec.schedule of the bundle.
+func setSchedule(t *testing.T, ctx context.Context, name string, schedule *werfv1alpha1.ScheduleConfig) {
+	t.Helper()
+
+	bundle := getWerfBundle(t, ctx, name, "default")
+	bundle.Spec.Schedule = schedule
+	if err := testk8sClient.Update(ctx, bundle); err != nil {
+		t.Fatalf("failed to update spec.schedule: %v", err)
+	}
+}
+
+func TestReconcile_Schedule_HoldsTagUntilWindow(t *testing.T) {
+	ctx := context.Background()
+	bundleName := testBundleNameForStep("schedule-freeze")
+	repoURL := "ghcr.io/test/schedule-freeze"
+	reconciler, _, req := newDigestTestReconciler(t, ctx, bundleName, repoURL, []string{"v1.0.0"})
+
+	// A freeze holds the new tag until it ends
+	now := time.Now().Truncate(time.Second)
+	freezeEnd := now.Add(2 * time.Hour)
+	setSchedule(t, ctx, bundleName, &werfv1alpha1.ScheduleConfig{
+		Freezes: []werfv1alpha1.FreezePeriod{{
+			Start:  metav1.NewTime(now.Add(-time.Hour)),
+			End:    metav1.NewTime(freezeEnd),
+			Reason: "release week",
+		}},
+	})
+	result, err := reconciler.Reconcile(ctx, req)
+	if err != nil {
+		t.Fatalf("reconcile failed: %v", err)
+	}
+	updated := getWerfBundle(t, ctx, bundleName, "default")
+	if updated.Status.ActiveJobName != "" {
+		t.Fatalf("expected no Job during a freeze, got %q", updated.Status.ActiveJobName)
+	}
+	if updated.Status.HeldTag != "v1.0.0" || updated.Status.HeldDigest != FakeDigest(repoURL, "v1.0.0") {
+		t.Errorf("expected v1.0.0 to be held, got %q (%q)", updated.Status.HeldTag, updated.Status.HeldDigest)
+	}
+	if updated.Status.NextWindowTime == nil || !updated.Status.NextWindowTime.Time.Equal(freezeEnd) {
+		t.Errorf("expected NextWindowTime %v, got %v", freezeEnd, updated.Status.NextWindowTime)
+	}
+	if updated.Status.NextPollTime == nil || updated.Status.NextPollTime.After(freezeEnd) {
+		t.Errorf("expected the next poll by the end of the freeze, got %v", updated.Status.NextPollTime)
+	}
+	if result.RequeueAfter == 0 || result.RequeueAfter > time.Until(freezeEnd) {
+		t.Errorf("expected requeue by the end of the freeze, got %v", result.RequeueAfter)
+	}
+	condition := meta.FindStatusCondition(updated.Status.Conditions, werfv1alpha1.ConditionDeploymentHeld)
+	if condition == nil || condition.Status != metav1.ConditionTrue ||
+		condition.Reason != werfv1alpha1.ReasonChangeFreeze {
+		t.Fatalf("expected DeploymentHeld True with reason %s, got %+v", werfv1alpha1.ReasonChangeFreeze, condition)
+	}
+	if !strings.Contains(condition.Message, "release week") {
+		t.Errorf("expected the freeze reason in the message, got %q", condition.Message)
+	}
+
+	// Lifting the freeze deploys the held tag
+	setSchedule(t, ctx, bundleName, &werfv1alpha1.ScheduleConfig{
+		Windows: []werfv1alpha1.DeploymentWindow{
+			{Start: "* * * * *", Duration: metav1.Duration{Duration: time.Hour}},
+		},
+	})
+	if _, err := reconciler.Reconcile(ctx, req); err != nil {
+		t.Fatalf("reconcile after lifting the freeze failed: %v", err)
+	}
+	updated = getWerfBundle(t, ctx, bundleName, "default")
+	if updated.Status.ActiveJobName == "" || updated.Status.LastAppliedTag != "v1.0.0" {
+		t.Fatalf("expected a Job for v1.0.0 inside the window, got job %q tag %q",
+			updated.Status.ActiveJobName, updated.Status.LastAppliedTag)
+	}
+	if updated.Status.HeldTag != "" || updated.Status.NextWindowTime != nil {
+		t.Errorf("expected no held version, got %q until %v", updated.Status.HeldTag, updated.Status.NextWindowTime)
+	}
+	condition = meta.FindStatusCondition(updated.Status.Conditions, werfv1alpha1.ConditionDeploymentHeld)
+	if condition == nil || condition.Status != metav1.ConditionFalse || condition.Reason != werfv1alpha1.ReasonWindowOpen {
+		t.Errorf("expected DeploymentHeld False with reason %s, got %+v", werfv1alpha1.ReasonWindowOpen, condition)
+	}
+}
+
+func TestReconcile_Schedule_Invalid(t *testing.T) {
+	ctx := context.Background()
+	bundleName := testBundleNameForStep("schedule-invalid")
+	repoURL := "ghcr.io/test/schedule-invalid"
+	reconciler, _, req := newDigestTestReconciler(t, ctx, bundleName, repoURL, []string{"v1.0.0"})
+	setSchedule(t, ctx, bundleName, &werfv1alpha1.ScheduleConfig{
+		TimeZone: "Europe/Atlantis",
+		Windows: []werfv1alpha1.DeploymentWindow{
+			{Start: "0 22 * * *", Duration: metav1.Duration{Duration: time.Hour}},
+		},
+	})
+
+	if _, err := reconciler.Reconcile(ctx, req); err != nil {
+		t.Fatalf("reconcile failed: %v", err)
+	}
+	updated := getWerfBundle(t, ctx, bundleName, "default")
+	if updated.Status.Phase != werfv1alpha1.PhaseFailed ||
+		updated.Status.FailureReason != werfv1alpha1.FailureReasonInvalidSchedule {
+		t.Errorf("expected phase Failed with reason %s, got %s with %q",
+			werfv1alpha1.FailureReasonInvalidSchedule, updated.Status.Phase, updated.Status.FailureReason)
+	}
+	if !strings.Contains(updated.Status.LastErrorMessage, "Europe/Atlantis") {
+		t.Errorf("expected the time zone in the message, got %q", updated.Status.LastErrorMessage)
+	}
+	if updated.Status.ActiveJobName != "" {
+		t.Errorf("expected no Job for an invalid schedule, got %q", updated.Status.ActiveJobName)
+	}
+}
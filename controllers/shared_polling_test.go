@@ -0,0 +1,89 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	werfv1alpha1 "github.com/werf/k8s-werf-operator-go/api/v1alpha1"
+	"github.com/werf/k8s-werf-operator-go/internal/registry"
+)
+
+// createBundlesForRepo creates one WerfBundle per name, all tracking repoURL.
+func createBundlesForRepo(t *testing.T, ctx context.Context, repoURL string, names ...string) []reconcile.Request {
+	t.Helper()
+
+	var reqs []reconcile.Request
+	for _, name := range names {
+		bundle := &werfv1alpha1.WerfBundle{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Spec: werfv1alpha1.WerfBundleSpec{
+				Registry: werfv1alpha1.RegistryConfig{URL: repoURL},
+				Converge: werfv1alpha1.ConvergeConfig{ServiceAccountName: "default"},
+			},
+		}
+		if err := testk8sClient.Create(ctx, bundle); err != nil {
+			t.Fatalf("failed to create WerfBundle: %v", err)
+		}
+		reqs = append(reqs, reconcile.Request{NamespacedName: types.NamespacedName{Name: name, Namespace: "default"}})
+	}
+	return reqs
+}
+
+func TestReconcile_SharedPolling_OneRequestPerRepository(t *testing.T) {
+	ctx := context.Background()
+	repoURL := "ghcr.io/test/shared-poll"
+	reqs := createBundlesForRepo(t, ctx, repoURL,
+		testBundleNameForStep("shared-dev"),
+		testBundleNameForStep("shared-staging"),
+		testBundleNameForStep("shared-prod"),
+	)
+
+	fakeReg := NewFakeRegistry()
+	fakeReg.SetTags(repoURL, []string{"v1.0.0"})
+	reconciler := &WerfBundleReconciler{
+		Client:         testk8sClient,
+		Scheme:         testk8sClient.Scheme(),
+		RegistryClient: registry.NewSharedClient(fakeReg, time.Minute),
+		Clientset:      testK8sClientset,
+	}
+
+	for _, req := range reqs {
+		if _, err := reconciler.Reconcile(ctx, req); err != nil {
+			t.Fatalf("reconcile %s failed: %v", req.Name, err)
+		}
+		bundle := getWerfBundle(t, ctx, req.Name, "default")
+		if bundle.Status.ActiveJobName == "" {
+			t.Errorf("expected a converge Job for %s", req.Name)
+		}
+	}
+
+	if fakeReg.Polls != 1 {
+		t.Errorf("expected 1 registry poll for %d bundles, got %d", len(reqs), fakeReg.Polls)
+	}
+}
+
+func TestPollRepository_RequestsPollOfMatchingBundles(t *testing.T) {
+	ctx := context.Background()
+	matching := createBundlesForRepo(t, ctx, "ghcr.io/test/fanout",
+		testBundleNameForStep("fanout-a"),
+		testBundleNameForStep("fanout-b"),
+	)
+	other := createBundlesForRepo(t, ctx, "ghcr.io/test/fanout-other", testBundleNameForStep("fanout-other"))
+
+	reconciler := &WerfBundleReconciler{Client: testk8sClient, Scheme: testk8sClient.Scheme()}
+	reconciler.PollRepository(ctx, "GHCR.io/test/fanout")
+
+	for _, req := range matching {
+		if _, ok := reconciler.pollRequests.Load(req.NamespacedName); !ok {
+			t.Errorf("expected a poll request for %s", req.Name)
+		}
+	}
+	if _, ok := reconciler.pollRequests.Load(other[0].NamespacedName); ok {
+		t.Errorf("expected no poll request for %s, which tracks another repository", other[0].Name)
+	}
+}
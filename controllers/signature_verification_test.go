@@ -0,0 +1,206 @@
+package controllers
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"strings"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	werfv1alpha1 "github.com/werf/k8s-werf-operator-go/api/v1alpha1"
+	"github.com/werf/k8s-werf-operator-go/internal/registry"
+	testingutil "github.com/werf/k8s-werf-operator-go/internal/testing"
+)
+
+// setupSignedBundleTest starts a registry with one v1.0.0 bundle and creates a WerfBundle that
+// requires signatures by key. The public key Secret is only created if createKeySecret is set.
+// Returns the reconciler, the request, the repository URL and the bundle digest.
+func setupSignedBundleTest(
+	t *testing.T,
+	ctx context.Context,
+	bundleName string,
+	key *ecdsa.PrivateKey,
+	createKeySecret bool,
+) (*WerfBundleReconciler, reconcile.Request, string, string) {
+	t.Helper()
+
+	repoURL := startAuthRegistry(t, "test/signed", "v1.0.0")
+	createRegistrySecret(t, ctx, bundleName+"-creds", "default", repoURL, testRegistryPassword)
+
+	keySecretName := bundleName + "-cosign"
+	if createKeySecret {
+		pub, err := testingutil.PublicKeyPEM(key)
+		if err != nil {
+			t.Fatalf("failed to encode public key: %v", err)
+		}
+		secret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: keySecretName, Namespace: "default"},
+			Data:       map[string][]byte{"cosign.pub": pub},
+		}
+		if err := testk8sClient.Create(ctx, secret); err != nil {
+			t.Fatalf("failed to create public key Secret: %v", err)
+		}
+		t.Cleanup(func() { _ = testk8sClient.Delete(context.Background(), secret) })
+	}
+
+	bundle := &werfv1alpha1.WerfBundle{
+		ObjectMeta: metav1.ObjectMeta{Name: bundleName, Namespace: "default"},
+		Spec: werfv1alpha1.WerfBundleSpec{
+			Registry: werfv1alpha1.RegistryConfig{
+				URL:       repoURL,
+				SecretRef: &corev1.LocalObjectReference{Name: bundleName + "-creds"},
+			},
+			Converge: werfv1alpha1.ConvergeConfig{ServiceAccountName: "default"},
+			Verify: &werfv1alpha1.VerifyConfig{
+				Provider:  werfv1alpha1.VerifyProviderCosign,
+				SecretRef: corev1.LocalObjectReference{Name: keySecretName},
+			},
+		},
+	}
+	if err := testk8sClient.Create(ctx, bundle); err != nil {
+		t.Fatalf("failed to create WerfBundle: %v", err)
+	}
+
+	client := registry.NewOCIClient()
+	digest, err := client.ResolveDigest(ctx, repoURL, "v1.0.0", testRegistryAuth())
+	if err != nil {
+		t.Fatalf("failed to resolve digest: %v", err)
+	}
+
+	reconciler := &WerfBundleReconciler{
+		Client:         testk8sClient,
+		Scheme:         testk8sClient.Scheme(),
+		RegistryClient: client,
+		Clientset:      testK8sClientset,
+	}
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: bundleName, Namespace: "default"}}
+	return reconciler, req, repoURL, digest
+}
+
+// testRegistryAuth returns the credentials accepted by registries from startAuthRegistry.
+func testRegistryAuth() authn.Authenticator {
+	return &authn.Basic{Username: testRegistryUser, Password: testRegistryPassword}
+}
+
+// newSigningKey generates an ECDSA P-256 key, the default cosign key type.
+func newSigningKey(t *testing.T) *ecdsa.PrivateKey {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate signing key: %v", err)
+	}
+	return key
+}
+
+func TestReconcile_Verify_SignedBundleCreatesJob(t *testing.T) {
+	ctx := context.Background()
+	bundleName := testBundleNameForStep("verify-signed")
+	key := newSigningKey(t)
+	reconciler, req, repoURL, digest := setupSignedBundleTest(t, ctx, bundleName, key, true)
+
+	if err := testingutil.SignBundle(ctx, repoURL, digest, key, testRegistryAuth()); err != nil {
+		t.Fatalf("failed to sign bundle: %v", err)
+	}
+
+	if _, err := reconciler.Reconcile(ctx, req); err != nil {
+		t.Fatalf("reconcile failed: %v", err)
+	}
+
+	updated := getWerfBundle(t, ctx, bundleName, "default")
+	if updated.Status.Phase != werfv1alpha1.PhaseSyncing {
+		t.Errorf("expected phase Syncing, got %s (error: %s)", updated.Status.Phase, updated.Status.LastErrorMessage)
+	}
+	if updated.Status.ActiveJobName == "" {
+		t.Error("expected a converge Job for the signed bundle")
+	}
+	if updated.Status.FailureReason != "" {
+		t.Errorf("expected no failure reason, got %q", updated.Status.FailureReason)
+	}
+}
+
+func TestReconcile_Verify_UnsignedBundleNeverCreatesJob(t *testing.T) {
+	ctx := context.Background()
+	bundleName := testBundleNameForStep("verify-unsigned")
+	key := newSigningKey(t)
+	reconciler, req, repoURL, digest := setupSignedBundleTest(t, ctx, bundleName, key, true)
+
+	// Signed, but by a key the bundle doesn't trust
+	if err := testingutil.SignBundle(ctx, repoURL, digest, newSigningKey(t), testRegistryAuth()); err != nil {
+		t.Fatalf("failed to sign bundle: %v", err)
+	}
+
+	result, err := reconciler.Reconcile(ctx, req)
+	if err != nil {
+		t.Fatalf("reconcile failed: %v", err)
+	}
+	if result.RequeueAfter == 0 {
+		t.Error("expected requeue so a later signature is picked up")
+	}
+
+	updated := getWerfBundle(t, ctx, bundleName, "default")
+	if updated.Status.Phase != werfv1alpha1.PhaseFailed {
+		t.Errorf("expected phase Failed, got %s", updated.Status.Phase)
+	}
+	if updated.Status.FailureReason != werfv1alpha1.FailureReasonVerificationFailed {
+		t.Errorf("expected failure reason %s, got %q",
+			werfv1alpha1.FailureReasonVerificationFailed, updated.Status.FailureReason)
+	}
+	if !strings.Contains(updated.Status.LastErrorMessage, "no signature matches") {
+		t.Errorf("expected signature error in message, got %q", updated.Status.LastErrorMessage)
+	}
+	if updated.Status.ActiveJobName != "" || updated.Status.LastAppliedTag != "" {
+		t.Errorf("expected no Job and no applied tag, got job %q tag %q",
+			updated.Status.ActiveJobName, updated.Status.LastAppliedTag)
+	}
+
+	// Signing with the trusted key lets the next poll deploy the bundle
+	if err := testingutil.SignBundle(ctx, repoURL, digest, key, testRegistryAuth()); err != nil {
+		t.Fatalf("failed to sign bundle: %v", err)
+	}
+	reconciler.requestPoll(req.NamespacedName)
+	if _, err := reconciler.Reconcile(ctx, req); err != nil {
+		t.Fatalf("reconcile after signing failed: %v", err)
+	}
+
+	updated = getWerfBundle(t, ctx, bundleName, "default")
+	if updated.Status.ActiveJobName == "" {
+		t.Error("expected a converge Job once the bundle is signed")
+	}
+	if updated.Status.FailureReason != "" {
+		t.Errorf("expected failure reason to be cleared, got %q", updated.Status.FailureReason)
+	}
+}
+
+func TestReconcile_Verify_MissingKeySecretMarksFailed(t *testing.T) {
+	ctx := context.Background()
+	bundleName := testBundleNameForStep("verify-nokeys")
+	key := newSigningKey(t)
+	reconciler, req, repoURL, digest := setupSignedBundleTest(t, ctx, bundleName, key, false)
+
+	if err := testingutil.SignBundle(ctx, repoURL, digest, key, testRegistryAuth()); err != nil {
+		t.Fatalf("failed to sign bundle: %v", err)
+	}
+
+	if _, err := reconciler.Reconcile(ctx, req); err != nil {
+		t.Fatalf("reconcile failed: %v", err)
+	}
+
+	updated := getWerfBundle(t, ctx, bundleName, "default")
+	if updated.Status.FailureReason != werfv1alpha1.FailureReasonVerificationFailed {
+		t.Errorf("expected failure reason %s, got %q",
+			werfv1alpha1.FailureReasonVerificationFailed, updated.Status.FailureReason)
+	}
+	if !strings.Contains(updated.Status.LastErrorMessage, "not found") {
+		t.Errorf("expected missing Secret in message, got %q", updated.Status.LastErrorMessage)
+	}
+	if updated.Status.ActiveJobName != "" {
+		t.Errorf("expected no Job, got %q", updated.Status.ActiveJobName)
+	}
+}
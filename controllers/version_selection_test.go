@@ -0,0 +1,327 @@
+package controllers
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	werfv1alpha1 "github.com/werf/k8s-werf-operator-go/api/v1alpha1"
+	"github.com/werf/k8s-werf-operator-go/internal/registry"
+)
+
+// reconcileWithTags creates a bundle with the given registry config, serves tags from a
+// fake registry, runs one reconcile and returns the updated bundle.
+func reconcileWithTags(
+	t *testing.T,
+	ctx context.Context,
+	bundleName string,
+	registryConfig werfv1alpha1.RegistryConfig,
+	tags []string,
+) *werfv1alpha1.WerfBundle {
+	t.Helper()
+
+	bundle := &werfv1alpha1.WerfBundle{
+		ObjectMeta: metav1.ObjectMeta{Name: bundleName, Namespace: "default"},
+		Spec: werfv1alpha1.WerfBundleSpec{
+			Registry: registryConfig,
+			Converge: werfv1alpha1.ConvergeConfig{
+				ServiceAccountName: "default",
+			},
+		},
+	}
+	if err := testk8sClient.Create(ctx, bundle); err != nil {
+		t.Fatalf("failed to create WerfBundle: %v", err)
+	}
+
+	fakeReg := NewFakeRegistry()
+	fakeReg.SetTags(registryConfig.URL, tags)
+	reconciler := &WerfBundleReconciler{
+		Client:         testk8sClient,
+		Scheme:         testk8sClient.Scheme(),
+		RegistryClient: fakeReg,
+		Clientset:      testK8sClientset,
+	}
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: bundleName, Namespace: "default"}}
+	if _, err := reconciler.Reconcile(ctx, req); err != nil {
+		t.Fatalf("reconcile failed: %v", err)
+	}
+
+	return getWerfBundle(t, ctx, bundleName, "default")
+}
+
+func TestReconcile_NoConstraint_SelectsHighestSemverTag(t *testing.T) {
+	ctx := context.Background()
+	bundleName := testBundleNameForStep("semver-default")
+
+	updated := reconcileWithTags(t, ctx, bundleName,
+		werfv1alpha1.RegistryConfig{URL: "ghcr.io/test/semver-default"},
+		[]string{"latest", "sha-abc123", "v1.9.0", "v1.10.0"})
+
+	if updated.Status.LastAppliedTag != "v1.10.0" {
+		t.Errorf("expected tag v1.10.0, got %q", updated.Status.LastAppliedTag)
+	}
+	if updated.Status.SelectedVersion != "1.10.0" {
+		t.Errorf("expected selected version 1.10.0, got %q", updated.Status.SelectedVersion)
+	}
+	if !strings.Contains(updated.Status.SelectionReason, "2 non-semver tags") {
+		t.Errorf("expected reason to mention ignored tags, got %q", updated.Status.SelectionReason)
+	}
+
+	job := getJobInNamespace(t, ctx, bundleName, "default")
+	args := job.Spec.Template.Spec.Containers[0].Args
+	if !containsArg(args, "ghcr.io/test/semver-default@"+FakeDigest("ghcr.io/test/semver-default", "v1.10.0")) {
+		t.Errorf("expected Job to deploy v1.10.0, got args %v", args)
+	}
+}
+
+func TestReconcile_VersionConstraint_SelectsMatchingTag(t *testing.T) {
+	ctx := context.Background()
+	bundleName := testBundleNameForStep("semver-constraint")
+
+	updated := reconcileWithTags(t, ctx, bundleName,
+		werfv1alpha1.RegistryConfig{
+			URL:               "ghcr.io/test/semver-constraint",
+			VersionConstraint: ">=1.2.0 <2.0.0",
+		},
+		[]string{"v1.1.0", "v1.2.0", "v1.4.3", "v2.0.0", "v2.1.0-rc.1"})
+
+	if updated.Status.LastAppliedTag != "v1.4.3" {
+		t.Errorf("expected tag v1.4.3, got %q", updated.Status.LastAppliedTag)
+	}
+	if updated.Status.SelectedVersion != "1.4.3" {
+		t.Errorf("expected selected version 1.4.3, got %q", updated.Status.SelectedVersion)
+	}
+	if !strings.Contains(updated.Status.SelectionReason, ">=1.2.0 <2.0.0") {
+		t.Errorf("expected reason to mention the constraint, got %q", updated.Status.SelectionReason)
+	}
+}
+
+func TestReconcile_VersionConstraint_NoMatchCreatesNoJob(t *testing.T) {
+	ctx := context.Background()
+	bundleName := testBundleNameForStep("semver-nomatch")
+
+	updated := reconcileWithTags(t, ctx, bundleName,
+		werfv1alpha1.RegistryConfig{
+			URL:               "ghcr.io/test/semver-nomatch",
+			VersionConstraint: "~3.0",
+		},
+		[]string{"v1.0.0", "v2.0.0"})
+
+	if updated.Status.ActiveJobName != "" {
+		t.Errorf("expected no Job, got %q", updated.Status.ActiveJobName)
+	}
+	if updated.Status.Phase != werfv1alpha1.PhaseSyncing {
+		t.Errorf("expected phase Syncing, got %s", updated.Status.Phase)
+	}
+	if !strings.Contains(updated.Status.SelectionReason, "no versions match constraint") {
+		t.Errorf("expected no-match reason, got %q", updated.Status.SelectionReason)
+	}
+}
+
+func TestReconcile_AllowPrerelease_SelectsPrerelease(t *testing.T) {
+	ctx := context.Background()
+	bundleName := testBundleNameForStep("semver-prerelease")
+
+	updated := reconcileWithTags(t, ctx, bundleName,
+		werfv1alpha1.RegistryConfig{
+			URL:             "ghcr.io/test/semver-prerelease",
+			AllowPrerelease: true,
+		},
+		[]string{"v1.0.0", "v1.1.0-rc.1"})
+
+	if updated.Status.LastAppliedTag != "v1.1.0-rc.1" {
+		t.Errorf("expected tag v1.1.0-rc.1, got %q", updated.Status.LastAppliedTag)
+	}
+}
+
+func TestReconcile_InvalidVersionConstraint_MarksFailed(t *testing.T) {
+	ctx := context.Background()
+	bundleName := testBundleNameForStep("semver-invalid")
+
+	updated := reconcileWithTags(t, ctx, bundleName,
+		werfv1alpha1.RegistryConfig{
+			URL:               "ghcr.io/test/semver-invalid",
+			VersionConstraint: ">=banana",
+		},
+		[]string{"v1.0.0"})
+
+	if updated.Status.Phase != werfv1alpha1.PhaseFailed {
+		t.Errorf("expected phase Failed, got %s", updated.Status.Phase)
+	}
+	if !strings.Contains(updated.Status.LastErrorMessage, "invalid version constraint") {
+		t.Errorf("expected invalid constraint error, got %q", updated.Status.LastErrorMessage)
+	}
+	if updated.Status.ActiveJobName != "" {
+		t.Errorf("expected no Job, got %q", updated.Status.ActiveJobName)
+	}
+}
+
+func TestReconcile_TagFilter_SelectsByExtractedTimestamp(t *testing.T) {
+	ctx := context.Background()
+	bundleName := testBundleNameForStep("tagfilter-calver")
+
+	updated := reconcileWithTags(t, ctx, bundleName,
+		werfv1alpha1.RegistryConfig{
+			URL: "ghcr.io/test/tagfilter-calver",
+			TagFilter: &werfv1alpha1.TagFilter{
+				Include:    `^main-(?P<ts>[0-9]{8}\.[0-9]+)-[a-f0-9]+$`,
+				Extract:    "ts",
+				SortPolicy: werfv1alpha1.SortPolicyCalver,
+			},
+		},
+		[]string{"main-20250101.1234-abcdef", "main-20250102.0001-123456", "v9.9.9", "latest"})
+
+	if updated.Status.LastAppliedTag != "main-20250102.0001-123456" {
+		t.Errorf("expected tag main-20250102.0001-123456, got %q", updated.Status.LastAppliedTag)
+	}
+	if updated.Status.SelectedVersion != "20250102.0001" {
+		t.Errorf("expected selected version 20250102.0001, got %q", updated.Status.SelectedVersion)
+	}
+	if !strings.Contains(updated.Status.SelectionReason, "2 of 4 tags match tagFilter") {
+		t.Errorf("expected reason to mention the filter, got %q", updated.Status.SelectionReason)
+	}
+}
+
+func TestReconcile_InvalidTagFilter_MarksFailed(t *testing.T) {
+	ctx := context.Background()
+	bundleName := testBundleNameForStep("tagfilter-invalid")
+
+	updated := reconcileWithTags(t, ctx, bundleName,
+		werfv1alpha1.RegistryConfig{
+			URL: "ghcr.io/test/tagfilter-invalid",
+			TagFilter: &werfv1alpha1.TagFilter{
+				Include: `^build-(?P<num>[0-9]+)$`,
+				Extract: "missing",
+			},
+		},
+		[]string{"build-1"})
+
+	if updated.Status.Phase != werfv1alpha1.PhaseFailed {
+		t.Errorf("expected phase Failed, got %s", updated.Status.Phase)
+	}
+	if !strings.Contains(updated.Status.LastErrorMessage, `no capture group named "missing"`) {
+		t.Errorf("expected extract group error, got %q", updated.Status.LastErrorMessage)
+	}
+}
+
+// containsArg reports whether args contains arg.
+func containsArg(args []string, arg string) bool {
+	for _, a := range args {
+		if a == arg {
+			return true
+		}
+	}
+	return false
+}
+
+func TestReconcile_IncrementalTagList_ListsTagsAfterApplied(t *testing.T) {
+	ctx := context.Background()
+	bundleName := testBundleNameForStep("incremental-tags")
+
+	// Zero-padded build numbers: lexical order is release order
+	repoURL := startAuthRegistry(t, "test/incremental", "build-0009", "build-0095", "build-0100")
+	createRegistrySecret(t, ctx, bundleName+"-creds", "default", repoURL, testRegistryPassword)
+	bundle := &werfv1alpha1.WerfBundle{
+		ObjectMeta: metav1.ObjectMeta{Name: bundleName, Namespace: "default"},
+		Spec: werfv1alpha1.WerfBundleSpec{
+			Registry: werfv1alpha1.RegistryConfig{
+				URL:                repoURL,
+				SecretRef:          &corev1.LocalObjectReference{Name: bundleName + "-creds"},
+				IncrementalTagList: true,
+				TagFilter:          &werfv1alpha1.TagFilter{SortPolicy: werfv1alpha1.SortPolicyLexical},
+			},
+			Converge: werfv1alpha1.ConvergeConfig{ServiceAccountName: "default"},
+		},
+	}
+	if err := testk8sClient.Create(ctx, bundle); err != nil {
+		t.Fatalf("failed to create WerfBundle: %v", err)
+	}
+
+	// build-0009 was deployed by an earlier poll of the current spec
+	bundle = getWerfBundle(t, ctx, bundleName, "default")
+	bundle.Status.Phase = werfv1alpha1.PhaseSynced
+	bundle.Status.LastAppliedTag = "build-0009"
+	bundle.Status.ObservedGeneration = bundle.Generation
+	if err := testk8sClient.Status().Update(ctx, bundle); err != nil {
+		t.Fatalf("failed to set status: %v", err)
+	}
+
+	reconciler := &WerfBundleReconciler{
+		Client:         testk8sClient,
+		Scheme:         testk8sClient.Scheme(),
+		RegistryClient: registry.NewOCIClient(),
+		Clientset:      testK8sClientset,
+	}
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: bundleName, Namespace: "default"}}
+	if _, err := reconciler.Reconcile(ctx, req); err != nil {
+		t.Fatalf("reconcile failed: %v", err)
+	}
+
+	updated := getWerfBundle(t, ctx, bundleName, "default")
+	if updated.Status.LastAppliedTag != "build-0100" {
+		t.Errorf("expected build-0100 from the tags after build-0009, got %q (error: %s)",
+			updated.Status.LastAppliedTag, updated.Status.LastErrorMessage)
+	}
+}
+
+func TestReconcile_MetadataSelection_SelectsNewestMatchingImage(t *testing.T) {
+	ctx := context.Background()
+	bundleName := testBundleNameForStep("metadata-selection")
+	repoURL := "ghcr.io/test/metadata-selection"
+
+	bundle := &werfv1alpha1.WerfBundle{
+		ObjectMeta: metav1.ObjectMeta{Name: bundleName, Namespace: "default"},
+		Spec: werfv1alpha1.WerfBundleSpec{
+			Registry: werfv1alpha1.RegistryConfig{
+				URL: repoURL,
+				MetadataSelection: &werfv1alpha1.MetadataSelection{
+					MatchAnnotations: map[string]string{"env": "prod"},
+				},
+			},
+			Converge: werfv1alpha1.ConvergeConfig{ServiceAccountName: "default"},
+		},
+	}
+	if err := testk8sClient.Create(ctx, bundle); err != nil {
+		t.Fatalf("failed to create WerfBundle: %v", err)
+	}
+
+	// Tag names say nothing about which bundle is newest
+	fakeReg := NewFakeRegistry()
+	fakeReg.SetTags(repoURL, []string{"abc123", "def456", "fed789"})
+	fakeReg.SetAnnotations(repoURL, "abc123", map[string]string{
+		registry.AnnotationCreated: "2025-03-02T09:00:00Z", "env": "prod",
+	})
+	fakeReg.SetAnnotations(repoURL, "def456", map[string]string{
+		registry.AnnotationCreated: "2025-03-01T09:00:00Z", "env": "prod",
+	})
+	fakeReg.SetAnnotations(repoURL, "fed789", map[string]string{
+		registry.AnnotationCreated: "2025-03-03T09:00:00Z", "env": "staging",
+	})
+	reconciler := &WerfBundleReconciler{
+		Client:         testk8sClient,
+		Scheme:         testk8sClient.Scheme(),
+		RegistryClient: fakeReg,
+		Clientset:      testK8sClientset,
+	}
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: bundleName, Namespace: "default"}}
+	if _, err := reconciler.Reconcile(ctx, req); err != nil {
+		t.Fatalf("reconcile failed: %v", err)
+	}
+
+	updated := getWerfBundle(t, ctx, bundleName, "default")
+	if updated.Status.LastAppliedTag != "abc123" {
+		t.Errorf("expected the newest prod image abc123, got %q (reason: %s)",
+			updated.Status.LastAppliedTag, updated.Status.SelectionReason)
+	}
+	if updated.Status.SelectedVersion != "2025-03-02T09:00:00Z" {
+		t.Errorf("expected the creation time as selected version, got %q", updated.Status.SelectedVersion)
+	}
+	if !strings.Contains(updated.Status.SelectionReason, "1 not matching matchAnnotations") {
+		t.Errorf("expected reason to mention the staging image, got %q", updated.Status.SelectionReason)
+	}
+}
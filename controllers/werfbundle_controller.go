@@ -3,33 +3,61 @@ package controllers
 
 import (
 	"context"
+	"crypto"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"slices"
+	"strings"
+	"sync"
 	"time"
 
+	"github.com/google/go-containerregistry/pkg/authn"
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/workqueue"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	werfv1alpha1 "github.com/werf/k8s-werf-operator-go/api/v1alpha1"
 	"github.com/werf/k8s-werf-operator-go/internal/converge"
 	"github.com/werf/k8s-werf-operator-go/internal/rbac"
+	"github.com/werf/k8s-werf-operator-go/internal/receiver"
 	"github.com/werf/k8s-werf-operator-go/internal/registry"
+	"github.com/werf/k8s-werf-operator-go/internal/schedule"
 	"github.com/werf/k8s-werf-operator-go/internal/values"
+	"github.com/werf/k8s-werf-operator-go/internal/version"
 )
 
 const (
 	finalizerName          = "werf.io/finalizer"
 	defaultPollInterval    = 15 * time.Minute
+	defaultMinPollInterval = time.Minute
 	maxConsecutiveFailures = 5
+	failedRetryInterval    = time.Hour
+	// endpointFailureThreshold is the number of failed polls in a row that opens the circuit
+	// of a registry endpoint
+	endpointFailureThreshold = 3
+	// endpointOpenInterval is how long an open registry endpoint is skipped
+	endpointOpenInterval = 30 * time.Minute
+	// maxSeenTags bounds status.seenTags, and the tags skipped for minTagAge in one poll
+	maxSeenTags = 100
+	// maxFailedVersions bounds status.failedVersions
+	maxFailedVersions = 20
 )
 
 // WerfBundleReconciler reconciles WerfBundle resources.
@@ -62,6 +90,25 @@ type WerfBundleReconciler struct {
 	Scheme         *runtime.Scheme
 	RegistryClient registry.Client
 	Clientset      kubernetes.Interface
+	// Triggers, when set, receives bundles to poll immediately (e.g., on registry push
+	// notifications), regardless of their poll schedule.
+	Triggers <-chan receiver.BundleEvent
+	// MinPollInterval is the operator-wide lower bound for spec.registry.pollInterval.
+	// Defaults to 1 minute.
+	MinPollInterval time.Duration
+	// Proxy is the operator-wide proxy for registry connections and converge Jobs, replaced by
+	// spec.registry.proxy. Nil uses the proxy environment variables of the operator for polling
+	// and no proxy for Jobs.
+	Proxy *registry.ProxyConfig
+	// CredentialProviders, when set, provides credentials from exec credential provider plugins
+	// for registries that have none in spec.registry.secretRef (e.g., short-lived cloud tokens).
+	CredentialProviders *registry.CredentialProviders
+
+	// pollRequests holds the bundles (types.NamespacedName) to poll on their next reconcile
+	// even if the poll isn't due yet.
+	pollRequests sync.Map
+	// repositoryEvents enqueues the bundles found by PollRepository.
+	repositoryEvents chan event.TypedGenericEvent[*werfv1alpha1.WerfBundle]
 }
 
 // Operator RBAC permissions - cluster-wide scope for cross-namespace deployments
@@ -74,6 +121,10 @@ type WerfBundleReconciler struct {
 //   - Registry credentials in target namespaces
 //   - Values resolution from Secrets in target namespaces
 //
+// Secrets: create and delete for:
+//   - Copying registry credentials into the target namespace for the converge Job
+//     (removed when the Job finishes or the bundle is deleted)
+//
 // ServiceAccounts: Cluster-wide read access (get, list, watch) for:
 //   - Pre-flight validation that target SA exists before Job creation
 //
@@ -89,7 +140,8 @@ type WerfBundleReconciler struct {
 // +kubebuilder:rbac:groups=werf.io,resources=werfbundles,verbs=get;list;watch;update;patch
 // +kubebuilder:rbac:groups=werf.io,resources=werfbundles/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=batch,resources=jobs,verbs=create;get;list;watch;delete
-// +kubebuilder:rbac:groups="",resources=secrets,verbs=get
+// Secrets are read uncached (get); list and watch are for the metadata-only watch.
+// +kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch;create;delete
 // +kubebuilder:rbac:groups="",resources=serviceaccounts,verbs=get;list;watch
 // +kubebuilder:rbac:groups="",resources=configmaps,verbs=create;update;get;list
 // +kubebuilder:rbac:groups="",resources=pods,verbs=get;list
@@ -113,7 +165,14 @@ func (r *WerfBundleReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 	// Check if bundle is being deleted
 	if bundle.DeletionTimestamp != nil {
 		if controllerutil.ContainsFinalizer(bundle, finalizerName) {
-			// Cleanup logic here if needed (none for Slice 1)
+			// Remove registry credentials copied for a Job that is still running
+			if bundle.Status.ActiveJobName != "" {
+				targetNamespace := values.GetTargetNamespace(&bundle.Spec.Converge, bundle.Namespace)
+				if err := r.deleteRegistrySecret(ctx, bundle.Status.ActiveJobName, targetNamespace); err != nil {
+					log.Error(err, "failed to delete registry credentials for active job")
+					return ctrl.Result{}, err
+				}
+			}
 			controllerutil.RemoveFinalizer(bundle, finalizerName)
 			if err := r.Update(ctx, bundle); err != nil {
 				log.Error(err, "failed to remove finalizer")
@@ -163,22 +222,132 @@ func (r *WerfBundleReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 		}
 	}
 
-	// Parse poll interval from spec, default to 15 minutes
-	pollInterval := defaultPollInterval
-	if bundle.Spec.Registry.PollInterval != "" {
-		parsed, err := time.ParseDuration(bundle.Spec.Registry.PollInterval)
-		if err != nil {
-			log.Error(err, "invalid pollInterval in spec, using default", "pollInterval", bundle.Spec.Registry.PollInterval)
-		} else {
-			pollInterval = parsed
+	// Build the tag policy before polling so configuration errors don't cost registry calls
+	tagPolicy, err := registry.NewTagPolicy(&bundle.Spec.Registry)
+	if err != nil {
+		// Invalid filter or constraint is a configuration error; retrying won't help until the spec changes
+		log.Error(err, "invalid tag selection settings")
+		if err := r.updateStatusFailed(ctx, bundle, err.Error()); err != nil {
+			log.Error(err, "failed to update status after invalid tag selection settings")
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{}, nil
+	}
+
+	// Validate the deployment schedule before polling, like the tag policy
+	if _, err := schedule.New(bundle.Spec.Schedule); err != nil {
+		log.Error(err, "invalid deployment schedule")
+		if err := r.updateStatusFailedWithReason(ctx, bundle, werfv1alpha1.FailureReasonInvalidSchedule,
+			"Invalid schedule: "+err.Error()); err != nil {
+			log.Error(err, "failed to update status after invalid deployment schedule")
+			return ctrl.Result{}, err
 		}
+		return ctrl.Result{}, nil
 	}
 
-	// Poll registry for latest tags with ETag caching
-	// Note: Authentication not yet implemented (Slice 2) - always uses nil for auth
-	tags, etag, err := r.RegistryClient.ListTagsWithETag(ctx, bundle.Spec.Registry.URL, nil, bundle.Status.LastETag)
+	// So is minTagAge: ignoring an invalid one would deploy tags before they are old enough
+	minTagAge, err := minTagAgeFor(bundle)
 	if err != nil {
-		return r.handleRegistryError(ctx, bundle, err, pollInterval)
+		log.Error(err, "invalid minTagAge")
+		if err := r.updateStatusFailedWithReason(ctx, bundle, werfv1alpha1.FailureReasonInvalidMinTagAge,
+			"Invalid minTagAge: "+err.Error()); err != nil {
+			log.Error(err, "failed to update status after invalid minTagAge")
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{}, nil
+	}
+
+	// Approving the pending version deploys it right away
+	if bundle.Status.PendingTag != "" && approves(bundle.Annotations[werfv1alpha1.ApprovalAnnotation],
+		bundle.Status.PendingTag, bundle.Status.PendingDigest) {
+		r.requestPoll(client.ObjectKeyFromObject(bundle))
+	}
+
+	// Between polls, only follow the running Job (if any)
+	if !r.pollDue(bundle) {
+		return r.reconcileBetweenPolls(ctx, bundle)
+	}
+	specChanged := bundle.Status.ObservedGeneration != bundle.Generation
+	pollInterval := r.pollIntervalFor(ctx, bundle)
+	if err := r.schedulePoll(ctx, bundle, pollInterval); err != nil {
+		log.Error(err, "failed to update poll schedule in status")
+		return ctrl.Result{}, err
+	}
+
+	// Load registry credentials from spec.registry.secretRef (nil means anonymous access)
+	auth, err := r.resolveRegistryAuth(ctx, bundle)
+	if err != nil {
+		return r.handleAuthSecretError(ctx, bundle, err)
+	}
+
+	// Load the certificates of spec.registry.tls; registry calls below connect with them
+	tlsConfig, err := r.resolveRegistryTLS(ctx, bundle)
+	if err != nil {
+		var tlsErr *registry.TLSError
+		if !errors.As(err, &tlsErr) {
+			log.Error(err, "failed to load registry TLS settings")
+			return ctrl.Result{}, err
+		}
+		return r.handleRegistryError(ctx, bundle, err)
+	}
+	ctx = registry.WithTLS(ctx, tlsConfig)
+	if proxy := r.proxyFor(bundle); proxy != nil {
+		ctx = registry.WithProxy(ctx, proxy)
+	}
+
+	// Poll registry for latest tags with ETag caching. A tag list shared with other bundles
+	// is good enough if it was fetched within our poll interval
+	listCtx := registry.WithMaxAge(ctx, pollInterval)
+	// A pinned version isn't where selection resumes, so pinned bundles list all tags
+	incremental := bundle.Spec.Registry.IncrementalTagList && !specChanged && bundle.Status.LastAppliedTag != "" &&
+		bundle.Spec.Version == ""
+	if incremental {
+		listCtx = registry.WithTagsAfter(listCtx, bundle.Status.LastAppliedTag)
+	}
+	// With selection by image metadata, re-pushing any tag can change the selection even if
+	// the tag list is unchanged, so the list is always fetched. So it is with minTagAge, as
+	// re-pushing a tag restarts its age
+	lastETag := bundle.Status.LastETag
+	if tagPolicy.UsesMetadata() || minTagAge > 0 {
+		lastETag = ""
+	}
+	tags, etag, auth, err := r.listTags(listCtx, bundle, auth, lastETag)
+	var notModified *registry.NotModifiedError
+	if errors.As(err, &notModified) && bundle.Spec.Version != "" {
+		// The tag list is unchanged, so the newest tag is too, but it may have been re-pushed
+		log.Info("registry content unchanged (cached ETag valid), checking pinned version")
+		if ok, result, err := r.checkPinnedVersion(ctx, bundle, auth, bundle.Status.LatestAvailableTag); !ok {
+			return result, err
+		}
+		return r.reconcilePinned(ctx, bundle, auth)
+	}
+	if errors.As(err, &notModified) && bundle.Status.LastAppliedTag != "" {
+		// The tag list is unchanged, so the selection is too, but a mutable tag
+		// (e.g., main) may have been re-pushed: check what it points to now
+		log.Info("registry content unchanged (cached ETag valid), checking digest of applied tag")
+		return r.reconcileTag(ctx, bundle, auth, bundle.Status.LastAppliedTag)
+	}
+	if err != nil {
+		return r.handleRegistryError(ctx, bundle, err)
+	}
+
+	// An incremental list only has the tags after the applied one; select from those and the
+	// applied tag, so a tag sorting after it but ordered before it isn't deployed as an upgrade
+	if incremental && !slices.Contains(tags, bundle.Status.LastAppliedTag) {
+		tags = append(tags, bundle.Status.LastAppliedTag)
+	}
+
+	// Selecting by image metadata reads the candidates' manifests from the registry;
+	// failing to read them fails the poll
+	var selection *version.Selection
+	lookupMetadata := func(ctx context.Context, tag string) (*registry.ImageMetadata, error) {
+		return r.RegistryClient.ImageMetadata(ctx, registryURL(bundle), tag, auth)
+	}
+	if tagPolicy.UsesMetadata() {
+		selection, err = tagPolicy.SelectByMetadata(ctx, tags, lookupMetadata)
+		if err != nil {
+			return r.handleRegistryError(ctx, bundle, err)
+		}
 	}
 
 	// Reset consecutive failures on successful registry access
@@ -190,36 +359,786 @@ func (r *WerfBundleReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 	// Update LastETag for caching
 	bundle.Status.LastETag = etag
 
-	// If no tag found, update status and wait
-	if len(tags) == 0 {
-		log.Info("no tags found in registry")
+	// Select the tag to deploy according to the tag filter and version constraint
+	if selection == nil {
+		selection, err = tagPolicy.Select(tags)
+		if err != nil {
+			log.Error(err, "failed to select tag")
+			if err := r.updateStatusFailed(ctx, bundle, err.Error()); err != nil {
+				log.Error(err, "failed to update status after tag selection failure")
+				return ctrl.Result{}, err
+			}
+			return ctrl.Result{}, nil
+		}
+	}
+
+	// Tags younger than minTagAge aren't eligible yet: select again without them
+	previous := bundle.Status.DeepCopy()
+	if minTagAge > 0 {
+		selection, err = r.selectEligible(ctx, bundle, tags, selection, minTagAge, lookupMetadata,
+			func(tags []string) (*version.Selection, error) {
+				if tagPolicy.UsesMetadata() {
+					return tagPolicy.SelectByMetadata(ctx, tags, lookupMetadata)
+				}
+				return tagPolicy.Select(tags)
+			})
+		if err != nil {
+			return r.handleRegistryError(ctx, bundle, err)
+		}
+	} else {
+		bundle.Status.SeenTags = nil
+	}
+
+	selectionChanged := bundle.Status.SelectedVersion != selection.Version ||
+		bundle.Status.SelectionReason != selection.Reason || bundle.Status.LatestAvailableTag != selection.Tag ||
+		!equality.Semantic.DeepEqual(previous.SeenTags, bundle.Status.SeenTags) ||
+		!equality.Semantic.DeepEqual(previous.NextPollTime, bundle.Status.NextPollTime)
+	if bundle.Spec.Version == "" &&
+		meta.RemoveStatusCondition(&bundle.Status.Conditions, werfv1alpha1.ConditionUpdateAvailable) {
+		selectionChanged = true
+	}
+	if !approvalRequired(bundle) &&
+		(meta.RemoveStatusCondition(&bundle.Status.Conditions, werfv1alpha1.ConditionAwaitingApproval) ||
+			bundle.Status.PendingTag != "") {
+		bundle.Status.PendingTag = ""
+		bundle.Status.PendingDigest = ""
+		selectionChanged = true
+	}
+	if bundle.Spec.Schedule == nil &&
+		(meta.RemoveStatusCondition(&bundle.Status.Conditions, werfv1alpha1.ConditionDeploymentHeld) ||
+			bundle.Status.HeldTag != "") {
+		bundle.Status.HeldTag = ""
+		bundle.Status.HeldDigest = ""
+		bundle.Status.NextWindowTime = nil
+		selectionChanged = true
+	}
+	if selectionChanged {
+		bundle.Status.SelectedVersion = selection.Version
+		bundle.Status.SelectionReason = selection.Reason
+		bundle.Status.LatestAvailableTag = selection.Tag
+		if err := r.Status().Update(ctx, bundle); err != nil {
+			log.Error(err, "failed to update selection in status")
+			return ctrl.Result{}, err
+		}
+	}
+
+	// A pinned version is deployed whatever the selection, which is only reported
+	if bundle.Spec.Version != "" {
+		if ok, result, err := r.checkPinnedVersion(ctx, bundle, auth, selection.Tag); !ok {
+			return result, err
+		}
+		if !isDigest(bundle.Spec.Version) && !slices.Contains(tags, bundle.Spec.Version) {
+			return r.failPinnedVersionNotFound(ctx, bundle)
+		}
+		return r.reconcilePinned(ctx, bundle, auth)
+	}
+
+	// If no tag qualifies, update status and wait
+	if selection.Tag == "" {
+		log.Info("no tag selected", "reason", selection.Reason)
 		if bundle.Status.Phase == "" || bundle.Status.Phase == werfv1alpha1.PhaseFailed {
 			if err := r.updateStatusSyncing(ctx, bundle, ""); err != nil {
 				log.Error(err, "failed to update status to Syncing")
 				return ctrl.Result{}, err
 			}
 		}
-		// Requeue after poll interval + jitter
-		requeueInterval := registry.AddJitter(pollInterval)
-		return ctrl.Result{RequeueAfter: requeueInterval}, nil
+		return requeueAtNextPoll(bundle), nil
+	}
+
+	log.Info("selected tag", "tag", selection.Tag, "reason", selection.Reason)
+	return r.reconcileTag(ctx, bundle, auth, selection.Tag)
+}
+
+// listTags lists the tags of the bundle's repository. With spec.registry.mirrors, the
+// endpoints (spec.registry.url, then the mirrors) are tried in order until one succeeds:
+// a transient error (see registry.IsTransient) falls back to the next endpoint, other errors
+// end the poll. Endpoints whose circuit is open are skipped until their retry time, unless
+// all of them are open.
+//
+// The serving endpoint is recorded as status.registryEndpoint, so the rest of the poll and
+// the converge Job use it (see registryURL), and the circuit state of each endpoint in
+// status.endpoints. lastETag is only sent to the endpoint that served the last tags.
+// Returns the credentials for the serving endpoint along with its tags.
+func (r *WerfBundleReconciler) listTags(
+	ctx context.Context,
+	bundle *werfv1alpha1.WerfBundle,
+	auth authn.Authenticator,
+	lastETag string,
+) ([]string, string, authn.Authenticator, error) {
+	log := ctrl.LoggerFrom(ctx)
+
+	previous := bundle.Status.DeepCopy()
+	defer func() {
+		// Circuit states must survive polls that otherwise leave the status untouched
+		if previous.RegistryEndpoint == bundle.Status.RegistryEndpoint &&
+			equality.Semantic.DeepEqual(previous.Endpoints, bundle.Status.Endpoints) {
+			return
+		}
+		if err := r.Status().Update(ctx, bundle); err != nil {
+			log.Error(err, "failed to update registry endpoints in status")
+		}
+	}()
+
+	if len(bundle.Spec.Registry.Mirrors) == 0 {
+		bundle.Status.RegistryEndpoint = ""
+		bundle.Status.Endpoints = nil
+		tags, etag, err := r.RegistryClient.ListTagsWithETag(ctx, bundle.Spec.Registry.URL, auth, lastETag)
+		return tags, etag, auth, err
+	}
+
+	current := registryURL(bundle)
+	bundle.Status.Endpoints = endpointStates(bundle)
+	now := time.Now()
+	var candidates []int
+	for i, state := range bundle.Status.Endpoints {
+		if state.State != werfv1alpha1.CircuitOpen || state.RetryTime == nil || !now.Before(state.RetryTime.Time) {
+			candidates = append(candidates, i)
+		}
+	}
+	if len(candidates) == 0 {
+		for i := range bundle.Status.Endpoints {
+			candidates = append(candidates, i)
+		}
+	}
+
+	var failures []string
+	var lastErr error
+	for _, i := range candidates {
+		state := &bundle.Status.Endpoints[i]
+		endpointAuth, err := r.endpointAuth(ctx, bundle, auth, state.URL)
+		var tags []string
+		var etag string
+		if err == nil {
+			endpointETag := ""
+			if state.URL == current {
+				endpointETag = lastETag
+			}
+			tags, etag, err = r.RegistryClient.ListTagsWithETag(ctx, state.URL, endpointAuth, endpointETag)
+		}
+
+		var notModified *registry.NotModifiedError
+		if err == nil || errors.As(err, &notModified) {
+			if state.URL != current {
+				log.Info("registry endpoint changed", "endpoint", state.URL, "previous", current)
+			}
+			*state = werfv1alpha1.EndpointStatus{URL: state.URL, State: werfv1alpha1.CircuitClosed}
+			bundle.Status.RegistryEndpoint = state.URL
+			return tags, etag, endpointAuth, err
+		}
+
+		err = fmt.Errorf("%s: %w", state.URL, err)
+		recordEndpointFailure(state, err, now)
+		if !registry.IsTransient(err) {
+			return nil, "", nil, err
+		}
+		log.Info("registry endpoint failed", "endpoint", state.URL, "failures", state.ConsecutiveFailures,
+			"circuit", state.State, "error", err.Error())
+		if lastErr != nil {
+			failures = append(failures, lastErr.Error())
+		}
+		lastErr = err
+	}
+
+	if len(failures) > 0 {
+		return nil, "", nil, fmt.Errorf("registry endpoints failed: %s; %w", strings.Join(failures, "; "), lastErr)
+	}
+	return nil, "", nil, lastErr
+}
+
+// endpointStates returns the circuit states of spec.registry.url and the mirrors, in order,
+// keeping the recorded state of endpoints still configured.
+func endpointStates(bundle *werfv1alpha1.WerfBundle) []werfv1alpha1.EndpointStatus {
+	urls := append([]string{bundle.Spec.Registry.URL}, bundle.Spec.Registry.Mirrors...)
+	states := make([]werfv1alpha1.EndpointStatus, 0, len(urls))
+	for _, url := range urls {
+		state := werfv1alpha1.EndpointStatus{URL: url, State: werfv1alpha1.CircuitClosed}
+		for _, recorded := range bundle.Status.Endpoints {
+			if recorded.URL == url {
+				state = recorded
+				break
+			}
+		}
+		states = append(states, state)
+	}
+	return states
+}
+
+// recordEndpointFailure counts a failed poll of a registry endpoint, and opens its circuit
+// after endpointFailureThreshold failures in a row, or right away when the failed poll was
+// the retry of an open endpoint.
+func recordEndpointFailure(state *werfv1alpha1.EndpointStatus, err error, now time.Time) {
+	state.ConsecutiveFailures++
+	state.LastError = err.Error()
+	if state.State == werfv1alpha1.CircuitOpen || state.ConsecutiveFailures >= endpointFailureThreshold {
+		retryTime := metav1.NewTime(now.Add(endpointOpenInterval))
+		state.State = werfv1alpha1.CircuitOpen
+		state.RetryTime = &retryTime
+	}
+}
+
+// endpointAuth returns the credentials for a registry endpoint: auth, resolved from
+// spec.registry.secretRef for spec.registry.url, or the Secret's credentials for a mirror's
+// host. Mirrors the Secret has no credentials for use the credential provider plugins, if any
+// match, or are accessed anonymously.
+func (r *WerfBundleReconciler) endpointAuth(
+	ctx context.Context,
+	bundle *werfv1alpha1.WerfBundle,
+	auth authn.Authenticator,
+	endpoint string,
+) (authn.Authenticator, error) {
+	if endpoint == bundle.Spec.Registry.URL {
+		return auth, nil
+	}
+	secret, err := r.getRegistrySecret(ctx, bundle)
+	if err != nil {
+		return nil, err
+	}
+	if secret == nil || !registry.HasCredentials(secret, endpoint) {
+		return r.providerAuth(ctx, endpoint)
+	}
+	return registry.AuthFromSecret(secret, endpoint)
+}
+
+// registryURL returns the repository the bundle is polled and deployed from: the endpoint
+// that served the tags on the last poll (status.registryEndpoint) while it's a configured
+// mirror, spec.registry.url otherwise.
+func registryURL(bundle *werfv1alpha1.WerfBundle) string {
+	if endpoint := bundle.Status.RegistryEndpoint; slices.Contains(bundle.Spec.Registry.Mirrors, endpoint) {
+		return endpoint
+	}
+	return bundle.Spec.Registry.URL
+}
+
+// minTagAgeFor returns the bundle's spec.registry.minTagAge, or 0 if not set.
+// Returns an error if it isn't a valid duration, e.g. one the CRD pattern lets through but
+// that overflows.
+func minTagAgeFor(bundle *werfv1alpha1.WerfBundle) (time.Duration, error) {
+	if bundle.Spec.Registry.MinTagAge == "" {
+		return 0, nil
+	}
+	minTagAge, err := time.ParseDuration(bundle.Spec.Registry.MinTagAge)
+	if err != nil {
+		return 0, err
+	}
+	return minTagAge, nil
+}
+
+// selectEligible returns selection if its tag is at least minTagAge old, otherwise selects
+// again with selectFrom from tags without it, until the selected tag is old enough or no tag
+// is selected. A tag's age is counted from its image creation time, or from when it was first
+// seen pointing to its digest, recorded in status.seenTags. The applied tag is eligible as
+// long as its digest is the applied one; once re-pushed, no tag is selected until the new
+// digest is old enough.
+//
+// If tags were skipped, the selection reason says so, the cached ETag is dropped so the next
+// poll selects them again, and the next poll is moved to when the first of them is old
+// enough. The status is persisted by the caller.
+func (r *WerfBundleReconciler) selectEligible(
+	ctx context.Context,
+	bundle *werfv1alpha1.WerfBundle,
+	tags []string,
+	selection *version.Selection,
+	minTagAge time.Duration,
+	lookupMetadata registry.MetadataFunc,
+	selectFrom func(tags []string) (*version.Selection, error),
+) (*version.Selection, error) {
+	log := ctrl.LoggerFrom(ctx)
+
+	now := time.Now()
+	seen := make([]werfv1alpha1.SeenTag, 0, len(bundle.Status.SeenTags))
+	for _, entry := range bundle.Status.SeenTags {
+		if slices.Contains(tags, entry.Tag) {
+			seen = append(seen, entry)
+		}
+	}
+
+	var skipped []string
+	var eligibleAt time.Time
+	for selection.Tag != "" {
+		if len(skipped) == maxSeenTags {
+			selection = &version.Selection{
+				Reason: fmt.Sprintf("the %d newest tags are younger than minTagAge %s", len(skipped), minTagAge),
+			}
+			break
+		}
+
+		tag := selection.Tag
+		metadata, err := lookupMetadata(ctx, tag)
+		if err != nil {
+			return nil, err
+		}
+		if tag == bundle.Status.LastAppliedTag && metadata.Digest == bundle.Status.LastAppliedDigest {
+			break
+		}
+		since, ok := createdAt(metadata, now)
+		if !ok {
+			since = firstSeen(&seen, tag, metadata.Digest, now)
+		}
+		ready := since.Add(minTagAge)
+		if !ready.After(now) {
+			break
+		}
+
+		log.Info("tag is younger than minTagAge, skipping it", "tag", tag, "digest", metadata.Digest,
+			"eligibleAt", ready)
+		skipped = append(skipped, tag)
+		if eligibleAt.IsZero() || ready.Before(eligibleAt) {
+			eligibleAt = ready
+		}
+		if tag == bundle.Status.LastAppliedTag {
+			// The applied tag was re-pushed: keep what it deployed rather than fall back to
+			// an older tag
+			selection = &version.Selection{Reason: fmt.Sprintf("applied tag %s was re-pushed", tag)}
+			break
+		}
+		if selection, err = selectFrom(slices.DeleteFunc(slices.Clone(tags), func(t string) bool {
+			return slices.Contains(skipped, t)
+		})); err != nil {
+			return nil, err
+		}
+	}
+
+	// Keep the most recently seen tags
+	slices.SortFunc(seen, func(a, b werfv1alpha1.SeenTag) int {
+		return b.FirstSeen.Compare(a.FirstSeen.Time)
+	})
+	if len(seen) > maxSeenTags {
+		seen = seen[:maxSeenTags]
+	}
+	if len(seen) == 0 {
+		seen = nil
+	}
+	bundle.Status.SeenTags = seen
+
+	if len(skipped) > 0 {
+		selection.Reason += fmt.Sprintf(" (skipped tags younger than minTagAge %s: %s; next eligible at %s)",
+			minTagAge, strings.Join(skipped, ", "), eligibleAt.UTC().Format(time.RFC3339))
+		bundle.Status.LastETag = ""
+		if bundle.Status.NextPollTime == nil || eligibleAt.Before(bundle.Status.NextPollTime.Time) {
+			next := metav1.NewTime(eligibleAt)
+			bundle.Status.NextPollTime = &next
+		}
+	}
+	return selection, nil
+}
+
+// createdAt returns the image creation time from metadata, if it has one that isn't after now.
+func createdAt(metadata *registry.ImageMetadata, now time.Time) (time.Time, bool) {
+	value, ok := metadata.Value(registry.AnnotationCreated)
+	if !ok {
+		return time.Time{}, false
+	}
+	created, err := time.Parse(time.RFC3339, value)
+	if err != nil || created.After(now) {
+		return time.Time{}, false
+	}
+	return created, true
+}
+
+// firstSeen returns when tag was first seen pointing to digest, recording now in seen if it
+// wasn't before or was re-pushed since.
+func firstSeen(seen *[]werfv1alpha1.SeenTag, tag, digest string, now time.Time) time.Time {
+	for i, entry := range *seen {
+		if entry.Tag != tag {
+			continue
+		}
+		if entry.Digest != digest {
+			(*seen)[i] = werfv1alpha1.SeenTag{Tag: tag, Digest: digest, FirstSeen: metav1.NewTime(now)}
+		}
+		return (*seen)[i].FirstSeen.Time
+	}
+	*seen = append(*seen, werfv1alpha1.SeenTag{Tag: tag, Digest: digest, FirstSeen: metav1.NewTime(now)})
+	return now
+}
+
+// pollIntervalFor returns the bundle's spec.registry.pollInterval, defaulting to 15 minutes,
+// and raised to the operator-wide minimum.
+func (r *WerfBundleReconciler) pollIntervalFor(ctx context.Context, bundle *werfv1alpha1.WerfBundle) time.Duration {
+	log := ctrl.LoggerFrom(ctx)
+
+	pollInterval := defaultPollInterval
+	if bundle.Spec.Registry.PollInterval != "" {
+		parsed, err := time.ParseDuration(bundle.Spec.Registry.PollInterval)
+		if err != nil {
+			log.Error(err, "invalid pollInterval in spec, using default", "pollInterval", bundle.Spec.Registry.PollInterval)
+		} else {
+			pollInterval = parsed
+		}
+	}
+
+	minInterval := r.MinPollInterval
+	if minInterval <= 0 {
+		minInterval = defaultMinPollInterval
+	}
+	if pollInterval < minInterval {
+		log.Info("pollInterval is below the operator minimum, using the minimum",
+			"pollInterval", pollInterval, "minPollInterval", minInterval)
+		pollInterval = minInterval
+	}
+	return pollInterval
+}
+
+// PollRepository makes every WerfBundle tracking repoURL (as spec.registry.url or a mirror)
+// poll the registry right away.
+// Set as registry.SharedClient.OnChange, so a tag list change seen by one bundle reaches all
+// bundles sharing the repository instead of waiting for each bundle's next poll.
+func (r *WerfBundleReconciler) PollRepository(ctx context.Context, repoURL string) {
+	log := ctrl.LoggerFrom(ctx)
+
+	repo, ok := registry.NormalizeRepository(repoURL)
+	if !ok {
+		return
+	}
+
+	var bundles werfv1alpha1.WerfBundleList
+	if err := r.List(ctx, &bundles); err != nil {
+		log.Error(err, "failed to list WerfBundles to poll", "repository", repoURL)
+		return
+	}
+	for i := range bundles.Items {
+		bundle := &bundles.Items[i]
+		if !tracksRepository(bundle, repo) || !bundle.DeletionTimestamp.IsZero() {
+			continue
+		}
+
+		r.requestPoll(client.ObjectKeyFromObject(bundle))
+		if r.repositoryEvents == nil {
+			continue
+		}
+		select {
+		case r.repositoryEvents <- event.TypedGenericEvent[*werfv1alpha1.WerfBundle]{Object: bundle}:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// tracksRepository reports whether the bundle polls the normalized repository repo, as its
+// spec.registry.url or one of its mirrors.
+func tracksRepository(bundle *werfv1alpha1.WerfBundle, repo string) bool {
+	for _, url := range append([]string{bundle.Spec.Registry.URL}, bundle.Spec.Registry.Mirrors...) {
+		if other, ok := registry.NormalizeRepository(url); ok && other == repo {
+			return true
+		}
+	}
+	return false
+}
+
+// requestPoll makes the next reconcile of the bundle poll the registry even if the poll
+// isn't due yet. Used for push notifications.
+func (r *WerfBundleReconciler) requestPoll(key types.NamespacedName) {
+	r.pollRequests.Store(key, struct{}{})
+}
+
+// bundlesForSecret maps a Secret to the Failed WerfBundles using it as registry credentials
+// (spec.registry.secretRef, looked up in the bundle or target namespace) or registry
+// certificates (spec.registry.tls, bundle namespace) and requests a poll of each, so a created
+// or fixed Secret is picked up without waiting for the next retry.
+// Healthy bundles pick up rotated credentials at their next scheduled poll.
+func (r *WerfBundleReconciler) bundlesForSecret(ctx context.Context, secret client.Object) []reconcile.Request {
+	log := ctrl.LoggerFrom(ctx)
+
+	var bundles werfv1alpha1.WerfBundleList
+	if err := r.List(ctx, &bundles); err != nil {
+		log.Error(err, "failed to list WerfBundles for Secret", "secret", client.ObjectKeyFromObject(secret))
+		return nil
+	}
+
+	var requests []reconcile.Request
+	for i := range bundles.Items {
+		bundle := &bundles.Items[i]
+		if bundle.Status.Phase != werfv1alpha1.PhaseFailed || !usesSecret(bundle, secret) {
+			continue
+		}
+		key := client.ObjectKeyFromObject(bundle)
+		r.requestPoll(key)
+		requests = append(requests, reconcile.Request{NamespacedName: key})
+	}
+	return requests
+}
+
+// usesSecret reports whether bundle reads secret as registry credentials or certificates.
+func usesSecret(bundle *werfv1alpha1.WerfBundle, secret client.Object) bool {
+	if ref := bundle.Spec.Registry.SecretRef; ref != nil && ref.Name == secret.GetName() {
+		targetNamespace := values.GetTargetNamespace(&bundle.Spec.Converge, bundle.Namespace)
+		if secret.GetNamespace() == bundle.Namespace || secret.GetNamespace() == targetNamespace {
+			return true
+		}
+	}
+
+	tls := bundle.Spec.Registry.TLS
+	if tls == nil || secret.GetNamespace() != bundle.Namespace {
+		return false
+	}
+	if tls.ClientCertSecretRef != nil && tls.ClientCertSecretRef.Name == secret.GetName() {
+		return true
+	}
+	return tls.CABundle != nil && tls.CABundle.SecretRef != nil && tls.CABundle.SecretRef.Name == secret.GetName()
+}
+
+// pollDue reports whether the registry should be polled for bundle now: a poll was requested,
+// the spec changed since the last poll, or status.nextPollTime has passed.
+// Consumes a pending poll request.
+func (r *WerfBundleReconciler) pollDue(bundle *werfv1alpha1.WerfBundle) bool {
+	_, requested := r.pollRequests.LoadAndDelete(client.ObjectKeyFromObject(bundle))
+	if requested || bundle.Status.ObservedGeneration != bundle.Generation {
+		return true
+	}
+	next := bundle.Status.NextPollTime
+	return next == nil || !time.Now().Before(next.Time)
+}
+
+// schedulePoll records a poll starting now in status and schedules the next one after the
+// poll interval with jitter. Persisting the schedule before contacting the registry keeps
+// restarts and failures from resetting it.
+// A spec change also drops the cached ETag: the tag list must be selected from again
+// even if the registry content is unchanged. It also forgets the failed versions, as the
+// change may fix their converge.
+func (r *WerfBundleReconciler) schedulePoll(
+	ctx context.Context,
+	bundle *werfv1alpha1.WerfBundle,
+	pollInterval time.Duration,
+) error {
+	if bundle.Status.ObservedGeneration != bundle.Generation {
+		bundle.Status.LastETag = ""
+		bundle.Status.FailedVersions = nil
+		bundle.Status.ObservedGeneration = bundle.Generation
+	}
+
+	now := metav1.Now()
+	next := metav1.NewTime(now.Add(registry.AddJitter(pollInterval)))
+	bundle.Status.LastPollTime = &now
+	bundle.Status.NextPollTime = &next
+
+	return r.Status().Update(ctx, bundle)
+}
+
+// requeueAtNextPoll returns a result that requeues the bundle at status.nextPollTime.
+func requeueAtNextPoll(bundle *werfv1alpha1.WerfBundle) ctrl.Result {
+	if bundle.Status.NextPollTime == nil {
+		return ctrl.Result{RequeueAfter: registry.AddJitter(defaultPollInterval)}
+	}
+	// Never requeue immediately: status times have second precision
+	wait := time.Until(bundle.Status.NextPollTime.Time)
+	if wait < time.Second {
+		wait = time.Second
+	}
+	return ctrl.Result{RequeueAfter: wait}
+}
+
+// reconcileBetweenPolls handles a reconcile when no poll is due: a running Job is monitored
+// until it completes, otherwise the bundle is requeued for its next poll.
+// If the active Job no longer exists, the registry is polled right away so it's recreated.
+func (r *WerfBundleReconciler) reconcileBetweenPolls(
+	ctx context.Context,
+	bundle *werfv1alpha1.WerfBundle,
+) (ctrl.Result, error) {
+	log := ctrl.LoggerFrom(ctx)
+
+	if bundle.Status.ActiveJobName == "" {
+		return requeueAtNextPoll(bundle), nil
+	}
+
+	targetNamespace := values.GetTargetNamespace(&bundle.Spec.Converge, bundle.Namespace)
+	job := &batchv1.Job{}
+	err := r.Get(ctx, types.NamespacedName{Name: bundle.Status.ActiveJobName, Namespace: targetNamespace}, job)
+	if err == nil {
+		return r.monitorJobCompletion(ctx, bundle, job, bundle.Status.LastAppliedTag)
+	}
+	if !apierrors.IsNotFound(err) {
+		log.Error(err, "failed to fetch active job", "jobName", bundle.Status.ActiveJobName)
+		return ctrl.Result{}, err
+	}
+
+	log.Info("active job no longer exists, polling registry", "jobName", bundle.Status.ActiveJobName)
+	r.requestPoll(client.ObjectKeyFromObject(bundle))
+	return ctrl.Result{Requeue: true}, nil
+}
+
+// reconcileTag resolves tag to its manifest digest and starts a converge if either the tag
+// or the digest behind it differs from what was last applied.
+// Comparing digests catches mutable tags (e.g., main, stable) that were re-pushed.
+// Returns a requeue at the next poll when the bundle is already up to date.
+func (r *WerfBundleReconciler) reconcileTag(
+	ctx context.Context,
+	bundle *werfv1alpha1.WerfBundle,
+	auth authn.Authenticator,
+	tag string,
+) (ctrl.Result, error) {
+	digest, err := r.RegistryClient.ResolveDigest(ctx, registryURL(bundle), tag, auth)
+	if err != nil {
+		return r.handleRegistryError(ctx, bundle, fmt.Errorf("tag %q: %w", tag, err))
+	}
+	return r.reconcileDigest(ctx, bundle, auth, tag, digest)
+}
+
+// reconcilePinned deploys the version pinned by spec.version. A pinned tag is reconciled like
+// a selected one, so re-pushing it is still deployed. A pinned digest is deployed as it is and
+// recorded as both the applied tag and digest.
+func (r *WerfBundleReconciler) reconcilePinned(
+	ctx context.Context,
+	bundle *werfv1alpha1.WerfBundle,
+	auth authn.Authenticator,
+) (ctrl.Result, error) {
+	pinned := bundle.Spec.Version
+	if !isDigest(pinned) {
+		return r.reconcileTag(ctx, bundle, auth, pinned)
+	}
+	return r.reconcileDigest(ctx, bundle, auth, pinned, pinned)
+}
+
+// checkPinnedVersion records in the UpdateAvailable condition whether latestTag, the tag
+// selection picked, differs from the version pinned by spec.version. A pinned digest is
+// compared with the digest latestTag resolves to.
+// Returns ok=true if the pinned version may be deployed. Otherwise returns the result to hand
+// back to controller-runtime: registry failures resolving latestTag are retried with backoff
+// like poll failures.
+func (r *WerfBundleReconciler) checkPinnedVersion(
+	ctx context.Context,
+	bundle *werfv1alpha1.WerfBundle,
+	auth authn.Authenticator,
+	latestTag string,
+) (bool, ctrl.Result, error) {
+	log := ctrl.LoggerFrom(ctx)
+	pinned := bundle.Spec.Version
+
+	condition := metav1.Condition{
+		Type:               werfv1alpha1.ConditionUpdateAvailable,
+		Status:             metav1.ConditionFalse,
+		Reason:             werfv1alpha1.ReasonPinnedVersionLatest,
+		Message:            fmt.Sprintf("Pinned version %s is the latest available", pinned),
+		ObservedGeneration: bundle.Generation,
+	}
+	switch {
+	case latestTag == "":
+		condition.Reason = werfv1alpha1.ReasonNoVersionSelected
+		condition.Message = fmt.Sprintf("No tag selected: %s", bundle.Status.SelectionReason)
+	case isDigest(pinned):
+		digest, err := r.RegistryClient.ResolveDigest(ctx, registryURL(bundle), latestTag, auth)
+		if err != nil {
+			result, err := r.handleRegistryError(ctx, bundle, fmt.Errorf("tag %q: %w", latestTag, err))
+			return false, result, err
+		}
+		if digest != pinned {
+			condition.Status = metav1.ConditionTrue
+			condition.Reason = werfv1alpha1.ReasonNewerVersionAvailable
+			condition.Message = fmt.Sprintf("Tag %s (%s) is available, pinned to %s", latestTag, digest, pinned)
+		}
+	case latestTag != pinned:
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = werfv1alpha1.ReasonNewerVersionAvailable
+		condition.Message = fmt.Sprintf("Tag %s is available, pinned to %s", latestTag, pinned)
+	}
+	if meta.SetStatusCondition(&bundle.Status.Conditions, condition) {
+		log.Info("pinned version drift changed", "pinned", pinned, "latest", latestTag,
+			"updateAvailable", condition.Status)
+		if err := r.Status().Update(ctx, bundle); err != nil {
+			log.Error(err, "failed to update UpdateAvailable condition")
+			return false, ctrl.Result{}, err
+		}
+	}
+	return true, ctrl.Result{}, nil
+}
+
+// failPinnedVersionNotFound marks the bundle Failed with reason PinnedVersionNotFound, for a
+// tag pinned by spec.version that isn't in the repository, and requeues for the next poll so
+// pushing the tag is picked up without editing the bundle.
+func (r *WerfBundleReconciler) failPinnedVersionNotFound(
+	ctx context.Context,
+	bundle *werfv1alpha1.WerfBundle,
+) (ctrl.Result, error) {
+	log := ctrl.LoggerFrom(ctx)
+
+	log.Info("pinned tag not found in repository, not creating Job", "tag", bundle.Spec.Version)
+	now := metav1.Now()
+	bundle.Status.LastErrorTime = &now
+	// Keep listing tags until the pinned one shows up, even if nothing was deployed yet
+	bundle.Status.LastETag = ""
+	if err := r.updateStatusFailedWithReason(ctx, bundle, werfv1alpha1.FailureReasonPinnedVersionNotFound,
+		fmt.Sprintf("Pinned version %s not found in %s", bundle.Spec.Version, registryURL(bundle))); err != nil {
+		log.Error(err, "failed to update status after pinned tag not found")
+		return ctrl.Result{}, err
+	}
+	return requeueAtNextPoll(bundle), nil
+}
+
+// isDigest reports whether version is a manifest digest (sha256:...) rather than a tag.
+func isDigest(version string) bool {
+	return strings.HasPrefix(version, "sha256:")
+}
+
+// reconcileDigest starts a converge of tag, resolved to digest, if either differs from what
+// was last applied and the version isn't in status.failedVersions.
+// Returns a requeue at the next poll when the bundle is already up to date.
+func (r *WerfBundleReconciler) reconcileDigest(
+	ctx context.Context,
+	bundle *werfv1alpha1.WerfBundle,
+	auth authn.Authenticator,
+	tag, digest string,
+) (ctrl.Result, error) {
+	log := ctrl.LoggerFrom(ctx)
+
+	// Don't deploy a version whose converge failed again until it's re-pushed or the spec
+	// changes; the rolled back version keeps running meanwhile
+	if bundle.Status.ActiveJobName == "" && isFailedVersion(bundle, tag, digest) {
+		log.Info("skipping version whose converge failed", "tag", tag, "digest", digest)
+		// Re-pushing the tag doesn't change the tag list: drop its ETag so the next poll
+		// selects the tag again and sees the new digest
+		if bundle.Status.LastETag != "" {
+			bundle.Status.LastETag = ""
+			if err := r.Status().Update(ctx, bundle); err != nil {
+				log.Error(err, "failed to clear ETag in status")
+				return ctrl.Result{}, err
+			}
+		}
+		return requeueAtNextPoll(bundle), nil
 	}
 
-	// Get the latest tag from the list (lexicographically last)
-	latestTag := tags[len(tags)-1]
+	if bundle.Status.LastAppliedTag == tag {
+		// Bundles deployed before digests were tracked adopt the current digest
+		// instead of redeploying the same tag
+		adopted := false
+		if bundle.Status.LastAppliedDigest == "" {
+			bundle.Status.LastAppliedDigest = digest
+			adopted = true
+		}
+
+		if bundle.Status.LastAppliedDigest != digest {
+			log.Info("tag moved to a new digest, redeploying",
+				"tag", tag, "previousDigest", bundle.Status.LastAppliedDigest, "digest", digest)
+			return r.ensureJobExists(ctx, bundle, auth, tag, digest)
+		}
 
-	// If latest tag matches what we already deployed, we're done
-	if bundle.Status.LastAppliedTag == latestTag {
+		// Converge for this digest is still running: keep monitoring it
+		if bundle.Status.ActiveJobName != "" {
+			return r.ensureJobExists(ctx, bundle, auth, tag, digest)
+		}
+
+		// The last converge of this digest failed, and it's no longer in status.failedVersions
+		// because the spec changed: deploy it again instead of reporting the failure Synced
+		if bundle.Status.LastJobStatus == werfv1alpha1.JobStatusFailed {
+			log.Info("last converge of the tag didn't succeed, redeploying", "tag", tag, "digest", digest)
+			return r.ensureJobExists(ctx, bundle, auth, tag, digest)
+		}
+
+		// Selected tag and digest match what we already deployed, we're done
 		if bundle.Status.Phase != werfv1alpha1.PhaseSynced {
-			if err := r.updateStatusSynced(ctx, bundle, latestTag); err != nil {
+			if err := r.updateStatusSynced(ctx, bundle, tag); err != nil {
 				log.Error(err, "failed to update status to Synced")
 				return ctrl.Result{}, err
 			}
+		} else if adopted {
+			if err := r.Status().Update(ctx, bundle); err != nil {
+				log.Error(err, "failed to update applied digest in status")
+				return ctrl.Result{}, err
+			}
 		}
-		return ctrl.Result{}, nil
+		// Keep polling: the tag may be re-pushed
+		return requeueAtNextPoll(bundle), nil
 	}
 
 	// New tag found - ensure Job exists and monitor it
-	return r.ensureJobExists(ctx, bundle, latestTag)
+	return r.ensureJobExists(ctx, bundle, auth, tag, digest)
 }
 
 // validateServiceAccount checks that the ServiceAccount exists in the target namespace.
@@ -253,74 +1172,303 @@ func (r *WerfBundleReconciler) validateServiceAccount(
 	return nil
 }
 
-// handleRegistryError handles registry polling errors with retry logic and exponential backoff.
-// Returns early with a requeue result if the error should be retried.
-// Returns nil, nil if max retries exceeded (stop retrying but don't propagate error).
+// resolveRegistryAuth loads the Secret referenced by spec.registry.secretRef and converts it
+// to an authenticator for the bundle's registry host.
+// The Secret is looked up in the bundle namespace first, then in the target namespace,
+// matching the precedence used for valuesFrom sources.
+// Without a secretRef, the credential provider plugins are used (see providerAuth).
+// Returns a registry.AuthError if the Secret is missing or malformed.
+func (r *WerfBundleReconciler) resolveRegistryAuth(
+	ctx context.Context,
+	bundle *werfv1alpha1.WerfBundle,
+) (authn.Authenticator, error) {
+	secret, err := r.getRegistrySecret(ctx, bundle)
+	if err != nil {
+		return nil, err
+	}
+	if secret == nil {
+		return r.providerAuth(ctx, bundle.Spec.Registry.URL)
+	}
+	return registry.AuthFromSecret(secret, bundle.Spec.Registry.URL)
+}
+
+// providerAuth returns the credentials for repoURL from the credential provider plugins.
+// Returns nil, nil (anonymous access) when no plugin is configured for repoURL.
+// Returns a registry.CredentialProviderError if the plugin fails.
+func (r *WerfBundleReconciler) providerAuth(ctx context.Context, repoURL string) (authn.Authenticator, error) {
+	cfg, err := r.CredentialProviders.Lookup(ctx, repoURL)
+	if err != nil || cfg == nil {
+		return nil, err
+	}
+	return authn.FromConfig(*cfg), nil
+}
+
+// getRegistrySecret fetches the Secret referenced by spec.registry.secretRef.
+// Returns nil, nil when no secretRef is configured.
+// Returns a registry.AuthError if the Secret doesn't exist in either namespace.
+func (r *WerfBundleReconciler) getRegistrySecret(
+	ctx context.Context,
+	bundle *werfv1alpha1.WerfBundle,
+) (*corev1.Secret, error) {
+	if bundle.Spec.Registry.SecretRef == nil || bundle.Spec.Registry.SecretRef.Name == "" {
+		return nil, nil
+	}
+
+	secretName := bundle.Spec.Registry.SecretRef.Name
+	targetNamespace := values.GetTargetNamespace(&bundle.Spec.Converge, bundle.Namespace)
+
+	namespaces := []string{bundle.Namespace}
+	if targetNamespace != bundle.Namespace {
+		namespaces = append(namespaces, targetNamespace)
+	}
+
+	for _, ns := range namespaces {
+		secret := &corev1.Secret{}
+		err := r.Get(ctx, types.NamespacedName{Name: secretName, Namespace: ns}, secret)
+		if err == nil {
+			return secret, nil
+		}
+		if !apierrors.IsNotFound(err) {
+			return nil, fmt.Errorf("failed to get registry secret %q from namespace %q: %w", secretName, ns, err)
+		}
+	}
+
+	return nil, &registry.AuthError{Err: fmt.Errorf("registry secret %q not found in namespace(s) %v",
+		secretName, namespaces)}
+}
+
+// handleAuthSecretError handles failures to load registry credentials from the referenced Secret.
+// A missing or malformed Secret is a configuration problem, so the bundle is marked Failed
+// immediately rather than burning registry retries. Creating or fixing the Secret triggers a
+// poll (see SetupWithManager); until then the bundle is retried at the long Failed interval.
+// Credential provider plugin failures are handled like registry errors, since plugins usually
+// depend on a cloud API. Other errors (e.g. API server failures) are returned so
+// controller-runtime retries them.
+func (r *WerfBundleReconciler) handleAuthSecretError(
+	ctx context.Context,
+	bundle *werfv1alpha1.WerfBundle,
+	authErr error,
+) (ctrl.Result, error) {
+	log := ctrl.LoggerFrom(ctx)
+
+	var providerErr *registry.CredentialProviderError
+	var invalidRepo *registry.InvalidRepositoryError
+	if errors.As(authErr, &providerErr) || errors.As(authErr, &invalidRepo) {
+		return r.handleRegistryError(ctx, bundle, authErr)
+	}
+
+	var registryAuthErr *registry.AuthError
+	if !errors.As(authErr, &registryAuthErr) {
+		log.Error(authErr, "failed to load registry credentials")
+		return ctrl.Result{}, authErr
+	}
+
+	log.Info("registry credentials unavailable, marking bundle as Failed", "error", authErr.Error())
+	now := metav1.Now()
+	bundle.Status.LastErrorTime = &now
+	return r.failRegistryPoll(ctx, bundle, werfv1alpha1.FailureReasonAuthenticationFailed,
+		fmt.Sprintf("Registry credentials error: %v", authErr))
+}
+
+// handleRegistryError handles registry polling errors according to their class:
+//   - transient errors (network, timeouts, server errors) are retried with the exponential
+//     backoff of spec.registry.retryPolicy, and mark the bundle Failed once it runs out of attempts
+//   - authentication and not-found errors mark the bundle Failed right away
+//   - an invalid repository URL marks the bundle Failed until the spec changes
+//   - a rate limited registry that says when to come back (Retry-After) is retried at that time
+//
+// Failed bundles aren't given up on: they're polled again at a long interval.
 func (r *WerfBundleReconciler) handleRegistryError(
 	ctx context.Context,
 	bundle *werfv1alpha1.WerfBundle,
 	registryErr error,
-	pollInterval time.Duration,
 ) (ctrl.Result, error) {
 	log := ctrl.LoggerFrom(ctx)
 
 	var notModified *registry.NotModifiedError
 	if errors.As(registryErr, &notModified) {
 		// Content hasn't changed - cached response is still valid
-		// Requeue after poll interval + jitter to check again later
+		// Requeue for the next poll to check again later
 		log.Info("registry content unchanged (cached ETag valid)")
-		requeueInterval := registry.AddJitter(pollInterval)
-		return ctrl.Result{RequeueAfter: requeueInterval}, nil
+		return requeueAtNextPoll(bundle), nil
+	}
+
+	// Rate limited with a known delay - retry when the registry accepts requests again.
+	// Not counted as a failure: the bundle is fine, the registry is busy
+	var rateLimited *registry.RateLimitedError
+	if errors.As(registryErr, &rateLimited) && rateLimited.RetryAfter > 0 {
+		retryAfter := rateLimited.RetryAfter
+		log.Info("registry rate limited, requeuing at the time the registry asked for", "retryAfter", retryAfter)
+		now := metav1.Now()
+		retryTime := metav1.NewTime(now.Add(retryAfter))
+		bundle.Status.LastErrorTime = &now
+		bundle.Status.NextPollTime = &retryTime
+		errMsg := fmt.Sprintf("Registry rate limited, retrying at %s: %v",
+			retryTime.UTC().Format(time.RFC3339), registryErr)
+		if err := r.updateStatusRetrying(ctx, bundle, errMsg); err != nil {
+			log.Error(err, "failed to update status after registry rate limit")
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{RequeueAfter: retryAfter}, nil
+	}
+
+	// An invalid repository URL is a configuration error, like an invalid schedule: fail
+	// without requeueing, the spec change fixing it triggers the next reconcile
+	var invalidRepo *registry.InvalidRepositoryError
+	if errors.As(registryErr, &invalidRepo) {
+		log.Info("invalid repository URL, marking bundle as Failed", "error", registryErr.Error())
+		if err := r.updateStatusFailedWithReason(ctx, bundle, werfv1alpha1.FailureReasonInvalidRepository,
+			fmt.Sprintf("Invalid registry URL: %v", registryErr)); err != nil {
+			log.Error(err, "failed to update status after invalid repository URL")
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{}, nil
 	}
 
 	// Registry error - implement retry logic with exponential backoff
 	bundle.Status.ConsecutiveFailures++
 	now := metav1.Now()
 	bundle.Status.LastErrorTime = &now
+	policy := r.retryPolicyFor(ctx, bundle)
+
+	// Bad credentials and missing repositories won't be fixed by retrying: fail right away,
+	// and poll again when the Secret or spec changes (or after the long Failed interval)
+	var authErr *registry.AuthError
+	var notFound *registry.NotFoundError
+	var tooManyTags *registry.TooManyTagsError
+	var tlsErr *registry.TLSError
+	switch {
+	case errors.As(registryErr, &authErr):
+		log.Info("registry rejected credentials, marking bundle as Failed", "error", registryErr.Error())
+		return r.failRegistryPoll(ctx, bundle, werfv1alpha1.FailureReasonAuthenticationFailed,
+			fmt.Sprintf("Registry authentication failed: %v", registryErr))
+	case errors.As(registryErr, &notFound):
+		log.Info("registry repository not found, marking bundle as Failed", "error", registryErr.Error())
+		return r.failRegistryPoll(ctx, bundle, werfv1alpha1.FailureReasonRepositoryNotFound,
+			fmt.Sprintf("Registry repository not found: %v", registryErr))
+	case errors.As(registryErr, &tooManyTags):
+		log.Info("registry repository has too many tags, marking bundle as Failed", "limit", tooManyTags.Limit)
+		return r.failRegistryPoll(ctx, bundle, werfv1alpha1.FailureReasonTooManyTags,
+			fmt.Sprintf("Registry repository has too many tags (raise --registry-max-tags): %v", registryErr))
+	case errors.As(registryErr, &tlsErr):
+		log.Info("registry TLS failed, marking bundle as Failed", "error", registryErr.Error())
+		return r.failRegistryPoll(ctx, bundle, werfv1alpha1.FailureReasonTLSFailed,
+			fmt.Sprintf("Registry TLS error: %v", registryErr))
+	}
+
 	log.Info("registry poll failed, incrementing retry counter",
 		"failures", bundle.Status.ConsecutiveFailures,
-		"maxRetries", maxConsecutiveFailures)
+		"maxRetries", policy.maxAttempts)
 
-	// Check if we've exceeded max retries (allow up to maxConsecutiveFailures attempts)
-	if bundle.Status.ConsecutiveFailures > maxConsecutiveFailures {
+	// Check if we've exceeded max retries (allow up to maxAttempts attempts)
+	if bundle.Status.ConsecutiveFailures > policy.maxAttempts {
 		log.Info("max consecutive failures reached, marking bundle as Failed")
-		errMsg := fmt.Sprintf("Registry error after %d retries: %v",
-			maxConsecutiveFailures, registryErr)
-		if err := r.updateStatusFailed(ctx, bundle, errMsg); err != nil {
-			log.Error(err, "failed to update status after max retries exceeded")
-			return ctrl.Result{}, err
-		}
-		return ctrl.Result{}, nil
+		return r.failRegistryPoll(ctx, bundle, werfv1alpha1.FailureReasonRegistryUnavailable,
+			fmt.Sprintf("Registry error after %d retries: %v", policy.maxAttempts, registryErr))
 	}
 
-	// Calculate backoff and requeue
-	backoff := registry.CalculateBackoff(bundle.Status.ConsecutiveFailures)
+	// Calculate backoff and requeue; the retry replaces the scheduled poll
+	backoff := registry.ExponentialBackoff(bundle.Status.ConsecutiveFailures, policy.baseDelay, policy.maxDelay)
 	log.Info("requeuing with exponential backoff", "backoff", backoff)
+	retryTime := metav1.NewTime(now.Add(backoff))
+	bundle.Status.NextPollTime = &retryTime
 	errMsg := fmt.Sprintf("Registry error (attempt %d/%d): %v",
-		bundle.Status.ConsecutiveFailures, maxConsecutiveFailures, registryErr)
-	if err := r.updateStatusSyncing(ctx, bundle, errMsg); err != nil {
+		bundle.Status.ConsecutiveFailures, policy.maxAttempts, registryErr)
+	if err := r.updateStatusRetrying(ctx, bundle, errMsg); err != nil {
 		log.Error(err, "failed to update status after registry error")
 		return ctrl.Result{}, err
 	}
 	return ctrl.Result{RequeueAfter: backoff}, nil
 }
 
-// ensureJobExists builds a Job for the given tag, creates it if it doesn't exist,
-// and monitors its status for completion.
+// failRegistryPoll marks the bundle Failed with reason and schedules the next poll after the
+// long Failed interval, so the bundle recovers by itself once the registry does.
+// Secret and spec changes trigger a poll earlier.
+func (r *WerfBundleReconciler) failRegistryPoll(
+	ctx context.Context,
+	bundle *werfv1alpha1.WerfBundle,
+	reason string,
+	errMsg string,
+) (ctrl.Result, error) {
+	log := ctrl.LoggerFrom(ctx)
+
+	retryAfter := failedRetryInterval
+	if pollInterval := r.pollIntervalFor(ctx, bundle); pollInterval > retryAfter {
+		retryAfter = pollInterval
+	}
+	retryTime := metav1.NewTime(time.Now().Add(registry.AddJitter(retryAfter)))
+	bundle.Status.NextPollTime = &retryTime
+
+	if err := r.updateStatusFailedWithReason(ctx, bundle, reason, errMsg); err != nil {
+		log.Error(err, "failed to update status after registry error")
+		return ctrl.Result{}, err
+	}
+	return requeueAtNextPoll(bundle), nil
+}
+
+// retryPolicy is a parsed spec.registry.retryPolicy.
+type retryPolicy struct {
+	baseDelay   time.Duration
+	maxDelay    time.Duration
+	maxAttempts int32
+}
+
+// retryPolicyFor returns the bundle's retry policy, with defaults for unset or invalid fields.
+func (r *WerfBundleReconciler) retryPolicyFor(ctx context.Context, bundle *werfv1alpha1.WerfBundle) retryPolicy {
+	log := ctrl.LoggerFrom(ctx)
+
+	policy := retryPolicy{
+		baseDelay:   registry.DefaultBackoffBase,
+		maxDelay:    registry.DefaultBackoffMax,
+		maxAttempts: maxConsecutiveFailures,
+	}
+	spec := bundle.Spec.Registry.RetryPolicy
+	if spec == nil {
+		return policy
+	}
+
+	if spec.BaseDelay != "" {
+		if d, err := time.ParseDuration(spec.BaseDelay); err != nil || d <= 0 {
+			log.Error(err, "invalid retryPolicy.baseDelay in spec, using default", "baseDelay", spec.BaseDelay)
+		} else {
+			policy.baseDelay = d
+		}
+	}
+	if spec.MaxDelay != "" {
+		if d, err := time.ParseDuration(spec.MaxDelay); err != nil || d <= 0 {
+			log.Error(err, "invalid retryPolicy.maxDelay in spec, using default", "maxDelay", spec.MaxDelay)
+		} else {
+			policy.maxDelay = d
+		}
+	}
+	if spec.MaxAttempts > 0 {
+		policy.maxAttempts = spec.MaxAttempts
+	}
+	return policy
+}
+
+// ensureJobExists builds a Job for the given tag, pinned to digest, creates it if it
+// doesn't exist, and monitors its status for completion.
 // Implements deduplication by tracking the active job name in Status.
+// Before the Job is created, the digest is checked to be a werf bundle. If spec.verify is set,
+// its signature is verified, and so are the referrers required by spec.attestations. In Manual
+// approval mode, the Job also waits for the version to be approved, and with spec.schedule, for
+// a deployment window outside change freezes.
 // Returns a requeue result if the Job is still running.
-// Returns nil, nil if the Job succeeds or fails.
+// Returns nil, nil if the Job fails.
 func (r *WerfBundleReconciler) ensureJobExists(
 	ctx context.Context,
 	bundle *werfv1alpha1.WerfBundle,
+	auth authn.Authenticator,
 	latestTag string,
+	digest string,
 ) (ctrl.Result, error) {
 	log := ctrl.LoggerFrom(ctx)
 
 	// Calculate target namespace - Jobs are created in target namespace
 	targetNamespace := values.GetTargetNamespace(&bundle.Spec.Converge, bundle.Namespace)
 
-	log.Info("new tag found, ensuring converge job exists", "tag", latestTag)
+	log.Info("new tag found, ensuring converge job exists", "tag", latestTag, "digest", digest)
 
 	// Check if we already have an active job running (deduplication)
 	if bundle.Status.ActiveJobName != "" {
@@ -345,8 +1493,55 @@ func (r *WerfBundleReconciler) ensureJobExists(
 		}
 	}
 
+	// Never create a Job for an artifact that isn't a werf bundle, e.g. an image pushed to the
+	// bundle repository by mistake: werf converge would only fail on it minutes later
+	if valid, result, err := r.inspectBundle(ctx, bundle, auth, latestTag, digest); !valid {
+		return result, err
+	}
+	// Nor for one that fails signature verification
+	if bundle.Spec.Verify != nil {
+		if verified, result, err := r.verifyBundle(ctx, bundle, auth, digest); !verified {
+			return result, err
+		}
+	}
+	// Nor for one missing a required attestation
+	if len(bundle.Spec.Attestations) > 0 {
+		if attested, result, err := r.checkAttestations(ctx, bundle, auth, digest); !attested {
+			return result, err
+		}
+	}
+	// Nor, in Manual approval mode, for one that isn't approved yet
+	if approvalRequired(bundle) {
+		if approved, result, err := r.checkApproval(ctx, bundle, latestTag, digest); !approved {
+			return result, err
+		}
+	}
+	// Nor outside the deployment windows, or during a change freeze
+	if bundle.Spec.Schedule != nil {
+		if allowed, result, err := r.checkSchedule(ctx, bundle, latestTag, digest); !allowed {
+			return result, err
+		}
+	}
+
+	return r.createJob(ctx, bundle, latestTag, digest)
+}
+
+// createJob builds the converge Job for tag, pinned to digest, creates it and tracks it in
+// status as the active Job. Unlike ensureJobExists, it runs no checks: it's also used to roll
+// back to a version that was already deployed.
+// Returns a requeue result to monitor the Job.
+// Returns nil, nil with the bundle marked Failed if the Job can't be created.
+func (r *WerfBundleReconciler) createJob(
+	ctx context.Context,
+	bundle *werfv1alpha1.WerfBundle,
+	tag string,
+	digest string,
+) (ctrl.Result, error) {
+	log := ctrl.LoggerFrom(ctx)
+
 	// No active job, update status to Syncing and build new job spec
-	if err := r.updateStatusSyncing(ctx, bundle, latestTag); err != nil {
+	bundle.Status.LastAppliedDigest = digest
+	if err := r.updateStatusSyncing(ctx, bundle, tag); err != nil {
 		log.Error(err, "failed to update status to Syncing")
 		return ctrl.Result{}, err
 	}
@@ -355,8 +1550,42 @@ func (r *WerfBundleReconciler) ensureJobExists(
 	valuesResolver := values.NewResolver(r.Client)
 	jobBuilder := converge.NewBuilder(bundle).
 		WithScheme(r.Scheme).
-		WithValuesResolver(valuesResolver)
-	jobSpec, err := jobBuilder.Build(ctx, latestTag)
+		WithValuesResolver(valuesResolver).
+		WithRepository(registryURL(bundle)).
+		WithDigest(digest)
+
+	// Pass registry credentials to werf so it can pull private bundles
+	dockerConfig, err := r.resolveRegistryDockerConfig(ctx, bundle)
+	if err != nil {
+		log.Error(err, "failed to prepare registry credentials for Job")
+		if err := r.updateStatusFailed(ctx, bundle,
+			fmt.Sprintf("Failed to prepare registry credentials for Job: %v", err)); err != nil {
+			log.Error(err, "failed to update status after registry credentials failure")
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{}, nil
+	}
+	if dockerConfig != nil {
+		jobBuilder.WithRegistryCredentials(dockerConfig)
+	}
+	tlsConfig, err := r.resolveRegistryTLS(ctx, bundle)
+	if err != nil {
+		log.Error(err, "failed to prepare registry certificates for Job")
+		if err := r.updateStatusFailed(ctx, bundle,
+			fmt.Sprintf("Failed to prepare registry certificates for Job: %v", err)); err != nil {
+			log.Error(err, "failed to update status after registry certificates failure")
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{}, nil
+	}
+	if tlsConfig != nil {
+		jobBuilder.WithRegistryTLS(tlsConfig.CACerts, tlsConfig.ClientCert, tlsConfig.ClientKey)
+	}
+	if proxy := r.proxyFor(bundle); proxy != nil {
+		jobBuilder.WithProxy(proxy.HTTPProxy, proxy.HTTPSProxy, proxy.NoProxy)
+	}
+
+	jobSpec, err := jobBuilder.Build(ctx, tag)
 	if err != nil {
 		log.Error(err, "failed to build Job")
 		if err := r.updateStatusFailed(ctx, bundle, fmt.Sprintf("Failed to build Job: %v", err)); err != nil {
@@ -379,6 +1608,24 @@ func (r *WerfBundleReconciler) ensureJobExists(
 
 	log.Info("Job created successfully", "jobName", jobSpec.Name)
 
+	// Copy registry credentials after the Job exists so the Secret can be owned by it
+	if jobBuilder.NeedsRegistrySecret() {
+		if err := r.createRegistrySecret(ctx, jobBuilder, jobSpec); err != nil {
+			log.Error(err, "failed to create registry credentials Secret", "jobName", jobSpec.Name)
+			// The Job can't pull the bundle without credentials, so don't leave it running
+			propagation := client.PropagationPolicy(metav1.DeletePropagationBackground)
+			if delErr := r.Delete(ctx, jobSpec, propagation); delErr != nil && !apierrors.IsNotFound(delErr) {
+				log.Error(delErr, "failed to delete Job after registry credentials failure", "jobName", jobSpec.Name)
+			}
+			if err := r.updateStatusFailed(ctx, bundle,
+				fmt.Sprintf("Failed to create registry credentials for Job: %v", err)); err != nil {
+				log.Error(err, "failed to update status after registry credentials failure")
+				return ctrl.Result{}, err
+			}
+			return ctrl.Result{}, nil
+		}
+	}
+
 	// Track active job in status for deduplication
 	bundle.Status.ActiveJobName = jobSpec.Name
 	bundle.Status.LastJobStatus = werfv1alpha1.JobStatusRunning
@@ -391,9 +1638,350 @@ func (r *WerfBundleReconciler) ensureJobExists(
 	return ctrl.Result{RequeueAfter: 5 * time.Second}, nil
 }
 
+// inspectBundle checks that digest, the artifact tag points to, is a werf bundle.
+// Returns valid=true if the Job may be created. Otherwise returns the result to hand back
+// to controller-runtime:
+//   - an artifact that isn't a bundle marks the bundle Failed with reason InvalidBundle and
+//     requeues for the next poll, so a bundle pushed to the tag (or a newer tag) is picked up
+//     without editing the bundle
+//   - registry failures while fetching the manifest are retried with backoff like poll failures
+func (r *WerfBundleReconciler) inspectBundle(
+	ctx context.Context,
+	bundle *werfv1alpha1.WerfBundle,
+	auth authn.Authenticator,
+	tag, digest string,
+) (bool, ctrl.Result, error) {
+	log := ctrl.LoggerFrom(ctx)
+
+	err := r.RegistryClient.InspectBundle(ctx, registryURL(bundle), digest, auth)
+	var invalidErr *registry.InvalidBundleError
+	switch {
+	case err == nil:
+		return true, ctrl.Result{}, nil
+	case !errors.As(err, &invalidErr):
+		result, err := r.handleRegistryError(ctx, bundle, fmt.Errorf("inspect %s: %w", digest, err))
+		return false, result, err
+	}
+
+	log.Info("tag isn't a werf bundle, not creating Job", "tag", tag, "digest", digest, "error", err.Error())
+	now := metav1.Now()
+	bundle.Status.LastErrorTime = &now
+	// Re-pushing the tag doesn't change the tag list: drop its ETag so the next poll selects
+	// and inspects the tag again even if nothing was deployed yet
+	bundle.Status.LastETag = ""
+	if err := r.updateStatusFailedWithReason(ctx, bundle,
+		werfv1alpha1.FailureReasonInvalidBundle, fmt.Sprintf("Tag %s (%s): %v", tag, digest, err)); err != nil {
+		log.Error(err, "failed to update status after bundle inspection failure")
+		return false, ctrl.Result{}, err
+	}
+	return false, requeueAtNextPoll(bundle), nil
+}
+
+// approvalRequired reports whether new versions of bundle wait for approval (spec.approval.mode
+// Manual).
+func approvalRequired(bundle *werfv1alpha1.WerfBundle) bool {
+	return bundle.Spec.Approval != nil && bundle.Spec.Approval.Mode == werfv1alpha1.ApprovalModeManual
+}
+
+// checkApproval checks that tag, resolved to digest, is approved by the werf.io/approve
+// annotation. Returns approved=true if the Job may be created, after recording the approval
+// and its approver in status.lastApproval; the status is persisted with the Syncing phase.
+// Otherwise records the version in status.pendingTag and status.pendingDigest and requeues
+// for the next poll. Approving the pending version triggers a poll right away (see Reconcile).
+func (r *WerfBundleReconciler) checkApproval(
+	ctx context.Context,
+	bundle *werfv1alpha1.WerfBundle,
+	tag, digest string,
+) (bool, ctrl.Result, error) {
+	log := ctrl.LoggerFrom(ctx)
+
+	if approves(bundle.Annotations[werfv1alpha1.ApprovalAnnotation], tag, digest) {
+		approvedBy, approvedAt := approvalManager(bundle)
+		if approvedAt == nil {
+			now := metav1.Now()
+			approvedAt = &now
+		}
+		log.Info("version approved", "tag", tag, "digest", digest, "approvedBy", approvedBy)
+		bundle.Status.LastApproval = &werfv1alpha1.ApprovalRecord{
+			Tag:        tag,
+			Digest:     digest,
+			ApprovedBy: approvedBy,
+			ApprovedAt: approvedAt,
+		}
+		bundle.Status.PendingTag = ""
+		bundle.Status.PendingDigest = ""
+		meta.SetStatusCondition(&bundle.Status.Conditions, metav1.Condition{
+			Type:               werfv1alpha1.ConditionAwaitingApproval,
+			Status:             metav1.ConditionFalse,
+			Reason:             werfv1alpha1.ReasonApproved,
+			Message:            fmt.Sprintf("Tag %s (%s) approved by %s", tag, digest, approvedBy),
+			ObservedGeneration: bundle.Generation,
+		})
+		return true, ctrl.Result{}, nil
+	}
+
+	if bundle.Status.PendingTag != tag || bundle.Status.PendingDigest != digest {
+		log.Info("new version waits for approval, not creating Job", "tag", tag, "digest", digest)
+	}
+	bundle.Status.PendingTag = tag
+	bundle.Status.PendingDigest = digest
+	// Approving doesn't change the tag list: drop its ETag so the next poll selects the
+	// pending version again even if nothing was deployed yet
+	bundle.Status.LastETag = ""
+	meta.SetStatusCondition(&bundle.Status.Conditions, metav1.Condition{
+		Type:   werfv1alpha1.ConditionAwaitingApproval,
+		Status: metav1.ConditionTrue,
+		Reason: werfv1alpha1.ReasonApprovalRequired,
+		Message: fmt.Sprintf("Tag %s (%s) waits for approval: annotate the WerfBundle with %s=%s",
+			tag, digest, werfv1alpha1.ApprovalAnnotation, digest),
+		ObservedGeneration: bundle.Generation,
+	})
+	if err := r.Status().Update(ctx, bundle); err != nil {
+		log.Error(err, "failed to record pending version in status")
+		return false, ctrl.Result{}, err
+	}
+	return false, requeueAtNextPoll(bundle), nil
+}
+
+// checkSchedule checks that spec.schedule allows a converge of tag, resolved to digest, to
+// start now. Returns allowed=true if the Job may be created; the status is persisted with the
+// Syncing phase. Otherwise records the version in status.heldTag and status.heldDigest with
+// the time it may start in status.nextWindowTime, and moves the next poll to that time so the
+// version is deployed as soon as the window opens.
+func (r *WerfBundleReconciler) checkSchedule(
+	ctx context.Context,
+	bundle *werfv1alpha1.WerfBundle,
+	tag, digest string,
+) (bool, ctrl.Result, error) {
+	log := ctrl.LoggerFrom(ctx)
+
+	// Already validated by Reconcile
+	deploySchedule, err := schedule.New(bundle.Spec.Schedule)
+	if err != nil {
+		return false, ctrl.Result{}, err
+	}
+	decision := deploySchedule.Check(time.Now())
+
+	if decision.Allowed {
+		if bundle.Status.HeldTag != "" {
+			log.Info("deployment window open, releasing held version", "tag", tag, "digest", digest)
+			meta.SetStatusCondition(&bundle.Status.Conditions, metav1.Condition{
+				Type:               werfv1alpha1.ConditionDeploymentHeld,
+				Status:             metav1.ConditionFalse,
+				Reason:             werfv1alpha1.ReasonWindowOpen,
+				Message:            fmt.Sprintf("Tag %s (%s) released in a deployment window", tag, digest),
+				ObservedGeneration: bundle.Generation,
+			})
+		}
+		bundle.Status.HeldTag = ""
+		bundle.Status.HeldDigest = ""
+		bundle.Status.NextWindowTime = nil
+		return true, ctrl.Result{}, nil
+	}
+
+	reason := werfv1alpha1.ReasonOutsideWindow
+	message := fmt.Sprintf("Tag %s (%s) is outside the deployment windows", tag, digest)
+	if decision.Freeze != nil {
+		reason = werfv1alpha1.ReasonChangeFreeze
+		message = fmt.Sprintf("Tag %s (%s) is held by a change freeze until %s",
+			tag, digest, decision.Freeze.End.UTC().Format(time.RFC3339))
+		if decision.Freeze.Reason != "" {
+			message += " (" + decision.Freeze.Reason + ")"
+		}
+	}
+	if decision.Next.IsZero() {
+		message += "; no deployment window opens in the next five years"
+		bundle.Status.NextWindowTime = nil
+	} else {
+		message += "; it will be deployed at " + decision.Next.UTC().Format(time.RFC3339)
+		next := metav1.NewTime(decision.Next)
+		bundle.Status.NextWindowTime = &next
+		// Poll again when the window opens, rather than at the next poll interval
+		if bundle.Status.NextPollTime == nil || decision.Next.Before(bundle.Status.NextPollTime.Time) {
+			bundle.Status.NextPollTime = &next
+		}
+	}
+
+	if bundle.Status.HeldTag != tag || bundle.Status.HeldDigest != digest {
+		log.Info("version held by the deployment schedule, not creating Job",
+			"tag", tag, "digest", digest, "reason", reason, "next", decision.Next)
+	}
+	bundle.Status.HeldTag = tag
+	bundle.Status.HeldDigest = digest
+	// Opening a window doesn't change the tag list: drop its ETag so the next poll selects
+	// the held version again even if nothing was deployed yet
+	bundle.Status.LastETag = ""
+	meta.SetStatusCondition(&bundle.Status.Conditions, metav1.Condition{
+		Type:               werfv1alpha1.ConditionDeploymentHeld,
+		Status:             metav1.ConditionTrue,
+		Reason:             reason,
+		Message:            message,
+		ObservedGeneration: bundle.Generation,
+	})
+	if err := r.Status().Update(ctx, bundle); err != nil {
+		log.Error(err, "failed to record held version in status")
+		return false, ctrl.Result{}, err
+	}
+	return false, requeueAtNextPoll(bundle), nil
+}
+
+// approves reports whether the werf.io/approve annotation value approves tag resolved to
+// digest: it names the digest, or both as <tag>@<digest>. The tag alone doesn't approve
+// anything, as a re-pushed tag would be approved with it.
+func approves(value, tag, digest string) bool {
+	value = strings.TrimSpace(value)
+	return value != "" && (value == digest || value == tag+"@"+digest)
+}
+
+// approvalManager returns the field manager that last set the werf.io/approve annotation,
+// and when, from the bundle's managedFields. Returns "" if no entry owns the annotation.
+func approvalManager(bundle *werfv1alpha1.WerfBundle) (string, *metav1.Time) {
+	var manager string
+	var at *metav1.Time
+	for _, entry := range bundle.ManagedFields {
+		if entry.FieldsV1 == nil {
+			continue
+		}
+		var fields map[string]any
+		if err := json.Unmarshal(entry.FieldsV1.Raw, &fields); err != nil {
+			continue
+		}
+		metadata, _ := fields["f:metadata"].(map[string]any)
+		annotations, _ := metadata["f:annotations"].(map[string]any)
+		if _, ok := annotations["f:"+werfv1alpha1.ApprovalAnnotation]; !ok {
+			continue
+		}
+		if at == nil || (entry.Time != nil && entry.Time.After(at.Time)) {
+			manager, at = entry.Manager, entry.Time
+		}
+	}
+	return manager, at
+}
+
+// verifyBundle checks that digest is signed by one of the public keys referenced by spec.verify.
+// Returns verified=true if the Job may be created. Otherwise returns the result to hand back
+// to controller-runtime:
+//   - an unsigned or untrusted bundle, or missing keys, marks the bundle Failed with reason
+//     VerificationFailed and requeues for the next poll, so signing the bundle or
+//     fixing the keys is picked up without editing the bundle
+//   - registry failures while fetching signatures are retried with backoff like poll failures
+//   - API errors reading the keys are returned so controller-runtime retries them
+func (r *WerfBundleReconciler) verifyBundle(
+	ctx context.Context,
+	bundle *werfv1alpha1.WerfBundle,
+	auth authn.Authenticator,
+	digest string,
+) (bool, ctrl.Result, error) {
+	log := ctrl.LoggerFrom(ctx)
+
+	keys, err := r.getVerificationKeys(ctx, bundle)
+	if err == nil {
+		err = r.RegistryClient.VerifyCosignSignature(ctx, registryURL(bundle), digest, auth, keys)
+	}
+
+	var verificationErr *registry.VerificationError
+	switch {
+	case err == nil:
+		log.Info("bundle signature verified", "digest", digest)
+		return true, ctrl.Result{}, nil
+	case errors.As(err, &verificationErr):
+		// Handled below
+	case keys == nil:
+		log.Error(err, "failed to load verification keys")
+		return false, ctrl.Result{}, err
+	default:
+		result, err := r.handleRegistryError(ctx, bundle, fmt.Errorf("verify %s: %w", digest, err))
+		return false, result, err
+	}
+
+	log.Info("bundle signature verification failed, not creating Job", "digest", digest, "error", err.Error())
+	now := metav1.Now()
+	bundle.Status.LastErrorTime = &now
+	if err := r.updateStatusFailedWithReason(ctx, bundle,
+		werfv1alpha1.FailureReasonVerificationFailed, fmt.Sprintf("Bundle %s: %v", digest, err)); err != nil {
+		log.Error(err, "failed to update status after signature verification failure")
+		return false, ctrl.Result{}, err
+	}
+	return false, requeueAtNextPoll(bundle), nil
+}
+
+// checkAttestations checks that digest has the referrers required by spec.attestations.
+// Returns attested=true if the Job may be created. Otherwise returns the result to hand back
+// to controller-runtime:
+//   - a missing attestation, or one not satisfying the requirement, marks the bundle Failed
+//     with reason AttestationFailed and requeues for the next poll, so attaching the
+//     attestation later is picked up without editing the bundle
+//   - registry failures while fetching referrers are retried with backoff like poll failures
+func (r *WerfBundleReconciler) checkAttestations(
+	ctx context.Context,
+	bundle *werfv1alpha1.WerfBundle,
+	auth authn.Authenticator,
+	digest string,
+) (bool, ctrl.Result, error) {
+	log := ctrl.LoggerFrom(ctx)
+
+	requirements := make([]registry.AttestationRequirement, 0, len(bundle.Spec.Attestations))
+	for _, spec := range bundle.Spec.Attestations {
+		requirement := registry.AttestationRequirement{
+			ArtifactType: spec.ArtifactType,
+			Annotations:  spec.Annotations,
+		}
+		for _, predicate := range spec.Predicates {
+			requirement.Predicates = append(requirement.Predicates, registry.AttestationPredicate{
+				Path:     predicate.Path,
+				Operator: predicate.Operator,
+				Value:    predicate.Value,
+			})
+		}
+		requirements = append(requirements, requirement)
+	}
+
+	err := r.RegistryClient.VerifyAttestations(ctx, registryURL(bundle), digest, auth, requirements)
+	var attestationErr *registry.AttestationError
+	switch {
+	case err == nil:
+		log.Info("bundle attestations verified", "digest", digest)
+		return true, ctrl.Result{}, nil
+	case !errors.As(err, &attestationErr):
+		result, err := r.handleRegistryError(ctx, bundle, fmt.Errorf("attestations of %s: %w", digest, err))
+		return false, result, err
+	}
+
+	log.Info("bundle attestation requirements not met, not creating Job", "digest", digest, "error", err.Error())
+	now := metav1.Now()
+	bundle.Status.LastErrorTime = &now
+	if err := r.updateStatusFailedWithReason(ctx, bundle,
+		werfv1alpha1.FailureReasonAttestationFailed, fmt.Sprintf("Bundle %s: %v", digest, err)); err != nil {
+		log.Error(err, "failed to update status after attestation failure")
+		return false, ctrl.Result{}, err
+	}
+	return false, requeueAtNextPoll(bundle), nil
+}
+
+// getVerificationKeys loads the public keys from the Secret referenced by spec.verify.secretRef.
+// The Secret is only looked up in the bundle namespace: whoever can write to the target
+// namespace must not be able to choose which keys are trusted.
+// Returns a registry.VerificationError if the Secret is missing or holds no valid keys.
+func (r *WerfBundleReconciler) getVerificationKeys(
+	ctx context.Context,
+	bundle *werfv1alpha1.WerfBundle,
+) ([]crypto.PublicKey, error) {
+	secretName := bundle.Spec.Verify.SecretRef.Name
+	secret := &corev1.Secret{}
+	err := r.Get(ctx, types.NamespacedName{Name: secretName, Namespace: bundle.Namespace}, secret)
+	if apierrors.IsNotFound(err) {
+		return nil, &registry.VerificationError{Err: fmt.Errorf("public key secret %q not found in namespace %q",
+			secretName, bundle.Namespace)}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get public key secret %q: %w", secretName, err)
+	}
+	return registry.PublicKeysFromSecret(secret)
+}
+
 // monitorJobCompletion checks the status of a running job and updates bundle status accordingly.
-// Returns a requeue result if the job is still running.
-// Returns nil, nil if the job completes (success or failure).
+// Returns a requeue result if the job is still running, or for the next poll if it succeeded.
+// Returns nil, nil if the job fails, unless spec.rollback.onFailure starts a rollback Job.
 func (r *WerfBundleReconciler) monitorJobCompletion(
 	ctx context.Context,
 	bundle *werfv1alpha1.WerfBundle,
@@ -404,9 +1992,33 @@ func (r *WerfBundleReconciler) monitorJobCompletion(
 
 	// Check Job status
 	if job.Status.Succeeded > 0 {
-		log.Info("Job succeeded, updating status to Synced", "tag", latestTag, "jobName", job.Name)
+		// Record what this Job deployed: the selection may have moved on while it ran,
+		// and the newer tag or digest still needs its own converge
+		appliedTag, appliedDigest := jobVersion(job, latestTag, bundle.Status.LastAppliedDigest)
+		bundle.Status.LastAppliedDigest = appliedDigest
+		bundle.Status.LastSuccessfulTag = appliedTag
+		bundle.Status.LastSuccessfulDigest = appliedDigest
+
+		log.Info("Job succeeded, updating status to Synced", "tag", appliedTag, "jobName", job.Name)
 		bundle.Status.LastJobStatus = werfv1alpha1.JobStatusSucceeded
 		bundle.Status.ActiveJobName = ""
+		r.cleanupRegistrySecret(ctx, job)
+
+		if rollback := bundle.Status.LastRollback; rollback != nil && rollback.JobName == job.Name {
+			now := metav1.Now()
+			rollback.Result = werfv1alpha1.JobStatusSucceeded
+			rollback.CompletionTime = &now
+			meta.SetStatusCondition(&bundle.Status.Conditions, metav1.Condition{
+				Type:               werfv1alpha1.ConditionRolledBack,
+				Status:             metav1.ConditionTrue,
+				Reason:             werfv1alpha1.ReasonRollbackSucceeded,
+				Message:            fmt.Sprintf("Tag %s failed to deploy, rolled back to %s", rollback.FailedTag, rollback.Tag),
+				ObservedGeneration: bundle.Generation,
+			})
+		} else {
+			// A new version is deployed: the rollback, if any, is history
+			meta.RemoveStatusCondition(&bundle.Status.Conditions, werfv1alpha1.ConditionRolledBack)
+		}
 
 		// Capture job logs for debugging
 		jobLogs, err := converge.CaptureJobLogs(ctx, r.Client, r.Clientset, job.Name, job.Namespace)
@@ -422,17 +2034,18 @@ func (r *WerfBundleReconciler) monitorJobCompletion(
 			}
 		}
 
-		if err := r.updateStatusSynced(ctx, bundle, latestTag); err != nil {
+		if err := r.updateStatusSynced(ctx, bundle, appliedTag); err != nil {
 			log.Error(err, "failed to update status after job success")
 			return ctrl.Result{}, err
 		}
-		return ctrl.Result{}, nil
+		return requeueAtNextPoll(bundle), nil
 	}
 
 	if job.Status.Failed > 0 {
 		log.Info("Job failed", "jobName", job.Name)
 		bundle.Status.LastJobStatus = werfv1alpha1.JobStatusFailed
 		bundle.Status.ActiveJobName = ""
+		r.cleanupRegistrySecret(ctx, job)
 
 		// Capture job logs for debugging
 		jobLogs, err := converge.CaptureJobLogs(ctx, r.Client, r.Clientset, job.Name, job.Namespace)
@@ -448,6 +2061,17 @@ func (r *WerfBundleReconciler) monitorJobCompletion(
 			}
 		}
 
+		// Don't deploy the failed version again until it's re-pushed or the spec changes
+		failedTag, failedDigest := jobVersion(job, latestTag, bundle.Status.LastAppliedDigest)
+		recordFailedVersion(bundle, failedTag, failedDigest, metav1.Now())
+		// The tag list may not change before the failed tag is re-pushed: drop its ETag so the
+		// next polls select from it again instead of only checking the applied tag
+		bundle.Status.LastETag = ""
+
+		if rollbackEnabled(bundle) {
+			return r.rollBack(ctx, bundle, job, failedTag, failedDigest)
+		}
+
 		if err := r.updateStatusFailed(ctx, bundle,
 			"Job failed, see job logs for details"); err != nil {
 			log.Error(err, "failed to update status after job failure")
@@ -461,6 +2085,301 @@ func (r *WerfBundleReconciler) monitorJobCompletion(
 	return ctrl.Result{RequeueAfter: 5 * time.Second}, nil
 }
 
+// jobVersion returns the tag and digest a converge Job deploys, from its annotations.
+// Jobs created before the annotations fall back to the tag label, then to tag and digest.
+func jobVersion(job *batchv1.Job, tag, digest string) (string, string) {
+	if jobTag, ok := job.Annotations[converge.TagAnnotation]; ok {
+		tag = jobTag
+	} else if jobTag, ok := job.Labels[converge.TagLabel]; ok {
+		tag = jobTag
+	}
+	if jobDigest, ok := job.Annotations[converge.DigestAnnotation]; ok {
+		digest = jobDigest
+	}
+	return tag, digest
+}
+
+// rollbackEnabled reports whether a failed converge rolls back to the last successfully
+// deployed version.
+func rollbackEnabled(bundle *werfv1alpha1.WerfBundle) bool {
+	return bundle.Spec.Rollback != nil && bundle.Spec.Rollback.OnFailure
+}
+
+// rollBack handles a failed converge Job of failedTag at failedDigest with
+// spec.rollback.onFailure set: a converge of the last successfully deployed version is
+// started. The rollback skips the checks of ensureJobExists (approval, schedule): it
+// restores what was running before.
+// If the failed Job was itself a rollback, or there is nothing to roll back to, the bundle is
+// marked Failed.
+// Returns a requeue result to monitor the rollback Job, or nil, nil if there is none.
+func (r *WerfBundleReconciler) rollBack(
+	ctx context.Context,
+	bundle *werfv1alpha1.WerfBundle,
+	job *batchv1.Job,
+	failedTag, failedDigest string,
+) (ctrl.Result, error) {
+	log := ctrl.LoggerFrom(ctx)
+	now := metav1.Now()
+
+	// Never roll back a failed rollback: that would loop between two broken versions
+	if rollback := bundle.Status.LastRollback; rollback != nil && rollback.JobName == job.Name {
+		log.Info("rollback Job failed", "jobName", job.Name, "tag", rollback.Tag)
+		message := fmt.Sprintf("Tag %s failed to deploy, and so did the rollback to %s, see job logs for details",
+			rollback.FailedTag, rollback.Tag)
+		return ctrl.Result{}, r.failRollback(ctx, bundle, message)
+	}
+
+	tag, digest := bundle.Status.LastSuccessfulTag, bundle.Status.LastSuccessfulDigest
+	if tag == "" || (tag == failedTag && digest == failedDigest) {
+		log.Info("Job failed with no version to roll back to", "jobName", job.Name, "tag", failedTag)
+		if err := r.updateStatusFailed(ctx, bundle,
+			"Job failed, see job logs for details; no successfully deployed version to roll back to"); err != nil {
+			log.Error(err, "failed to update status after job failure")
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{}, nil
+	}
+
+	log.Info("Job failed, rolling back to the last successfully deployed version",
+		"jobName", job.Name, "failedTag", failedTag, "tag", tag, "digest", digest)
+	bundle.Status.LastRollback = &werfv1alpha1.RollbackStatus{
+		FailedTag:    failedTag,
+		FailedDigest: failedDigest,
+		Tag:          tag,
+		Digest:       digest,
+		Result:       werfv1alpha1.JobStatusRunning,
+		StartTime:    now,
+	}
+	meta.SetStatusCondition(&bundle.Status.Conditions, metav1.Condition{
+		Type:               werfv1alpha1.ConditionRolledBack,
+		Status:             metav1.ConditionTrue,
+		Reason:             werfv1alpha1.ReasonRollbackInProgress,
+		Message:            fmt.Sprintf("Tag %s failed to deploy, rolling back to %s", failedTag, tag),
+		ObservedGeneration: bundle.Generation,
+	})
+
+	result, err := r.createJob(ctx, bundle, tag, digest)
+	if err != nil {
+		return result, err
+	}
+	if bundle.Status.ActiveJobName == "" {
+		// createJob marked the bundle Failed with the reason the Job couldn't be created
+		message := fmt.Sprintf("Tag %s failed to deploy, and the rollback to %s couldn't start: %s",
+			failedTag, tag, bundle.Status.LastErrorMessage)
+		return ctrl.Result{}, r.failRollback(ctx, bundle, message)
+	}
+
+	bundle.Status.LastRollback.JobName = bundle.Status.ActiveJobName
+	if err := r.Status().Update(ctx, bundle); err != nil {
+		log.Error(err, "failed to record rollback Job in status")
+		return ctrl.Result{}, err
+	}
+	return result, nil
+}
+
+// failRollback records that the rollback in status.lastRollback failed and marks the bundle
+// Failed with reason RollbackFailed.
+func (r *WerfBundleReconciler) failRollback(
+	ctx context.Context,
+	bundle *werfv1alpha1.WerfBundle,
+	message string,
+) error {
+	now := metav1.Now()
+	bundle.Status.LastRollback.Result = werfv1alpha1.JobStatusFailed
+	bundle.Status.LastRollback.CompletionTime = &now
+	meta.SetStatusCondition(&bundle.Status.Conditions, metav1.Condition{
+		Type:               werfv1alpha1.ConditionRolledBack,
+		Status:             metav1.ConditionFalse,
+		Reason:             werfv1alpha1.ReasonRollbackFailed,
+		Message:            message,
+		ObservedGeneration: bundle.Generation,
+	})
+	return r.updateStatusFailedWithReason(ctx, bundle, werfv1alpha1.FailureReasonRollbackFailed, message)
+}
+
+// recordFailedVersion adds tag and digest to status.failedVersions, most recent last, keeping
+// at most maxFailedVersions of them.
+func recordFailedVersion(bundle *werfv1alpha1.WerfBundle, tag, digest string, now metav1.Time) {
+	failed := make([]werfv1alpha1.FailedVersion, 0, len(bundle.Status.FailedVersions)+1)
+	for _, version := range bundle.Status.FailedVersions {
+		if version.Tag != tag || version.Digest != digest {
+			failed = append(failed, version)
+		}
+	}
+	failed = append(failed, werfv1alpha1.FailedVersion{Tag: tag, Digest: digest, FailedAt: now})
+	if len(failed) > maxFailedVersions {
+		failed = failed[len(failed)-maxFailedVersions:]
+	}
+	bundle.Status.FailedVersions = failed
+}
+
+// isFailedVersion reports whether the converge of tag at digest failed before
+// (see monitorJobCompletion).
+func isFailedVersion(bundle *werfv1alpha1.WerfBundle, tag, digest string) bool {
+	for _, version := range bundle.Status.FailedVersions {
+		if version.Tag == tag && version.Digest == digest {
+			return true
+		}
+	}
+	return false
+}
+
+// resolveRegistryDockerConfig renders the credentials from spec.registry.secretRef for the
+// registry the bundle is deployed from (see registryURL) as a Docker config.json for the
+// converge Job.
+// Without a secretRef, or when it has no credentials for a mirror, the credentials of the
+// credential provider plugins are rendered instead.
+// Returns nil, nil when neither has credentials for the registry (anonymous access).
+func (r *WerfBundleReconciler) resolveRegistryDockerConfig(
+	ctx context.Context,
+	bundle *werfv1alpha1.WerfBundle,
+) ([]byte, error) {
+	secret, err := r.getRegistrySecret(ctx, bundle)
+	if err != nil {
+		return nil, err
+	}
+	repoURL := registryURL(bundle)
+	if secret != nil && (repoURL == bundle.Spec.Registry.URL || registry.HasCredentials(secret, repoURL)) {
+		return registry.DockerConfigJSON(secret, repoURL)
+	}
+	cfg, err := r.CredentialProviders.Lookup(ctx, repoURL)
+	if err != nil || cfg == nil {
+		return nil, err
+	}
+	return registry.DockerConfigJSONFromAuth(cfg, repoURL)
+}
+
+// resolveRegistryTLS loads the certificates referenced by spec.registry.tls.
+// Returns nil, nil when spec.registry.tls isn't set.
+// Returns a registry.TLSError if a referenced ConfigMap, Secret or key is missing, or the
+// certificates are invalid.
+func (r *WerfBundleReconciler) resolveRegistryTLS(
+	ctx context.Context,
+	bundle *werfv1alpha1.WerfBundle,
+) (*registry.TLSConfig, error) {
+	spec := bundle.Spec.Registry.TLS
+	if spec == nil {
+		return nil, nil
+	}
+
+	cfg := &registry.TLSConfig{Insecure: spec.Insecure}
+	if ca := spec.CABundle; ca != nil {
+		key := ca.Key
+		if key == "" {
+			key = registry.DefaultCABundleKey
+		}
+		var kind, objName string
+		switch {
+		case ca.ConfigMapRef != nil:
+			kind, objName = "ConfigMap", ca.ConfigMapRef.Name
+			configMap := &corev1.ConfigMap{}
+			if err := r.getTLSObject(ctx, bundle.Namespace, kind, objName, configMap); err != nil {
+				return nil, err
+			}
+			cfg.CACerts = []byte(configMap.Data[key])
+			if len(cfg.CACerts) == 0 {
+				cfg.CACerts = configMap.BinaryData[key]
+			}
+		case ca.SecretRef != nil:
+			kind, objName = "Secret", ca.SecretRef.Name
+			secret := &corev1.Secret{}
+			if err := r.getTLSObject(ctx, bundle.Namespace, kind, objName, secret); err != nil {
+				return nil, err
+			}
+			cfg.CACerts = secret.Data[key]
+		}
+		if len(cfg.CACerts) == 0 {
+			return nil, &registry.TLSError{Err: fmt.Errorf("CA bundle %s %q has no key %q", kind, objName, key)}
+		}
+	}
+	if ref := spec.ClientCertSecretRef; ref != nil {
+		secret := &corev1.Secret{}
+		if err := r.getTLSObject(ctx, bundle.Namespace, "Secret", ref.Name, secret); err != nil {
+			return nil, err
+		}
+		cfg.ClientCert = secret.Data[corev1.TLSCertKey]
+		cfg.ClientKey = secret.Data[corev1.TLSPrivateKeyKey]
+		if len(cfg.ClientCert) == 0 || len(cfg.ClientKey) == 0 {
+			return nil, &registry.TLSError{Err: fmt.Errorf("client certificate Secret %q needs keys %s and %s",
+				ref.Name, corev1.TLSCertKey, corev1.TLSPrivateKeyKey)}
+		}
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// proxyFor returns the proxy of the bundle: spec.registry.proxy if set, the operator-wide
+// proxy otherwise.
+func (r *WerfBundleReconciler) proxyFor(bundle *werfv1alpha1.WerfBundle) *registry.ProxyConfig {
+	if spec := bundle.Spec.Registry.Proxy; spec != nil {
+		return &registry.ProxyConfig{HTTPProxy: spec.HTTPProxy, HTTPSProxy: spec.HTTPSProxy, NoProxy: spec.NoProxy}
+	}
+	return r.Proxy
+}
+
+// getTLSObject fetches a ConfigMap or Secret referenced by spec.registry.tls from the bundle
+// namespace. Like verification keys, certificates are never read from the target namespace.
+// Returns a registry.TLSError if the object doesn't exist.
+func (r *WerfBundleReconciler) getTLSObject(
+	ctx context.Context,
+	namespace, kind, name string,
+	obj client.Object,
+) error {
+	err := r.Get(ctx, types.NamespacedName{Name: name, Namespace: namespace}, obj)
+	if apierrors.IsNotFound(err) {
+		return &registry.TLSError{Err: fmt.Errorf("%s %q not found in namespace %q", kind, name, namespace)}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get %s %q from namespace %q: %w", kind, name, namespace, err)
+	}
+	return nil
+}
+
+// createRegistrySecret creates the Secret with registry credentials for job in the job namespace.
+// An existing Secret is left in place: it was created for the same Job by an earlier reconcile.
+func (r *WerfBundleReconciler) createRegistrySecret(
+	ctx context.Context,
+	jobBuilder *converge.Builder,
+	job *batchv1.Job,
+) error {
+	secret, err := jobBuilder.BuildRegistrySecret(job)
+	if err != nil {
+		return err
+	}
+	if err := r.Create(ctx, secret); err != nil && !apierrors.IsAlreadyExists(err) {
+		return err
+	}
+	return nil
+}
+
+// cleanupRegistrySecret removes the registry credentials copied for a finished Job.
+// The Secret is owned by the Job and would be garbage-collected with it, but Jobs are
+// kept for log retention, so credentials are removed as soon as they're no longer needed.
+// Failures are logged only: the owner reference still guarantees eventual cleanup.
+func (r *WerfBundleReconciler) cleanupRegistrySecret(ctx context.Context, job *batchv1.Job) {
+	if err := r.deleteRegistrySecret(ctx, job.Name, job.Namespace); err != nil {
+		ctrl.LoggerFrom(ctx).Error(err, "failed to delete registry credentials for finished job", "jobName", job.Name)
+	}
+}
+
+// deleteRegistrySecret deletes the registry credentials Secret for jobName.
+// Returns nil if the Secret doesn't exist (e.g. the bundle uses anonymous access).
+func (r *WerfBundleReconciler) deleteRegistrySecret(ctx context.Context, jobName, namespace string) error {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      converge.RegistrySecretName(jobName),
+			Namespace: namespace,
+		},
+	}
+	if err := r.Delete(ctx, secret); err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete registry secret %q: %w", secret.Name, err)
+	}
+	return nil
+}
+
 // updateStatusSyncing sets status to Syncing and clears error.
 // Returns error if status update fails so caller can decide to requeue.
 func (r *WerfBundleReconciler) updateStatusSyncing(
@@ -472,12 +2391,28 @@ func (r *WerfBundleReconciler) updateStatusSyncing(
 	if tag != "" {
 		bundle.Status.LastAppliedTag = tag
 	}
+	bundle.Status.FailureReason = ""
 	bundle.Status.LastErrorMessage = ""
 	bundle.Status.LastSyncTime = nil
 
 	return r.Status().Update(ctx, bundle)
 }
 
+// updateStatusRetrying keeps status in Syncing while recording a retryable error.
+// Unlike updateStatusSyncing, the last applied tag is left untouched.
+// Returns error if status update fails so caller can decide to requeue.
+func (r *WerfBundleReconciler) updateStatusRetrying(
+	ctx context.Context,
+	bundle *werfv1alpha1.WerfBundle,
+	errMsg string,
+) error {
+	bundle.Status.Phase = werfv1alpha1.PhaseSyncing
+	bundle.Status.FailureReason = ""
+	bundle.Status.LastErrorMessage = errMsg
+
+	return r.Status().Update(ctx, bundle)
+}
+
 // updateStatusSynced sets status to Synced with timestamp.
 // Returns error if status update fails so caller can decide to requeue.
 func (r *WerfBundleReconciler) updateStatusSynced(
@@ -487,6 +2422,7 @@ func (r *WerfBundleReconciler) updateStatusSynced(
 ) error {
 	bundle.Status.Phase = werfv1alpha1.PhaseSynced
 	bundle.Status.LastAppliedTag = tag
+	bundle.Status.FailureReason = ""
 	bundle.Status.LastErrorMessage = ""
 	now := metav1.Now()
 	bundle.Status.LastSyncTime = &now
@@ -500,8 +2436,21 @@ func (r *WerfBundleReconciler) updateStatusFailed(
 	ctx context.Context,
 	bundle *werfv1alpha1.WerfBundle,
 	errMsg string,
+) error {
+	return r.updateStatusFailedWithReason(ctx, bundle, "", errMsg)
+}
+
+// updateStatusFailedWithReason sets status to Failed with a machine-readable reason
+// (one of the werfv1alpha1.FailureReason constants, or empty) and error message.
+// Returns error if status update fails so caller can decide to requeue.
+func (r *WerfBundleReconciler) updateStatusFailedWithReason(
+	ctx context.Context,
+	bundle *werfv1alpha1.WerfBundle,
+	reason string,
+	errMsg string,
 ) error {
 	bundle.Status.Phase = werfv1alpha1.PhaseFailed
+	bundle.Status.FailureReason = reason
 	bundle.Status.LastErrorMessage = errMsg
 
 	return r.Status().Update(ctx, bundle)
@@ -572,10 +2521,38 @@ func (r *WerfBundleReconciler) SetupWithManager(mgr ctrl.Manager) error {
 
 	// Ignore status subresource updates to avoid infinite reconciliation
 	pred := predicate.GenerationChangedPredicate{}
+	// Approving a pending version only changes an annotation, not the generation
+	approvalChanged := predicate.Funcs{
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			return e.ObjectOld.GetAnnotations()[werfv1alpha1.ApprovalAnnotation] !=
+				e.ObjectNew.GetAnnotations()[werfv1alpha1.ApprovalAnnotation]
+		},
+	}
 
-	return ctrl.NewControllerManagedBy(mgr).
-		For(&werfv1alpha1.WerfBundle{}).
-		Owns(&batchv1.Job{}).
-		WithEventFilter(pred).
-		Complete(r)
+	// Triggered bundles poll right away instead of waiting for status.nextPollTime
+	pollNow := handler.TypedFuncs[*werfv1alpha1.WerfBundle, reconcile.Request]{
+		GenericFunc: func(
+			_ context.Context,
+			e event.TypedGenericEvent[*werfv1alpha1.WerfBundle],
+			q workqueue.TypedRateLimitingInterface[reconcile.Request],
+		) {
+			key := client.ObjectKeyFromObject(e.Object)
+			r.requestPoll(key)
+			q.Add(reconcile.Request{NamespacedName: key})
+		},
+	}
+	r.repositoryEvents = make(chan event.TypedGenericEvent[*werfv1alpha1.WerfBundle], 100)
+
+	b := ctrl.NewControllerManagedBy(mgr).
+		For(&werfv1alpha1.WerfBundle{}, builder.WithPredicates(predicate.Or(pred, approvalChanged))).
+		Owns(&batchv1.Job{}, builder.WithPredicates(pred)).
+		// Creating or fixing registry credentials retries Failed bundles right away.
+		// Only Secret metadata is cached, and Secrets are read uncached (see cmd/main.go);
+		// the resource version changes with the data
+		Watches(&corev1.Secret{}, handler.EnqueueRequestsFromMapFunc(r.bundlesForSecret), builder.OnlyMetadata).
+		WatchesRawSource(source.Channel(r.repositoryEvents, pollNow))
+	if r.Triggers != nil {
+		b = b.WatchesRawSource(source.Channel(r.Triggers, pollNow))
+	}
+	return b.Complete(r)
 }
@@ -17,6 +17,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	werfv1alpha1 "github.com/werf/k8s-werf-operator-go/api/v1alpha1"
+	"github.com/werf/k8s-werf-operator-go/internal/registry"
 )
 
 func TestReconcile_CreateWerfBundle_CreatesJob(t *testing.T) {
@@ -405,6 +406,8 @@ func TestReconcile_RegistryError_ExponentialBackoff(t *testing.T) {
 	}
 
 	for i, expectedBackoff := range expectedBackoffs {
+		// Each reconcile stands for the retry after the previous backoff elapsed
+		reconciler.requestPoll(req.NamespacedName)
 		result, err := reconciler.Reconcile(ctx, req)
 		if err != nil {
 			t.Fatalf("reconcile %d failed: %v", i, err)
@@ -496,9 +499,9 @@ func TestReconcile_FifthFailure_MarksAsFailed(t *testing.T) {
 		t.Fatalf("reconcile failed: %v", err)
 	}
 
-	// Should NOT requeue after exceeding max retries
-	if result.RequeueAfter != 0 {
-		t.Errorf("expected no requeue after max retries, got %v", result.RequeueAfter)
+	// Failed bundles keep retrying, at the long Failed interval instead of the backoff
+	if result.RequeueAfter < failedRetryInterval/2 {
+		t.Errorf("expected requeue at the Failed retry interval after max retries, got %v", result.RequeueAfter)
 	}
 
 	// Verify status is Failed
@@ -511,11 +514,88 @@ func TestReconcile_FifthFailure_MarksAsFailed(t *testing.T) {
 		t.Errorf("expected phase Failed, got %s", updatedBundle.Status.Phase)
 	}
 
+	if updatedBundle.Status.FailureReason != werfv1alpha1.FailureReasonRegistryUnavailable {
+		t.Errorf("expected failure reason %s, got %q",
+			werfv1alpha1.FailureReasonRegistryUnavailable, updatedBundle.Status.FailureReason)
+	}
+
 	if updatedBundle.Status.LastErrorMessage == "" {
 		t.Error("expected error message to be set")
 	}
 }
 
+func TestReconcile_RateLimited_RequeuesAtRetryAfter(t *testing.T) {
+	ctx := context.Background()
+
+	bundleName := "test-rate-limited"
+	bundle := &werfv1alpha1.WerfBundle{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      bundleName,
+			Namespace: "default",
+		},
+		Spec: werfv1alpha1.WerfBundleSpec{
+			Registry: werfv1alpha1.RegistryConfig{
+				URL: "ghcr.io/test/rate-limited",
+			},
+			Converge: werfv1alpha1.ConvergeConfig{
+				ServiceAccountName: "default",
+			},
+		},
+	}
+
+	if err := testk8sClient.Create(ctx, bundle); err != nil {
+		t.Fatalf("failed to create WerfBundle: %v", err)
+	}
+
+	// Registry answers 429 with Retry-After: 600
+	fakeReg := NewFakeRegistry()
+	fakeReg.SetError("ghcr.io/test/rate-limited", &registry.RateLimitedError{
+		RetryAfter: 10 * time.Minute,
+		Err:        fmt.Errorf("HTTP 429: Too Many Requests"),
+	})
+
+	reconciler := &WerfBundleReconciler{
+		Client:         testk8sClient,
+		Scheme:         testk8sClient.Scheme(),
+		RegistryClient: fakeReg,
+		Clientset:      testK8sClientset,
+	}
+
+	req := reconcile.Request{
+		NamespacedName: types.NamespacedName{Name: bundleName, Namespace: "default"},
+	}
+
+	// More rate limited polls than maxConsecutiveFailures allows failures
+	for i := 0; i < maxConsecutiveFailures+2; i++ {
+		reconciler.requestPoll(req.NamespacedName)
+		result, err := reconciler.Reconcile(ctx, req)
+		if err != nil {
+			t.Fatalf("reconcile %d failed: %v", i, err)
+		}
+
+		// Requeued at the server-provided time, not the exponential backoff
+		if result.RequeueAfter != 10*time.Minute {
+			t.Errorf("reconcile %d: expected requeue after 10m, got %v", i, result.RequeueAfter)
+		}
+	}
+
+	updatedBundle := getWerfBundle(t, ctx, bundleName, "default")
+	if updatedBundle.Status.ConsecutiveFailures != 0 {
+		t.Errorf("expected rate limiting not to count as failure, got %d failures",
+			updatedBundle.Status.ConsecutiveFailures)
+	}
+	if updatedBundle.Status.Phase != werfv1alpha1.PhaseSyncing {
+		t.Errorf("expected phase Syncing while rate limited, got %s", updatedBundle.Status.Phase)
+	}
+	if updatedBundle.Status.NextPollTime == nil ||
+		time.Until(updatedBundle.Status.NextPollTime.Time) < 9*time.Minute {
+		t.Errorf("expected nextPollTime about 10m ahead, got %v", updatedBundle.Status.NextPollTime)
+	}
+	if !strings.Contains(updatedBundle.Status.LastErrorMessage, "rate limited") {
+		t.Errorf("expected rate limit error message, got %q", updatedBundle.Status.LastErrorMessage)
+	}
+}
+
 func TestReconcile_SuccessAfterFailures_ResetsCounter(t *testing.T) {
 	ctx := context.Background()
 
@@ -1735,6 +1815,7 @@ func TestReconcile_JobCompletesSuccessfully_StatusUpdatedToSynced(t *testing.T)
 	// This forces the controller to call ensureJobExists, which detects active job
 	// and calls monitorJobCompletion to handle the job completion
 	fakeReg.SetTags("ghcr.io/test/success-bundle", []string{"v1.0.0", "v1.0.1"})
+	reconciler.requestPoll(req.NamespacedName)
 
 	// Second reconcile - should detect new tag and monitor existing job for completion
 	result2, err := reconciler.Reconcile(ctx, req)
@@ -1742,9 +1823,9 @@ func TestReconcile_JobCompletesSuccessfully_StatusUpdatedToSynced(t *testing.T)
 		t.Fatalf("second reconcile failed: %v", err)
 	}
 
-	// On completion, should return no requeue (nil, nil)
-	if result2.RequeueAfter != 0 {
-		t.Errorf("expected no requeue after job completion, got %v", result2.RequeueAfter)
+	// On completion, should requeue for the next scheduled poll
+	if result2.RequeueAfter <= 5*time.Second || result2.RequeueAfter > defaultPollInterval+defaultPollInterval/10 {
+		t.Errorf("expected requeue at the next poll after job completion, got %v", result2.RequeueAfter)
 	}
 
 	// Get updated bundle
@@ -0,0 +1,182 @@
+package converge
+
+import (
+	"fmt"
+	"strings"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// RegistryCredentialsLabel marks Secrets holding registry credentials copied for a converge Job.
+	RegistryCredentialsLabel = "werf.io/registry-credentials"
+
+	// dockerConfigDir is where the copied registry credentials are mounted in the werf container.
+	dockerConfigDir = "/werf/docker-config"
+
+	registryCredentialsVolume = "registry-credentials"
+
+	// Keys of the registry TLS material in the copied Secret, named as in the Docker
+	// certs.d layout (/etc/docker/certs.d/<host>/{ca.crt,client.cert,client.key}).
+	registryCAKey         = "ca.crt"
+	registryClientCertKey = "client.cert"
+	registryClientKeyKey  = "client.key"
+
+	// dockerCertsDir is where Docker-compatible registry clients look for per-host certificates.
+	dockerCertsDir = "/etc/docker/certs.d"
+
+	// registryCADir holds the registry CA certificates, trusted by werf through SSL_CERT_DIR
+	// in addition to the system certificates.
+	registryCADir = "/werf/registry-ca"
+
+	// systemCertDir is the system certificate directory, kept in SSL_CERT_DIR.
+	systemCertDir = "/etc/ssl/certs"
+
+	registryTLSVolume = "registry-tls"
+	registryCAVolume  = "registry-ca"
+)
+
+// RegistrySecretName returns the name of the Secret holding registry credentials for a Job.
+// Derived from the Job name so cleanup only needs the Job name recorded in bundle status.
+func RegistrySecretName(jobName string) string {
+	return jobName + "-registry"
+}
+
+// NeedsRegistrySecret reports whether Jobs built by b mount the Secret created by
+// BuildRegistrySecret: registry credentials or TLS material are set.
+func (b *Builder) NeedsRegistrySecret() bool {
+	return len(b.registryCredentials) > 0 || len(b.registryCA) > 0 || len(b.registryClientCert) > 0
+}
+
+// BuildRegistrySecret creates the Secret holding registry credentials and TLS material for job.
+// The Secret lives in the Job namespace (the target namespace, which may differ from the
+// bundle namespace) and is owned by the Job, so Kubernetes garbage-collects it together
+// with the Job once the TTL expires. The controller deletes it earlier when the Job finishes.
+// The job must already exist in the cluster so its UID is known.
+func (b *Builder) BuildRegistrySecret(job *batchv1.Job) (*corev1.Secret, error) {
+	if !b.NeedsRegistrySecret() {
+		return nil, fmt.Errorf("registry credentials are not set")
+	}
+	if job == nil || job.UID == "" {
+		return nil, fmt.Errorf("job must be created before its registry Secret")
+	}
+
+	labels := make(map[string]string, len(job.Labels)+1)
+	for k, v := range job.Labels {
+		labels[k] = v
+	}
+	labels[RegistryCredentialsLabel] = "true"
+
+	// Without credentials there is no Docker config, and the Secret can't be a dockerconfigjson one
+	secretType := corev1.SecretTypeOpaque
+	data := make(map[string][]byte)
+	if len(b.registryCredentials) > 0 {
+		secretType = corev1.SecretTypeDockerConfigJson
+		data[corev1.DockerConfigJsonKey] = b.registryCredentials
+	}
+	if len(b.registryCA) > 0 {
+		data[registryCAKey] = b.registryCA
+	}
+	if len(b.registryClientCert) > 0 {
+		data[registryClientCertKey] = b.registryClientCert
+		data[registryClientKeyKey] = b.registryClientKey
+	}
+
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      RegistrySecretName(job.Name),
+			Namespace: job.Namespace,
+			Labels:    labels,
+			OwnerReferences: []metav1.OwnerReference{
+				{
+					APIVersion: batchv1.SchemeGroupVersion.String(),
+					Kind:       "Job",
+					Name:       job.Name,
+					UID:        job.UID,
+				},
+			},
+		},
+		Type: secretType,
+		Data: data,
+	}, nil
+}
+
+// addRegistryCredentials mounts the registry Secret into the werf container as DOCKER_CONFIG.
+// The same Secret is also used as an imagePullSecret in case the werf image itself is private.
+func addRegistryCredentials(podSpec *corev1.PodSpec, secretName string) {
+	podSpec.Volumes = append(podSpec.Volumes, corev1.Volume{
+		Name: registryCredentialsVolume,
+		VolumeSource: corev1.VolumeSource{
+			Secret: &corev1.SecretVolumeSource{
+				SecretName: secretName,
+				Items: []corev1.KeyToPath{
+					{Key: corev1.DockerConfigJsonKey, Path: "config.json"},
+				},
+			},
+		},
+	})
+	podSpec.ImagePullSecrets = append(podSpec.ImagePullSecrets, corev1.LocalObjectReference{Name: secretName})
+
+	container := &podSpec.Containers[0]
+	container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{
+		Name:      registryCredentialsVolume,
+		MountPath: dockerConfigDir,
+		ReadOnly:  true,
+	})
+	container.Env = append(container.Env, corev1.EnvVar{Name: "DOCKER_CONFIG", Value: dockerConfigDir})
+}
+
+// addRegistryTLS mounts the registry TLS material from the registry Secret into the werf
+// container: the CA certificates are added to the trusted roots through SSL_CERT_DIR, and
+// the CA and client certificates are laid out in /etc/docker/certs.d/<registry host>.
+func (b *Builder) addRegistryTLS(podSpec *corev1.PodSpec, secretName string) {
+	host, _, _ := strings.Cut(b.repositoryURL(), "/")
+	container := &podSpec.Containers[0]
+
+	var certsItems []corev1.KeyToPath
+	if len(b.registryCA) > 0 {
+		certsItems = append(certsItems, corev1.KeyToPath{Key: registryCAKey, Path: host + "/" + registryCAKey})
+
+		podSpec.Volumes = append(podSpec.Volumes, corev1.Volume{
+			Name: registryCAVolume,
+			VolumeSource: corev1.VolumeSource{
+				Secret: &corev1.SecretVolumeSource{
+					SecretName: secretName,
+					Items:      []corev1.KeyToPath{{Key: registryCAKey, Path: registryCAKey}},
+				},
+			},
+		})
+		container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{
+			Name:      registryCAVolume,
+			MountPath: registryCADir,
+			ReadOnly:  true,
+		})
+		container.Env = append(container.Env, corev1.EnvVar{
+			Name:  "SSL_CERT_DIR",
+			Value: registryCADir + ":" + systemCertDir,
+		})
+	}
+	if len(b.registryClientCert) > 0 {
+		certsItems = append(certsItems,
+			corev1.KeyToPath{Key: registryClientCertKey, Path: host + "/" + registryClientCertKey},
+			corev1.KeyToPath{Key: registryClientKeyKey, Path: host + "/" + registryClientKeyKey},
+		)
+	}
+
+	podSpec.Volumes = append(podSpec.Volumes, corev1.Volume{
+		Name: registryTLSVolume,
+		VolumeSource: corev1.VolumeSource{
+			Secret: &corev1.SecretVolumeSource{
+				SecretName: secretName,
+				Items:      certsItems,
+			},
+		},
+	})
+	container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{
+		Name:      registryTLSVolume,
+		MountPath: dockerCertsDir,
+		ReadOnly:  true,
+	})
+}
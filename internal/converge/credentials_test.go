@@ -0,0 +1,270 @@
+package converge
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	werfv1alpha1 "github.com/werf/k8s-werf-operator-go/api/v1alpha1"
+)
+
+const testDockerConfig = `{"auths":{"ghcr.io":{"auth":"dXNlcjpwYXNz"}}}`
+
+func newCredentialsTestBundle() *werfv1alpha1.WerfBundle {
+	return &werfv1alpha1.WerfBundle{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      testBundleName,
+			Namespace: "default",
+			UID:       "bundle-uid",
+		},
+		Spec: werfv1alpha1.WerfBundleSpec{
+			Registry: werfv1alpha1.RegistryConfig{
+				URL: "ghcr.io/test/bundle",
+			},
+			Converge: werfv1alpha1.ConvergeConfig{
+				ServiceAccountName: "werf-converge",
+				TargetNamespace:    "app-prod",
+			},
+		},
+	}
+}
+
+func TestBuilder_Build_WithRegistryCredentials(t *testing.T) {
+	builder := NewBuilder(newCredentialsTestBundle()).
+		WithScheme(testScheme).
+		WithRegistryCredentials([]byte(testDockerConfig))
+	job, err := builder.Build(context.Background(), "v1.0.0")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	secretName := RegistrySecretName(job.Name)
+	podSpec := job.Spec.Template.Spec
+
+	// Verify the Secret is mounted as a volume with config.json
+	if len(podSpec.Volumes) != 1 {
+		t.Fatalf("expected 1 volume, got %d", len(podSpec.Volumes))
+	}
+	volume := podSpec.Volumes[0]
+	if volume.Secret == nil || volume.Secret.SecretName != secretName {
+		t.Fatalf("expected volume from Secret %q, got %+v", secretName, volume.VolumeSource)
+	}
+	if len(volume.Secret.Items) != 1 || volume.Secret.Items[0].Key != corev1.DockerConfigJsonKey ||
+		volume.Secret.Items[0].Path != "config.json" {
+		t.Errorf("expected %s projected to config.json, got %+v", corev1.DockerConfigJsonKey, volume.Secret.Items)
+	}
+
+	// Verify imagePullSecrets
+	if len(podSpec.ImagePullSecrets) != 1 || podSpec.ImagePullSecrets[0].Name != secretName {
+		t.Errorf("expected imagePullSecrets [%s], got %v", secretName, podSpec.ImagePullSecrets)
+	}
+
+	// Verify the werf container mounts the volume and points DOCKER_CONFIG at it
+	container := podSpec.Containers[0]
+	if len(container.VolumeMounts) != 1 {
+		t.Fatalf("expected 1 volume mount, got %d", len(container.VolumeMounts))
+	}
+	mount := container.VolumeMounts[0]
+	if mount.Name != volume.Name || !mount.ReadOnly {
+		t.Errorf("expected read-only mount of volume %q, got %+v", volume.Name, mount)
+	}
+
+	var dockerConfig string
+	for _, env := range container.Env {
+		if env.Name == "DOCKER_CONFIG" {
+			dockerConfig = env.Value
+		}
+	}
+	if dockerConfig != mount.MountPath {
+		t.Errorf("DOCKER_CONFIG: got %q, want mount path %q", dockerConfig, mount.MountPath)
+	}
+}
+
+func TestBuilder_Build_WithoutRegistryCredentials(t *testing.T) {
+	job, err := NewBuilder(newCredentialsTestBundle()).
+		WithScheme(testScheme).
+		Build(context.Background(), "v1.0.0")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	podSpec := job.Spec.Template.Spec
+	if len(podSpec.Volumes) != 0 {
+		t.Errorf("expected no volumes, got %v", podSpec.Volumes)
+	}
+	if len(podSpec.ImagePullSecrets) != 0 {
+		t.Errorf("expected no imagePullSecrets, got %v", podSpec.ImagePullSecrets)
+	}
+	if len(podSpec.Containers[0].Env) != 0 {
+		t.Errorf("expected no env, got %v", podSpec.Containers[0].Env)
+	}
+}
+
+func TestBuilder_BuildRegistrySecret(t *testing.T) {
+	builder := NewBuilder(newCredentialsTestBundle()).
+		WithScheme(testScheme).
+		WithRegistryCredentials([]byte(testDockerConfig))
+	job, err := builder.Build(context.Background(), "v1.0.0")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	job.UID = "job-uid"
+
+	secret, err := builder.BuildRegistrySecret(job)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The Secret must land in the Job (target) namespace under the name the Job mounts
+	if secret.Namespace != "app-prod" {
+		t.Errorf("namespace: got %q, want %q", secret.Namespace, "app-prod")
+	}
+	if secret.Name != job.Spec.Template.Spec.Volumes[0].Secret.SecretName {
+		t.Errorf("name: got %q, want %q", secret.Name, job.Spec.Template.Spec.Volumes[0].Secret.SecretName)
+	}
+	if secret.Type != corev1.SecretTypeDockerConfigJson {
+		t.Errorf("type: got %q, want %q", secret.Type, corev1.SecretTypeDockerConfigJson)
+	}
+	if string(secret.Data[corev1.DockerConfigJsonKey]) != testDockerConfig {
+		t.Errorf("data: got %q, want %q", secret.Data[corev1.DockerConfigJsonKey], testDockerConfig)
+	}
+
+	if secret.Labels["werf.io/bundle"] != testBundleName {
+		t.Errorf("bundle label: got %q, want %q", secret.Labels["werf.io/bundle"], testBundleName)
+	}
+	if secret.Labels[RegistryCredentialsLabel] != "true" {
+		t.Errorf("expected %s label", RegistryCredentialsLabel)
+	}
+
+	// Owned by the Job so it's garbage-collected with it
+	if len(secret.OwnerReferences) != 1 {
+		t.Fatalf("expected 1 owner reference, got %d", len(secret.OwnerReferences))
+	}
+	owner := secret.OwnerReferences[0]
+	if owner.Kind != "Job" || owner.APIVersion != batchv1.SchemeGroupVersion.String() ||
+		owner.Name != job.Name || owner.UID != job.UID {
+		t.Errorf("unexpected owner reference: %+v", owner)
+	}
+}
+
+func TestBuilder_BuildRegistrySecret_Errors(t *testing.T) {
+	bundle := newCredentialsTestBundle()
+
+	job, err := NewBuilder(bundle).WithScheme(testScheme).Build(context.Background(), "v1.0.0")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// No credentials configured
+	job.UID = "job-uid"
+	if _, err := NewBuilder(bundle).BuildRegistrySecret(job); err == nil {
+		t.Error("expected error without registry credentials")
+	}
+
+	// Job not yet created (no UID)
+	job.UID = ""
+	builder := NewBuilder(bundle).WithRegistryCredentials([]byte(testDockerConfig))
+	if _, err := builder.BuildRegistrySecret(job); err == nil {
+		t.Error("expected error for Job without UID")
+	}
+}
+
+func TestBuilder_Build_WithRegistryTLS(t *testing.T) {
+	bundle := newCredentialsTestBundle()
+	bundle.Spec.Registry.URL = "registry.internal:5000/test/bundle"
+	builder := NewBuilder(bundle).
+		WithScheme(testScheme).
+		WithRegistryTLS([]byte("ca"), []byte("cert"), []byte("key"))
+	job, err := builder.Build(context.Background(), "v1.0.0")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	podSpec := job.Spec.Template.Spec
+	secretName := RegistrySecretName(job.Name)
+	paths := make(map[string]string)
+	for _, volume := range podSpec.Volumes {
+		if volume.Secret == nil || volume.Secret.SecretName != secretName {
+			t.Fatalf("expected volumes from Secret %q, got %+v", secretName, volume.VolumeSource)
+		}
+		for _, mount := range podSpec.Containers[0].VolumeMounts {
+			if mount.Name != volume.Name {
+				continue
+			}
+			for _, item := range volume.Secret.Items {
+				paths[mount.MountPath+"/"+item.Path] = item.Key
+			}
+		}
+	}
+	want := map[string]string{
+		"/werf/registry-ca/ca.crt":                               "ca.crt",
+		"/etc/docker/certs.d/registry.internal:5000/ca.crt":      "ca.crt",
+		"/etc/docker/certs.d/registry.internal:5000/client.cert": "client.cert",
+		"/etc/docker/certs.d/registry.internal:5000/client.key":  "client.key",
+	}
+	if len(paths) != len(want) {
+		t.Errorf("mounted files: got %v, want %v", paths, want)
+	}
+	for path, key := range want {
+		if paths[path] != key {
+			t.Errorf("expected %s mounted at %s, got %v", key, path, paths)
+		}
+	}
+
+	env := podSpec.Containers[0].Env
+	if len(env) != 1 || env[0].Name != "SSL_CERT_DIR" || env[0].Value != "/werf/registry-ca:/etc/ssl/certs" {
+		t.Errorf("expected SSL_CERT_DIR with the registry CA and system certificates, got %v", env)
+	}
+	// Without credentials the Secret isn't usable as an imagePullSecret
+	if len(podSpec.ImagePullSecrets) != 0 {
+		t.Errorf("expected no imagePullSecrets, got %v", podSpec.ImagePullSecrets)
+	}
+
+	job.UID = "job-uid"
+	secret, err := builder.BuildRegistrySecret(job)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if secret.Type != corev1.SecretTypeOpaque {
+		t.Errorf("type: got %q, want %q", secret.Type, corev1.SecretTypeOpaque)
+	}
+	if string(secret.Data["ca.crt"]) != "ca" || string(secret.Data["client.cert"]) != "cert" ||
+		string(secret.Data["client.key"]) != "key" {
+		t.Errorf("unexpected data %v", secret.Data)
+	}
+}
+
+func TestBuilder_Build_InsecureRegistry(t *testing.T) {
+	bundle := newCredentialsTestBundle()
+	bundle.Spec.Registry.TLS = &werfv1alpha1.RegistryTLS{Insecure: true}
+	builder := NewBuilder(bundle).
+		WithScheme(testScheme).
+		WithRegistryCredentials([]byte(testDockerConfig))
+	job, err := builder.Build(context.Background(), "v1.0.0")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	args := job.Spec.Template.Spec.Containers[0].Args
+	for _, flag := range []string{"--insecure-registry", "--skip-tls-verify-registry"} {
+		if !slices.Contains(args, flag) {
+			t.Errorf("expected %s in args %v", flag, args)
+		}
+	}
+	if len(job.Spec.Template.Spec.Volumes) != 1 {
+		t.Errorf("expected only the credentials volume, got %v", job.Spec.Template.Spec.Volumes)
+	}
+
+	job.UID = "job-uid"
+	secret, err := builder.BuildRegistrySecret(job)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if secret.Type != corev1.SecretTypeDockerConfigJson || len(secret.Data) != 1 {
+		t.Errorf("expected a dockerconfigjson Secret with credentials only, got %s %v", secret.Type, secret.Data)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"encoding/hex"
 	"fmt"
 	"hash/fnv"
+	"strings"
 
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
@@ -23,8 +24,34 @@ type Builder struct {
 	werf           *werfv1alpha1.WerfBundle
 	scheme         *runtime.Scheme
 	valuesResolver values.Resolver
+	// registryCredentials is a Docker config.json document mounted into the werf container
+	registryCredentials []byte
+	// registryCA, registryClientCert and registryClientKey are PEM-encoded TLS material
+	// for the registry, mounted into the werf container
+	registryCA         []byte
+	registryClientCert []byte
+	registryClientKey  []byte
+	// httpProxy, httpsProxy and noProxy are passed to werf as proxy environment variables
+	httpProxy  string
+	httpsProxy string
+	noProxy    string
+	// repository is where werf pulls the bundle from, when not spec.registry.url (e.g., a mirror)
+	repository string
+	// digest pins the bundle to a manifest digest instead of the mutable tag
+	digest string
 }
 
+// DigestAnnotation records the manifest digest a Job deploys.
+// An annotation rather than a label: digests exceed the 63-character label value limit.
+const DigestAnnotation = "werf.io/digest"
+
+// TagLabel records the tag a Job deploys, shortened to a valid label value (see tagLabelValue).
+const TagLabel = "werf.io/tag"
+
+// TagAnnotation records the exact tag a Job deploys, or the digest when the bundle is pinned
+// to one, which isn't a valid label value.
+const TagAnnotation = "werf.io/tag"
+
 // NewBuilder creates a new Job builder for a WerfBundle.
 func NewBuilder(bundle *werfv1alpha1.WerfBundle) *Builder {
 	return &Builder{werf: bundle, scheme: nil}
@@ -42,6 +69,48 @@ func (b *Builder) WithValuesResolver(resolver values.Resolver) *Builder {
 	return b
 }
 
+// WithRegistryCredentials sets the Docker config.json used by werf to pull the bundle.
+// When set, Build mounts the Secret named by RegistrySecretName into the werf container
+// and points DOCKER_CONFIG at it; the caller must create that Secret with BuildRegistrySecret.
+func (b *Builder) WithRegistryCredentials(dockerConfigJSON []byte) *Builder {
+	b.registryCredentials = dockerConfigJSON
+	return b
+}
+
+// WithRegistryTLS sets the CA certificates trusted for the registry and the client certificate
+// and key presented to it (all PEM-encoded; any may be empty). When set, Build mounts them from
+// the Secret named by RegistrySecretName; the caller must create that Secret with
+// BuildRegistrySecret.
+func (b *Builder) WithRegistryTLS(caCerts, clientCert, clientKey []byte) *Builder {
+	b.registryCA = caCerts
+	b.registryClientCert = clientCert
+	b.registryClientKey = clientKey
+	return b
+}
+
+// WithProxy sets the HTTP(S) proxy werf connects through, as HTTP_PROXY, HTTPS_PROXY and
+// NO_PROXY environment variables (and their lowercase forms). Empty values aren't set.
+func (b *Builder) WithProxy(httpProxy, httpsProxy, noProxy string) *Builder {
+	b.httpProxy = httpProxy
+	b.httpsProxy = httpsProxy
+	b.noProxy = noProxy
+	return b
+}
+
+// WithRepository sets the repository werf pulls the bundle from, e.g. the registry mirror
+// that served the tags. Defaults to spec.registry.url.
+func (b *Builder) WithRepository(repoURL string) *Builder {
+	b.repository = repoURL
+	return b
+}
+
+// WithDigest pins the Job to the manifest digest the tag resolved to (e.g., "sha256:abc...").
+// werf then converges repo@digest, so re-pushing the tag while the Job runs has no effect.
+func (b *Builder) WithDigest(digest string) *Builder {
+	b.digest = digest
+	return b
+}
+
 // Build creates a Kubernetes Job spec for werf converge.
 // The job name is deterministic based on bundle and tag to enable idempotency.
 // If valuesFrom is configured, resolves values and adds --set flags to the job.
@@ -55,11 +124,20 @@ func (b *Builder) Build(ctx context.Context, tag string) (*batchv1.Job, error) {
 
 	jobName := b.jobName(tag)
 
+	// Reference the bundle by digest when known so the Job deploys exactly what was resolved
+	bundleRef := fmt.Sprintf("%s:%s", b.repositoryURL(), tag)
+	if b.digest != "" {
+		bundleRef = fmt.Sprintf("%s@%s", b.repositoryURL(), b.digest)
+	}
+
 	// Build base werf converge arguments
 	args := []string{
 		"converge",
 		"--log-color=false",
-		fmt.Sprintf("%s:%s", b.werf.Spec.Registry.URL, tag),
+		bundleRef,
+	}
+	if tls := b.werf.Spec.Registry.TLS; tls != nil && tls.Insecure {
+		args = append(args, "--insecure-registry", "--skip-tls-verify-registry")
 	}
 
 	// Resolve values if configured
@@ -104,7 +182,7 @@ func (b *Builder) Build(ctx context.Context, tag string) (*batchv1.Job, error) {
 				"app.kubernetes.io/instance":   b.werf.Name,
 				"app.kubernetes.io/managed-by": "werf-operator",
 				"werf.io/bundle":               b.werf.Name,
-				"werf.io/tag":                  tag,
+				TagLabel:                       tagLabelValue(tag),
 			},
 		},
 		Spec: batchv1.JobSpec{
@@ -117,7 +195,7 @@ func (b *Builder) Build(ctx context.Context, tag string) (*batchv1.Job, error) {
 						"app.kubernetes.io/instance":   b.werf.Name,
 						"app.kubernetes.io/managed-by": "werf-operator",
 						"werf.io/bundle":               b.werf.Name,
-						"werf.io/tag":                  tag,
+						TagLabel:                       tagLabelValue(tag),
 					},
 				},
 				Spec: corev1.PodSpec{
@@ -147,6 +225,19 @@ func (b *Builder) Build(ctx context.Context, tag string) (*batchv1.Job, error) {
 		},
 	}
 
+	job.Annotations = map[string]string{TagAnnotation: tag}
+	if b.digest != "" {
+		job.Annotations[DigestAnnotation] = b.digest
+	}
+
+	if len(b.registryCredentials) > 0 {
+		addRegistryCredentials(&job.Spec.Template.Spec, RegistrySecretName(jobName))
+	}
+	if len(b.registryCA) > 0 || len(b.registryClientCert) > 0 {
+		b.addRegistryTLS(&job.Spec.Template.Spec, RegistrySecretName(jobName))
+	}
+	b.addProxyEnv(&job.Spec.Template.Spec)
+
 	// Set WerfBundle as owner of this Job
 	// Use regular owner reference (not controller reference) to support cross-namespace deployments.
 	// Note: Cross-namespace owner references don't support automatic garbage collection,
@@ -176,6 +267,50 @@ func (b *Builder) Build(ctx context.Context, tag string) (*batchv1.Job, error) {
 	return job, nil
 }
 
+// repositoryURL returns the repository werf pulls the bundle from.
+func (b *Builder) repositoryURL() string {
+	if b.repository != "" {
+		return b.repository
+	}
+	return b.werf.Spec.Registry.URL
+}
+
+// addProxyEnv sets the proxy environment variables of the werf container. The Kubernetes API
+// server is always connected to directly: werf reaches it through the in-cluster service, whose
+// address Kubernetes expands in place of $(KUBERNETES_SERVICE_HOST).
+func (b *Builder) addProxyEnv(podSpec *corev1.PodSpec) {
+	if b.httpProxy == "" && b.httpsProxy == "" {
+		return
+	}
+	noProxy := "$(KUBERNETES_SERVICE_HOST)"
+	if b.noProxy != "" {
+		noProxy = b.noProxy + "," + noProxy
+	}
+	container := &podSpec.Containers[0]
+	for _, env := range []corev1.EnvVar{
+		{Name: "HTTP_PROXY", Value: b.httpProxy},
+		{Name: "HTTPS_PROXY", Value: b.httpsProxy},
+		{Name: "NO_PROXY", Value: noProxy},
+	} {
+		if env.Value == "" {
+			continue
+		}
+		container.Env = append(container.Env, env, corev1.EnvVar{Name: strings.ToLower(env.Name), Value: env.Value})
+	}
+}
+
+// tagLabelValue shortens tag to a valid label value: at most 63 characters, without colons
+// (digests such as sha256:abc... become sha256-abc...), starting and ending alphanumeric.
+func tagLabelValue(tag string) string {
+	value := strings.ReplaceAll(tag, ":", "-")
+	if len(value) > 63 {
+		value = value[:63]
+	}
+	return strings.TrimFunc(value, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9')
+	})
+}
+
 // jobName generates a unique name for the job with format: <bundle>-<tag-hash>-<uuid>.
 // The tag hash is deterministic (enables duplicate detection), UUID ensures collision prevention.
 // Uses 8 hex chars for both tag hash and UUID for readability.
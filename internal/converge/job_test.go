@@ -8,6 +8,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/validation"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 
 	werfv1alpha1 "github.com/werf/k8s-werf-operator-go/api/v1alpha1"
@@ -95,6 +96,173 @@ func TestBuilder_Build_ValidBundle(t *testing.T) {
 	}
 }
 
+func TestBuilder_Build_WithDigest(t *testing.T) {
+	bundle := &werfv1alpha1.WerfBundle{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      testBundleName,
+			Namespace: "default",
+		},
+		Spec: werfv1alpha1.WerfBundleSpec{
+			Registry: werfv1alpha1.RegistryConfig{
+				URL: "ghcr.io/test/bundle",
+			},
+		},
+	}
+	digest := "sha256:9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
+
+	job, err := NewBuilder(bundle).WithScheme(testScheme).WithDigest(digest).Build(context.Background(), "main")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The Job must deploy the resolved digest, not whatever the tag points to when it starts
+	args := job.Spec.Template.Spec.Containers[0].Args
+	if args[len(args)-1] != "ghcr.io/test/bundle@"+digest {
+		t.Errorf("bundle arg: got %q, want %q", args[len(args)-1], "ghcr.io/test/bundle@"+digest)
+	}
+
+	if job.Annotations[DigestAnnotation] != digest {
+		t.Errorf("digest annotation: got %q, want %q", job.Annotations[DigestAnnotation], digest)
+	}
+	if job.Labels["werf.io/tag"] != "main" {
+		t.Errorf("tag label: got %q, want %q", job.Labels["werf.io/tag"], "main")
+	}
+}
+
+func TestBuilder_Build_PinnedDigest(t *testing.T) {
+	bundle := &werfv1alpha1.WerfBundle{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      testBundleName,
+			Namespace: "default",
+		},
+		Spec: werfv1alpha1.WerfBundleSpec{
+			Registry: werfv1alpha1.RegistryConfig{
+				URL: "ghcr.io/test/bundle",
+			},
+		},
+	}
+	digest := "sha256:9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
+
+	// A bundle pinned to a digest has no tag: the digest stands in for it
+	job, err := NewBuilder(bundle).WithScheme(testScheme).WithDigest(digest).Build(context.Background(), digest)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if job.Annotations[TagAnnotation] != digest {
+		t.Errorf("tag annotation: got %q, want %q", job.Annotations[TagAnnotation], digest)
+	}
+	wantLabel := "sha256-9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15"
+	if job.Labels[TagLabel] != wantLabel {
+		t.Errorf("tag label: got %q, want %q", job.Labels[TagLabel], wantLabel)
+	}
+	if errs := validation.IsValidLabelValue(job.Labels[TagLabel]); len(errs) > 0 {
+		t.Errorf("tag label %q is invalid: %v", job.Labels[TagLabel], errs)
+	}
+}
+
+func TestBuilder_Build_WithRepository(t *testing.T) {
+	bundle := &werfv1alpha1.WerfBundle{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      testBundleName,
+			Namespace: "default",
+		},
+		Spec: werfv1alpha1.WerfBundleSpec{
+			Registry: werfv1alpha1.RegistryConfig{
+				URL:     "ghcr.io/test/bundle",
+				Mirrors: []string{"mirror.example.com/test/bundle"},
+			},
+		},
+	}
+	digest := "sha256:9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
+
+	// The bundle is pulled from the mirror that served the tags
+	job, err := NewBuilder(bundle).WithScheme(testScheme).
+		WithRepository("mirror.example.com/test/bundle").
+		WithDigest(digest).
+		Build(context.Background(), "v1.0.0")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	args := job.Spec.Template.Spec.Containers[0].Args
+	if want := "mirror.example.com/test/bundle@" + digest; args[2] != want {
+		t.Errorf("bundle arg: got %q, want %q", args[2], want)
+	}
+}
+
+func TestBuilder_Build_WithProxy(t *testing.T) {
+	bundle := &werfv1alpha1.WerfBundle{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      testBundleName,
+			Namespace: "default",
+		},
+		Spec: werfv1alpha1.WerfBundleSpec{
+			Registry: werfv1alpha1.RegistryConfig{
+				URL: "ghcr.io/test/bundle",
+			},
+		},
+	}
+
+	tests := []struct {
+		name                           string
+		httpProxy, httpsProxy, noProxy string
+		want                           map[string]string
+	}{
+		{
+			name:       "all set",
+			httpProxy:  "http://proxy:3128",
+			httpsProxy: "http://secure-proxy:3128",
+			noProxy:    ".corp.example,10.0.0.0/8",
+			want: map[string]string{
+				"HTTP_PROXY":  "http://proxy:3128",
+				"http_proxy":  "http://proxy:3128",
+				"HTTPS_PROXY": "http://secure-proxy:3128",
+				"https_proxy": "http://secure-proxy:3128",
+				"NO_PROXY":    ".corp.example,10.0.0.0/8,$(KUBERNETES_SERVICE_HOST)",
+				"no_proxy":    ".corp.example,10.0.0.0/8,$(KUBERNETES_SERVICE_HOST)",
+			},
+		},
+		{
+			name:       "HTTPS only",
+			httpsProxy: "http://proxy:3128",
+			want: map[string]string{
+				"HTTPS_PROXY": "http://proxy:3128",
+				"https_proxy": "http://proxy:3128",
+				"NO_PROXY":    "$(KUBERNETES_SERVICE_HOST)",
+				"no_proxy":    "$(KUBERNETES_SERVICE_HOST)",
+			},
+		},
+		{
+			name:    "no proxy",
+			noProxy: ".corp.example",
+			want:    map[string]string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			job, err := NewBuilder(bundle).WithScheme(testScheme).
+				WithProxy(tt.httpProxy, tt.httpsProxy, tt.noProxy).
+				Build(context.Background(), "v1.0.0")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			env := map[string]string{}
+			for _, e := range job.Spec.Template.Spec.Containers[0].Env {
+				env[e.Name] = e.Value
+			}
+			if len(env) != len(tt.want) {
+				t.Errorf("env: got %v, want %v", env, tt.want)
+			}
+			for name, value := range tt.want {
+				if env[name] != value {
+					t.Errorf("%s: got %q, want %q", name, env[name], value)
+				}
+			}
+		})
+	}
+}
+
 func TestBuilder_Build_DeterministicName(t *testing.T) {
 	bundle := &werfv1alpha1.WerfBundle{
 		ObjectMeta: metav1.ObjectMeta{
@@ -0,0 +1,163 @@
+// Parsing of registry push notification payloads.
+package receiver
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// distributionEnvelope is a Docker Distribution notification.
+// See https://distribution.github.io/distribution/about/notifications/
+type distributionEnvelope struct {
+	Events []struct {
+		Action string `json:"action"`
+		Target struct {
+			Repository string `json:"repository"`
+			Tag        string `json:"tag"`
+			URL        string `json:"url"`
+		} `json:"target"`
+		Request struct {
+			Host string `json:"host"`
+		} `json:"request"`
+	} `json:"events"`
+}
+
+// parseDistribution returns the repositories with tags pushed in a Distribution notification.
+// Blob pushes and pushes by digest don't add tags and are ignored.
+func parseDistribution(_ http.Header, body []byte) ([]string, error) {
+	var envelope distributionEnvelope
+	if err := json.Unmarshal(body, &envelope); err != nil {
+		return nil, err
+	}
+
+	var repos []string
+	for _, ev := range envelope.Events {
+		if ev.Action != "push" || ev.Target.Tag == "" || ev.Target.Repository == "" {
+			continue
+		}
+		// The registry host isn't part of the repository name; take it from the request,
+		// falling back to the manifest URL
+		host := ev.Request.Host
+		if host == "" {
+			if u, err := url.Parse(ev.Target.URL); err == nil {
+				host = u.Host
+			}
+		}
+		if host == "" {
+			return nil, fmt.Errorf("event for %q has no registry host", ev.Target.Repository)
+		}
+		repos = appendUnique(repos, host+"/"+ev.Target.Repository)
+	}
+	return repos, nil
+}
+
+// harborEvent is a Harbor webhook notification (HTTP payload format).
+type harborEvent struct {
+	Type      string `json:"type"`
+	EventData struct {
+		Resources []struct {
+			ResourceURL string `json:"resource_url"`
+		} `json:"resources"`
+	} `json:"event_data"`
+}
+
+// parseHarbor returns the repositories pushed in a Harbor PUSH_ARTIFACT notification.
+func parseHarbor(_ http.Header, body []byte) ([]string, error) {
+	var ev harborEvent
+	if err := json.Unmarshal(body, &ev); err != nil {
+		return nil, err
+	}
+	if ev.Type != "PUSH_ARTIFACT" {
+		return nil, nil
+	}
+
+	var repos []string
+	for _, res := range ev.EventData.Resources {
+		if res.ResourceURL == "" {
+			continue
+		}
+		repos = appendUnique(repos, repositoryOf(res.ResourceURL))
+	}
+	return repos, nil
+}
+
+// githubPackage is the package object of GitHub package and registry_package events.
+type githubPackage struct {
+	Name        string `json:"name"`
+	PackageType string `json:"package_type"`
+	Owner       struct {
+		Login string `json:"login"`
+	} `json:"owner"`
+	PackageVersion struct {
+		PackageURL string `json:"package_url"`
+	} `json:"package_version"`
+}
+
+// githubEvent is a GitHub package or registry_package webhook event.
+type githubEvent struct {
+	Action          string         `json:"action"`
+	Package         *githubPackage `json:"package"`
+	RegistryPackage *githubPackage `json:"registry_package"`
+}
+
+// parseGitHub returns the GHCR repository of a published or updated container package.
+// Other GitHub events (including the ping sent when the webhook is created) are ignored.
+func parseGitHub(header http.Header, body []byte) ([]string, error) {
+	switch header.Get("X-GitHub-Event") {
+	case "package", "registry_package":
+	default:
+		return nil, nil
+	}
+
+	var ev githubEvent
+	if err := json.Unmarshal(body, &ev); err != nil {
+		return nil, err
+	}
+	if ev.Action != "published" && ev.Action != "updated" {
+		return nil, nil
+	}
+
+	pkg := ev.Package
+	if pkg == nil {
+		pkg = ev.RegistryPackage
+	}
+	if pkg == nil {
+		return nil, fmt.Errorf("event has no package")
+	}
+	if !strings.EqualFold(pkg.PackageType, "container") {
+		return nil, nil
+	}
+
+	if pkg.PackageVersion.PackageURL != "" {
+		return []string{repositoryOf(pkg.PackageVersion.PackageURL)}, nil
+	}
+	if pkg.Owner.Login == "" || pkg.Name == "" {
+		return nil, fmt.Errorf("package has no owner or name")
+	}
+	return []string{"ghcr.io/" + pkg.Owner.Login + "/" + pkg.Name}, nil
+}
+
+// repositoryOf strips the scheme, tag and digest from an image reference
+// ("harbor.example.com/library/app:v1" -> "harbor.example.com/library/app").
+func repositoryOf(reference string) string {
+	reference = strings.TrimPrefix(strings.TrimPrefix(reference, "https://"), "http://")
+	reference, _, _ = strings.Cut(reference, "@")
+	// A colon after the last slash separates the tag; one before it is a registry port
+	if i := strings.LastIndex(reference, ":"); i > strings.LastIndex(reference, "/") {
+		reference = reference[:i]
+	}
+	return reference
+}
+
+// appendUnique appends s to list unless it's already present.
+func appendUnique(list []string, s string) []string {
+	for _, existing := range list {
+		if existing == s {
+			return list
+		}
+	}
+	return append(list, s)
+}
@@ -0,0 +1,272 @@
+// Package receiver accepts registry push notifications and triggers immediate reconciliation
+// of the WerfBundles that track the pushed repository, instead of waiting for the next poll.
+package receiver
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	werfv1alpha1 "github.com/werf/k8s-werf-operator-go/api/v1alpha1"
+	"github.com/werf/k8s-werf-operator-go/internal/registry"
+)
+
+// Endpoints for each notification format. Each expects a POST with the sender's JSON payload.
+const (
+	// PathDistribution receives Docker Distribution (registry:2) notifications.
+	PathDistribution = "/registry/distribution"
+	// PathHarbor receives Harbor webhook notifications (PUSH_ARTIFACT events).
+	PathHarbor = "/registry/harbor"
+	// PathGitHub receives GitHub package and registry_package webhook events for GHCR.
+	PathGitHub = "/registry/github"
+)
+
+// SignatureHeader carries the HMAC-SHA256 of the request body, hex-encoded with a
+// "sha256=" prefix. This is the header GitHub sets for webhooks with a secret.
+const SignatureHeader = "X-Hub-Signature-256"
+
+const (
+	// maxBodySize bounds the notification payload; real payloads are a few kilobytes.
+	maxBodySize = 1 << 20
+	// readHeaderTimeout protects the server against slow clients holding connections open.
+	readHeaderTimeout = 10 * time.Second
+	// shutdownTimeout is how long in-flight notifications get to finish on shutdown.
+	shutdownTimeout = 5 * time.Second
+)
+
+// BundleEvent asks the WerfBundle controller to reconcile a bundle.
+type BundleEvent = event.TypedGenericEvent[*werfv1alpha1.WerfBundle]
+
+// parseFunc extracts the pushed repositories (e.g., "ghcr.io/org/bundle") from a notification.
+// Notifications that aren't pushes (pings, deletions) return no repositories.
+type parseFunc func(header http.Header, body []byte) ([]string, error)
+
+// Server receives registry push notifications over HTTP and sends a BundleEvent for every
+// WerfBundle whose spec.registry.url or one of its mirrors matches a pushed repository.
+//
+// Every request must be authenticated with the shared secret, either as an HMAC-SHA256
+// signature of the body in SignatureHeader (GitHub), or as the Authorization header value
+// (Distribution and Harbor, which can only send a static header).
+//
+// Server implements manager.Runnable and runs on every replica, so that the Service can
+// select all manager pods. Only the leader's controller consumes events, so until Elected
+// is closed, notifications are refused with 503 Service Unavailable.
+type Server struct {
+	// OnPush, if set, is called with the pushed repositories before bundles are triggered,
+	// e.g. to drop cached tag lists so the triggered polls see the push.
+	OnPush func(repos ...string)
+	// Elected, if set, is closed once this replica is the leader (see manager.Elected).
+	// A nil Elected means the replica always processes events.
+	Elected <-chan struct{}
+
+	addr   string
+	secret []byte
+	reader client.Reader
+	events chan<- BundleEvent
+	log    logr.Logger
+}
+
+// NewServer creates a receiver listening on addr. Bundles are looked up with reader and
+// sent to events, which should be watched by the WerfBundle controller.
+func NewServer(addr string, secret []byte, reader client.Reader, events chan<- BundleEvent) *Server {
+	return &Server{
+		addr:   addr,
+		secret: secret,
+		reader: reader,
+		events: events,
+		log:    logf.Log.WithName("receiver"),
+	}
+}
+
+// Handler returns the HTTP handler serving all notification endpoints.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("POST "+PathDistribution, s.receive("distribution", parseDistribution))
+	mux.Handle("POST "+PathHarbor, s.receive("harbor", parseHarbor))
+	mux.Handle("POST "+PathGitHub, s.receive("github", parseGitHub))
+	return mux
+}
+
+// Start serves notifications until ctx is cancelled.
+func (s *Server) Start(ctx context.Context) error {
+	srv := &http.Server{
+		Addr:              s.addr,
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: readHeaderTimeout,
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		s.log.Info("starting registry webhook receiver", "address", s.addr)
+		errCh <- srv.ListenAndServe()
+	}()
+
+	select {
+	case <-ctx.Done():
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		return srv.Shutdown(shutdownCtx)
+	case err := <-errCh:
+		if errors.Is(err, http.ErrServerClosed) {
+			return nil
+		}
+		return fmt.Errorf("registry webhook receiver failed: %w", err)
+	}
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable. The receiver only enqueues
+// reconciles, so it runs on standby replicas too and refuses notifications until elected.
+func (s *Server) NeedLeaderElection() bool {
+	return false
+}
+
+// elected reports whether this replica's controller consumes events.
+func (s *Server) elected() bool {
+	if s.Elected == nil {
+		return true
+	}
+	select {
+	case <-s.Elected:
+		return true
+	default:
+		return false
+	}
+}
+
+// receive wraps parse with body limits, authentication and bundle triggering.
+func (s *Server) receive(format string, parse parseFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := s.log.WithValues("format", format, "remote", r.RemoteAddr)
+
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodySize))
+		if err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				http.Error(w, "payload too large", http.StatusRequestEntityTooLarge)
+				return
+			}
+			http.Error(w, "failed to read payload", http.StatusBadRequest)
+			return
+		}
+
+		if !s.authenticate(r.Header, body) {
+			log.Info("rejected notification with invalid signature or token")
+			http.Error(w, "invalid signature", http.StatusUnauthorized)
+			return
+		}
+
+		repos, err := parse(r.Header, body)
+		if err != nil {
+			log.Info("rejected malformed notification", "error", err.Error())
+			http.Error(w, fmt.Sprintf("invalid payload: %v", err), http.StatusBadRequest)
+			return
+		}
+
+		if len(repos) > 0 && !s.elected() {
+			// The events channel is only drained by the leader; let the sender retry
+			log.Info("refused notification on a standby replica", "repositories", repos)
+			http.Error(w, "not the leader replica", http.StatusServiceUnavailable)
+			return
+		}
+
+		triggered, err := s.trigger(r.Context(), repos)
+		if err != nil {
+			log.Error(err, "failed to trigger bundles", "repositories", repos)
+			http.Error(w, "failed to trigger bundles", http.StatusInternalServerError)
+			return
+		}
+		if len(repos) > 0 {
+			log.Info("push notification received", "repositories", repos, "bundles", triggered)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusAccepted)
+		_ = json.NewEncoder(w).Encode(map[string]any{"repositories": repos, "bundles": triggered})
+	}
+}
+
+// authenticate checks the request against the shared secret.
+// A request with SignatureHeader must carry a valid HMAC; otherwise the Authorization
+// header must equal the secret, optionally with a "Bearer " prefix.
+func (s *Server) authenticate(header http.Header, body []byte) bool {
+	if signature := header.Get(SignatureHeader); signature != "" {
+		encoded, ok := strings.CutPrefix(signature, "sha256=")
+		if !ok {
+			return false
+		}
+		got, err := hex.DecodeString(encoded)
+		if err != nil {
+			return false
+		}
+		mac := hmac.New(sha256.New, s.secret)
+		mac.Write(body)
+		return hmac.Equal(got, mac.Sum(nil))
+	}
+
+	token := strings.TrimPrefix(header.Get("Authorization"), "Bearer ")
+	return token != "" && subtle.ConstantTimeCompare([]byte(token), s.secret) == 1
+}
+
+// trigger sends a BundleEvent for each WerfBundle tracking one of repos.
+// Returns the number of bundles triggered.
+func (s *Server) trigger(ctx context.Context, repos []string) (int, error) {
+	if len(repos) == 0 {
+		return 0, nil
+	}
+	if s.OnPush != nil {
+		s.OnPush(repos...)
+	}
+
+	pushed := make(map[string]bool, len(repos))
+	for _, repo := range repos {
+		if normalized, ok := registry.NormalizeRepository(repo); ok {
+			pushed[normalized] = true
+		}
+	}
+
+	var bundles werfv1alpha1.WerfBundleList
+	if err := s.reader.List(ctx, &bundles); err != nil {
+		return 0, fmt.Errorf("failed to list WerfBundles: %w", err)
+	}
+
+	triggered := 0
+	for i := range bundles.Items {
+		bundle := &bundles.Items[i]
+		if !tracksAny(bundle, pushed) || !bundle.DeletionTimestamp.IsZero() {
+			continue
+		}
+
+		select {
+		case s.events <- BundleEvent{Object: bundle}:
+			triggered++
+		case <-ctx.Done():
+			return triggered, ctx.Err()
+		}
+	}
+	return triggered, nil
+}
+
+// tracksAny reports whether the bundle polls one of the normalized repositories in pushed,
+// as its spec.registry.url or one of its mirrors.
+func tracksAny(bundle *werfv1alpha1.WerfBundle, pushed map[string]bool) bool {
+	for _, url := range append([]string{bundle.Spec.Registry.URL}, bundle.Spec.Registry.Mirrors...) {
+		if repo, ok := registry.NormalizeRepository(url); ok && pushed[repo] {
+			return true
+		}
+	}
+	return false
+}
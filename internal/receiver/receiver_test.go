@@ -0,0 +1,317 @@
+package receiver
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	werfv1alpha1 "github.com/werf/k8s-werf-operator-go/api/v1alpha1"
+)
+
+const testSecret = "s3cret"
+
+// newTestServer serves a receiver backed by a fake client holding bundles.
+// Returns the server URL and the channel receiving triggered bundles.
+func newTestServer(t *testing.T, bundles ...*werfv1alpha1.WerfBundle) (string, chan BundleEvent) {
+	t.Helper()
+
+	scheme := runtime.NewScheme()
+	if err := werfv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatalf("failed to build scheme: %v", err)
+	}
+	builder := fake.NewClientBuilder().WithScheme(scheme)
+	for _, bundle := range bundles {
+		builder = builder.WithObjects(bundle)
+	}
+
+	events := make(chan BundleEvent, 10)
+	srv := httptest.NewServer(NewServer(":0", []byte(testSecret), builder.Build(), events).Handler())
+	t.Cleanup(srv.Close)
+	return srv.URL, events
+}
+
+// testBundle returns a WerfBundle tracking repoURL.
+func testBundle(name, namespace, repoURL string) *werfv1alpha1.WerfBundle {
+	return &werfv1alpha1.WerfBundle{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Spec: werfv1alpha1.WerfBundleSpec{
+			Registry: werfv1alpha1.RegistryConfig{URL: repoURL},
+		},
+	}
+}
+
+// post sends body to url with header and returns the response status.
+func post(t *testing.T, url string, header http.Header, body string) int {
+	t.Helper()
+
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewBufferString(body))
+	if err != nil {
+		t.Fatalf("failed to build request: %v", err)
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	_ = resp.Body.Close()
+	return resp.StatusCode
+}
+
+// drain returns the namespace/name of every event sent so far.
+func drain(events chan BundleEvent) []string {
+	var keys []string
+	for {
+		select {
+		case ev := <-events:
+			keys = append(keys, ev.Object.Namespace+"/"+ev.Object.Name)
+		default:
+			sort.Strings(keys)
+			return keys
+		}
+	}
+}
+
+func tokenHeader() http.Header {
+	return http.Header{"Authorization": []string{"Bearer " + testSecret}}
+}
+
+func signatureHeader(body string) http.Header {
+	mac := hmac.New(sha256.New, []byte(testSecret))
+	mac.Write([]byte(body))
+	return http.Header{
+		SignatureHeader:  []string{"sha256=" + hex.EncodeToString(mac.Sum(nil))},
+		"X-Github-Event": []string{"package"},
+	}
+}
+
+func TestReceiver_TriggersMatchingBundles(t *testing.T) {
+	mirrored := testBundle("mirrored", "default", "registry.example.com:5000/org/bundle")
+	mirrored.Spec.Registry.Mirrors = []string{"harbor.example.com/library/bundle"}
+	url, events := newTestServer(t,
+		mirrored,
+		testBundle("app", "team-a", "registry.example.com:5000/org/app"),
+		testBundle("app", "team-b", "registry.example.com:5000/org/app"),
+		testBundle("harbor", "default", "harbor.example.com/library/bundle"),
+		testBundle("ghcr", "default", "ghcr.io/werf/bundle"),
+		testBundle("other", "default", "ghcr.io/werf/other"),
+	)
+
+	tests := []struct {
+		name   string
+		path   string
+		header http.Header
+		body   string
+		want   []string
+	}{
+		{
+			name:   "distribution push",
+			path:   PathDistribution,
+			header: tokenHeader(),
+			body: `{"events":[` +
+				`{"action":"push","target":{"repository":"org/app","tag":"v1.2.0"},"request":{"host":"registry.example.com:5000"}},` +
+				`{"action":"push","target":{"repository":"org/app","tag":"v1.2.0"},"request":{"host":"registry.example.com:5000"}}]}`,
+			want: []string{"team-a/app", "team-b/app"},
+		},
+		{
+			name:   "distribution host from target url",
+			path:   PathDistribution,
+			header: tokenHeader(),
+			body: `{"events":[{"action":"push","target":{"repository":"org/app","tag":"v1",` +
+				`"url":"https://registry.example.com:5000/v2/org/app/manifests/sha256:abc"}}]}`,
+			want: []string{"team-a/app", "team-b/app"},
+		},
+		{
+			name:   "distribution blob push",
+			path:   PathDistribution,
+			header: tokenHeader(),
+			body: `{"events":[{"action":"push","target":{"repository":"org/app"},` +
+				`"request":{"host":"registry.example.com:5000"}}]}`,
+		},
+		{
+			name:   "harbor push",
+			path:   PathHarbor,
+			header: http.Header{"Authorization": []string{testSecret}},
+			body: `{"type":"PUSH_ARTIFACT","event_data":{"resources":` +
+				`[{"tag":"v1","resource_url":"harbor.example.com/library/bundle:v1"}]}}`,
+			want: []string{"default/harbor", "default/mirrored"},
+		},
+		{
+			name:   "harbor delete",
+			path:   PathHarbor,
+			header: tokenHeader(),
+			body: `{"type":"DELETE_ARTIFACT","event_data":{"resources":` +
+				`[{"resource_url":"harbor.example.com/library/bundle:v1"}]}}`,
+		},
+		{
+			name:   "github package published",
+			path:   PathGitHub,
+			header: signatureHeader(`{"action":"published","package":{"name":"bundle","package_type":"CONTAINER","owner":{"login":"Werf"},"package_version":{"package_url":"ghcr.io/werf/bundle:v1"}}}`),
+			body:   `{"action":"published","package":{"name":"bundle","package_type":"CONTAINER","owner":{"login":"Werf"},"package_version":{"package_url":"ghcr.io/werf/bundle:v1"}}}`,
+			want:   []string{"default/ghcr"},
+		},
+		{
+			name:   "github package without url",
+			path:   PathGitHub,
+			header: signatureHeader(`{"action":"published","package":{"name":"bundle","package_type":"container","owner":{"login":"Werf"}}}`),
+			body:   `{"action":"published","package":{"name":"bundle","package_type":"container","owner":{"login":"Werf"}}}`,
+			want:   []string{"default/ghcr"},
+		},
+		{
+			name:   "unrelated repository",
+			path:   PathDistribution,
+			header: tokenHeader(),
+			body: `{"events":[{"action":"push","target":{"repository":"org/unknown","tag":"v1"},` +
+				`"request":{"host":"registry.example.com:5000"}}]}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if status := post(t, url+tt.path, tt.header, tt.body); status != http.StatusAccepted {
+				t.Fatalf("expected status 202, got %d", status)
+			}
+			got := drain(events)
+			if len(got) != len(tt.want) {
+				t.Fatalf("expected bundles %v, got %v", tt.want, got)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("expected bundles %v, got %v", tt.want, got)
+				}
+			}
+		})
+	}
+}
+
+func TestReceiver_RejectsInvalidRequests(t *testing.T) {
+	url, events := newTestServer(t, testBundle("app", "default", "ghcr.io/werf/bundle"))
+	push := `{"action":"published","package":{"name":"bundle","package_type":"container","owner":{"login":"werf"}}}`
+
+	badSignature := signatureHeader(push)
+	badSignature.Set(SignatureHeader, "sha256="+hex.EncodeToString([]byte("forged")))
+
+	tests := []struct {
+		name   string
+		path   string
+		header http.Header
+		body   string
+		want   int
+	}{
+		{name: "no credentials", path: PathGitHub, header: http.Header{}, body: push, want: http.StatusUnauthorized},
+		{name: "wrong token", path: PathHarbor, header: http.Header{"Authorization": []string{"wrong"}}, body: push,
+			want: http.StatusUnauthorized},
+		{name: "forged signature", path: PathGitHub, header: badSignature, body: push, want: http.StatusUnauthorized},
+		{name: "signature of another body", path: PathGitHub, header: signatureHeader(push + " "), body: push,
+			want: http.StatusUnauthorized},
+		{name: "malformed payload", path: PathDistribution, header: tokenHeader(), body: "{", want: http.StatusBadRequest},
+		{name: "unknown endpoint", path: "/registry/quay", header: tokenHeader(), body: push, want: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if status := post(t, url+tt.path, tt.header, tt.body); status != tt.want {
+				t.Errorf("expected status %d, got %d", tt.want, status)
+			}
+			if got := drain(events); len(got) != 0 {
+				t.Errorf("expected no bundles triggered, got %v", got)
+			}
+		})
+	}
+}
+
+func TestReceiver_GitHubPingIsAccepted(t *testing.T) {
+	url, events := newTestServer(t, testBundle("app", "default", "ghcr.io/werf/bundle"))
+
+	body := `{"zen":"Keep it logically awesome.","hook_id":1}`
+	header := signatureHeader(body)
+	header.Set("X-GitHub-Event", "ping")
+
+	if status := post(t, url+PathGitHub, header, body); status != http.StatusAccepted {
+		t.Errorf("expected status 202 for ping, got %d", status)
+	}
+	if got := drain(events); len(got) != 0 {
+		t.Errorf("expected no bundles triggered, got %v", got)
+	}
+}
+
+func TestRepositoryOf(t *testing.T) {
+	tests := []struct {
+		reference string
+		want      string
+	}{
+		{"harbor.example.com/library/app:v1", "harbor.example.com/library/app"},
+		{"harbor.example.com:8443/lib/app:v1", "harbor.example.com:8443/lib/app"},
+		{"registry.local:5000/app", "registry.local:5000/app"},
+		{"https://registry.local/app@sha256:abc", "registry.local/app"},
+	}
+	for _, tt := range tests {
+		if got := repositoryOf(tt.reference); got != tt.want {
+			t.Errorf("repositoryOf(%q) = %q, want %q", tt.reference, got, tt.want)
+		}
+	}
+}
+
+func TestReceiver_OnPushSeesPushedRepositories(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := werfv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatalf("failed to build scheme: %v", err)
+	}
+	reader := fake.NewClientBuilder().WithScheme(scheme).Build()
+
+	server := NewServer(":0", []byte(testSecret), reader, make(chan BundleEvent, 1))
+	var pushed []string
+	server.OnPush = func(repos ...string) { pushed = append(pushed, repos...) }
+	srv := httptest.NewServer(server.Handler())
+	t.Cleanup(srv.Close)
+
+	body := `{"type":"PUSH_ARTIFACT","event_data":{"resources":[{"resource_url":"harbor.example.com/library/app:v1"}]}}`
+	if status := post(t, srv.URL+PathHarbor, tokenHeader(), body); status != http.StatusAccepted {
+		t.Fatalf("expected status 202, got %d", status)
+	}
+	if len(pushed) != 1 || pushed[0] != "harbor.example.com/library/app" {
+		t.Errorf("expected OnPush with the pushed repository, got %v", pushed)
+	}
+}
+
+func TestReceiver_StandbyReplicaRefusesPushes(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := werfv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatalf("failed to build scheme: %v", err)
+	}
+	reader := fake.NewClientBuilder().WithScheme(scheme).
+		WithObjects(testBundle("harbor", "default", "harbor.example.com/library/bundle")).Build()
+
+	events := make(chan BundleEvent, 1)
+	elected := make(chan struct{})
+	server := NewServer(":0", []byte(testSecret), reader, events)
+	server.Elected = elected
+	srv := httptest.NewServer(server.Handler())
+	t.Cleanup(srv.Close)
+
+	body := `{"type":"PUSH_ARTIFACT","event_data":{"resources":[{"resource_url":"harbor.example.com/library/bundle:v1"}]}}`
+	if status := post(t, srv.URL+PathHarbor, tokenHeader(), body); status != http.StatusServiceUnavailable {
+		t.Errorf("expected status 503 before election, got %d", status)
+	}
+	if got := drain(events); len(got) != 0 {
+		t.Errorf("expected no bundles triggered before election, got %v", got)
+	}
+
+	close(elected)
+	if status := post(t, srv.URL+PathHarbor, tokenHeader(), body); status != http.StatusAccepted {
+		t.Errorf("expected status 202 once elected, got %d", status)
+	}
+	if got := drain(events); len(got) != 1 || got[0] != "default/harbor" {
+		t.Errorf("expected default/harbor triggered once elected, got %v", got)
+	}
+}
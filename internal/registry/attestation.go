@@ -0,0 +1,390 @@
+// Attestation policy for bundles: artifacts attached to the bundle digest as OCI referrers.
+package registry
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+)
+
+// Operators of AttestationPredicate.
+const (
+	PredicateExists             = "Exists"
+	PredicateEquals             = "Equals"
+	PredicateNotEquals          = "NotEquals"
+	PredicateLessThanOrEqual    = "LessThanOrEqual"
+	PredicateGreaterThanOrEqual = "GreaterThanOrEqual"
+)
+
+// attestationCacheSize bounds the number of verified digests an OCIClient keeps.
+const attestationCacheSize = 1000
+
+// maxAttestationSize bounds the size of an attestation read from the registry.
+// SBOMs of large bundles can be a few megabytes.
+const maxAttestationSize = 16 << 20
+
+// AttestationError indicates the bundle digest doesn't meet an attestation requirement: it has
+// no referrer of the artifact type, or none with the annotations and content the requirement
+// asks for. Retrying won't help until the attestation is attached.
+type AttestationError struct {
+	Err error
+}
+
+func (e *AttestationError) Error() string {
+	return fmt.Sprintf("attestation requirements not met: %v", e.Err)
+}
+
+func (e *AttestationError) Unwrap() error {
+	return e.Err
+}
+
+// AttestationRequirement requires a referrer of ArtifactType attached to the bundle digest,
+// with the Annotations on its manifest and content satisfying all Predicates.
+type AttestationRequirement struct {
+	ArtifactType string
+	Annotations  map[string]string
+	Predicates   []AttestationPredicate
+}
+
+// AttestationPredicate is a condition on the value at Path (dot-separated, numeric segments
+// index arrays) of an attestation's JSON content. An empty Operator means PredicateEquals.
+type AttestationPredicate struct {
+	Path     string
+	Operator string
+	Value    string
+}
+
+// VerifyAttestations checks that the manifest digest in repoURL has referrers meeting every
+// requirement. Referrers are listed with the OCI referrers API, or the referrers tag schema
+// (sha256-<hex>) on registries without it. auth is an optional authn.Authenticator; if nil,
+// anonymous access is used. The registry is reached with the TLS and proxy configuration of
+// ctx (see WithTLS and WithProxy), through the host's connection pool and rate limit.
+//
+// Attestation content is read from the first layer of the referrer. DSSE envelopes (in-toto
+// attestations) are unwrapped to their statement; their signatures aren't verified.
+//
+// A digest meeting the requirements is cached, so it's only checked again for other
+// requirements. Unmet requirements aren't cached: the attestation may be attached later.
+//
+// Returns an AttestationError if a requirement isn't met. Failures to reach the registry are
+// returned as registry errors (AuthError, NetworkError, ...) so they can be retried.
+func (c *OCIClient) VerifyAttestations(
+	ctx context.Context,
+	repoURL, digest string,
+	auth authn.Authenticator,
+	requirements []AttestationRequirement,
+) error {
+	ref, err := name.NewDigest(fmt.Sprintf("%s@%s", repoURL, digest), tlsFrom(ctx).nameOptions()...)
+	if err != nil {
+		return fmt.Errorf("invalid digest reference: %w", err)
+	}
+	key, err := attestationKey(ref, requirements)
+	if err != nil {
+		return err
+	}
+	if c.cachedAttestations(key) {
+		return nil
+	}
+
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	puller, release, err := c.puller(ctx, ref.Registry, auth)
+	if err != nil {
+		return fmt.Errorf("failed to list referrers: %w", classifyError(err))
+	}
+	err = verifyAttestations(ctx, puller, ref, requirements)
+	var attestationErr *AttestationError
+	if errors.As(err, &attestationErr) {
+		release(nil)
+		return err
+	}
+	release(err)
+	if err != nil {
+		return err
+	}
+	c.storeAttestations(key)
+	return nil
+}
+
+// verifyAttestations lists the referrers of ref and checks them against every requirement.
+func verifyAttestations(
+	ctx context.Context,
+	puller *remote.Puller,
+	ref name.Digest,
+	requirements []AttestationRequirement,
+) error {
+	index, err := remote.Referrers(ref, remote.WithContext(ctx), remote.Reuse(puller))
+	if err != nil {
+		return fmt.Errorf("failed to list referrers: %w", classifyError(err))
+	}
+	manifest, err := index.IndexManifest()
+	if err != nil {
+		return fmt.Errorf("failed to list referrers: %w", classifyError(err))
+	}
+
+	for _, requirement := range requirements {
+		if err := checkRequirement(ctx, puller, ref, manifest.Manifests, requirement); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkRequirement checks that one of the referrers meets requirement.
+// Returns an AttestationError explaining why the last candidate was rejected if none does.
+func checkRequirement(
+	ctx context.Context,
+	puller *remote.Puller,
+	ref name.Digest,
+	referrers []v1.Descriptor,
+	requirement AttestationRequirement,
+) error {
+	lastErr := fmt.Errorf("no referrer of type %s for %s", requirement.ArtifactType, ref.DigestStr())
+	for _, desc := range referrers {
+		if desc.ArtifactType != requirement.ArtifactType {
+			continue
+		}
+		if len(requirement.Annotations) == 0 && len(requirement.Predicates) == 0 {
+			return nil
+		}
+
+		referrer, err := puller.Get(ctx, ref.Context().Digest(desc.Digest.String()))
+		if err != nil {
+			return fmt.Errorf("failed to fetch referrer %s: %w", desc.Digest, classifyError(err))
+		}
+		manifest, err := v1.ParseManifest(bytes.NewReader(referrer.Manifest))
+		if err != nil {
+			return fmt.Errorf("failed to fetch referrer %s: %w", desc.Digest, err)
+		}
+		if err := checkAnnotations(manifest.Annotations, requirement.Annotations); err != nil {
+			lastErr = fmt.Errorf("referrer %s of type %s: %w", desc.Digest, desc.ArtifactType, err)
+			continue
+		}
+		if len(requirement.Predicates) == 0 {
+			return nil
+		}
+
+		if len(manifest.Layers) == 0 {
+			lastErr = fmt.Errorf("referrer %s of type %s has no content", desc.Digest, desc.ArtifactType)
+			continue
+		}
+		layer, err := puller.Layer(ctx, ref.Context().Digest(manifest.Layers[0].Digest.String()))
+		if err != nil {
+			return fmt.Errorf("failed to fetch referrer %s: %w", desc.Digest, classifyError(err))
+		}
+		content, err := readPayload(layer.Compressed, maxAttestationSize)
+		if err != nil {
+			return fmt.Errorf("failed to fetch referrer %s: %w", desc.Digest, classifyError(err))
+		}
+		doc, err := parseAttestation(content)
+		if err == nil {
+			err = evaluatePredicates(doc, requirement.Predicates)
+		}
+		if err != nil {
+			lastErr = fmt.Errorf("referrer %s of type %s: %w", desc.Digest, desc.ArtifactType, err)
+			continue
+		}
+		return nil
+	}
+	return &AttestationError{Err: lastErr}
+}
+
+// attestationKey identifies a digest and the requirements it was checked against in the cache.
+func attestationKey(ref name.Digest, requirements []AttestationRequirement) (string, error) {
+	data, err := json.Marshal(requirements)
+	if err != nil {
+		return "", fmt.Errorf("invalid attestation requirements: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	return ref.Context().Name() + "@" + ref.DigestStr() + "|" + hex.EncodeToString(sum[:]), nil
+}
+
+// cachedAttestations reports whether the digest and requirements of key were verified before.
+func (c *OCIClient) cachedAttestations(key string) bool {
+	c.metadataMu.Lock()
+	defer c.metadataMu.Unlock()
+	_, ok := c.attested[key]
+	return ok
+}
+
+// storeAttestations caches that the digest of key meets its requirements. When the cache is
+// full, an arbitrary entry is dropped.
+func (c *OCIClient) storeAttestations(key string) {
+	c.metadataMu.Lock()
+	defer c.metadataMu.Unlock()
+	if c.attested == nil {
+		c.attested = make(map[string]struct{})
+	}
+	if _, ok := c.attested[key]; !ok && len(c.attested) >= attestationCacheSize {
+		for evict := range c.attested {
+			delete(c.attested, evict)
+			break
+		}
+	}
+	c.attested[key] = struct{}{}
+}
+
+// checkAnnotations checks that annotations have the wanted values.
+func checkAnnotations(annotations, want map[string]string) error {
+	for key, value := range want {
+		got, ok := annotations[key]
+		if !ok {
+			return fmt.Errorf("annotation %s not found", key)
+		}
+		if got != value {
+			return fmt.Errorf("annotation %s is %q, expected %q", key, got, value)
+		}
+	}
+	return nil
+}
+
+// parseAttestation parses a JSON attestation, unwrapping DSSE envelopes
+// ({"payloadType": ..., "payload": <base64>}) to their payload.
+func parseAttestation(content []byte) (any, error) {
+	doc, err := decodeJSON(content)
+	if err != nil {
+		return nil, fmt.Errorf("content is not JSON: %w", err)
+	}
+	envelope, ok := doc.(map[string]any)
+	if !ok || envelope["payloadType"] == nil {
+		return doc, nil
+	}
+	encoded, ok := envelope["payload"].(string)
+	if !ok {
+		return doc, nil
+	}
+	payload, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("malformed DSSE payload: %w", err)
+	}
+	if doc, err = decodeJSON(payload); err != nil {
+		return nil, fmt.Errorf("DSSE payload is not JSON: %w", err)
+	}
+	return doc, nil
+}
+
+// decodeJSON decodes a JSON document, keeping numbers as json.Number.
+func decodeJSON(data []byte) (any, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var doc any
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// evaluatePredicates checks that doc satisfies all predicates.
+// Returns an error describing the first one it doesn't.
+func evaluatePredicates(doc any, predicates []AttestationPredicate) error {
+	for _, predicate := range predicates {
+		if err := evaluatePredicate(doc, predicate); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// evaluatePredicate checks that doc satisfies predicate.
+func evaluatePredicate(doc any, predicate AttestationPredicate) error {
+	value, ok := lookupPath(doc, predicate.Path)
+	if !ok {
+		return fmt.Errorf("%s not found", predicate.Path)
+	}
+
+	switch predicate.Operator {
+	case PredicateExists:
+		return nil
+	case PredicateEquals, "":
+		if got := valueString(value); got != predicate.Value {
+			return fmt.Errorf("%s is %q, expected %q", predicate.Path, got, predicate.Value)
+		}
+		return nil
+	case PredicateNotEquals:
+		if got := valueString(value); got == predicate.Value {
+			return fmt.Errorf("%s is %q", predicate.Path, got)
+		}
+		return nil
+	case PredicateLessThanOrEqual, PredicateGreaterThanOrEqual:
+		got, ok := valueNumber(value)
+		if !ok {
+			return fmt.Errorf("%s is %s, not a number", predicate.Path, valueString(value))
+		}
+		limit, err := strconv.ParseFloat(predicate.Value, 64)
+		if err != nil {
+			return fmt.Errorf("invalid number %q for %s", predicate.Value, predicate.Path)
+		}
+		if predicate.Operator == PredicateLessThanOrEqual && got > limit {
+			return fmt.Errorf("%s is %v, expected at most %v", predicate.Path, got, limit)
+		}
+		if predicate.Operator == PredicateGreaterThanOrEqual && got < limit {
+			return fmt.Errorf("%s is %v, expected at least %v", predicate.Path, got, limit)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown predicate operator %q", predicate.Operator)
+	}
+}
+
+// lookupPath returns the value at the dot-separated path in doc.
+func lookupPath(doc any, path string) (any, bool) {
+	value := doc
+	for _, segment := range strings.Split(path, ".") {
+		switch node := value.(type) {
+		case map[string]any:
+			next, ok := node[segment]
+			if !ok {
+				return nil, false
+			}
+			value = next
+		case []any:
+			i, err := strconv.Atoi(segment)
+			if err != nil || i < 0 || i >= len(node) {
+				return nil, false
+			}
+			value = node[i]
+		default:
+			return nil, false
+		}
+	}
+	return value, true
+}
+
+// valueString formats a JSON value for string comparison: strings as they are, other values
+// as JSON.
+func valueString(value any) string {
+	if s, ok := value.(string); ok {
+		return s
+	}
+	data, _ := json.Marshal(value)
+	return string(data)
+}
+
+// valueNumber returns the number of a JSON value: numbers as they are, arrays and objects by
+// their length.
+func valueNumber(value any) (float64, bool) {
+	switch v := value.(type) {
+	case json.Number:
+		n, err := v.Float64()
+		return n, err == nil
+	case []any:
+		return float64(len(v)), true
+	case map[string]any:
+		return float64(len(v)), true
+	default:
+		return 0, false
+	}
+}
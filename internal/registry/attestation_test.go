@@ -0,0 +1,224 @@
+package registry
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	ggcrregistry "github.com/google/go-containerregistry/pkg/registry"
+
+	testingutil "github.com/werf/k8s-werf-operator-go/internal/testing"
+)
+
+// Artifact types attached to the test bundle.
+const (
+	testSBOMType       = "application/spdx+json"
+	testProvenanceType = "application/vnd.in-toto+json"
+	testScanType       = "application/vnd.example.scan+json"
+)
+
+// pushAttestedBundle pushes a bundle to an in-process registry and attaches an SBOM, a SLSA
+// provenance in a DSSE envelope and a vulnerability scan to it. Returns the repository URL
+// and the bundle digest.
+func pushAttestedBundle(t *testing.T, referrersAPI bool) (string, string) {
+	t.Helper()
+
+	handler := ggcrregistry.New(
+		ggcrregistry.Logger(log.New(io.Discard, "", 0)),
+		ggcrregistry.WithReferrersSupport(referrersAPI),
+	)
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	repoURL := strings.TrimPrefix(server.URL, "http://") + "/test/attested"
+	digest := pushImage(t, repoURL, "v1.0.0", authn.Anonymous)
+
+	statement := `{"_type": "https://in-toto.io/Statement/v1", "predicateType": "https://slsa.dev/provenance/v1",` +
+		` "predicate": {"buildDefinition": {"buildType": "https://werf.io/bundle"}}}`
+	envelope, err := json.Marshal(map[string]string{
+		"payloadType": "application/vnd.in-toto+json",
+		"payload":     base64.StdEncoding.EncodeToString([]byte(statement)),
+	})
+	if err != nil {
+		t.Fatalf("failed to encode envelope: %v", err)
+	}
+	artifacts := []struct {
+		artifactType string
+		content      string
+		annotations  map[string]string
+	}{
+		{testSBOMType, `{"spdxVersion": "SPDX-2.3", "packages": [{"name": "app"}, {"name": "db"}]}`, nil},
+		{testProvenanceType, string(envelope), map[string]string{"predicateType": "https://slsa.dev/provenance/v1"}},
+		{testScanType, `{"summary": {"critical": 0, "high": 2}}`, nil},
+	}
+	ctx := context.Background()
+	for _, artifact := range artifacts {
+		if err := testingutil.AttachArtifact(ctx, repoURL, digest, artifact.artifactType,
+			[]byte(artifact.content), artifact.annotations, nil); err != nil {
+			t.Fatalf("failed to attach %s: %v", artifact.artifactType, err)
+		}
+	}
+	return repoURL, digest
+}
+
+func TestVerifyAttestations(t *testing.T) {
+	tests := []struct {
+		name         string
+		requirements []AttestationRequirement
+		wantErr      string
+	}{
+		{
+			name:         "artifact type",
+			requirements: []AttestationRequirement{{ArtifactType: testSBOMType}},
+		},
+		{
+			name: "all attestations",
+			requirements: []AttestationRequirement{
+				{ArtifactType: testSBOMType, Predicates: []AttestationPredicate{
+					{Path: "spdxVersion", Value: "SPDX-2.3"},
+					{Path: "packages", Operator: PredicateGreaterThanOrEqual, Value: "1"},
+				}},
+				{
+					ArtifactType: testProvenanceType,
+					Annotations:  map[string]string{"predicateType": "https://slsa.dev/provenance/v1"},
+					Predicates: []AttestationPredicate{
+						{Path: "predicate.buildDefinition.buildType", Operator: PredicateEquals, Value: "https://werf.io/bundle"},
+					},
+				},
+				{ArtifactType: testScanType, Predicates: []AttestationPredicate{
+					{Path: "summary.critical", Operator: PredicateLessThanOrEqual, Value: "0"},
+				}},
+			},
+		},
+		{
+			name:         "missing artifact type",
+			requirements: []AttestationRequirement{{ArtifactType: "application/vnd.cyclonedx+json"}},
+			wantErr:      "no referrer of type application/vnd.cyclonedx+json",
+		},
+		{
+			name: "annotation mismatch",
+			requirements: []AttestationRequirement{{
+				ArtifactType: testProvenanceType,
+				Annotations:  map[string]string{"predicateType": "https://spdx.dev/Document"},
+			}},
+			wantErr: `annotation predicateType is "https://slsa.dev/provenance/v1"`,
+		},
+		{
+			name: "predicate not satisfied",
+			requirements: []AttestationRequirement{{ArtifactType: testScanType, Predicates: []AttestationPredicate{
+				{Path: "summary.high", Operator: PredicateLessThanOrEqual, Value: "0"},
+			}}},
+			wantErr: "summary.high is 2, expected at most 0",
+		},
+		{
+			name: "path not found",
+			requirements: []AttestationRequirement{{ArtifactType: testSBOMType, Predicates: []AttestationPredicate{
+				{Path: "creationInfo.created", Operator: PredicateExists},
+			}}},
+			wantErr: "creationInfo.created not found",
+		},
+	}
+
+	for _, referrersAPI := range []bool{true, false} {
+		repoURL, digest := pushAttestedBundle(t, referrersAPI)
+		client := NewOCIClient()
+		for _, tt := range tests {
+			name := tt.name
+			if !referrersAPI {
+				name += " (tag schema)"
+			}
+			t.Run(name, func(t *testing.T) {
+				err := client.VerifyAttestations(context.Background(), repoURL, digest, nil, tt.requirements)
+				if tt.wantErr == "" {
+					if err != nil {
+						t.Errorf("VerifyAttestations() error = %v", err)
+					}
+					return
+				}
+				var attestationErr *AttestationError
+				if !errors.As(err, &attestationErr) {
+					t.Fatalf("expected AttestationError, got %v", err)
+				}
+				if !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("expected error containing %q, got %q", tt.wantErr, err.Error())
+				}
+			})
+		}
+	}
+}
+
+func TestVerifyAttestations_CachesVerifiedDigests(t *testing.T) {
+	repoURL, digest := pushAttestedBundle(t, true)
+	client := NewOCIClient()
+	sbom := []AttestationRequirement{{ArtifactType: testSBOMType, Predicates: []AttestationPredicate{
+		{Path: "spdxVersion", Value: "SPDX-2.3"},
+	}}}
+	if err := client.VerifyAttestations(context.Background(), repoURL, digest, nil, sbom); err != nil {
+		t.Fatalf("VerifyAttestations() error = %v", err)
+	}
+
+	// A verified digest isn't fetched again: the call succeeds without reaching the registry
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := client.VerifyAttestations(cancelled, repoURL, digest, nil, sbom); err != nil {
+		t.Errorf("expected the cached result, got %v", err)
+	}
+
+	// Other requirements are checked against the registry
+	scan := []AttestationRequirement{{ArtifactType: testScanType}}
+	if err := client.VerifyAttestations(cancelled, repoURL, digest, nil, scan); err == nil {
+		t.Error("expected other requirements to reach the registry")
+	}
+
+	// Unmet requirements aren't cached
+	missing := []AttestationRequirement{{ArtifactType: "application/vnd.cyclonedx+json"}}
+	for range 2 {
+		var attestationErr *AttestationError
+		if err := client.VerifyAttestations(context.Background(), repoURL, digest, nil, missing); !errors.As(err, &attestationErr) {
+			t.Fatalf("expected AttestationError, got %v", err)
+		}
+	}
+	if err := client.VerifyAttestations(cancelled, repoURL, digest, nil, missing); err == nil {
+		t.Error("expected an unmet requirement to be checked again")
+	}
+}
+
+func TestEvaluatePredicate(t *testing.T) {
+	doc, err := decodeJSON([]byte(`{"name": "app", "version": 3, "signed": true, "layers": [{"size": 10}], "labels": {}}`))
+	if err != nil {
+		t.Fatalf("failed to decode document: %v", err)
+	}
+
+	tests := []struct {
+		predicate AttestationPredicate
+		want      bool
+	}{
+		{AttestationPredicate{Path: "name", Operator: PredicateExists}, true},
+		{AttestationPredicate{Path: "name", Value: "app"}, true},
+		{AttestationPredicate{Path: "name", Operator: PredicateNotEquals, Value: "app"}, false},
+		{AttestationPredicate{Path: "signed", Operator: PredicateEquals, Value: "true"}, true},
+		{AttestationPredicate{Path: "version", Operator: PredicateEquals, Value: "3"}, true},
+		{AttestationPredicate{Path: "version", Operator: PredicateGreaterThanOrEqual, Value: "2.5"}, true},
+		{AttestationPredicate{Path: "version", Operator: PredicateLessThanOrEqual, Value: "2"}, false},
+		{AttestationPredicate{Path: "layers.0.size", Operator: PredicateLessThanOrEqual, Value: "10"}, true},
+		{AttestationPredicate{Path: "layers.1.size", Operator: PredicateExists}, false},
+		{AttestationPredicate{Path: "labels", Operator: PredicateLessThanOrEqual, Value: "0"}, true},
+		{AttestationPredicate{Path: "name", Operator: PredicateLessThanOrEqual, Value: "1"}, false},
+		{AttestationPredicate{Path: "version", Operator: PredicateLessThanOrEqual, Value: "many"}, false},
+		{AttestationPredicate{Path: "name", Operator: "Matches", Value: "a.*"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.predicate.Path+" "+tt.predicate.Operator+" "+tt.predicate.Value, func(t *testing.T) {
+			err := evaluatePredicate(doc, tt.predicate)
+			if (err == nil) != tt.want {
+				t.Errorf("evaluatePredicate(%+v) error = %v, want satisfied %v", tt.predicate, err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,213 @@
+// Registry credential handling for Kubernetes Secrets.
+package registry
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Secret data keys used for the basic (non-dockerconfig) credential layout.
+// These match the keys of the built-in kubernetes.io/basic-auth Secret type,
+// plus "token" for registries that issue access tokens instead of passwords.
+const (
+	SecretKeyUsername = "username"
+	SecretKeyPassword = "password"
+	SecretKeyToken    = "token"
+)
+
+// dockerConfigJSON mirrors the layout of ~/.docker/config.json and the
+// .dockerconfigjson key of kubernetes.io/dockerconfigjson Secrets.
+type dockerConfigJSON struct {
+	Auths map[string]dockerConfigEntry `json:"auths"`
+}
+
+// dockerConfigEntry is a single registry entry in a Docker config file.
+type dockerConfigEntry struct {
+	Username      string `json:"username,omitempty"`
+	Password      string `json:"password,omitempty"`
+	Auth          string `json:"auth,omitempty"`
+	IdentityToken string `json:"identitytoken,omitempty"`
+	RegistryToken string `json:"registrytoken,omitempty"`
+}
+
+// AuthFromSecret builds an authn.Authenticator for repoURL from a Kubernetes Secret.
+//
+// Supported Secret layouts:
+//   - kubernetes.io/dockerconfigjson: the .dockerconfigjson key is parsed and the
+//     entry matching the registry host of repoURL is used
+//   - kubernetes.io/dockercfg: the legacy .dockercfg key, matched the same way
+//   - basic layout (Opaque or kubernetes.io/basic-auth): username and password keys,
+//     or a token key. A token with a username is sent as the password (e.g. GHCR
+//     personal access tokens); a token on its own is sent as a bearer token.
+//
+// Returns an AuthError if the Secret is malformed or has no credentials for the
+// registry host, so callers can surface a clear status instead of retrying blindly,
+// and an InvalidRepositoryError if repoURL isn't a valid repository reference.
+func AuthFromSecret(secret *corev1.Secret, repoURL string) (authn.Authenticator, error) {
+	cfg, err := authConfigFromSecret(secret, repoURL)
+	if err != nil {
+		return nil, err
+	}
+	return authn.FromConfig(*cfg), nil
+}
+
+// DockerConfigJSON renders the credentials for repoURL from a Kubernetes Secret as a
+// Docker config.json document containing a single entry for the registry host.
+// Accepts the same Secret layouts as AuthFromSecret, so tools that read DOCKER_CONFIG
+// (such as werf) see the same credentials the operator uses for polling.
+func DockerConfigJSON(secret *corev1.Secret, repoURL string) ([]byte, error) {
+	cfg, err := authConfigFromSecret(secret, repoURL)
+	if err != nil {
+		return nil, err
+	}
+	return DockerConfigJSONFromAuth(cfg, repoURL)
+}
+
+// DockerConfigJSONFromAuth renders cfg as a Docker config.json document containing a single
+// entry for the registry host of repoURL.
+func DockerConfigJSONFromAuth(cfg *authn.AuthConfig, repoURL string) ([]byte, error) {
+	repo, err := name.NewRepository(repoURL)
+	if err != nil {
+		return nil, &InvalidRepositoryError{URL: repoURL, Err: err}
+	}
+
+	entry := dockerConfigEntry{
+		Username:      cfg.Username,
+		Password:      cfg.Password,
+		IdentityToken: cfg.IdentityToken,
+		RegistryToken: cfg.RegistryToken,
+	}
+	if cfg.Username != "" || cfg.Password != "" {
+		entry.Auth = base64.StdEncoding.EncodeToString([]byte(cfg.Username + ":" + cfg.Password))
+	}
+
+	// Docker Hub credentials must be keyed by the legacy index URL for docker to find them
+	host := repo.RegistryStr()
+	if host == name.DefaultRegistry {
+		host = "https://index.docker.io/v1/"
+	}
+
+	return json.Marshal(dockerConfigJSON{Auths: map[string]dockerConfigEntry{host: entry}})
+}
+
+// HasCredentials reports whether the Secret holds credentials for the registry of repoURL:
+// a Docker config entry for its host, or username/password/token keys, which apply to any host.
+func HasCredentials(secret *corev1.Secret, repoURL string) bool {
+	_, err := authConfigFromSecret(secret, repoURL)
+	return err == nil
+}
+
+// authConfigFromSecret extracts the credentials for repoURL's registry host from secret.
+func authConfigFromSecret(secret *corev1.Secret, repoURL string) (*authn.AuthConfig, error) {
+	if secret == nil {
+		return nil, &AuthError{Err: fmt.Errorf("registry secret is nil")}
+	}
+
+	repo, err := name.NewRepository(repoURL)
+	if err != nil {
+		return nil, &InvalidRepositoryError{URL: repoURL, Err: err}
+	}
+	host := repo.RegistryStr()
+
+	if data, ok := secret.Data[corev1.DockerConfigJsonKey]; ok {
+		var cfg dockerConfigJSON
+		if err := json.Unmarshal(data, &cfg); err != nil {
+			return nil, &AuthError{Err: fmt.Errorf("secret %q: invalid %s: %w",
+				secret.Name, corev1.DockerConfigJsonKey, err)}
+		}
+		return authConfigForHost(secret.Name, cfg.Auths, host)
+	}
+
+	if data, ok := secret.Data[corev1.DockerConfigKey]; ok {
+		// Legacy .dockercfg is the "auths" map without the wrapping object
+		var auths map[string]dockerConfigEntry
+		if err := json.Unmarshal(data, &auths); err != nil {
+			return nil, &AuthError{Err: fmt.Errorf("secret %q: invalid %s: %w",
+				secret.Name, corev1.DockerConfigKey, err)}
+		}
+		return authConfigForHost(secret.Name, auths, host)
+	}
+
+	username := string(secret.Data[SecretKeyUsername])
+	password := string(secret.Data[SecretKeyPassword])
+	token := string(secret.Data[SecretKeyToken])
+
+	switch {
+	case username != "" && password != "":
+		return &authn.AuthConfig{Username: username, Password: password}, nil
+	case username != "" && token != "":
+		return &authn.AuthConfig{Username: username, Password: token}, nil
+	case token != "":
+		return &authn.AuthConfig{RegistryToken: token}, nil
+	}
+
+	return nil, &AuthError{Err: fmt.Errorf(
+		"secret %q has no registry credentials: expected %s, or %s/%s, or %s keys",
+		secret.Name, corev1.DockerConfigJsonKey, SecretKeyUsername, SecretKeyPassword, SecretKeyToken)}
+}
+
+// authConfigForHost finds the Docker config entry for host and decodes it.
+// Entry keys are normalised before matching, so "https://ghcr.io/v1/" matches "ghcr.io".
+func authConfigForHost(secretName string, auths map[string]dockerConfigEntry, host string) (*authn.AuthConfig, error) {
+	for key, entry := range auths {
+		if normalizeRegistryHost(key) != normalizeRegistryHost(host) {
+			continue
+		}
+
+		cfg := &authn.AuthConfig{
+			Username:      entry.Username,
+			Password:      entry.Password,
+			IdentityToken: entry.IdentityToken,
+			RegistryToken: entry.RegistryToken,
+		}
+
+		// The "auth" field is base64(username:password) and takes precedence when set
+		if entry.Auth != "" {
+			decoded, err := base64.StdEncoding.DecodeString(entry.Auth)
+			if err != nil {
+				return nil, &AuthError{Err: fmt.Errorf("secret %q: invalid auth field for %q: %w",
+					secretName, key, err)}
+			}
+			user, pass, found := strings.Cut(string(decoded), ":")
+			if !found {
+				return nil, &AuthError{Err: fmt.Errorf("secret %q: auth field for %q is not username:password",
+					secretName, key)}
+			}
+			cfg.Username = user
+			cfg.Password = pass
+		}
+
+		if *cfg == (authn.AuthConfig{}) {
+			return nil, &AuthError{Err: fmt.Errorf("secret %q: entry for %q has no credentials", secretName, key)}
+		}
+		return cfg, nil
+	}
+
+	return nil, &AuthError{Err: fmt.Errorf("secret %q has no credentials for registry %q", secretName, host)}
+}
+
+// normalizeRegistryHost reduces a Docker config key or registry name to a bare host[:port].
+// Docker Hub aliases (docker.io, index.docker.io, registry-1.docker.io) collapse to one value.
+func normalizeRegistryHost(key string) string {
+	host := key
+	if strings.Contains(host, "://") {
+		if u, err := url.Parse(host); err == nil {
+			host = u.Host
+		}
+	}
+	host, _, _ = strings.Cut(host, "/")
+	host = strings.ToLower(host)
+
+	switch host {
+	case "docker.io", "index.docker.io", "registry-1.docker.io":
+		return name.DefaultRegistry
+	}
+	return host
+}
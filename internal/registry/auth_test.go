@@ -0,0 +1,408 @@
+package registry
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	ggcrregistry "github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// startBasicAuthRegistry starts an in-process OCI registry that requires HTTP Basic auth.
+// Returns the registry host (e.g., "127.0.0.1:34567"). The server is stopped on test cleanup.
+func startBasicAuthRegistry(t *testing.T, username, password string) string {
+	t.Helper()
+
+	handler := ggcrregistry.New(ggcrregistry.Logger(log.New(io.Discard, "", 0)))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, pass, ok := r.BasicAuth()
+		if !ok || user != username || pass != password {
+			w.Header().Set("WWW-Authenticate", `Basic realm="test"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	return strings.TrimPrefix(server.URL, "http://")
+}
+
+// pushRandomImage pushes a random image to repoURL:tag using the given credentials.
+func pushRandomImage(t *testing.T, repoURL, tag string, auth authn.Authenticator) {
+	t.Helper()
+
+	img, err := random.Image(256, 1)
+	if err != nil {
+		t.Fatalf("failed to create random image: %v", err)
+	}
+	ref, err := name.ParseReference(fmt.Sprintf("%s:%s", repoURL, tag))
+	if err != nil {
+		t.Fatalf("failed to parse reference: %v", err)
+	}
+	if err := remote.Write(ref, img, remote.WithAuth(auth)); err != nil {
+		t.Fatalf("failed to push image: %v", err)
+	}
+}
+
+// dockerConfigSecret builds a kubernetes.io/dockerconfigjson Secret for host.
+func dockerConfigSecret(host, username, password string) *corev1.Secret {
+	auth := base64.StdEncoding.EncodeToString([]byte(username + ":" + password))
+	config := fmt.Sprintf(`{"auths":{%q:{"auth":%q}}}`, host, auth)
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "registry-creds"},
+		Type:       corev1.SecretTypeDockerConfigJson,
+		Data:       map[string][]byte{corev1.DockerConfigJsonKey: []byte(config)},
+	}
+}
+
+func TestAuthFromSecret_Layouts(t *testing.T) {
+	tests := []struct {
+		name     string
+		secret   *corev1.Secret
+		repoURL  string
+		wantUser string
+		wantPass string
+		wantTok  string
+	}{
+		{
+			name:     "dockerconfigjson with auth field",
+			secret:   dockerConfigSecret("ghcr.io", "alice", "s3cret"),
+			repoURL:  "ghcr.io/org/bundle",
+			wantUser: "alice",
+			wantPass: "s3cret",
+		},
+		{
+			name: "dockerconfigjson with username and password fields and URL key",
+			secret: &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "creds"},
+				Data: map[string][]byte{
+					corev1.DockerConfigJsonKey: []byte(
+						`{"auths":{"https://registry.example.com/v1/":{"username":"bob","password":"pw"}}}`),
+				},
+			},
+			repoURL:  "registry.example.com/team/bundle",
+			wantUser: "bob",
+			wantPass: "pw",
+		},
+		{
+			name: "dockerconfigjson for Docker Hub matches docker.io alias",
+			secret: &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "creds"},
+				Data: map[string][]byte{
+					corev1.DockerConfigJsonKey: []byte(
+						`{"auths":{"https://index.docker.io/v1/":{"username":"hub","password":"pw"}}}`),
+				},
+			},
+			repoURL:  "docker.io/org/bundle",
+			wantUser: "hub",
+			wantPass: "pw",
+		},
+		{
+			name: "legacy dockercfg",
+			secret: &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "creds"},
+				Data: map[string][]byte{
+					corev1.DockerConfigKey: []byte(`{"quay.io":{"username":"q","password":"pw"}}`),
+				},
+			},
+			repoURL:  "quay.io/org/bundle",
+			wantUser: "q",
+			wantPass: "pw",
+		},
+		{
+			name: "basic username and password",
+			secret: &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "creds"},
+				Type:       corev1.SecretTypeBasicAuth,
+				Data: map[string][]byte{
+					SecretKeyUsername: []byte("carol"),
+					SecretKeyPassword: []byte("pw"),
+				},
+			},
+			repoURL:  "ghcr.io/org/bundle",
+			wantUser: "carol",
+			wantPass: "pw",
+		},
+		{
+			name: "username with token uses token as password",
+			secret: &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "creds"},
+				Data: map[string][]byte{
+					SecretKeyUsername: []byte("dave"),
+					SecretKeyToken:    []byte("ghp_token"),
+				},
+			},
+			repoURL:  "ghcr.io/org/bundle",
+			wantUser: "dave",
+			wantPass: "ghp_token",
+		},
+		{
+			name: "token only is a bearer token",
+			secret: &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "creds"},
+				Data:       map[string][]byte{SecretKeyToken: []byte("bearer-token")},
+			},
+			repoURL: "ghcr.io/org/bundle",
+			wantTok: "bearer-token",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			auth, err := AuthFromSecret(tt.secret, tt.repoURL)
+			if err != nil {
+				t.Fatalf("AuthFromSecret() error = %v", err)
+			}
+			cfg, err := auth.Authorization()
+			if err != nil {
+				t.Fatalf("Authorization() error = %v", err)
+			}
+			if cfg.Username != tt.wantUser || cfg.Password != tt.wantPass {
+				t.Errorf("got credentials %q/%q, want %q/%q", cfg.Username, cfg.Password, tt.wantUser, tt.wantPass)
+			}
+			if cfg.RegistryToken != tt.wantTok {
+				t.Errorf("got registry token %q, want %q", cfg.RegistryToken, tt.wantTok)
+			}
+		})
+	}
+}
+
+func TestAuthFromSecret_Malformed(t *testing.T) {
+	tests := []struct {
+		name   string
+		secret *corev1.Secret
+	}{
+		{
+			name:   "nil secret",
+			secret: nil,
+		},
+		{
+			name: "invalid dockerconfigjson",
+			secret: &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "creds"},
+				Data:       map[string][]byte{corev1.DockerConfigJsonKey: []byte("{not json")},
+			},
+		},
+		{
+			name:   "dockerconfigjson without entry for host",
+			secret: dockerConfigSecret("quay.io", "user", "pw"),
+		},
+		{
+			name: "auth field is not base64",
+			secret: &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "creds"},
+				Data: map[string][]byte{
+					corev1.DockerConfigJsonKey: []byte(`{"auths":{"ghcr.io":{"auth":"!!!"}}}`),
+				},
+			},
+		},
+		{
+			name: "no recognised keys",
+			secret: &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "creds"},
+				Data:       map[string][]byte{"something": []byte("else")},
+			},
+		},
+		{
+			name: "username without password",
+			secret: &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "creds"},
+				Data:       map[string][]byte{SecretKeyUsername: []byte("user")},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := AuthFromSecret(tt.secret, "ghcr.io/org/bundle")
+			var authErr *AuthError
+			if !errors.As(err, &authErr) {
+				t.Fatalf("expected AuthError, got %v", err)
+			}
+		})
+	}
+}
+
+func TestAuthFromSecret_InvalidRepositoryURL(t *testing.T) {
+	secret := dockerConfigSecret("ghcr.io", "user", "pw")
+	for _, repoURL := range []string{"ghcr.io/Org/Bundle", "ghcr.io/org/bundle:", "ghcr.io/org/bundle@sha256"} {
+		_, err := AuthFromSecret(secret, repoURL)
+		var invalidRepo *InvalidRepositoryError
+		if !errors.As(err, &invalidRepo) {
+			t.Errorf("%s: expected InvalidRepositoryError, got %v", repoURL, err)
+		}
+		if _, err := DockerConfigJSONFromAuth(&authn.AuthConfig{Username: "user"}, repoURL); !errors.As(err, &invalidRepo) {
+			t.Errorf("%s: expected InvalidRepositoryError from DockerConfigJSONFromAuth, got %v", repoURL, err)
+		}
+	}
+}
+
+func TestHasCredentials(t *testing.T) {
+	tests := []struct {
+		name    string
+		secret  *corev1.Secret
+		repoURL string
+		want    bool
+	}{
+		{name: "entry for the host", secret: dockerConfigSecret("ghcr.io", "alice", "s3cret"), repoURL: "ghcr.io/org/bundle", want: true},
+		{name: "entry for another host", secret: dockerConfigSecret("ghcr.io", "alice", "s3cret"), repoURL: "mirror.example.com/org/bundle"},
+		{
+			name: "basic layout applies to any host",
+			secret: &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "creds"},
+				Data:       map[string][]byte{SecretKeyUsername: []byte("alice"), SecretKeyPassword: []byte("s3cret")},
+			},
+			repoURL: "mirror.example.com/org/bundle",
+			want:    true,
+		},
+		{name: "nil Secret", secret: nil, repoURL: "ghcr.io/org/bundle"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := HasCredentials(tt.secret, tt.repoURL); got != tt.want {
+				t.Errorf("HasCredentials() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDockerConfigJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		secret  *corev1.Secret
+		repoURL string
+		want    string
+	}{
+		{
+			name:    "dockerconfigjson keeps only the entry for the registry host",
+			secret:  dockerConfigSecret("ghcr.io", "alice", "s3cret"),
+			repoURL: "ghcr.io/org/bundle",
+			want:    `{"auths":{"ghcr.io":{"username":"alice","password":"s3cret","auth":"YWxpY2U6czNjcmV0"}}}`,
+		},
+		{
+			name: "basic layout",
+			secret: &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "creds"},
+				Data: map[string][]byte{
+					SecretKeyUsername: []byte("alice"),
+					SecretKeyPassword: []byte("s3cret"),
+				},
+			},
+			repoURL: "registry.example.com:5000/org/bundle",
+			want:    `{"auths":{"registry.example.com:5000":{"username":"alice","password":"s3cret","auth":"YWxpY2U6czNjcmV0"}}}`,
+		},
+		{
+			name: "token only",
+			secret: &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "creds"},
+				Data:       map[string][]byte{SecretKeyToken: []byte("bearer-token")},
+			},
+			repoURL: "ghcr.io/org/bundle",
+			want:    `{"auths":{"ghcr.io":{"registrytoken":"bearer-token"}}}`,
+		},
+		{
+			name: "Docker Hub uses the legacy index key",
+			secret: &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "creds"},
+				Data: map[string][]byte{
+					SecretKeyUsername: []byte("alice"),
+					SecretKeyPassword: []byte("s3cret"),
+				},
+			},
+			repoURL: "docker.io/org/bundle",
+			want:    `{"auths":{"https://index.docker.io/v1/":{"username":"alice","password":"s3cret","auth":"YWxpY2U6czNjcmV0"}}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DockerConfigJSON(tt.secret, tt.repoURL)
+			if err != nil {
+				t.Fatalf("DockerConfigJSON() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("DockerConfigJSON() = %s, want %s", got, tt.want)
+			}
+
+			// The rendered config must be readable back with the same credentials
+			roundTrip := &corev1.Secret{Data: map[string][]byte{corev1.DockerConfigJsonKey: got}}
+			if _, err := AuthFromSecret(roundTrip, tt.repoURL); err != nil {
+				t.Errorf("AuthFromSecret() on rendered config error = %v", err)
+			}
+		})
+	}
+
+	var authErr *AuthError
+	if _, err := DockerConfigJSON(dockerConfigSecret("quay.io", "u", "p"), "ghcr.io/org/bundle"); !errors.As(err, &authErr) {
+		t.Errorf("expected AuthError for secret without matching host, got %v", err)
+	}
+}
+
+// TestAuthFromSecret_LocalRegistry verifies that credentials from both Secret layouts
+// authenticate against a registry that enforces Basic auth.
+func TestAuthFromSecret_LocalRegistry(t *testing.T) {
+	ctx := context.Background()
+	host := startBasicAuthRegistry(t, "testuser", "testpass")
+	repoURL := host + "/test/private"
+
+	pushRandomImage(t, repoURL, "v1.0.0", &authn.Basic{Username: "testuser", Password: "testpass"})
+
+	secrets := map[string]*corev1.Secret{
+		"dockerconfigjson": dockerConfigSecret(host, "testuser", "testpass"),
+		"basic": {
+			ObjectMeta: metav1.ObjectMeta{Name: "basic"},
+			Data: map[string][]byte{
+				SecretKeyUsername: []byte("testuser"),
+				SecretKeyPassword: []byte("testpass"),
+			},
+		},
+	}
+
+	client := NewOCIClient()
+	for layout, secret := range secrets {
+		t.Run(layout, func(t *testing.T) {
+			auth, err := AuthFromSecret(secret, repoURL)
+			if err != nil {
+				t.Fatalf("AuthFromSecret() error = %v", err)
+			}
+			tags, _, err := client.ListTagsWithETag(ctx, repoURL, auth, "")
+			if err != nil {
+				t.Fatalf("ListTagsWithETag() with secret credentials failed: %v", err)
+			}
+			if len(tags) != 1 || tags[0] != "v1.0.0" {
+				t.Errorf("expected [v1.0.0], got %v", tags)
+			}
+		})
+	}
+
+	// Without credentials the registry rejects the request with an AuthError
+	_, _, err := client.ListTagsWithETag(ctx, repoURL, nil, "")
+	var authErr *AuthError
+	if !errors.As(err, &authErr) {
+		t.Errorf("expected AuthError without credentials, got %v", err)
+	}
+
+	// Wrong credentials are rejected too
+	wrong, err := AuthFromSecret(dockerConfigSecret(host, "testuser", "wrong"), repoURL)
+	if err != nil {
+		t.Fatalf("AuthFromSecret() error = %v", err)
+	}
+	_, _, err = client.ListTagsWithETag(ctx, repoURL, wrong, "")
+	if !errors.As(err, &authErr) {
+		t.Errorf("expected AuthError with wrong credentials, got %v", err)
+	}
+}
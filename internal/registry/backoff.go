@@ -6,6 +6,12 @@ import (
 	"time"
 )
 
+// Default retry policy for failed registry polls.
+const (
+	DefaultBackoffBase = 30 * time.Second
+	DefaultBackoffMax  = 8 * time.Minute
+)
+
 // CalculateBackoff returns the backoff duration for the given number of consecutive failures.
 // Uses exponential backoff: base 30s * 2^failures, capped at 8 minutes (480s).
 // Examples:
@@ -15,19 +21,34 @@ import (
 //   - 3 failures: 4m (30s * 2^3)
 //   - 4+ failures: 8m (capped)
 func CalculateBackoff(consecutiveFailures int32) time.Duration {
-	const baseDuration = 30 * time.Second
-	const maxDuration = 8 * time.Minute
-
-	if consecutiveFailures <= 0 {
-		return baseDuration
+	if consecutiveFailures < 0 {
+		consecutiveFailures = 0
 	}
+	return ExponentialBackoff(consecutiveFailures+1, DefaultBackoffBase, DefaultBackoffMax)
+}
 
-	// Calculate 2^failures (bit shift for power of 2)
-	multiplier := 1 << uint(consecutiveFailures)
-	duration := baseDuration * time.Duration(multiplier)
+// ExponentialBackoff returns the delay before retrying after the given number of consecutive
+// failures: base after the first failure, doubling with each further failure, capped at max.
+// Examples with base 30s and max 8m:
+//   - 1 failure: 30s
+//   - 2 failures: 1m
+//   - 3 failures: 2m
+//   - 5+ failures: 8m (capped)
+func ExponentialBackoff(consecutiveFailures int32, base, max time.Duration) time.Duration {
+	if base <= 0 {
+		base = DefaultBackoffBase
+	}
+	if max < base {
+		max = base
+	}
 
-	if duration > maxDuration {
-		return maxDuration
+	duration := base
+	for i := int32(1); i < consecutiveFailures; i++ {
+		// Double until capped; checking before doubling also guards against overflow
+		if duration >= max/2 {
+			return max
+		}
+		duration *= 2
 	}
 	return duration
 }
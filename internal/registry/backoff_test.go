@@ -167,3 +167,29 @@ func TestAddJitter_Distribution(t *testing.T) {
 		t.Errorf("Distribution not balanced: lower=%d, upper=%d (expected >20 each)", lowerHalf, upperHalf)
 	}
 }
+
+func TestExponentialBackoff(t *testing.T) {
+	tests := []struct {
+		failures int32
+		base     time.Duration
+		max      time.Duration
+		want     time.Duration
+	}{
+		{0, 30 * time.Second, 8 * time.Minute, 30 * time.Second},
+		{1, 30 * time.Second, 8 * time.Minute, 30 * time.Second},
+		{2, 30 * time.Second, 8 * time.Minute, time.Minute},
+		{5, 30 * time.Second, 8 * time.Minute, 8 * time.Minute},
+		{6, 30 * time.Second, 8 * time.Minute, 8 * time.Minute},
+		{3, 10 * time.Second, 25 * time.Second, 25 * time.Second},
+		{1000, time.Minute, time.Hour, time.Hour},
+		// Invalid settings fall back to sane values
+		{1, 0, 0, DefaultBackoffBase},
+		{3, time.Minute, time.Second, time.Minute},
+	}
+
+	for _, tt := range tests {
+		if got := ExponentialBackoff(tt.failures, tt.base, tt.max); got != tt.want {
+			t.Errorf("ExponentialBackoff(%d, %v, %v) = %v, want %v", tt.failures, tt.base, tt.max, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,169 @@
+// Preflight validation that an artifact is a werf bundle before it is deployed.
+package registry
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+)
+
+// werf publishes bundles as Helm charts in the OCI layout: a chart config and the chart archive,
+// optionally with a provenance file. Bundles published by older werf versions (Helm's
+// experimental OCI support) have a legacy chart layer media type.
+const (
+	HelmConfigMediaType       = "application/vnd.cncf.helm.config.v1+json"
+	HelmChartContentMediaType = "application/vnd.cncf.helm.chart.content.v1.tar+gzip"
+	HelmLegacyChartMediaType  = "application/tar+gzip"
+	HelmProvenanceMediaType   = "application/vnd.cncf.helm.chart.provenance.v1.prov"
+)
+
+// maxBundleConfigSize bounds the size of a bundle's chart config read from the registry.
+// The config holds the Chart.yaml metadata, a few kilobytes at most.
+const maxBundleConfigSize = 1 << 20
+
+// bundleCacheSize bounds the number of digests whose InspectBundle result an OCIClient keeps.
+const bundleCacheSize = 1000
+
+// InvalidBundleError indicates the artifact a tag points to isn't a werf bundle, e.g. a
+// container image pushed to the bundle repository. Retrying won't help until the tag is
+// re-pushed with a bundle.
+type InvalidBundleError struct {
+	Err error
+}
+
+func (e *InvalidBundleError) Error() string {
+	return fmt.Sprintf("not a werf bundle: %v", e.Err)
+}
+
+func (e *InvalidBundleError) Unwrap() error {
+	return e.Err
+}
+
+// InspectBundle checks that the manifest digest in repoURL is a werf bundle: an image manifest
+// with a Helm chart config naming the chart and its version, and a single chart archive layer.
+// Results are cached by digest, since the content of a digest never changes.
+//
+// Returns an InvalidBundleError if it isn't a bundle. Failures to reach the registry are
+// returned as registry errors (AuthError, NetworkError, ...) so they can be retried.
+func (c *OCIClient) InspectBundle(
+	ctx context.Context,
+	repoURL, digest string,
+	auth authn.Authenticator,
+) error {
+	ref, err := name.NewDigest(fmt.Sprintf("%s@%s", repoURL, digest), tlsFrom(ctx).nameOptions()...)
+	if err != nil {
+		return fmt.Errorf("invalid digest reference: %w", err)
+	}
+	key := ref.Context().Name() + "@" + ref.DigestStr()
+	if cached, result := c.cachedBundle(key); cached {
+		return result
+	}
+
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	puller, release, err := c.puller(ctx, ref.Registry, auth)
+	if err != nil {
+		return fmt.Errorf("failed to inspect bundle: %w", classifyError(err))
+	}
+	err = inspectBundle(ctx, puller, ref)
+	var invalid *InvalidBundleError
+	if errors.As(err, &invalid) {
+		release(nil)
+		c.storeBundle(key, err)
+		return err
+	}
+	release(err)
+	if err != nil {
+		return fmt.Errorf("failed to inspect bundle: %w", classifyError(err))
+	}
+	c.storeBundle(key, nil)
+	return nil
+}
+
+// inspectBundle downloads the manifest and chart config of ref and checks their layout.
+func inspectBundle(ctx context.Context, puller *remote.Puller, ref name.Digest) error {
+	desc, err := puller.Get(ctx, ref)
+	if err != nil {
+		return err
+	}
+	if !desc.MediaType.IsImage() {
+		return &InvalidBundleError{Err: fmt.Errorf("manifest media type is %s, expected an image manifest",
+			desc.MediaType)}
+	}
+	manifest, err := v1.ParseManifest(bytes.NewReader(desc.Manifest))
+	if err != nil {
+		return &InvalidBundleError{Err: fmt.Errorf("invalid manifest: %w", err)}
+	}
+
+	if manifest.Config.MediaType != HelmConfigMediaType {
+		return &InvalidBundleError{Err: fmt.Errorf("config media type is %s, expected %s (a Helm chart)",
+			manifest.Config.MediaType, HelmConfigMediaType)}
+	}
+	charts := 0
+	for _, layer := range manifest.Layers {
+		switch layer.MediaType {
+		case HelmChartContentMediaType, HelmLegacyChartMediaType:
+			charts++
+		case HelmProvenanceMediaType:
+		default:
+			return &InvalidBundleError{Err: fmt.Errorf("unexpected layer media type %s", layer.MediaType)}
+		}
+	}
+	if charts != 1 {
+		return &InvalidBundleError{Err: fmt.Errorf("found %d chart layers, expected one %s layer",
+			charts, HelmChartContentMediaType)}
+	}
+
+	blob, err := puller.Layer(ctx, ref.Context().Digest(manifest.Config.Digest.String()))
+	if err != nil {
+		return err
+	}
+	data, err := readPayload(blob.Compressed, maxBundleConfigSize)
+	if err != nil {
+		return err
+	}
+	var chart struct {
+		Name    string `json:"name"`
+		Version string `json:"version"`
+	}
+	if err := json.Unmarshal(data, &chart); err != nil {
+		return &InvalidBundleError{Err: fmt.Errorf("invalid chart config: %w", err)}
+	}
+	if chart.Name == "" || chart.Version == "" {
+		return &InvalidBundleError{Err: errors.New("chart config has no name or version")}
+	}
+	return nil
+}
+
+// cachedBundle returns the cached InspectBundle result of an image, keyed by repository and digest.
+func (c *OCIClient) cachedBundle(key string) (bool, error) {
+	c.metadataMu.Lock()
+	defer c.metadataMu.Unlock()
+	result, ok := c.bundles[key]
+	return ok, result
+}
+
+// storeBundle caches the InspectBundle result of an image. When the cache is full, an arbitrary
+// entry is dropped.
+func (c *OCIClient) storeBundle(key string, result error) {
+	c.metadataMu.Lock()
+	defer c.metadataMu.Unlock()
+	if c.bundles == nil {
+		c.bundles = make(map[string]error)
+	}
+	if _, ok := c.bundles[key]; !ok && len(c.bundles) >= bundleCacheSize {
+		for evict := range c.bundles {
+			delete(c.bundles, evict)
+			break
+		}
+	}
+	c.bundles[key] = result
+}
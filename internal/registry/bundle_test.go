@@ -0,0 +1,146 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	ggcrregistry "github.com/google/go-containerregistry/pkg/registry"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/static"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+
+	testingutil "github.com/werf/k8s-werf-operator-go/internal/testing"
+)
+
+// pushArtifact pushes img to repoURL:tag and returns its digest.
+func pushArtifact(t *testing.T, repoURL, tag string, img remote.Taggable) string {
+	t.Helper()
+
+	ref, err := name.ParseReference(repoURL + ":" + tag)
+	if err != nil {
+		t.Fatalf("failed to parse reference: %v", err)
+	}
+	var digest v1.Hash
+	switch artifact := img.(type) {
+	case v1.ImageIndex:
+		err = remote.WriteIndex(ref, artifact)
+		if err == nil {
+			digest, err = artifact.Digest()
+		}
+	case v1.Image:
+		err = remote.Write(ref, artifact)
+		if err == nil {
+			digest, err = artifact.Digest()
+		}
+	}
+	if err != nil {
+		t.Fatalf("failed to push %s: %v", tag, err)
+	}
+	return digest.String()
+}
+
+func TestOCIClient_InspectBundle(t *testing.T) {
+	var manifestGets atomic.Int32
+	handler := ggcrregistry.New(ggcrregistry.Logger(log.New(io.Discard, "", 0)))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet && strings.Contains(r.URL.Path, "/manifests/") {
+			manifestGets.Add(1)
+		}
+		handler.ServeHTTP(w, r)
+	}))
+	t.Cleanup(server.Close)
+	repoURL := strings.TrimPrefix(server.URL, "http://") + "/test/bundle"
+
+	bundle, err := testingutil.BundleImage("app", "1.0.0")
+	if err != nil {
+		t.Fatalf("failed to build bundle: %v", err)
+	}
+	image, err := random.Image(256, 1)
+	if err != nil {
+		t.Fatalf("failed to create random image: %v", err)
+	}
+	index, err := random.Index(256, 1, 2)
+	if err != nil {
+		t.Fatalf("failed to create random index: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		artifact remote.Taggable
+		wantErr  string
+	}{
+		{name: "bundle", artifact: bundle},
+		{name: "container image", artifact: image,
+			wantErr: "config media type is application/vnd.docker.container.image.v1+json"},
+		{name: "image index", artifact: index, wantErr: "expected an image manifest"},
+		{name: "extra layer", artifact: appendLayer(t, bundle, "application/vnd.oci.image.layer.v1.tar"),
+			wantErr: "unexpected layer media type application/vnd.oci.image.layer.v1.tar"},
+		{name: "two charts", artifact: appendLayer(t, bundle, HelmChartContentMediaType),
+			wantErr: "found 2 chart layers"},
+	}
+
+	client := NewOCIClient()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			digest := pushArtifact(t, repoURL, strings.ReplaceAll(tt.name, " ", "-"), tt.artifact)
+			for attempt := range 2 {
+				before := manifestGets.Load()
+				err := client.InspectBundle(context.Background(), repoURL, digest, nil)
+				if tt.wantErr == "" {
+					if err != nil {
+						t.Fatalf("InspectBundle() error = %v", err)
+					}
+				} else {
+					var invalidErr *InvalidBundleError
+					if !errors.As(err, &invalidErr) {
+						t.Fatalf("expected InvalidBundleError, got %v", err)
+					}
+					if !strings.Contains(err.Error(), tt.wantErr) {
+						t.Errorf("expected error containing %q, got %q", tt.wantErr, err.Error())
+					}
+				}
+				if gets := manifestGets.Load() - before; attempt == 1 && gets != 0 {
+					t.Errorf("expected the result for the digest to be cached, got %d manifest requests", gets)
+				}
+			}
+		})
+	}
+}
+
+func TestOCIClient_InspectBundle_NotFound(t *testing.T) {
+	handler := ggcrregistry.New(ggcrregistry.Logger(log.New(io.Discard, "", 0)))
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	repoURL := strings.TrimPrefix(server.URL, "http://") + "/test/bundle"
+
+	digest := "sha256:" + strings.Repeat("0", 64)
+	err := NewOCIClient().InspectBundle(context.Background(), repoURL, digest, nil)
+	var invalidErr *InvalidBundleError
+	if err == nil || errors.As(err, &invalidErr) {
+		t.Fatalf("expected a registry error for a missing manifest, got %v", err)
+	}
+}
+
+// appendLayer returns img with a small layer of mediaType appended.
+func appendLayer(t *testing.T, img v1.Image, mediaType string) v1.Image {
+	t.Helper()
+
+	appended, err := mutate.Append(img, mutate.Addendum{
+		Layer: static.NewLayer([]byte("extra"), types.MediaType(mediaType)),
+	})
+	if err != nil {
+		t.Fatalf("failed to append layer: %v", err)
+	}
+	return appended
+}
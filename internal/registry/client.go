@@ -3,13 +3,21 @@ package registry
 
 import (
 	"context"
+	"crypto"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"net/http"
 	"sort"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/google/go-containerregistry/pkg/authn"
 	"github.com/google/go-containerregistry/pkg/name"
 	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
 )
 
 // NotModifiedError indicates that the registry returned 304 Not Modified,
@@ -65,6 +73,61 @@ func (e *NotFoundError) Unwrap() error {
 	return e.Err
 }
 
+// TooManyTagsError indicates the repository has more tags than the client keeps in memory
+// (see OCIClientOptions.MaxTags). Retrying won't help until tags are deleted or the limit raised.
+type TooManyTagsError struct {
+	Limit int
+}
+
+func (e *TooManyTagsError) Error() string {
+	return fmt.Sprintf("repository has more than %d tags", e.Limit)
+}
+
+// InvalidRepositoryError indicates the repository URL isn't a valid OCI repository reference.
+// It's a configuration error: retrying won't help until the URL changes.
+type InvalidRepositoryError struct {
+	URL string
+	Err error
+}
+
+func (e *InvalidRepositoryError) Error() string {
+	return fmt.Sprintf("invalid repository URL %q: %v", e.URL, e.Err)
+}
+
+func (e *InvalidRepositoryError) Unwrap() error {
+	return e.Err
+}
+
+// IsTransient reports whether err may go away by itself: network errors, timeouts, server
+// errors and rate limiting. Authentication, not-found, TLS, too-many-tags and invalid
+// repository errors need a configuration change and aren't transient.
+func IsTransient(err error) bool {
+	var authErr *AuthError
+	var notFound *NotFoundError
+	var tooManyTags *TooManyTagsError
+	var tlsErr *TLSError
+	var invalidRepo *InvalidRepositoryError
+	return !errors.As(err, &authErr) && !errors.As(err, &notFound) && !errors.As(err, &tooManyTags) &&
+		!errors.As(err, &tlsErr) && !errors.As(err, &invalidRepo)
+}
+
+// tagsAfterKey is the context key for WithTagsAfter.
+type tagsAfterKey struct{}
+
+// WithTagsAfter returns a context for listing only the tags that sort lexically after tag,
+// using the "last" parameter of the registry API. Registries list tags in lexical order, so
+// for tags whose lexical order is their release order (timestamps, zero-padded build numbers)
+// this skips the tags already seen.
+func WithTagsAfter(ctx context.Context, tag string) context.Context {
+	return context.WithValue(ctx, tagsAfterKey{}, tag)
+}
+
+// tagsAfter returns the tag set by WithTagsAfter, or "" to list all tags.
+func tagsAfter(ctx context.Context) string {
+	tag, _ := ctx.Value(tagsAfterKey{}).(string)
+	return tag
+}
+
 // Client defines the interface for OCI registry operations.
 type Client interface {
 	// ListTags returns all tags available in the given repository.
@@ -87,26 +150,171 @@ type Client interface {
 		auth authn.Authenticator,
 		lastETag string,
 	) (tags []string, newETag string, err error)
+
+	// ResolveDigest returns the manifest digest (e.g., "sha256:abc...") that tag currently
+	// points to. Used to detect mutable tags (e.g., main, stable) being re-pushed.
+	// auth is an optional authn.Authenticator; if nil, anonymous access is used.
+	ResolveDigest(ctx context.Context, repoURL, tag string, auth authn.Authenticator) (string, error)
+
+	// ImageMetadata returns the OCI metadata (manifest annotations, image config creation
+	// time and labels) of the image tag points to. Used to select bundles by metadata.
+	// auth is an optional authn.Authenticator; if nil, anonymous access is used.
+	ImageMetadata(ctx context.Context, repoURL, tag string, auth authn.Authenticator) (*ImageMetadata, error)
+
+	// InspectBundle checks that the manifest digest (e.g., "sha256:abc...") in the repository
+	// is a werf bundle, so that tags pointing to other artifacts aren't deployed.
+	// Returns an InvalidBundleError if it isn't.
+	// auth is an optional authn.Authenticator; if nil, anonymous access is used.
+	InspectBundle(ctx context.Context, repoURL, digest string, auth authn.Authenticator) error
+
+	// VerifyCosignSignature checks that the manifest digest in the repository carries a cosign
+	// signature made by one of keys. Returns a VerificationError if it doesn't.
+	// auth is an optional authn.Authenticator; if nil, anonymous access is used.
+	VerifyCosignSignature(
+		ctx context.Context,
+		repoURL, digest string,
+		auth authn.Authenticator,
+		keys []crypto.PublicKey,
+	) error
+
+	// VerifyAttestations checks that the manifest digest in the repository has OCI referrers
+	// meeting every requirement. Returns an AttestationError if it doesn't.
+	// auth is an optional authn.Authenticator; if nil, anonymous access is used.
+	VerifyAttestations(
+		ctx context.Context,
+		repoURL, digest string,
+		auth authn.Authenticator,
+		requirements []AttestationRequirement,
+	) error
+}
+
+// DefaultTimeout bounds each registry operation of an OCIClient created with a zero Timeout.
+const DefaultTimeout = 30 * time.Second
+
+// DefaultMaxTags bounds the tags listed from one repository by an OCIClient created with
+// a zero MaxTags.
+const DefaultMaxTags = 100000
+
+// pagedETagPrefix starts the ETags OCIClient derives for tag lists spanning several pages.
+const pagedETagPrefix = `W/"pages-`
+
+// lastTagSeparator separates the registry's ETag of a single-page tag list from the last tag
+// on the page (see singlePageETag).
+const lastTagSeparator = ";last="
+
+// pullerIdleTimeout is how long an unused authenticated session is kept. Sessions of rotated
+// credentials are dropped after it.
+const pullerIdleTimeout = 30 * time.Minute
+
+// OCIClientOptions configures an OCIClient.
+type OCIClientOptions struct {
+	// Timeout bounds each registry operation (listing tags, resolving a digest), including
+	// authentication and retries. Defaults to DefaultTimeout.
+	Timeout time.Duration
+
+	// QPS and Burst limit the requests per second to each registry host, shared by all
+	// callers. Default to DefaultQPS and DefaultBurst.
+	QPS   float64
+	Burst int
+
+	// MaxTags bounds the number of tags listed from one repository, so that a repository with
+	// a huge number of tags can't exhaust memory. Listing a larger repository fails with
+	// TooManyTagsError. Defaults to DefaultMaxTags.
+	MaxTags int
 }
 
 // OCIClient implements Client for OCI registries using go-containerregistry.
-type OCIClient struct{}
+//
+// Each registry host gets a long-lived HTTP transport, so polls reuse connections instead of
+// dialing and negotiating TLS every time. Authenticated sessions are cached per host and
+// credentials: registry bearer tokens are reused across polls until the registry rejects them
+// as expired, at which point a new token is fetched transparently. Image metadata is cached
+// by digest, and so is whether a digest is a werf bundle. Connections use the TLS and proxy
+// configurations of the request context (see WithTLS and WithProxy), with a separate
+// connection pool per configuration.
+//
+// Tag lists spanning several pages are followed through the registry's Link headers. Only the
+// first page is requested conditionally with If-None-Match; the ETag of a multi-page list is
+// derived from its tags, so an unchanged list is still reported as NotModifiedError.
+//
+// Requests to each host are rate limited (see OCIClientOptions.QPS). A 429 response is
+// returned as RateLimitedError, and requests to the host are held back for its Retry-After.
+//
+// OCIClient is safe for concurrent use.
+type OCIClient struct {
+	opts OCIClientOptions
+
+	mu     sync.Mutex
+	hosts  map[string]*hostClient
+	limits map[string]*hostRateLimit
+
+	metadataMu sync.Mutex
+	metadata   map[string]*ImageMetadata
+	bundles    map[string]error
+	attested   map[string]struct{}
+}
+
+// hostClient is the connection pool and authenticated sessions of one registry host.
+type hostClient struct {
+	transport http.RoundTripper
 
-// NewOCIClient creates a new OCI registry client.
+	mu      sync.Mutex
+	pullers map[string]*pullerEntry
+}
+
+// pullerEntry is an authenticated session for one set of credentials.
+// The puller caches bearer tokens per repository.
+type pullerEntry struct {
+	puller   *remote.Puller
+	lastUsed time.Time
+}
+
+// NewOCIClient creates a new OCI registry client with default options.
 func NewOCIClient() Client {
-	return &OCIClient{}
+	return NewOCIClientWithOptions(OCIClientOptions{})
+}
+
+// NewOCIClientWithOptions creates a new OCI registry client.
+func NewOCIClientWithOptions(opts OCIClientOptions) Client {
+	if opts.Timeout <= 0 {
+		opts.Timeout = DefaultTimeout
+	}
+	if opts.QPS <= 0 {
+		opts.QPS = DefaultQPS
+	}
+	if opts.Burst <= 0 {
+		opts.Burst = DefaultBurst
+	}
+	if opts.MaxTags <= 0 {
+		opts.MaxTags = DefaultMaxTags
+	}
+	return &OCIClient{
+		opts:     opts,
+		hosts:    make(map[string]*hostClient),
+		limits:   make(map[string]*hostRateLimit),
+		metadata: make(map[string]*ImageMetadata),
+	}
 }
 
-// ListTags returns all tags in the OCI repository.
+// ListTags returns all tags in the OCI repository (or those after the tag set by WithTagsAfter).
 func (c *OCIClient) ListTags(ctx context.Context, repoURL string, auth authn.Authenticator) ([]string, error) {
-	ref, err := name.NewRepository(repoURL)
+	ref, err := name.NewRepository(repoURL, tlsFrom(ctx).nameOptions()...)
 	if err != nil {
-		return nil, fmt.Errorf("invalid repository URL: %w", err)
+		return nil, &InvalidRepositoryError{URL: repoURL, Err: err}
 	}
 
-	tags, err := remote.List(ref, remote.WithContext(ctx), remote.WithAuth(auth))
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	ctx, _ = withTagList(ctx, "", tagsAfter(ctx))
+
+	puller, release, err := c.puller(ctx, ref.Registry, auth)
 	if err != nil {
-		return nil, fmt.Errorf("failed to list tags: %w", err)
+		return nil, fmt.Errorf("failed to list tags: %w", classifyError(err))
+	}
+	tags, _, err := c.listPages(ctx, puller, ref)
+	release(err)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tags: %w", classifyError(err))
 	}
 
 	sort.Strings(tags)
@@ -143,44 +351,370 @@ func (c *OCIClient) GetLatestTag(ctx context.Context, repoURL string, auth authn
 // If the registry returns 304 Not Modified (ETag matches), returns NotModifiedError
 // to indicate the cached response is still valid (no download needed).
 // On success, returns the tag list and the ETag from response headers for future requests.
+// A list spanning several pages gets an ETag derived from its tags; NotModifiedError is
+// returned if it matches lastETag. A list that fit on one page gets the registry's ETag and
+// its last tag (see singlePageETag): after a 304, the tags sorting after it are listed, since
+// the list may have grown onto a second page while the first one is unchanged.
 func (c *OCIClient) ListTagsWithETag(
 	ctx context.Context,
 	repoURL string,
 	auth authn.Authenticator,
 	lastETag string,
 ) ([]string, string, error) {
-	ref, err := name.NewRepository(repoURL)
+	ref, err := name.NewRepository(repoURL, tlsFrom(ctx).nameOptions()...)
 	if err != nil {
-		return nil, "", fmt.Errorf("invalid repository URL: %w", err)
+		return nil, "", &InvalidRepositoryError{URL: repoURL, Err: err}
 	}
 
-	// Get the default HTTP transport
-	baseTransport := http.DefaultTransport
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
 
-	// Wrap it with our ETag-aware transport
-	etagTransport := newETagRoundTripper(baseTransport, lastETag)
+	// The ETag travels in the request context, since the transport is shared.
+	// A derived ETag isn't known to the registry, and a registry ETag without the last tag
+	// can't tell whether the list grew past the first page, so neither is sent
+	conditionalETag, lastTag, ok := parseSinglePageETag(lastETag)
+	if !ok {
+		conditionalETag = ""
+	}
+	listCtx, etag := withTagList(ctx, conditionalETag, tagsAfter(ctx))
 
-	// Call remote.List with our ETag transport
-	tags, err := remote.List(
-		ref,
-		remote.WithContext(ctx),
-		remote.WithAuth(auth),
-		remote.WithTransport(etagTransport),
-	)
+	puller, release, err := c.puller(ctx, ref.Registry, auth)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to list tags: %w", classifyError(err))
+	}
+	tags, pages, err := c.listPages(listCtx, puller, ref)
+	release(err)
 
 	// Check if we got NotModifiedError from the transport
-	if notModified, ok := err.(*NotModifiedError); ok {
-		// Return NotModifiedError with captured ETag
-		return nil, etagTransport.CapturedETag(), notModified
+	// (http.Client wraps transport errors in *url.Error, so unwrap with errors.As)
+	var notModified *NotModifiedError
+	if errors.As(err, &notModified) {
+		// The first page is unchanged, but a registry paging at its own size may have put
+		// new tags on a second page: an empty page never grows one
+		if lastTag == "" {
+			return nil, lastETag, notModified
+		}
+		grown, err := c.hasTagsAfter(ctx, ref, auth, lastTag)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to list tags: %w", classifyError(err))
+		}
+		if !grown {
+			return nil, lastETag, notModified
+		}
+		return c.ListTagsWithETag(ctx, repoURL, auth, "")
 	}
 
 	if err != nil {
-		return nil, "", fmt.Errorf("failed to list tags: %w", err)
+		return nil, "", fmt.Errorf("failed to list tags: %w", classifyError(err))
+	}
+
+	// The last tag of a single page, in the registry's order, before sorting
+	if len(tags) > 0 {
+		lastTag = tags[len(tags)-1]
+	} else {
+		lastTag = ""
 	}
 
 	// Sort tags lexicographically
 	sort.Strings(tags)
 
+	if pages > 1 {
+		// The registry's ETag only covers the first page
+		newETag := pagedETag(tags)
+		if newETag == lastETag {
+			return nil, newETag, &NotModifiedError{}
+		}
+		return tags, newETag, nil
+	}
+
 	// Return tags with captured ETag from response headers
-	return tags, etagTransport.CapturedETag(), nil
+	return tags, singlePageETag(etag.CapturedETag(), lastTag), nil
+}
+
+// hasTagsAfter reports whether the registry lists tags sorting after tag, requested with the
+// "last" parameter.
+func (c *OCIClient) hasTagsAfter(
+	ctx context.Context,
+	ref name.Repository,
+	auth authn.Authenticator,
+	tag string,
+) (bool, error) {
+	ctx, _ = withTagList(ctx, "", tag)
+	puller, release, err := c.puller(ctx, ref.Registry, auth)
+	if err != nil {
+		return false, err
+	}
+	tags, _, err := c.listPages(ctx, puller, ref)
+	release(err)
+	if err != nil {
+		return false, err
+	}
+	return len(tags) > 0, nil
+}
+
+// singlePageETag returns the ETag stored for a tag list that fit on one page: the registry's
+// ETag of the page and the last tag on it. Returns "" if the registry sent no ETag.
+func singlePageETag(etag, lastTag string) string {
+	if etag == "" {
+		return ""
+	}
+	return etag + lastTagSeparator + lastTag
+}
+
+// parseSinglePageETag splits an ETag returned by singlePageETag into the registry's ETag and
+// the last tag. Returns ok=false for any other ETag.
+func parseSinglePageETag(stored string) (etag, lastTag string, ok bool) {
+	i := strings.LastIndex(stored, lastTagSeparator)
+	if i < 0 || strings.HasPrefix(stored, pagedETagPrefix) {
+		return "", "", false
+	}
+	return stored[:i], stored[i+len(lastTagSeparator):], true
+}
+
+// listPages lists the tags of ref page by page, following the registry's Link headers.
+// Returns the tags and the number of pages. Fails with TooManyTagsError once more than
+// MaxTags tags are listed.
+func (c *OCIClient) listPages(ctx context.Context, puller *remote.Puller, ref name.Repository) ([]string, int, error) {
+	maxTags := c.opts.MaxTags
+	if maxTags <= 0 {
+		maxTags = DefaultMaxTags
+	}
+
+	lister, err := puller.Lister(ctx, ref)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	tags := []string{}
+	pages := 0
+	seen := make(map[string]bool)
+	for lister.HasNext() {
+		page, err := lister.Next(ctx)
+		if err != nil {
+			return nil, pages, err
+		}
+		pages++
+		tags = append(tags, page.Tags...)
+		if len(tags) > maxTags {
+			return nil, pages, &TooManyTagsError{Limit: maxTags}
+		}
+		// A registry linking back to a page already listed would keep us paging forever
+		if page.Next != "" {
+			if seen[page.Next] {
+				return nil, pages, fmt.Errorf("registry returned page %s twice", page.Next)
+			}
+			seen[page.Next] = true
+		}
+	}
+	return tags, pages, nil
+}
+
+// pagedETag derives the ETag of a tag list spanning several pages from its sorted tags.
+func pagedETag(tags []string) string {
+	sum := sha256.Sum256([]byte(strings.Join(tags, "\n")))
+	return fmt.Sprintf(`%s%d-%s"`, pagedETagPrefix, len(tags), hex.EncodeToString(sum[:16]))
+}
+
+// ResolveDigest returns the manifest digest that tag currently points to.
+// Uses a HEAD request so the manifest isn't downloaded, and falls back to GET for
+// registries that don't return Docker-Content-Digest on HEAD.
+func (c *OCIClient) ResolveDigest(
+	ctx context.Context,
+	repoURL, tag string,
+	auth authn.Authenticator,
+) (string, error) {
+	ref, err := name.NewTag(fmt.Sprintf("%s:%s", repoURL, tag), tlsFrom(ctx).nameOptions()...)
+	if err != nil {
+		return "", fmt.Errorf("invalid tag reference: %w", err)
+	}
+
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	puller, release, err := c.puller(ctx, ref.Registry, auth)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve digest: %w", classifyError(err))
+	}
+	desc, err := puller.Head(ctx, ref)
+	if err == nil {
+		release(nil)
+		return desc.Digest.String(), nil
+	}
+
+	// Auth, not-found and rate limit failures won't be fixed by a GET
+	headErr := classifyError(err)
+	var authErr *AuthError
+	var notFound *NotFoundError
+	var rateLimited *RateLimitedError
+	if errors.As(headErr, &authErr) || errors.As(headErr, &notFound) || errors.As(headErr, &rateLimited) {
+		release(err)
+		return "", fmt.Errorf("failed to resolve digest: %w", headErr)
+	}
+
+	getDesc, err := puller.Get(ctx, ref)
+	release(err)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve digest: %w", classifyError(err))
+	}
+	return getDesc.Digest.String(), nil
+}
+
+// withTimeout bounds a registry operation by the client's timeout.
+func (c *OCIClient) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	timeout := c.opts.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// host returns the long-lived client of a registry host under the TLS configuration cfg and
+// proxy, creating it if needed. Each TLS and proxy configuration gets its own connection pool,
+// but all of them share the rate limit of the host.
+func (c *OCIClient) host(registry string, cfg *TLSConfig, proxy *ProxyConfig) (*hostClient, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.hosts == nil {
+		c.hosts = make(map[string]*hostClient)
+	}
+	if c.limits == nil {
+		c.limits = make(map[string]*hostRateLimit)
+	}
+	key := registry
+	if tlsKey := cfg.key(); tlsKey != "" {
+		key += "|" + tlsKey
+	}
+	if proxyKey := proxy.key(); proxyKey != "" {
+		key += "|" + proxyKey
+	}
+	h, ok := c.hosts[key]
+	if !ok {
+		base, err := newBaseTransport(cfg, proxy)
+		if err != nil {
+			return nil, err
+		}
+		// Bundles of one registry poll concurrently; keep their connections around
+		base.MaxIdleConnsPerHost = 10
+		limit, ok := c.limits[registry]
+		if !ok {
+			qps, burst := c.opts.QPS, c.opts.Burst
+			if qps <= 0 {
+				qps = DefaultQPS
+			}
+			if burst <= 0 {
+				burst = DefaultBurst
+			}
+			limit = newHostRateLimit(qps, burst)
+			c.limits[registry] = limit
+		}
+		h = &hostClient{
+			transport: newETagRoundTripper(newRateLimitRoundTripper(base, limit)),
+			pullers:   make(map[string]*pullerEntry),
+		}
+		c.hosts[key] = h
+	}
+	return h, nil
+}
+
+// puller returns the authenticated session of auth on reg. The caller must call release with
+// the error of the operation: a failed operation drops the session, because the puller caches
+// handshake failures and the failure may be caused by the session (e.g., revoked credentials).
+//
+// Credentials that can't be resolved get a one-off session. The TLS and proxy configurations
+// are taken from ctx (see WithTLS and WithProxy).
+func (c *OCIClient) puller(
+	ctx context.Context,
+	reg name.Registry,
+	auth authn.Authenticator,
+) (*remote.Puller, func(error), error) {
+	if auth == nil {
+		auth = authn.Anonymous
+	}
+	h, err := c.host(reg.RegistryStr(), tlsFrom(ctx), proxyFrom(ctx))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	creds, ok := credentialsKey(auth)
+	if !ok {
+		puller, err := remote.NewPuller(remote.WithAuth(auth), remote.WithTransport(h.transport))
+		return puller, func(error) {}, err
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	now := time.Now()
+	for key, entry := range h.pullers {
+		if now.Sub(entry.lastUsed) > pullerIdleTimeout {
+			delete(h.pullers, key)
+		}
+	}
+
+	entry, ok := h.pullers[creds]
+	if !ok {
+		puller, err := remote.NewPuller(remote.WithAuth(auth), remote.WithTransport(h.transport))
+		if err != nil {
+			return nil, nil, err
+		}
+		entry = &pullerEntry{puller: puller}
+		h.pullers[creds] = entry
+	}
+	entry.lastUsed = now
+
+	release := func(err error) {
+		var notModified *NotModifiedError
+		if err == nil || errors.As(err, &notModified) {
+			return
+		}
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		if h.pullers[creds] == entry {
+			delete(h.pullers, creds)
+		}
+	}
+	return entry.puller, release, nil
+}
+
+// classifyError maps errors reported by go-containerregistry to the typed errors
+// used by this package. Errors already classified by the ETag transport are returned
+// unchanged. Authentication failures during the token exchange surface as
+// *transport.Error rather than passing through the ETag transport, so they are
+// converted to AuthError here.
+func classifyError(err error) error {
+	var authErr *AuthError
+	var notFound *NotFoundError
+	var networkErr *NetworkError
+	var rateLimited *RateLimitedError
+	var tooManyTags *TooManyTagsError
+	var tlsErr *TLSError
+	if errors.As(err, &authErr) || errors.As(err, &notFound) || errors.As(err, &networkErr) ||
+		errors.As(err, &rateLimited) || errors.As(err, &tooManyTags) || errors.As(err, &tlsErr) {
+		return err
+	}
+
+	// An untrusted registry certificate won't become trusted by retrying
+	if isCertificateError(err) {
+		return &TLSError{Err: err}
+	}
+
+	// The operation ran out of time (see OCIClientOptions.Timeout); worth retrying
+	if errors.Is(err, context.DeadlineExceeded) {
+		return &NetworkError{Err: err}
+	}
+
+	var transportErr *transport.Error
+	if errors.As(err, &transportErr) {
+		switch transportErr.StatusCode {
+		case http.StatusUnauthorized, http.StatusForbidden:
+			return &AuthError{Err: err}
+		case http.StatusNotFound:
+			return &NotFoundError{Err: err}
+		case http.StatusTooManyRequests:
+			return &RateLimitedError{Err: err}
+		}
+	}
+
+	return err
 }
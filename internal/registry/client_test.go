@@ -2,9 +2,25 @@ package registry
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httptrace"
 	"sort"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	ggcrregistry "github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
 )
 
 // FakeClient implements Client for testing without network access.
@@ -426,3 +442,500 @@ func TestGetLatestTag_NotFoundError(t *testing.T) {
 		t.Errorf("expected empty tag on NotFoundError, got %q", tag)
 	}
 }
+
+func TestResolveDigest_LocalRegistry(t *testing.T) {
+	ctx := context.Background()
+	host := startBasicAuthRegistry(t, "testuser", "testpass")
+	repoURL := host + "/test/mutable"
+	auth := &authn.Basic{Username: "testuser", Password: "testpass"}
+	ref, err := name.ParseReference(repoURL + ":main")
+	if err != nil {
+		t.Fatalf("failed to parse reference: %v", err)
+	}
+
+	// push writes a new random image to the main tag and returns its digest
+	push := func() string {
+		img, err := random.Image(256, 1)
+		if err != nil {
+			t.Fatalf("failed to create random image: %v", err)
+		}
+		if err := remote.Write(ref, img, remote.WithAuth(auth)); err != nil {
+			t.Fatalf("failed to push image: %v", err)
+		}
+		digest, err := img.Digest()
+		if err != nil {
+			t.Fatalf("failed to compute digest: %v", err)
+		}
+		return digest.String()
+	}
+
+	client := NewOCIClient()
+	first := push()
+	got, err := client.ResolveDigest(ctx, repoURL, "main", auth)
+	if err != nil {
+		t.Fatalf("ResolveDigest() error = %v", err)
+	}
+	if got != first {
+		t.Errorf("ResolveDigest() = %q, want %q", got, first)
+	}
+
+	// Re-pushing the tag moves it to a new digest
+	second := push()
+	got, err = client.ResolveDigest(ctx, repoURL, "main", auth)
+	if err != nil {
+		t.Fatalf("ResolveDigest() after re-push error = %v", err)
+	}
+	if got != second || got == first {
+		t.Errorf("ResolveDigest() after re-push = %q, want %q", got, second)
+	}
+
+	_, err = client.ResolveDigest(ctx, repoURL, "missing", auth)
+	var notFound *NotFoundError
+	if !errors.As(err, &notFound) {
+		t.Errorf("expected NotFoundError for missing tag, got %v", err)
+	}
+
+	_, err = client.ResolveDigest(ctx, repoURL, "main", nil)
+	var authErr *AuthError
+	if !errors.As(err, &authErr) {
+		t.Errorf("expected AuthError without credentials, got %v", err)
+	}
+}
+
+// tokenRegistry is an in-process OCI registry that requires bearer tokens from its own token
+// endpoint, like Docker Hub or GHCR. It counts issued tokens.
+type tokenRegistry struct {
+	host string
+
+	mu     sync.Mutex
+	tokens int
+	valid  map[string]bool
+}
+
+// startTokenRegistry starts a tokenRegistry. The server is stopped on test cleanup.
+func startTokenRegistry(t *testing.T) *tokenRegistry {
+	t.Helper()
+
+	reg := &tokenRegistry{valid: make(map[string]bool)}
+	handler := ggcrregistry.New(ggcrregistry.Logger(log.New(io.Discard, "", 0)))
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			reg.mu.Lock()
+			reg.tokens++
+			token := fmt.Sprintf("token-%d", reg.tokens)
+			reg.valid[token] = true
+			reg.mu.Unlock()
+			_, _ = fmt.Fprintf(w, `{"token":%q,"expires_in":300}`, token)
+			return
+		}
+
+		reg.mu.Lock()
+		ok := reg.valid[strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")]
+		reg.mu.Unlock()
+		if !ok {
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="http://%s/token",service="test"`, reg.host))
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	}))
+	server.Start()
+	t.Cleanup(server.Close)
+
+	reg.host = strings.TrimPrefix(server.URL, "http://")
+	return reg
+}
+
+// issuedTokens returns the number of tokens issued since the last call.
+func (r *tokenRegistry) issuedTokens() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	tokens := r.tokens
+	r.tokens = 0
+	return tokens
+}
+
+// expireTokens makes the registry reject every token issued so far.
+func (r *tokenRegistry) expireTokens() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.valid = make(map[string]bool)
+}
+
+func TestOCIClient_ReusesTokensAndConnections(t *testing.T) {
+	reg := startTokenRegistry(t)
+	repoURL := reg.host + "/test/pooled"
+	pushRandomImage(t, repoURL, "v1.0.0", nil)
+	pushRandomImage(t, repoURL, "v1.1.0", nil)
+	reg.issuedTokens()
+
+	// Count the connections the client dials
+	var mu sync.Mutex
+	dialed := 0
+	ctx := httptrace.WithClientTrace(context.Background(), &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			if !info.Reused {
+				mu.Lock()
+				dialed++
+				mu.Unlock()
+			}
+		},
+	})
+
+	client := NewOCIClient()
+	_, etag, err := client.ListTagsWithETag(ctx, repoURL, nil, "")
+	if err != nil {
+		t.Fatalf("ListTagsWithETag() error = %v", err)
+	}
+	for range 3 {
+		_, _, err = client.ListTagsWithETag(ctx, repoURL, nil, etag)
+		if err != nil {
+			var notModified *NotModifiedError
+			if !errors.As(err, &notModified) {
+				t.Fatalf("ListTagsWithETag() error = %v", err)
+			}
+		}
+	}
+	if _, err := client.ResolveDigest(ctx, repoURL, "v1.1.0", nil); err != nil {
+		t.Fatalf("ResolveDigest() error = %v", err)
+	}
+
+	if tokens := reg.issuedTokens(); tokens != 1 {
+		t.Errorf("expected 1 token for repeated polls, got %d", tokens)
+	}
+	mu.Lock()
+	if dialed != 1 {
+		t.Errorf("expected polls to reuse 1 connection, got %d", dialed)
+	}
+	mu.Unlock()
+
+	// An expired token is replaced transparently
+	reg.expireTokens()
+	tags, _, err := client.ListTagsWithETag(ctx, repoURL, nil, "")
+	if err != nil {
+		t.Fatalf("ListTagsWithETag() after token expiry error = %v", err)
+	}
+	if len(tags) != 2 {
+		t.Errorf("expected 2 tags, got %v", tags)
+	}
+	if tokens := reg.issuedTokens(); tokens != 1 {
+		t.Errorf("expected 1 new token after expiry, got %d", tokens)
+	}
+}
+
+func TestOCIClient_Timeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	client := NewOCIClientWithOptions(OCIClientOptions{Timeout: 100 * time.Millisecond})
+	start := time.Now()
+	_, _, err := client.ListTagsWithETag(context.Background(), strings.TrimPrefix(server.URL, "http://")+"/test/slow", nil, "")
+
+	var networkErr *NetworkError
+	if !errors.As(err, &networkErr) {
+		t.Errorf("expected NetworkError for a registry that doesn't respond, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("expected the request to time out after 100ms, took %v", elapsed)
+	}
+}
+
+func TestOCIClient_RateLimited(t *testing.T) {
+	var mu sync.Mutex
+	tagRequests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/tags/list") {
+			mu.Lock()
+			tagRequests++
+			mu.Unlock()
+			w.Header().Set("Retry-After", "120")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(server.Close)
+	repoURL := strings.TrimPrefix(server.URL, "http://") + "/test/limited"
+
+	client := NewOCIClient()
+	for i := range 2 {
+		_, _, err := client.ListTagsWithETag(context.Background(), repoURL, nil, "")
+		var rateLimited *RateLimitedError
+		if !errors.As(err, &rateLimited) {
+			t.Fatalf("poll %d: expected RateLimitedError, got %v", i, err)
+		}
+		if rateLimited.RetryAfter <= time.Minute || rateLimited.RetryAfter > 2*time.Minute {
+			t.Errorf("poll %d: expected RetryAfter of about 2m, got %v", i, rateLimited.RetryAfter)
+		}
+	}
+
+	// A bundle with its own proxy configuration gets its own connection pool, but the
+	// registry limits the host as a whole
+	ctx := WithProxy(context.Background(), &ProxyConfig{HTTPProxy: "http://proxy.invalid:3128", NoProxy: "*"})
+	var rateLimited *RateLimitedError
+	if _, _, err := client.ListTagsWithETag(ctx, repoURL, nil, ""); !errors.As(err, &rateLimited) {
+		t.Fatalf("expected RateLimitedError through another proxy configuration, got %v", err)
+	}
+
+	// The later polls are held back instead of hitting the registry again
+	mu.Lock()
+	defer mu.Unlock()
+	if tagRequests != 1 {
+		t.Errorf("expected 1 tag list request, got %d", tagRequests)
+	}
+}
+
+// pagedRegistry serves the tag list of one repository in pages of pageSize tags, linking pages
+// with Link headers like Docker Distribution. Each page has an ETag of its own.
+type pagedRegistry struct {
+	pageSize int
+
+	mu          sync.Mutex
+	tags        []string
+	requests    []*http.Request
+	loopingLink bool
+}
+
+func startPagedRegistry(t *testing.T, pageSize int, tags ...string) (*pagedRegistry, string) {
+	t.Helper()
+	reg := &pagedRegistry{pageSize: pageSize, tags: tags}
+	server := httptest.NewServer(reg)
+	t.Cleanup(server.Close)
+	return reg, strings.TrimPrefix(server.URL, "http://") + "/test/paged"
+}
+
+func (p *pagedRegistry) setTags(tags ...string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.tags = tags
+}
+
+func (p *pagedRegistry) tagRequests() []*http.Request {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]*http.Request(nil), p.requests...)
+}
+
+func (p *pagedRegistry) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasSuffix(r.URL.Path, "/tags/list") {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.requests = append(p.requests, r)
+
+	last := r.URL.Query().Get("last")
+	var page []string
+	for _, tag := range p.tags {
+		if tag > last {
+			page = append(page, tag)
+		}
+	}
+	more := len(page) > p.pageSize
+	if more {
+		page = page[:p.pageSize]
+	}
+
+	quoted := make([]string, len(page))
+	for i, tag := range page {
+		quoted[i] = strconv.Quote(tag)
+	}
+	body := fmt.Sprintf(`{"name":"test/paged","tags":[%s]}`, strings.Join(quoted, ","))
+	etag := GenerateFakeETag(page)
+	w.Header().Set("ETag", etag)
+	if more {
+		next := page[len(page)-1]
+		if p.loopingLink {
+			next = last
+		}
+		w.Header().Set("Link", fmt.Sprintf(`</v2/test/paged/tags/list?n=%d&last=%s>; rel="next"`, p.pageSize, next))
+	}
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	_, _ = io.WriteString(w, body)
+}
+
+// buildTags returns count tags whose lexical order is their numeric order.
+func buildTags(count int) []string {
+	tags := make([]string, count)
+	for i := range tags {
+		tags[i] = fmt.Sprintf("build-%04d", i+1)
+	}
+	return tags
+}
+
+func TestOCIClient_PaginatedTagList(t *testing.T) {
+	reg, repoURL := startPagedRegistry(t, 100, buildTags(250)...)
+	client := NewOCIClient()
+	ctx := context.Background()
+
+	tags, etag, err := client.ListTagsWithETag(ctx, repoURL, nil, "")
+	if err != nil {
+		t.Fatalf("ListTagsWithETag() error = %v", err)
+	}
+	if len(tags) != 250 || tags[249] != "build-0250" {
+		t.Fatalf("expected all 250 tags from 3 pages, got %d", len(tags))
+	}
+	if !strings.HasPrefix(etag, pagedETagPrefix) {
+		t.Errorf("expected an ETag covering all pages, got %q", etag)
+	}
+
+	// Unchanged list: reported as not modified, without sending the derived ETag to the registry
+	_, _, err = client.ListTagsWithETag(ctx, repoURL, nil, etag)
+	var notModified *NotModifiedError
+	if !errors.As(err, &notModified) {
+		t.Errorf("expected NotModifiedError for an unchanged paginated list, got %v", err)
+	}
+	for _, req := range reg.tagRequests() {
+		if req.Header.Get("If-None-Match") != "" {
+			t.Errorf("expected no If-None-Match for a paginated list, got %q on %s",
+				req.Header.Get("If-None-Match"), req.URL)
+		}
+	}
+
+	// A tag pushed to the last page changes the list, though the first page is unchanged
+	reg.setTags(buildTags(251)...)
+	tags, newETag, err := client.ListTagsWithETag(ctx, repoURL, nil, etag)
+	if err != nil {
+		t.Fatalf("ListTagsWithETag() error = %v", err)
+	}
+	if len(tags) != 251 || newETag == etag {
+		t.Errorf("expected the new tag and a new ETag, got %d tags, ETag %q", len(tags), newETag)
+	}
+}
+
+func TestOCIClient_ConditionalRequestOnlyForFirstPage(t *testing.T) {
+	reg, repoURL := startPagedRegistry(t, 100, buildTags(50)...)
+	client := NewOCIClient()
+	ctx := context.Background()
+
+	_, etag, err := client.ListTagsWithETag(ctx, repoURL, nil, "")
+	if err != nil {
+		t.Fatalf("ListTagsWithETag() error = %v", err)
+	}
+	if strings.HasPrefix(etag, pagedETagPrefix) {
+		t.Fatalf("expected the registry's ETag for a single page, got %q", etag)
+	}
+	_, _, err = client.ListTagsWithETag(ctx, repoURL, nil, etag)
+	var notModified *NotModifiedError
+	if !errors.As(err, &notModified) {
+		t.Fatalf("expected NotModifiedError from a 304, got %v", err)
+	}
+
+	// Growing past one page: the first page's ETag is kept, not the last page's
+	reg.setTags(buildTags(150)...)
+	tags, newETag, err := client.ListTagsWithETag(ctx, repoURL, nil, "")
+	if err != nil || len(tags) != 150 {
+		t.Fatalf("ListTagsWithETag() = %d tags, %v", len(tags), err)
+	}
+	requests := reg.tagRequests()
+	if got := requests[len(requests)-1].Header.Get("If-None-Match"); got != "" {
+		t.Errorf("expected no If-None-Match on the second page, got %q", got)
+	}
+	if !strings.HasPrefix(newETag, pagedETagPrefix) {
+		t.Errorf("expected an ETag covering all pages, got %q", newETag)
+	}
+}
+
+func TestOCIClient_SinglePageGrowsToTwo(t *testing.T) {
+	// The tags fill exactly one page of the registry, which pages at its own size
+	reg, repoURL := startPagedRegistry(t, 100, buildTags(100)...)
+	client := NewOCIClient()
+	ctx := context.Background()
+
+	_, etag, err := client.ListTagsWithETag(ctx, repoURL, nil, "")
+	if err != nil {
+		t.Fatalf("ListTagsWithETag() error = %v", err)
+	}
+
+	// A new tag sorting after them goes to a second page: the first page is unchanged (304)
+	reg.setTags(buildTags(101)...)
+	tags, newETag, err := client.ListTagsWithETag(ctx, repoURL, nil, etag)
+	if err != nil {
+		t.Fatalf("ListTagsWithETag() error = %v", err)
+	}
+	if len(tags) != 101 || tags[100] != "build-0101" {
+		t.Fatalf("expected the tag on the second page, got %d tags", len(tags))
+	}
+	if !strings.HasPrefix(newETag, pagedETagPrefix) {
+		t.Errorf("expected an ETag covering both pages, got %q", newETag)
+	}
+	var sent bool
+	for _, req := range reg.tagRequests() {
+		if req.Header.Get("If-None-Match") == strings.Split(etag, lastTagSeparator)[0] {
+			sent = true
+		}
+	}
+	if !sent {
+		t.Errorf("expected the first page to be requested with the registry's ETag")
+	}
+}
+
+func TestOCIClient_TagsAfter(t *testing.T) {
+	reg, repoURL := startPagedRegistry(t, 100, buildTags(250)...)
+	client := NewOCIClient()
+
+	tags, _, err := client.ListTagsWithETag(WithTagsAfter(context.Background(), "build-0240"), repoURL, nil, "")
+	if err != nil {
+		t.Fatalf("ListTagsWithETag() error = %v", err)
+	}
+	if len(tags) != 10 || tags[0] != "build-0241" {
+		t.Errorf("expected the 10 tags after build-0240, got %v", tags)
+	}
+	requests := reg.tagRequests()
+	if len(requests) != 1 || requests[0].URL.Query().Get("last") != "build-0240" {
+		t.Errorf("expected one request with last=build-0240, got %d requests", len(requests))
+	}
+}
+
+func TestOCIClient_PaginationLimits(t *testing.T) {
+	_, repoURL := startPagedRegistry(t, 100, buildTags(250)...)
+	client := NewOCIClientWithOptions(OCIClientOptions{MaxTags: 150})
+	_, _, err := client.ListTagsWithETag(context.Background(), repoURL, nil, "")
+	var tooManyTags *TooManyTagsError
+	if !errors.As(err, &tooManyTags) || tooManyTags.Limit != 150 {
+		t.Errorf("expected TooManyTagsError with limit 150, got %v", err)
+	}
+
+	// A registry linking back to a page already listed
+	reg, repoURL := startPagedRegistry(t, 100, buildTags(250)...)
+	reg.loopingLink = true
+	_, _, err = NewOCIClient().ListTagsWithETag(context.Background(), repoURL, nil, "")
+	if err == nil || !strings.Contains(err.Error(), "twice") {
+		t.Errorf("expected an error for a pagination loop, got %v", err)
+	}
+}
+
+func TestIsTransient(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "network", err: &NetworkError{Err: errors.New("connection refused")}, want: true},
+		{name: "rate limited", err: &RateLimitedError{Err: errors.New("HTTP 429")}, want: true},
+		{name: "unclassified", err: errors.New("HTTP 503: Service Unavailable"), want: true},
+		{name: "wrapped network", err: fmt.Errorf("mirror: %w", &NetworkError{Err: errors.New("timeout")}), want: true},
+		{name: "auth", err: &AuthError{Err: errors.New("HTTP 401")}},
+		{name: "not found", err: fmt.Errorf("tag: %w", &NotFoundError{Err: errors.New("HTTP 404")})},
+		{name: "too many tags", err: &TooManyTagsError{Limit: 10}},
+		{name: "TLS", err: &TLSError{Err: errors.New("unknown authority")}},
+		{name: "invalid repository", err: &InvalidRepositoryError{URL: "Invalid/Repo", Err: errors.New("uppercase")}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsTransient(tt.err); got != tt.want {
+				t.Errorf("IsTransient() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
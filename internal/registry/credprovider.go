@@ -0,0 +1,340 @@
+// Exec credential provider plugins, for registries that need short-lived tokens (ECR, GAR, ACR).
+package registry
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// Credential provider API versions, shared with the kubelet.
+const (
+	credentialProviderConfigKind = "CredentialProviderConfig"
+	credentialProviderAPIVersion = "credentialprovider.kubelet.k8s.io/v1"
+	credentialProviderRequest    = "CredentialProviderRequest"
+	credentialProviderResponse   = "CredentialProviderResponse"
+)
+
+// Cache key types of a CredentialProviderResponse.
+const (
+	cacheKeyImage    = "Image"
+	cacheKeyRegistry = "Registry"
+	cacheKeyGlobal   = "Global"
+)
+
+// credentialProviderTimeout bounds each plugin invocation.
+const credentialProviderTimeout = time.Minute
+
+// CredentialProviderError indicates a credential provider plugin failed or returned an
+// invalid response. The plugin may depend on a cloud API being reachable, so it's retried
+// like a network error.
+type CredentialProviderError struct {
+	Provider string
+	Err      error
+}
+
+func (e *CredentialProviderError) Error() string {
+	return fmt.Sprintf("credential provider %q: %v", e.Provider, e.Err)
+}
+
+func (e *CredentialProviderError) Unwrap() error {
+	return e.Err
+}
+
+// credentialProviderConfig is the kubelet's CredentialProviderConfig (kubelet.config.k8s.io/v1),
+// so the same configuration file serves both.
+type credentialProviderConfig struct {
+	Kind      string               `json:"kind"`
+	Providers []credentialProvider `json:"providers"`
+}
+
+// credentialProvider configures one plugin.
+type credentialProvider struct {
+	// Name is the plugin's executable in the plugin directory.
+	Name string `json:"name"`
+	// MatchImages are the images the plugin provides credentials for, e.g.
+	// "*.dkr.ecr.*.amazonaws.com". Globs match whole domain parts, not ports or paths.
+	MatchImages []string `json:"matchImages"`
+	// DefaultCacheDuration is how long credentials are cached when the plugin doesn't say.
+	DefaultCacheDuration *metav1.Duration `json:"defaultCacheDuration,omitempty"`
+	// APIVersion is the version of the request and response documents.
+	APIVersion string `json:"apiVersion"`
+	// Args and Env are passed to the plugin, in addition to the operator's environment.
+	Args []string     `json:"args,omitempty"`
+	Env  []execEnvVar `json:"env,omitempty"`
+}
+
+// execEnvVar is an environment variable passed to a plugin.
+type execEnvVar struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// credentialProviderRequestDoc is the CredentialProviderRequest written to the plugin's stdin.
+type credentialProviderRequestDoc struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Image      string `json:"image"`
+}
+
+// credentialProviderResponseDoc is the CredentialProviderResponse read from the plugin's stdout.
+type credentialProviderResponseDoc struct {
+	APIVersion    string                        `json:"apiVersion"`
+	Kind          string                        `json:"kind"`
+	CacheKeyType  string                        `json:"cacheKeyType"`
+	CacheDuration *metav1.Duration              `json:"cacheDuration,omitempty"`
+	Auth          map[string]providerAuthConfig `json:"auth,omitempty"`
+}
+
+// providerAuthConfig is a credential in a CredentialProviderResponse.
+type providerAuthConfig struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// cachedCredentials are the credentials of a plugin response, valid until expires.
+type cachedCredentials struct {
+	auth    map[string]providerAuthConfig
+	expires time.Time
+}
+
+// CredentialProviders gets registry credentials from exec credential provider plugins, using
+// the kubelet's plugin protocol: the plugin receives a CredentialProviderRequest for the image
+// on stdin and answers with a CredentialProviderResponse on stdout. The first provider matching
+// an image is used. Responses are cached for the duration and key (image, registry or global)
+// the plugin asks for.
+//
+// CredentialProviders is safe for concurrent use.
+type CredentialProviders struct {
+	binDir    string
+	providers []credentialProvider
+
+	mu    sync.Mutex
+	cache map[string]cachedCredentials
+}
+
+// LoadCredentialProviders reads a kubelet CredentialProviderConfig file. The plugins are
+// executables in binDir named after the providers.
+func LoadCredentialProviders(configPath, binDir string) (*CredentialProviders, error) {
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read credential provider config: %w", err)
+	}
+	var config credentialProviderConfig
+	// Not strict: fields of newer kubelet versions are ignored
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("invalid credential provider config: %w", err)
+	}
+	if config.Kind != credentialProviderConfigKind {
+		return nil, fmt.Errorf("invalid credential provider config: kind must be %s, got %q",
+			credentialProviderConfigKind, config.Kind)
+	}
+
+	for _, provider := range config.Providers {
+		switch {
+		case provider.Name == "" || provider.Name != filepath.Base(provider.Name) || provider.Name == "..":
+			return nil, fmt.Errorf("invalid credential provider name %q", provider.Name)
+		case len(provider.MatchImages) == 0:
+			return nil, fmt.Errorf("credential provider %q: matchImages is required", provider.Name)
+		case provider.APIVersion != credentialProviderAPIVersion:
+			return nil, fmt.Errorf("credential provider %q: unsupported apiVersion %q, expected %s",
+				provider.Name, provider.APIVersion, credentialProviderAPIVersion)
+		}
+		if _, err := os.Stat(filepath.Join(binDir, provider.Name)); err != nil {
+			return nil, fmt.Errorf("credential provider %q: %w", provider.Name, err)
+		}
+	}
+
+	return &CredentialProviders{
+		binDir:    binDir,
+		providers: config.Providers,
+		cache:     make(map[string]cachedCredentials),
+	}, nil
+}
+
+// Lookup returns the credentials for repoURL from the first provider matching it, running the
+// plugin unless its response is cached. Returns nil, nil if no provider matches repoURL, or
+// the plugin has no credentials for it.
+// Returns a CredentialProviderError if the plugin fails, and an InvalidRepositoryError if
+// repoURL isn't a valid repository reference.
+func (p *CredentialProviders) Lookup(ctx context.Context, repoURL string) (*authn.AuthConfig, error) {
+	if p == nil {
+		return nil, nil
+	}
+	repo, err := name.NewRepository(repoURL)
+	if err != nil {
+		return nil, &InvalidRepositoryError{URL: repoURL, Err: err}
+	}
+	image := repo.Name()
+
+	provider := p.providerFor(image)
+	if provider == nil {
+		return nil, nil
+	}
+
+	// One invocation at a time: concurrent polls of a registry wait for a single token
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	keys := map[string]string{
+		cacheKeyImage:    provider.Name + "|" + cacheKeyImage + "|" + image,
+		cacheKeyRegistry: provider.Name + "|" + cacheKeyRegistry + "|" + repo.RegistryStr(),
+		cacheKeyGlobal:   provider.Name + "|" + cacheKeyGlobal,
+	}
+	now := time.Now()
+	for _, key := range keys {
+		if cached, ok := p.cache[key]; ok && now.Before(cached.expires) {
+			return authFor(cached.auth, image), nil
+		}
+	}
+
+	response, err := p.exec(ctx, provider, image)
+	if err != nil {
+		return nil, &CredentialProviderError{Provider: provider.Name, Err: err}
+	}
+
+	duration := time.Duration(0)
+	if provider.DefaultCacheDuration != nil {
+		duration = provider.DefaultCacheDuration.Duration
+	}
+	if response.CacheDuration != nil {
+		duration = response.CacheDuration.Duration
+	}
+	if duration > 0 {
+		p.cache[keys[response.CacheKeyType]] = cachedCredentials{auth: response.Auth, expires: now.Add(duration)}
+	}
+	return authFor(response.Auth, image), nil
+}
+
+// providerFor returns the first provider matching image, or nil.
+func (p *CredentialProviders) providerFor(image string) *credentialProvider {
+	for i := range p.providers {
+		for _, pattern := range p.providers[i].MatchImages {
+			if matchImage(pattern, image) {
+				return &p.providers[i]
+			}
+		}
+	}
+	return nil
+}
+
+// exec runs the plugin of provider for image and validates its response.
+func (p *CredentialProviders) exec(
+	ctx context.Context,
+	provider *credentialProvider,
+	image string,
+) (*credentialProviderResponseDoc, error) {
+	request, err := json.Marshal(credentialProviderRequestDoc{
+		APIVersion: provider.APIVersion,
+		Kind:       credentialProviderRequest,
+		Image:      image,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, credentialProviderTimeout)
+	defer cancel()
+	// nolint:gosec // the plugin is chosen by the operator's configuration, not by WerfBundles
+	cmd := exec.CommandContext(ctx, filepath.Join(p.binDir, provider.Name), provider.Args...)
+	cmd.Env = os.Environ()
+	for _, env := range provider.Env {
+		cmd.Env = append(cmd.Env, env.Name+"="+env.Value)
+	}
+	cmd.Stdin = bytes.NewReader(request)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("%w: %s", err, msg)
+		}
+		return nil, err
+	}
+
+	var response credentialProviderResponseDoc
+	if err := json.Unmarshal(stdout.Bytes(), &response); err != nil {
+		return nil, fmt.Errorf("invalid response: %w", err)
+	}
+	switch {
+	case response.Kind != credentialProviderResponse:
+		return nil, fmt.Errorf("invalid response: kind must be %s, got %q", credentialProviderResponse, response.Kind)
+	case response.APIVersion != provider.APIVersion:
+		return nil, fmt.Errorf("invalid response: apiVersion %q doesn't match the request's %q",
+			response.APIVersion, provider.APIVersion)
+	case response.CacheKeyType != cacheKeyImage && response.CacheKeyType != cacheKeyRegistry &&
+		response.CacheKeyType != cacheKeyGlobal:
+		return nil, fmt.Errorf("invalid response: unknown cacheKeyType %q", response.CacheKeyType)
+	}
+	return &response, nil
+}
+
+// authFor returns the credentials of a plugin response for image: the entry with the most
+// specific key matching it. Returns nil if no entry matches.
+func authFor(auth map[string]providerAuthConfig, image string) *authn.AuthConfig {
+	best := ""
+	for key := range auth {
+		if matchImage(key, image) && len(key) > len(best) {
+			best = key
+		}
+	}
+	if best == "" {
+		return nil
+	}
+	return &authn.AuthConfig{Username: auth[best].Username, Password: auth[best].Password}
+}
+
+// matchImage reports whether image matches pattern, with the kubelet's matchImages semantics:
+// globs (filepath.Match) match whole domain parts, the number of parts must be equal, ports
+// must be equal, and the pattern's path, if any, must be a prefix of the image path.
+func matchImage(pattern, image string) bool {
+	patternURL, err := parseImageURL(pattern)
+	if err != nil {
+		return false
+	}
+	imageURL, err := parseImageURL(image)
+	if err != nil {
+		return false
+	}
+
+	patternParts := strings.Split(patternURL.Hostname(), ".")
+	imageParts := strings.Split(imageURL.Hostname(), ".")
+	if len(patternParts) != len(imageParts) || patternURL.Port() != imageURL.Port() {
+		return false
+	}
+	for i := range patternParts {
+		if matched, err := filepath.Match(patternParts[i], imageParts[i]); err != nil || !matched {
+			return false
+		}
+	}
+	return strings.HasPrefix(imageURL.Path, patternURL.Path)
+}
+
+// parseImageURL parses an image or pattern such as "*.gcr.io/project" as a URL.
+func parseImageURL(value string) (*url.URL, error) {
+	if !strings.Contains(value, "://") {
+		value = "https://" + value
+	}
+	parsed, err := url.Parse(value)
+	if err != nil {
+		return nil, err
+	}
+	if parsed.Host == "" {
+		return nil, errors.New("no host")
+	}
+	return parsed, nil
+}
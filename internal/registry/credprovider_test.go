@@ -0,0 +1,241 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	testingutil "github.com/werf/k8s-werf-operator-go/internal/testing"
+)
+
+func TestCredentialProviders_Lookup(t *testing.T) {
+	tests := []struct {
+		name      string
+		response  string
+		wantCalls int
+	}{
+		{
+			// One invocation for the registry, until the token expires
+			name:      "registry cache",
+			response:  `"cacheKeyType": "Registry", "cacheDuration": "1h",`,
+			wantCalls: 1,
+		},
+		{
+			name:      "image cache",
+			response:  `"cacheKeyType": "Image", "cacheDuration": "1h",`,
+			wantCalls: 2,
+		},
+		{
+			// Without a duration from the plugin or the config, nothing is cached
+			name:      "not cached",
+			response:  `"cacheKeyType": "Global",`,
+			wantCalls: 3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			response := `{"kind": "CredentialProviderResponse", "apiVersion": "credentialprovider.kubelet.k8s.io/v1", ` +
+				tt.response + `
+  "auth": {
+    "*.example.com": {"username": "AWS", "password": "registry-token"},
+    "ecr.example.com/team": {"username": "AWS", "password": "team-token"}
+  }}`
+			configPath, err := testingutil.WriteFakeCredentialProvider(dir, "fake-provider", response, "*.example.com")
+			if err != nil {
+				t.Fatalf("failed to write plugin: %v", err)
+			}
+			providers, err := LoadCredentialProviders(configPath, dir)
+			if err != nil {
+				t.Fatalf("LoadCredentialProviders() error = %v", err)
+			}
+
+			ctx := context.Background()
+			lookups := []struct{ repoURL, wantPassword string }{
+				{"ecr.example.com/app", "registry-token"},
+				{"ecr.example.com/team/app", "team-token"},
+				{"ecr.example.com/app", "registry-token"},
+			}
+			for _, lookup := range lookups {
+				cfg, err := providers.Lookup(ctx, lookup.repoURL)
+				if err != nil {
+					t.Fatalf("Lookup(%s) error = %v", lookup.repoURL, err)
+				}
+				if cfg == nil || cfg.Username != "AWS" || cfg.Password != lookup.wantPassword {
+					t.Errorf("Lookup(%s) = %+v, want password %s", lookup.repoURL, cfg, lookup.wantPassword)
+				}
+			}
+
+			// Other registries aren't sent to the plugin
+			if cfg, err := providers.Lookup(ctx, "ghcr.io/org/app"); cfg != nil || err != nil {
+				t.Errorf("Lookup(ghcr.io/org/app) = %+v, %v, want nil", cfg, err)
+			}
+
+			images, err := testingutil.CredentialProviderRequests(dir, "fake-provider")
+			if err != nil {
+				t.Fatalf("failed to read requests: %v", err)
+			}
+			if len(images) != tt.wantCalls {
+				t.Errorf("expected %d plugin invocations, got %v", tt.wantCalls, images)
+			}
+			if !slices.Contains(images, "ecr.example.com/app") {
+				t.Errorf("expected a request for ecr.example.com/app, got %v", images)
+			}
+		})
+	}
+}
+
+func TestCredentialProviders_LookupErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		response string
+	}{
+		{name: "plugin fails", response: ""},
+		{name: "not a response", response: `{"kind": "Status"}`},
+		{
+			name: "wrong version",
+			response: `{"kind": "CredentialProviderResponse", "apiVersion": "credentialprovider.kubelet.k8s.io/v1beta1",` +
+				` "cacheKeyType": "Registry"}`,
+		},
+		{
+			name: "unknown cache key",
+			response: `{"kind": "CredentialProviderResponse", "apiVersion": "credentialprovider.kubelet.k8s.io/v1",` +
+				` "cacheKeyType": "Repository"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			configPath, err := testingutil.WriteFakeCredentialProvider(dir, "fake-provider", tt.response, "*.example.com")
+			if err != nil {
+				t.Fatalf("failed to write plugin: %v", err)
+			}
+			providers, err := LoadCredentialProviders(configPath, dir)
+			if err != nil {
+				t.Fatalf("LoadCredentialProviders() error = %v", err)
+			}
+
+			_, err = providers.Lookup(context.Background(), "ecr.example.com/app")
+			var providerErr *CredentialProviderError
+			if !errors.As(err, &providerErr) || providerErr.Provider != "fake-provider" {
+				t.Errorf("expected a CredentialProviderError, got %v", err)
+			}
+			if !IsTransient(err) {
+				t.Errorf("expected plugin failures to be retried")
+			}
+		})
+	}
+}
+
+func TestLoadCredentialProviders(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  string
+		wantErr bool
+	}{
+		{
+			name: "valid",
+			config: `apiVersion: kubelet.config.k8s.io/v1
+kind: CredentialProviderConfig
+providers:
+  - name: fake-provider
+    matchImages: ["*.dkr.ecr.*.amazonaws.com"]
+    defaultCacheDuration: 12h
+    apiVersion: credentialprovider.kubelet.k8s.io/v1
+    args: [get-credentials]
+    env:
+      - name: AWS_PROFILE
+        value: registry`,
+		},
+		{
+			name:    "wrong kind",
+			config:  `kind: KubeletConfiguration`,
+			wantErr: true,
+		},
+		{
+			name: "unsupported apiVersion",
+			config: `kind: CredentialProviderConfig
+providers:
+  - name: fake-provider
+    matchImages: ["*.example.com"]
+    apiVersion: credentialprovider.kubelet.k8s.io/v1alpha1`,
+			wantErr: true,
+		},
+		{
+			name: "no matchImages",
+			config: `kind: CredentialProviderConfig
+providers:
+  - name: fake-provider
+    apiVersion: credentialprovider.kubelet.k8s.io/v1`,
+			wantErr: true,
+		},
+		{
+			name: "path in name",
+			config: `kind: CredentialProviderConfig
+providers:
+  - name: ../fake-provider
+    matchImages: ["*.example.com"]
+    apiVersion: credentialprovider.kubelet.k8s.io/v1`,
+			wantErr: true,
+		},
+		{
+			name: "missing plugin",
+			config: `kind: CredentialProviderConfig
+providers:
+  - name: other-provider
+    matchImages: ["*.example.com"]
+    apiVersion: credentialprovider.kubelet.k8s.io/v1`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if _, err := testingutil.WriteFakeCredentialProvider(dir, "fake-provider", "{}", "*"); err != nil {
+				t.Fatalf("failed to write plugin: %v", err)
+			}
+			configPath := filepath.Join(dir, "config.yaml")
+			if err := os.WriteFile(configPath, []byte(tt.config), 0o600); err != nil {
+				t.Fatalf("failed to write config: %v", err)
+			}
+
+			_, err := LoadCredentialProviders(configPath, dir)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("LoadCredentialProviders() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestMatchImage(t *testing.T) {
+	tests := []struct {
+		pattern string
+		image   string
+		want    bool
+	}{
+		{"123456789.dkr.ecr.us-east-1.amazonaws.com", "123456789.dkr.ecr.us-east-1.amazonaws.com/app", true},
+		{"*.dkr.ecr.*.amazonaws.com", "123456789.dkr.ecr.us-east-1.amazonaws.com/app", true},
+		{"*.dkr.ecr.*.amazonaws.com", "dkr.ecr.us-east-1.amazonaws.com/app", false},
+		{"*.azurecr.io", "myregistry.azurecr.io/team/app", true},
+		{"*-docker.pkg.dev", "europe-docker.pkg.dev/project/repo/app", true},
+		{"*.example.com", "example.com/app", false},
+		{"registry.example.com:5000", "registry.example.com:5000/app", true},
+		{"registry.example.com:5000", "registry.example.com/app", false},
+		{"registry.example.com/team", "registry.example.com/team/app", true},
+		{"registry.example.com/team", "registry.example.com/other/app", false},
+		{"https://registry.example.com", "registry.example.com/app", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern+" "+tt.image, func(t *testing.T) {
+			if got := matchImage(tt.pattern, tt.image); got != tt.want {
+				t.Errorf("matchImage(%q, %q) = %v, want %v", tt.pattern, tt.image, got, tt.want)
+			}
+		})
+	}
+}
@@ -2,37 +2,112 @@
 package registry
 
 import (
+	"context"
 	"fmt"
 	"net/http"
+	"strings"
+	"sync"
 )
 
+// etagKey is the context key for the etagRequest of a conditional tag list request.
+type etagKey struct{}
+
+// etagRequest carries the ETag sent with a tag list request and the ETag the registry returned.
+// It lives in the request context rather than the transport, so that one transport can serve
+// concurrent polls of different repositories.
+//
+// A tag list may span several pages. Only the first page is requested conditionally, and only
+// its ETag is captured: the ETag of a page says nothing about the other pages.
+type etagRequest struct {
+	lastETag string
+	// after, if set, is sent as the "last" parameter of the first page, so that the registry
+	// only lists tags sorting after it
+	after string
+
+	mu           sync.Mutex
+	capturedETag string
+}
+
+// withETag returns a context for a tag list request that sends lastETag in If-None-Match
+// and captures the ETag of the response.
+func withETag(ctx context.Context, lastETag string) (context.Context, *etagRequest) {
+	return withTagList(ctx, lastETag, "")
+}
+
+// withTagList is withETag for a tag list starting after the tag after.
+func withTagList(ctx context.Context, lastETag, after string) (context.Context, *etagRequest) {
+	etag := &etagRequest{lastETag: lastETag, after: after}
+	return context.WithValue(ctx, etagKey{}, etag), etag
+}
+
+// CapturedETag returns the ETag value captured from the first page.
+func (r *etagRequest) CapturedETag() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.capturedETag
+}
+
+// capture records the ETag of the first page. A page requested again after a token refresh
+// keeps the first ETag received.
+func (r *etagRequest) capture(etag string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.capturedETag == "" {
+		r.capturedETag = etag
+	}
+}
+
+// isFirstPage reports whether req requests the first page of the tag list. Pages after the
+// first are requested with the "last" parameter from the registry's Link header.
+func (r *etagRequest) isFirstPage(req *http.Request) bool {
+	last := req.URL.Query().Get("last")
+	return last == "" || last == r.after
+}
+
 // etagRoundTripper wraps an http.RoundTripper to add ETag support.
-// It automatically sets If-None-Match headers and captures ETag response headers.
+// It sets If-None-Match headers and captures ETag response headers for requests whose
+// context carries an etagRequest (see withETag). It holds no per-request state and is
+// safe for concurrent use.
 type etagRoundTripper struct {
-	base         http.RoundTripper
-	lastETag     string
-	capturedETag string
+	base http.RoundTripper
 }
 
 // newETagRoundTripper creates a new ETag-aware transport wrapper.
-func newETagRoundTripper(base http.RoundTripper, lastETag string) *etagRoundTripper {
-	return &etagRoundTripper{
-		base:     base,
-		lastETag: lastETag,
-	}
+func newETagRoundTripper(base http.RoundTripper) *etagRoundTripper {
+	return &etagRoundTripper{base: base}
 }
 
 // RoundTrip implements http.RoundTripper.
-// Sets If-None-Match header if lastETag is set, and captures ETag from response.
+// Sets If-None-Match header on the first page if the request has a last ETag, and captures
+// the ETag of the first page from the response.
 // Detects HTTP error status codes and returns appropriate error types.
 // Returns NotModifiedError if server returns 304 Not Modified.
 // Returns NotFoundError for 404 status code.
 // Returns AuthError for 401/403 status codes.
 // Returns NetworkError for 5xx status codes.
+//
+// Only tag list requests are intercepted. Other requests made during the same
+// operation (the /v2/ ping and token exchange) are passed through untouched, because
+// go-containerregistry relies on seeing their 401 challenge responses to authenticate.
+// For the same reason a 401 with a WWW-Authenticate challenge is passed through: it's how
+// the registry says a cached bearer token expired, and go-containerregistry fetches a new
+// token and retries. If the retry is rejected too, the 401 is reported as an AuthError.
 func (t *etagRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !isTagListRequest(req) {
+		return t.base.RoundTrip(req)
+	}
+
+	etag, _ := req.Context().Value(etagKey{}).(*etagRequest)
+	firstPage := etag != nil && etag.isFirstPage(req)
+	if firstPage && etag.after != "" && !req.URL.Query().Has("last") {
+		query := req.URL.Query()
+		query.Set("last", etag.after)
+		req.URL.RawQuery = query.Encode()
+	}
+
 	// Set If-None-Match header if we have a cached ETag
-	if t.lastETag != "" {
-		req.Header.Set("If-None-Match", t.lastETag)
+	if firstPage && etag.lastETag != "" {
+		req.Header.Set("If-None-Match", etag.lastETag)
 	}
 
 	resp, err := t.base.RoundTrip(req)
@@ -41,39 +116,48 @@ func (t *etagRoundTripper) RoundTrip(req *http.Request) (*http.Response, error)
 	}
 
 	// Capture ETag header from response for future requests
-	if etag := resp.Header.Get("ETag"); etag != "" {
-		t.capturedETag = etag
+	if value := resp.Header.Get("ETag"); value != "" && firstPage {
+		etag.capture(value)
 	}
 
 	// Detect HTTP error status codes and return appropriate error types
 	switch resp.StatusCode {
 	case http.StatusNotModified: // 304
 		// Content hasn't changed, signal with NotModifiedError
+		_ = resp.Body.Close()
 		return nil, &NotModifiedError{}
 
 	case http.StatusNotFound: // 404
 		// Repository does not exist
+		_ = resp.Body.Close()
 		return nil, &NotFoundError{Err: fmt.Errorf("HTTP %d: %s", resp.StatusCode, http.StatusText(resp.StatusCode))}
 
 	case http.StatusUnauthorized, http.StatusForbidden: // 401, 403
+		if resp.StatusCode == http.StatusUnauthorized && resp.Header.Get("WWW-Authenticate") != "" {
+			// Expired token or missing scope: let the auth transport refresh and retry
+			return resp, nil
+		}
 		// Authentication/authorization failure
+		_ = resp.Body.Close()
 		return nil, &AuthError{Err: fmt.Errorf("HTTP %d: %s", resp.StatusCode, http.StatusText(resp.StatusCode))}
 
 	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout: // 502, 503, 504
 		// Transient server errors
+		_ = resp.Body.Close()
 		return nil, &NetworkError{Err: fmt.Errorf("HTTP %d: %s", resp.StatusCode, http.StatusText(resp.StatusCode))}
 
 	case http.StatusInternalServerError: // 500
 		// Internal server error (might be transient)
+		_ = resp.Body.Close()
 		return nil, &NetworkError{Err: fmt.Errorf("HTTP %d: %s", resp.StatusCode, http.StatusText(resp.StatusCode))}
 	}
 
 	return resp, nil
 }
 
-// CapturedETag returns the ETag value captured from the last response.
-func (t *etagRoundTripper) CapturedETag() string {
-	return t.capturedETag
+// isTagListRequest reports whether req is a /v2/<name>/tags/list request.
+func isTagListRequest(req *http.Request) bool {
+	return req.URL != nil && strings.HasSuffix(req.URL.Path, "/tags/list")
 }
 
 // GenerateFakeETag creates a deterministic ETag for testing purposes.
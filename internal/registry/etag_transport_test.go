@@ -1,9 +1,12 @@
 package registry
 
 import (
+	"context"
+	"fmt"
 	"io"
 	"net/http"
 	"strings"
+	"sync"
 	"testing"
 )
 
@@ -11,6 +14,7 @@ import (
 type mockRoundTripper struct {
 	statusCode int
 	etagValue  string
+	challenge  string
 }
 
 func (m *mockRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
@@ -23,6 +27,9 @@ func (m *mockRoundTripper) RoundTrip(req *http.Request) (*http.Response, error)
 	if m.etagValue != "" {
 		resp.Header.Set("ETag", m.etagValue)
 	}
+	if m.challenge != "" {
+		resp.Header.Set("WWW-Authenticate", m.challenge)
+	}
 
 	return resp, nil
 }
@@ -30,9 +37,10 @@ func (m *mockRoundTripper) RoundTrip(req *http.Request) (*http.Response, error)
 func TestETagRoundTripper_SetIfNoneMatch(t *testing.T) {
 	// Verify that If-None-Match header is set when lastETag is provided
 	mockRT := &mockRoundTripper{statusCode: 200, etagValue: `"new-etag"`}
-	transport := newETagRoundTripper(mockRT, `"old-etag"`)
+	transport := newETagRoundTripper(mockRT)
+	ctx, etag := withETag(context.Background(), `"old-etag"`)
 
-	req, _ := http.NewRequest("GET", "http://example.com/v2/repo/tags/list", nil)
+	req, _ := http.NewRequestWithContext(ctx, "GET", "http://example.com/v2/repo/tags/list", nil)
 	resp, err := transport.RoundTrip(req)
 
 	if err != nil {
@@ -45,8 +53,8 @@ func TestETagRoundTripper_SetIfNoneMatch(t *testing.T) {
 	}
 
 	// Check that new ETag was captured
-	if transport.CapturedETag() != `"new-etag"` {
-		t.Errorf("ETag not captured: %s", transport.CapturedETag())
+	if etag.CapturedETag() != `"new-etag"` {
+		t.Errorf("ETag not captured: %s", etag.CapturedETag())
 	}
 
 	if err := resp.Body.Close(); err != nil {
@@ -57,9 +65,10 @@ func TestETagRoundTripper_SetIfNoneMatch(t *testing.T) {
 func TestETagRoundTripper_NotModified_Error(t *testing.T) {
 	// Verify that 304 Not Modified returns NotModifiedError
 	mockRT := &mockRoundTripper{statusCode: 304, etagValue: `"same-etag"`}
-	transport := newETagRoundTripper(mockRT, `"same-etag"`)
+	transport := newETagRoundTripper(mockRT)
+	ctx, etag := withETag(context.Background(), `"same-etag"`)
 
-	req, _ := http.NewRequest("GET", "http://example.com/v2/repo/tags/list", nil)
+	req, _ := http.NewRequestWithContext(ctx, "GET", "http://example.com/v2/repo/tags/list", nil)
 	_, err := transport.RoundTrip(req)
 
 	if _, ok := err.(*NotModifiedError); !ok {
@@ -67,15 +76,15 @@ func TestETagRoundTripper_NotModified_Error(t *testing.T) {
 	}
 
 	// ETag should still be captured
-	if transport.CapturedETag() != `"same-etag"` {
-		t.Errorf("ETag not captured on 304: %s", transport.CapturedETag())
+	if etag.CapturedETag() != `"same-etag"` {
+		t.Errorf("ETag not captured on 304: %s", etag.CapturedETag())
 	}
 }
 
 func TestETagRoundTripper_Unauthorized_Error(t *testing.T) {
 	// Verify that 401 returns AuthError
 	mockRT := &mockRoundTripper{statusCode: 401}
-	transport := newETagRoundTripper(mockRT, "")
+	transport := newETagRoundTripper(mockRT)
 
 	req, _ := http.NewRequest("GET", "http://example.com/v2/repo/tags/list", nil)
 	_, err := transport.RoundTrip(req)
@@ -88,7 +97,7 @@ func TestETagRoundTripper_Unauthorized_Error(t *testing.T) {
 func TestETagRoundTripper_Forbidden_Error(t *testing.T) {
 	// Verify that 403 returns AuthError
 	mockRT := &mockRoundTripper{statusCode: 403}
-	transport := newETagRoundTripper(mockRT, "")
+	transport := newETagRoundTripper(mockRT)
 
 	req, _ := http.NewRequest("GET", "http://example.com/v2/repo/tags/list", nil)
 	_, err := transport.RoundTrip(req)
@@ -101,7 +110,7 @@ func TestETagRoundTripper_Forbidden_Error(t *testing.T) {
 func TestETagRoundTripper_InternalServerError(t *testing.T) {
 	// Verify that 500 returns NetworkError
 	mockRT := &mockRoundTripper{statusCode: 500}
-	transport := newETagRoundTripper(mockRT, "")
+	transport := newETagRoundTripper(mockRT)
 
 	req, _ := http.NewRequest("GET", "http://example.com/v2/repo/tags/list", nil)
 	_, err := transport.RoundTrip(req)
@@ -114,7 +123,7 @@ func TestETagRoundTripper_InternalServerError(t *testing.T) {
 func TestETagRoundTripper_ServiceUnavailable(t *testing.T) {
 	// Verify that 503 returns NetworkError
 	mockRT := &mockRoundTripper{statusCode: 503}
-	transport := newETagRoundTripper(mockRT, "")
+	transport := newETagRoundTripper(mockRT)
 
 	req, _ := http.NewRequest("GET", "http://example.com/v2/repo/tags/list", nil)
 	_, err := transport.RoundTrip(req)
@@ -127,7 +136,7 @@ func TestETagRoundTripper_ServiceUnavailable(t *testing.T) {
 func TestETagRoundTripper_NotFound_Error(t *testing.T) {
 	// Verify that 404 returns NotFoundError
 	mockRT := &mockRoundTripper{statusCode: 404}
-	transport := newETagRoundTripper(mockRT, "")
+	transport := newETagRoundTripper(mockRT)
 
 	req, _ := http.NewRequest("GET", "http://example.com/v2/repo/tags/list", nil)
 	_, err := transport.RoundTrip(req)
@@ -140,9 +149,10 @@ func TestETagRoundTripper_NotFound_Error(t *testing.T) {
 func TestETagRoundTripper_Success(t *testing.T) {
 	// Verify that 200 OK returns response normally
 	mockRT := &mockRoundTripper{statusCode: 200, etagValue: `"new-etag"`}
-	transport := newETagRoundTripper(mockRT, `"old-etag"`)
+	transport := newETagRoundTripper(mockRT)
+	ctx, etag := withETag(context.Background(), `"old-etag"`)
 
-	req, _ := http.NewRequest("GET", "http://example.com/v2/repo/tags/list", nil)
+	req, _ := http.NewRequestWithContext(ctx, "GET", "http://example.com/v2/repo/tags/list", nil)
 	resp, err := transport.RoundTrip(req)
 
 	if err != nil {
@@ -153,11 +163,104 @@ func TestETagRoundTripper_Success(t *testing.T) {
 		t.Errorf("expected status 200, got %d", resp.StatusCode)
 	}
 
-	if transport.CapturedETag() != `"new-etag"` {
-		t.Errorf("ETag not captured on 200: %s", transport.CapturedETag())
+	if etag.CapturedETag() != `"new-etag"` {
+		t.Errorf("ETag not captured on 200: %s", etag.CapturedETag())
 	}
 
 	if err := resp.Body.Close(); err != nil {
 		t.Errorf("failed to close response body: %v", err)
 	}
 }
+
+func TestETagRoundTripper_UnauthorizedChallengePassedThrough(t *testing.T) {
+	// Verify that a 401 challenge (expired bearer token) reaches the auth transport,
+	// which fetches a new token and retries
+	mockRT := &mockRoundTripper{statusCode: 401, challenge: `Bearer realm="https://auth.example.com/token"`}
+	transport := newETagRoundTripper(mockRT)
+
+	req, _ := http.NewRequest("GET", "http://example.com/v2/repo/tags/list", nil)
+	resp, err := transport.RoundTrip(req)
+	if err != nil {
+		t.Fatalf("expected the challenge response, got error %v", err)
+	}
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("expected status 401, got %d", resp.StatusCode)
+	}
+	if err := resp.Body.Close(); err != nil {
+		t.Errorf("failed to close response body: %v", err)
+	}
+}
+
+func TestETagRoundTripper_ConcurrentRequests(t *testing.T) {
+	// One transport serves concurrent polls; each captures the ETag of its own response
+	transport := newETagRoundTripper(roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		resp := &http.Response{StatusCode: 200, Header: make(http.Header), Body: io.NopCloser(strings.NewReader("[]"))}
+		resp.Header.Set("ETag", `"`+req.URL.Query().Get("repo")+`"`)
+		return resp, nil
+	}))
+
+	var wg sync.WaitGroup
+	for i := range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			repo := fmt.Sprintf("repo-%d", i)
+			ctx, etag := withETag(context.Background(), "")
+			req, _ := http.NewRequestWithContext(ctx, "GET", "http://example.com/v2/app/tags/list?repo="+repo, nil)
+			resp, err := transport.RoundTrip(req)
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+				return
+			}
+			_ = resp.Body.Close()
+			if got := etag.CapturedETag(); got != `"`+repo+`"` {
+				t.Errorf("request for %s captured ETag %s", repo, got)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestETagRoundTripper_Pages(t *testing.T) {
+	// Only the first page is conditional and captured; the list starts after the given tag
+	var sent []*http.Request
+	transport := newETagRoundTripper(roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		sent = append(sent, req)
+		resp := &http.Response{StatusCode: 200, Header: make(http.Header), Body: io.NopCloser(strings.NewReader("[]"))}
+		resp.Header.Set("ETag", `"`+req.URL.Query().Get("last")+`"`)
+		return resp, nil
+	}))
+	ctx, etag := withTagList(context.Background(), `"old-etag"`, "v1")
+
+	for _, url := range []string{
+		"http://example.com/v2/repo/tags/list?n=100",
+		"http://example.com/v2/repo/tags/list?n=100&last=v5",
+	} {
+		req, _ := http.NewRequestWithContext(ctx, "GET", url, nil)
+		resp, err := transport.RoundTrip(req)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		_ = resp.Body.Close()
+	}
+
+	if got := sent[0].URL.Query().Get("last"); got != "v1" {
+		t.Errorf("expected the first page to start after v1, got last=%q", got)
+	}
+	if got := sent[0].Header.Get("If-None-Match"); got != `"old-etag"` {
+		t.Errorf("expected If-None-Match on the first page, got %q", got)
+	}
+	if got := sent[1].Header.Get("If-None-Match"); got != "" {
+		t.Errorf("expected no If-None-Match on the second page, got %q", got)
+	}
+	if got := etag.CapturedETag(); got != `"v1"` {
+		t.Errorf("expected the ETag of the first page, got %s", got)
+	}
+}
+
+// roundTripperFunc adapts a function to http.RoundTripper.
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
@@ -0,0 +1,169 @@
+// OCI metadata (manifest annotations and image config) of bundle images.
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+)
+
+// AnnotationCreated is the OCI annotation holding the image creation time (RFC 3339).
+const AnnotationCreated = "org.opencontainers.image.created"
+
+// metadataCacheSize bounds the number of images whose metadata an OCIClient keeps.
+const metadataCacheSize = 1000
+
+// ImageMetadata is the OCI metadata of an image used to select bundles.
+type ImageMetadata struct {
+	// Digest is the manifest digest the metadata was read from.
+	Digest string
+
+	// Created is the creation time from the image config; zero if unset or for image indexes.
+	Created time.Time
+
+	// Annotations are the manifest annotations, plus the image config labels that have no
+	// annotation of the same name.
+	Annotations map[string]string
+}
+
+// Value returns the annotation key of the image. For AnnotationCreated, the creation time
+// from the image config is used when the manifest has no such annotation.
+func (m *ImageMetadata) Value(key string) (string, bool) {
+	if value, ok := m.Annotations[key]; ok {
+		return value, true
+	}
+	if key == AnnotationCreated && !m.Created.IsZero() {
+		return m.Created.UTC().Format(time.RFC3339), true
+	}
+	return "", false
+}
+
+// ImageMetadata returns the OCI metadata of the image tag points to.
+// The tag is resolved with a HEAD request; the manifest and config are only downloaded for
+// digests not seen before, since the metadata of a digest never changes.
+func (c *OCIClient) ImageMetadata(
+	ctx context.Context,
+	repoURL, tag string,
+	auth authn.Authenticator,
+) (*ImageMetadata, error) {
+	ref, err := name.NewTag(fmt.Sprintf("%s:%s", repoURL, tag), tlsFrom(ctx).nameOptions()...)
+	if err != nil {
+		return nil, fmt.Errorf("invalid tag reference: %w", err)
+	}
+
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	puller, release, err := c.puller(ctx, ref.Registry, auth)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read image metadata: %w", classifyError(err))
+	}
+
+	var manifestRef name.Reference = ref
+	desc, err := puller.Head(ctx, ref)
+	if err == nil {
+		key := ref.Context().Name() + "@" + desc.Digest.String()
+		if metadata, ok := c.cachedMetadata(key); ok {
+			release(nil)
+			return metadata, nil
+		}
+		manifestRef = ref.Context().Digest(desc.Digest.String())
+	} else {
+		// Auth, not-found and rate limit failures won't be fixed by a GET
+		headErr := classifyError(err)
+		var authErr *AuthError
+		var notFound *NotFoundError
+		var rateLimited *RateLimitedError
+		if errors.As(headErr, &authErr) || errors.As(headErr, &notFound) || errors.As(headErr, &rateLimited) {
+			release(err)
+			return nil, fmt.Errorf("failed to read image metadata: %w", headErr)
+		}
+	}
+
+	metadata, err := readMetadata(ctx, puller, manifestRef)
+	release(err)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read image metadata: %w", classifyError(err))
+	}
+	c.storeMetadata(ref.Context().Name()+"@"+metadata.Digest, metadata)
+	return metadata, nil
+}
+
+// readMetadata downloads the manifest of ref and, for images, the image config.
+func readMetadata(ctx context.Context, puller *remote.Puller, ref name.Reference) (*ImageMetadata, error) {
+	desc, err := puller.Get(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	// Image manifests and indexes both carry annotations at the top level
+	var manifest struct {
+		Annotations map[string]string `json:"annotations"`
+	}
+	if err := json.Unmarshal(desc.Manifest, &manifest); err != nil {
+		return nil, fmt.Errorf("invalid manifest: %w", err)
+	}
+	metadata := &ImageMetadata{
+		Digest:      desc.Digest.String(),
+		Annotations: make(map[string]string, len(manifest.Annotations)),
+	}
+	for key, value := range manifest.Annotations {
+		metadata.Annotations[key] = value
+	}
+
+	if !desc.MediaType.IsImage() {
+		return metadata, nil
+	}
+	img, err := desc.Image()
+	if err != nil {
+		return nil, err
+	}
+	config, err := img.ConfigFile()
+	if err != nil {
+		return nil, err
+	}
+	addConfigMetadata(metadata, config)
+	return metadata, nil
+}
+
+// addConfigMetadata adds the creation time and labels of an image config to metadata.
+func addConfigMetadata(metadata *ImageMetadata, config *v1.ConfigFile) {
+	metadata.Created = config.Created.Time
+	for key, value := range config.Config.Labels {
+		if _, ok := metadata.Annotations[key]; !ok {
+			metadata.Annotations[key] = value
+		}
+	}
+}
+
+// cachedMetadata returns the cached metadata of an image, keyed by repository and digest.
+func (c *OCIClient) cachedMetadata(key string) (*ImageMetadata, bool) {
+	c.metadataMu.Lock()
+	defer c.metadataMu.Unlock()
+	metadata, ok := c.metadata[key]
+	return metadata, ok
+}
+
+// storeMetadata caches the metadata of an image. When the cache is full, an arbitrary
+// entry is dropped.
+func (c *OCIClient) storeMetadata(key string, metadata *ImageMetadata) {
+	c.metadataMu.Lock()
+	defer c.metadataMu.Unlock()
+	if c.metadata == nil {
+		c.metadata = make(map[string]*ImageMetadata)
+	}
+	if _, ok := c.metadata[key]; !ok && len(c.metadata) >= metadataCacheSize {
+		for evict := range c.metadata {
+			delete(c.metadata, evict)
+			break
+		}
+	}
+	c.metadata[key] = metadata
+}
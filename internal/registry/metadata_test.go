@@ -0,0 +1,122 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	ggcrregistry "github.com/google/go-containerregistry/pkg/registry"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+)
+
+// pushImageWithMetadata pushes a random image with the given creation time, config labels
+// and manifest annotations to repoURL:tag.
+func pushImageWithMetadata(
+	t *testing.T,
+	repoURL, tag string,
+	created time.Time,
+	labels, annotations map[string]string,
+) {
+	t.Helper()
+
+	img, err := random.Image(256, 1)
+	if err != nil {
+		t.Fatalf("failed to create random image: %v", err)
+	}
+	img, err = mutate.Config(img, v1.Config{Labels: labels})
+	if err != nil {
+		t.Fatalf("failed to set labels: %v", err)
+	}
+	img, err = mutate.CreatedAt(img, v1.Time{Time: created})
+	if err != nil {
+		t.Fatalf("failed to set creation time: %v", err)
+	}
+	img = mutate.Annotations(img, annotations).(v1.Image)
+
+	ref, err := name.ParseReference(repoURL + ":" + tag)
+	if err != nil {
+		t.Fatalf("failed to parse reference: %v", err)
+	}
+	if err := remote.Write(ref, img); err != nil {
+		t.Fatalf("failed to push image: %v", err)
+	}
+}
+
+func TestOCIClient_ImageMetadata(t *testing.T) {
+	var mu sync.Mutex
+	manifestGets := 0
+	handler := ggcrregistry.New(ggcrregistry.Logger(log.New(io.Discard, "", 0)))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet && strings.Contains(r.URL.Path, "/manifests/") {
+			mu.Lock()
+			manifestGets++
+			mu.Unlock()
+		}
+		handler.ServeHTTP(w, r)
+	}))
+	t.Cleanup(server.Close)
+	repoURL := strings.TrimPrefix(server.URL, "http://") + "/test/metadata"
+
+	created := time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC)
+	pushImageWithMetadata(t, repoURL, "main", created,
+		map[string]string{"env": "staging", "team": "platform"},
+		map[string]string{"env": "prod"})
+
+	client := NewOCIClient()
+	ctx := context.Background()
+	metadata, err := client.ImageMetadata(ctx, repoURL, "main", nil)
+	if err != nil {
+		t.Fatalf("ImageMetadata() error = %v", err)
+	}
+	if !metadata.Created.Equal(created) {
+		t.Errorf("Created = %v, want %v", metadata.Created, created)
+	}
+	if value, _ := metadata.Value(AnnotationCreated); value != "2025-03-01T10:00:00Z" {
+		t.Errorf("expected the config creation time as %s, got %q", AnnotationCreated, value)
+	}
+	// Manifest annotations take precedence over config labels
+	if metadata.Annotations["env"] != "prod" || metadata.Annotations["team"] != "platform" {
+		t.Errorf("unexpected annotations %v", metadata.Annotations)
+	}
+	digest, err := client.ResolveDigest(ctx, repoURL, "main", nil)
+	if err != nil || metadata.Digest != digest {
+		t.Errorf("Digest = %q, want %q (%v)", metadata.Digest, digest, err)
+	}
+
+	// Same digest: served from the cache without downloading the manifest
+	if _, err := client.ImageMetadata(ctx, repoURL, "main", nil); err != nil {
+		t.Fatalf("ImageMetadata() error = %v", err)
+	}
+	mu.Lock()
+	if manifestGets != 1 {
+		t.Errorf("expected 1 manifest download, got %d", manifestGets)
+	}
+	mu.Unlock()
+
+	// Re-pushed tag: new digest, new metadata
+	pushImageWithMetadata(t, repoURL, "main", created.Add(time.Hour), nil, nil)
+	metadata, err = client.ImageMetadata(ctx, repoURL, "main", nil)
+	if err != nil {
+		t.Fatalf("ImageMetadata() error = %v", err)
+	}
+	if !metadata.Created.Equal(created.Add(time.Hour)) || metadata.Annotations["env"] != "" {
+		t.Errorf("expected the re-pushed image's metadata, got %+v", metadata)
+	}
+
+	_, err = client.ImageMetadata(ctx, repoURL, "missing", nil)
+	var notFound *NotFoundError
+	if !errors.As(err, &notFound) {
+		t.Errorf("expected NotFoundError for a missing tag, got %v", err)
+	}
+}
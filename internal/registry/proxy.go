@@ -0,0 +1,93 @@
+// Proxy settings for registries reachable only through an HTTP(S) proxy.
+package registry
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/http/httpproxy"
+)
+
+// ProxyConfig configures the proxy for registry connections, with the semantics of the
+// HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables. The zero value connects directly.
+type ProxyConfig struct {
+	// HTTPProxy and HTTPSProxy are the proxy URLs for plain HTTP and HTTPS registries.
+	HTTPProxy  string
+	HTTPSProxy string
+
+	// NoProxy is a comma-separated list of hosts, domains and CIDRs connected to directly.
+	NoProxy string
+}
+
+// proxyKey is the context key for WithProxy.
+type proxyKey struct{}
+
+// WithProxy returns a context whose registry operations connect through cfg.
+// Without it, the proxy environment variables of the process apply.
+func WithProxy(ctx context.Context, cfg *ProxyConfig) context.Context {
+	return context.WithValue(ctx, proxyKey{}, cfg)
+}
+
+// proxyFrom returns the configuration set by WithProxy, or nil for the environment.
+func proxyFrom(ctx context.Context) *ProxyConfig {
+	cfg, _ := ctx.Value(proxyKey{}).(*ProxyConfig)
+	return cfg
+}
+
+// ProxyFromEnvironment returns the proxy configuration of the process environment
+// (HTTP_PROXY, HTTPS_PROXY and NO_PROXY, or their lowercase forms).
+func ProxyFromEnvironment() ProxyConfig {
+	env := httpproxy.FromEnvironment()
+	return ProxyConfig{HTTPProxy: env.HTTPProxy, HTTPSProxy: env.HTTPSProxy, NoProxy: env.NoProxy}
+}
+
+// Validate checks that the proxy URLs can be parsed. Like in the environment variables, URLs
+// without a scheme are taken as http.
+func (cfg *ProxyConfig) Validate() error {
+	if cfg == nil {
+		return nil
+	}
+	for _, proxy := range []string{cfg.HTTPProxy, cfg.HTTPSProxy} {
+		if proxy == "" {
+			continue
+		}
+		rawURL := proxy
+		if !strings.Contains(rawURL, "://") {
+			rawURL = "http://" + rawURL
+		}
+		u, err := url.Parse(rawURL)
+		if err != nil {
+			return fmt.Errorf("invalid proxy URL %q: %w", proxy, err)
+		}
+		if u.Host == "" {
+			return fmt.Errorf("invalid proxy URL %q: no host", proxy)
+		}
+	}
+	return nil
+}
+
+// key identifies the settings of cfg; "" for the environment.
+func (cfg *ProxyConfig) key() string {
+	if cfg == nil {
+		return ""
+	}
+	return "proxy=" + cfg.HTTPProxy + "," + cfg.HTTPSProxy + "," + cfg.NoProxy
+}
+
+// proxyFunc returns the http.Transport.Proxy function of cfg.
+func (cfg *ProxyConfig) proxyFunc() func(*http.Request) (*url.URL, error) {
+	if cfg == nil {
+		return http.ProxyFromEnvironment
+	}
+	proxy := (&httpproxy.Config{
+		HTTPProxy:  cfg.HTTPProxy,
+		HTTPSProxy: cfg.HTTPSProxy,
+		NoProxy:    cfg.NoProxy,
+	}).ProxyFunc()
+	return func(req *http.Request) (*url.URL, error) {
+		return proxy(req.URL)
+	}
+}
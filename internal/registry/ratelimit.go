@@ -0,0 +1,167 @@
+// Client-side rate limiting of registry requests and handling of 429 responses.
+package registry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// Default client-side rate limit per registry host, shared by all bundles polling the host.
+const (
+	DefaultQPS   = 10
+	DefaultBurst = 20
+)
+
+// RateLimitedError indicates the registry rejected a request with 429 Too Many Requests,
+// or that the request was held back because the registry host is rate limited.
+// RetryAfter is the delay after which the registry accepts requests again; zero if the
+// registry didn't say (no Retry-After header).
+type RateLimitedError struct {
+	RetryAfter time.Duration
+	Err        error
+}
+
+func (e *RateLimitedError) Error() string {
+	if e.RetryAfter > 0 {
+		return fmt.Sprintf("rate limited (retry after %s): %v", e.RetryAfter, e.Err)
+	}
+	return fmt.Sprintf("rate limited: %v", e.Err)
+}
+
+func (e *RateLimitedError) Unwrap() error {
+	return e.Err
+}
+
+// hostRateLimit is the rate limit state of one registry host: a token bucket, and the pause
+// requested by the host's last 429 response. It's shared by every transport to the host,
+// whatever their TLS and proxy configurations, as the registry limits the host as a whole.
+type hostRateLimit struct {
+	limiter *rate.Limiter
+
+	mu          sync.Mutex
+	pausedUntil time.Time
+}
+
+// newHostRateLimit allows qps requests per second with bursts of up to burst requests.
+func newHostRateLimit(qps float64, burst int) *hostRateLimit {
+	return &hostRateLimit{limiter: rate.NewLimiter(rate.Limit(qps), burst)}
+}
+
+// rateLimitRoundTripper limits the request rate to one registry host with the host's token
+// bucket and turns 429 responses into RateLimitedError.
+//
+// When the registry answers 429 with a Retry-After delay, the host is paused: further
+// requests fail with a RateLimitedError right away instead of adding to the registry's load,
+// until the delay has passed.
+type rateLimitRoundTripper struct {
+	base  http.RoundTripper
+	limit *hostRateLimit
+}
+
+// newRateLimitRoundTripper creates a transport sending requests through base under limit.
+func newRateLimitRoundTripper(base http.RoundTripper, limit *hostRateLimit) *rateLimitRoundTripper {
+	return &rateLimitRoundTripper{base: base, limit: limit}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *rateLimitRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if wait := t.limit.pauseRemaining(); wait > 0 {
+		return nil, &RateLimitedError{
+			RetryAfter: wait,
+			Err:        fmt.Errorf("%s asked to slow down", req.URL.Host),
+		}
+	}
+	if err := t.limit.wait(req.Context()); err != nil {
+		return nil, err
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusTooManyRequests {
+		return resp, nil
+	}
+
+	retryAfter := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+	_ = resp.Body.Close()
+	if retryAfter > 0 {
+		t.limit.pause(retryAfter)
+	}
+	return nil, &RateLimitedError{
+		RetryAfter: retryAfter,
+		Err:        fmt.Errorf("HTTP %d: %s", resp.StatusCode, http.StatusText(resp.StatusCode)),
+	}
+}
+
+// wait blocks until the token bucket allows a request. If the wait would outlast the
+// request's deadline, returns a RateLimitedError right away, without taking a token.
+func (l *hostRateLimit) wait(ctx context.Context) error {
+	reservation := l.limiter.Reserve()
+	if !reservation.OK() {
+		return &RateLimitedError{Err: errors.New("client-side rate limit allows no requests")}
+	}
+	delay := reservation.Delay()
+	if delay == 0 {
+		return nil
+	}
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+		reservation.Cancel()
+		return &RateLimitedError{RetryAfter: delay, Err: errors.New("client-side rate limit exceeded")}
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		reservation.Cancel()
+		return ctx.Err()
+	}
+}
+
+// pause holds back requests to the host for d.
+func (l *hostRateLimit) pause(d time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if until := time.Now().Add(d); until.After(l.pausedUntil) {
+		l.pausedUntil = until
+	}
+}
+
+// pauseRemaining returns how long requests to the host are still held back.
+func (l *hostRateLimit) pauseRemaining() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return time.Until(l.pausedUntil)
+}
+
+// parseRetryAfter parses a Retry-After header, given either as delay in seconds or as an
+// HTTP date. Returns zero if the header is missing, invalid or in the past.
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds <= 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil && date.After(now) {
+		return date.Sub(now)
+	}
+	return 0
+}
@@ -0,0 +1,136 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"", 0},
+		{"120", 2 * time.Minute},
+		{" 5 ", 5 * time.Second},
+		{"0", 0},
+		{"-10", 0},
+		{"Wed, 01 Jan 2025 12:01:30 GMT", 90 * time.Second},
+		{"Wed, 01 Jan 2025 11:00:00 GMT", 0},
+		{"soon", 0},
+	}
+	for _, tt := range tests {
+		if got := parseRetryAfter(tt.value, now); got != tt.want {
+			t.Errorf("parseRetryAfter(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestRateLimitRoundTripper_TooManyRequests(t *testing.T) {
+	calls := 0
+	base := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		calls++
+		resp := &http.Response{
+			StatusCode: http.StatusTooManyRequests,
+			Header:     make(http.Header),
+			Body:       io.NopCloser(strings.NewReader("slow down")),
+		}
+		resp.Header.Set("Retry-After", "60")
+		return resp, nil
+	})
+	transport := newRateLimitRoundTripper(base, newHostRateLimit(DefaultQPS, DefaultBurst))
+
+	req, _ := http.NewRequest("GET", "http://example.com/v2/repo/tags/list", nil)
+	_, err := transport.RoundTrip(req)
+	var rateLimited *RateLimitedError
+	if !errors.As(err, &rateLimited) {
+		t.Fatalf("expected RateLimitedError for 429, got %T: %v", err, err)
+	}
+	if rateLimited.RetryAfter != time.Minute {
+		t.Errorf("expected RetryAfter 1m, got %v", rateLimited.RetryAfter)
+	}
+
+	// Requests during the pause fail without reaching the registry
+	req, _ = http.NewRequest("GET", "http://example.com/v2/other/tags/list", nil)
+	_, err = transport.RoundTrip(req)
+	if !errors.As(err, &rateLimited) {
+		t.Fatalf("expected RateLimitedError during the pause, got %T: %v", err, err)
+	}
+	if rateLimited.RetryAfter <= 0 || rateLimited.RetryAfter > time.Minute {
+		t.Errorf("expected the remaining pause as RetryAfter, got %v", rateLimited.RetryAfter)
+	}
+	if calls != 1 {
+		t.Errorf("expected 1 request to reach the registry, got %d", calls)
+	}
+}
+
+func TestRateLimitRoundTripper_TooManyRequestsWithoutRetryAfter(t *testing.T) {
+	calls := 0
+	base := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		calls++
+		if calls == 1 {
+			return &http.Response{StatusCode: http.StatusTooManyRequests, Header: make(http.Header),
+				Body: io.NopCloser(strings.NewReader(""))}, nil
+		}
+		return &http.Response{StatusCode: http.StatusOK, Header: make(http.Header),
+			Body: io.NopCloser(strings.NewReader("[]"))}, nil
+	})
+	transport := newRateLimitRoundTripper(base, newHostRateLimit(DefaultQPS, DefaultBurst))
+
+	req, _ := http.NewRequest("GET", "http://example.com/v2/repo/tags/list", nil)
+	_, err := transport.RoundTrip(req)
+	var rateLimited *RateLimitedError
+	if !errors.As(err, &rateLimited) || rateLimited.RetryAfter != 0 {
+		t.Fatalf("expected RateLimitedError without RetryAfter, got %v", err)
+	}
+
+	// Without a Retry-After the host isn't paused
+	resp, err := transport.RoundTrip(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_ = resp.Body.Close()
+}
+
+func TestRateLimitRoundTripper_TokenBucket(t *testing.T) {
+	calls := 0
+	base := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		calls++
+		return &http.Response{StatusCode: http.StatusOK, Header: make(http.Header),
+			Body: io.NopCloser(strings.NewReader("[]"))}, nil
+	})
+	// One request per minute, no burst beyond the first
+	transport := newRateLimitRoundTripper(base, newHostRateLimit(1.0/60, 1))
+
+	req, _ := http.NewRequest("GET", "http://example.com/v2/repo/tags/list", nil)
+	resp, err := transport.RoundTrip(req)
+	if err != nil {
+		t.Fatalf("unexpected error for the first request: %v", err)
+	}
+	_ = resp.Body.Close()
+
+	// The next token is a minute away, past the request's deadline
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	start := time.Now()
+	_, err = transport.RoundTrip(req.WithContext(ctx))
+	var rateLimited *RateLimitedError
+	if !errors.As(err, &rateLimited) {
+		t.Fatalf("expected RateLimitedError when the bucket is empty, got %v", err)
+	}
+	if rateLimited.RetryAfter < 50*time.Second {
+		t.Errorf("expected RetryAfter close to 1m, got %v", rateLimited.RetryAfter)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("expected to fail without waiting, took %v", elapsed)
+	}
+	if calls != 1 {
+		t.Errorf("expected 1 request to reach the registry, got %d", calls)
+	}
+}
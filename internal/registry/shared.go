@@ -0,0 +1,257 @@
+package registry
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+)
+
+// maxAgeKey is the context key for WithMaxAge.
+type maxAgeKey struct{}
+
+// WithMaxAge returns a context that lets SharedClient serve tag lists fetched up to maxAge ago.
+// The controller passes the bundle's poll interval, so a repository tracked by many bundles
+// is listed about once per interval no matter how many bundles poll it.
+func WithMaxAge(ctx context.Context, maxAge time.Duration) context.Context {
+	return context.WithValue(ctx, maxAgeKey{}, maxAge)
+}
+
+// SharedClient is a Client that shares tag lists between callers polling the same repository.
+//
+// Tag lists are cached per repository and credentials: bundles only share a list fetched with
+// the same credentials, so a bundle never sees tags it couldn't list itself. Lists fetched with
+// other TLS or proxy settings (see WithTLS and WithProxy) are cached separately. Lists of tags
+// after a given tag (see WithTagsAfter) aren't shared: each bundle lists after its own tag.
+// A cached list is reused while it's younger than the caller's WithMaxAge (defaultMaxAge
+// otherwise); refreshing it sends the cached ETag, so unchanged repositories cost a 304
+// response. Concurrent polls of the same repository wait for a single request. A list nobody
+// asked for in idlePolls of its maximum ages (e.g., after its credentials were rotated) is
+// dropped.
+//
+// ETags returned to callers are the cache's ETags, so a caller passing back the ETag it got
+// earlier gets a NotModifiedError while the shared list is unchanged.
+//
+// Other methods are passed through to the wrapped Client.
+type SharedClient struct {
+	Client
+
+	// OnChange, if set, is called when a refresh finds a repository's tag list changed,
+	// so that other bundles tracking the repository can pick up the change right away.
+	OnChange func(ctx context.Context, repoURL string)
+
+	defaultMaxAge time.Duration
+
+	mu        sync.Mutex
+	entries   map[string]*tagListEntry
+	nextSweep time.Time
+}
+
+const (
+	// idlePolls is how many poll intervals a cached list is kept without being asked for.
+	idlePolls = 3
+	// sweepInterval is how often idle lists are looked for.
+	sweepInterval = time.Minute
+)
+
+// tagListEntry is the cached tag list of one repository for one set of credentials.
+// mu is held while fetching, so concurrent polls wait for the same request. expires is
+// guarded by SharedClient.mu.
+type tagListEntry struct {
+	mu        sync.Mutex
+	repo      string
+	expires   time.Time
+	listed    bool
+	tags      []string
+	etag      string
+	fetchedAt time.Time
+}
+
+// NewSharedClient wraps client so that tag lists are shared between callers.
+// Lists are reused for up to defaultMaxAge when the caller doesn't set WithMaxAge.
+func NewSharedClient(client Client, defaultMaxAge time.Duration) *SharedClient {
+	return &SharedClient{
+		Client:        client,
+		defaultMaxAge: defaultMaxAge,
+		entries:       make(map[string]*tagListEntry),
+	}
+}
+
+// ListTags returns the shared tag list of the repository.
+func (c *SharedClient) ListTags(ctx context.Context, repoURL string, auth authn.Authenticator) ([]string, error) {
+	tags, _, err := c.ListTagsWithETag(ctx, repoURL, auth, "")
+	return tags, err
+}
+
+// GetLatestTag returns the lexicographically last tag of the shared tag list.
+func (c *SharedClient) GetLatestTag(ctx context.Context, repoURL string, auth authn.Authenticator) (string, error) {
+	tags, err := c.ListTags(ctx, repoURL, auth)
+	if err != nil || len(tags) == 0 {
+		return "", err
+	}
+	return tags[len(tags)-1], nil
+}
+
+// ListTagsWithETag returns the shared tag list of the repository, refreshing it if it's older
+// than the caller's maximum age. Returns a NotModifiedError if lastETag matches the list.
+func (c *SharedClient) ListTagsWithETag(
+	ctx context.Context,
+	repoURL string,
+	auth authn.Authenticator,
+	lastETag string,
+) ([]string, string, error) {
+	creds, ok := credentialsKey(auth)
+	if !ok || tagsAfter(ctx) != "" {
+		return c.Client.ListTagsWithETag(ctx, repoURL, auth, lastETag)
+	}
+	repo := repositoryKey(repoURL)
+	maxAge := c.maxAge(ctx)
+	entry := c.entry(repo+"|"+creds+"|"+tlsFrom(ctx).key()+"|"+proxyFrom(ctx).key(), maxAge)
+
+	entry.mu.Lock()
+	changed := false
+	if entry.fetchedAt.IsZero() || time.Since(entry.fetchedAt) >= maxAge {
+		tags, etag, err := c.Client.ListTagsWithETag(ctx, repoURL, auth, entry.etag)
+		var notModified *NotModifiedError
+		switch {
+		case errors.As(err, &notModified) && entry.listed:
+			// Unchanged since the cached list
+		case err != nil:
+			entry.mu.Unlock()
+			return nil, "", err
+		default:
+			// Compare tags rather than ETags: not every registry sends ETags
+			changed = entry.listed && !slices.Equal(tags, entry.tags)
+			entry.listed = true
+			entry.tags = tags
+			entry.etag = etag
+		}
+		entry.fetchedAt = time.Now()
+	}
+	tags := append([]string(nil), entry.tags...)
+	etag := entry.etag
+	entry.mu.Unlock()
+
+	if changed {
+		// Lists fetched with other credentials are stale too
+		c.invalidate(repo, entry)
+		if c.OnChange != nil {
+			c.OnChange(ctx, repoURL)
+		}
+	}
+
+	if lastETag != "" && lastETag == etag {
+		return nil, etag, &NotModifiedError{}
+	}
+	return tags, etag, nil
+}
+
+// Invalidate drops the cached tag lists of the repositories, so the next poll lists them from
+// the registry. Used when a push notification says the repository changed.
+func (c *SharedClient) Invalidate(repoURLs ...string) {
+	for _, repoURL := range repoURLs {
+		c.invalidate(repositoryKey(repoURL), nil)
+	}
+}
+
+// invalidate marks all cached lists of repo except keep as stale.
+func (c *SharedClient) invalidate(repo string, keep *tagListEntry) {
+	c.mu.Lock()
+	var stale []*tagListEntry
+	for _, entry := range c.entries {
+		if entry.repo == repo && entry != keep {
+			stale = append(stale, entry)
+		}
+	}
+	c.mu.Unlock()
+
+	for _, entry := range stale {
+		entry.mu.Lock()
+		entry.fetchedAt = time.Time{}
+		entry.mu.Unlock()
+	}
+}
+
+// entry returns the cache entry for key, creating it if needed, and keeps it for idlePolls
+// of maxAge. Drops the entries that expired.
+func (c *SharedClient) entry(key string, maxAge time.Duration) *tagListEntry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	if now.After(c.nextSweep) {
+		for key, entry := range c.entries {
+			if now.After(entry.expires) {
+				delete(c.entries, key)
+			}
+		}
+		c.nextSweep = now.Add(sweepInterval)
+	}
+
+	entry, ok := c.entries[key]
+	if !ok {
+		repo, _, _ := strings.Cut(key, "|")
+		entry = &tagListEntry{repo: repo}
+		c.entries[key] = entry
+	}
+	if expires := now.Add(idlePolls * maxAge); expires.After(entry.expires) {
+		entry.expires = expires
+	}
+	return entry
+}
+
+// maxAge returns how old a cached list the caller accepts.
+func (c *SharedClient) maxAge(ctx context.Context) time.Duration {
+	if maxAge, ok := ctx.Value(maxAgeKey{}).(time.Duration); ok {
+		return maxAge
+	}
+	return c.defaultMaxAge
+}
+
+// NormalizeRepository returns the canonical name of a repository, so that "docker.io/org/app"
+// matches "index.docker.io/org/app" and "GHCR.io/Org/App" matches "ghcr.io/org/app".
+// Returns false if repoURL isn't a valid repository.
+func NormalizeRepository(repoURL string) (string, bool) {
+	repo, err := name.NewRepository(strings.ToLower(repoURL))
+	if err != nil {
+		return "", false
+	}
+	return repo.Name(), true
+}
+
+// repositoryKey identifies a repository in the cache.
+func repositoryKey(repoURL string) string {
+	if repo, ok := NormalizeRepository(repoURL); ok {
+		return repo
+	}
+	return repoURL
+}
+
+// credentialsKey identifies the credentials of auth without keeping them in memory.
+// Returns false if the credentials can't be resolved; such polls bypass the cache.
+func credentialsKey(auth authn.Authenticator) (string, bool) {
+	if auth == nil || auth == authn.Anonymous {
+		return "anonymous", true
+	}
+	cfg, err := auth.Authorization()
+	if err != nil {
+		return "", false
+	}
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return "", false
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), true
+}
+
+// Verify that SharedClient implements Client
+var _ Client = (*SharedClient)(nil)
@@ -0,0 +1,285 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+)
+
+// countingClient is a Client serving fixed tag lists with ETags that counts tag list requests.
+type countingClient struct {
+	Client
+
+	mu      sync.Mutex
+	tags    map[string][]string
+	err     error
+	calls   int
+	release chan struct{}
+}
+
+func newCountingClient() *countingClient {
+	return &countingClient{tags: make(map[string][]string)}
+}
+
+func (c *countingClient) setTags(repoURL string, tags ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.tags[repoURL] = tags
+}
+
+func (c *countingClient) requests() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.calls
+}
+
+func (c *countingClient) ListTagsWithETag(
+	_ context.Context,
+	repoURL string,
+	_ authn.Authenticator,
+	lastETag string,
+) ([]string, string, error) {
+	if c.release != nil {
+		<-c.release
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.calls++
+	if c.err != nil {
+		return nil, "", c.err
+	}
+	tags := c.tags[repoURL]
+	etag := GenerateFakeETag(tags)
+	if lastETag == etag {
+		return nil, etag, &NotModifiedError{}
+	}
+	return tags, etag, nil
+}
+
+func TestSharedClient_SharesTagListWithinMaxAge(t *testing.T) {
+	inner := newCountingClient()
+	inner.setTags("ghcr.io/org/app", "v1.0.0", "v1.1.0")
+	client := NewSharedClient(inner, time.Hour)
+	ctx := context.Background()
+
+	tags, etag, err := client.ListTagsWithETag(ctx, "ghcr.io/org/app", nil, "")
+	if err != nil || len(tags) != 2 || etag == "" {
+		t.Fatalf("ListTagsWithETag() = %v, %q, %v", tags, etag, err)
+	}
+
+	// Another bundle tracking the same repository, spelled differently
+	tags, _, err = client.ListTagsWithETag(ctx, "GHCR.io/org/app", nil, "")
+	if err != nil || len(tags) != 2 {
+		t.Fatalf("ListTagsWithETag() = %v, %v", tags, err)
+	}
+
+	// A bundle that already has the list
+	_, _, err = client.ListTagsWithETag(ctx, "ghcr.io/org/app", nil, etag)
+	var notModified *NotModifiedError
+	if !errors.As(err, &notModified) {
+		t.Errorf("expected NotModifiedError for the shared ETag, got %v", err)
+	}
+
+	if got := inner.requests(); got != 1 {
+		t.Errorf("expected 1 registry request, got %d", got)
+	}
+}
+
+func TestSharedClient_RefreshesAfterMaxAge(t *testing.T) {
+	inner := newCountingClient()
+	inner.setTags("ghcr.io/org/app", "v1.0.0")
+	client := NewSharedClient(inner, time.Hour)
+
+	var changed []string
+	client.OnChange = func(_ context.Context, repoURL string) { changed = append(changed, repoURL) }
+
+	_, etag, err := client.ListTagsWithETag(context.Background(), "ghcr.io/org/app", nil, "")
+	if err != nil {
+		t.Fatalf("ListTagsWithETag() error = %v", err)
+	}
+
+	// Unchanged repository: the refresh is a conditional request
+	stale := WithMaxAge(context.Background(), 0)
+	_, _, err = client.ListTagsWithETag(stale, "ghcr.io/org/app", nil, etag)
+	var notModified *NotModifiedError
+	if !errors.As(err, &notModified) {
+		t.Errorf("expected NotModifiedError for unchanged repository, got %v", err)
+	}
+	if len(changed) != 0 {
+		t.Errorf("expected no change notification, got %v", changed)
+	}
+
+	inner.setTags("ghcr.io/org/app", "v1.0.0", "v2.0.0")
+	tags, _, err := client.ListTagsWithETag(stale, "ghcr.io/org/app", nil, etag)
+	if err != nil || len(tags) != 2 {
+		t.Fatalf("expected the new tag list, got %v, %v", tags, err)
+	}
+	if len(changed) != 1 || changed[0] != "ghcr.io/org/app" {
+		t.Errorf("expected one change notification, got %v", changed)
+	}
+	if got := inner.requests(); got != 3 {
+		t.Errorf("expected 3 registry requests, got %d", got)
+	}
+}
+
+func TestSharedClient_DeduplicatesConcurrentPolls(t *testing.T) {
+	inner := newCountingClient()
+	inner.setTags("ghcr.io/org/app", "v1.0.0")
+	inner.release = make(chan struct{})
+	client := NewSharedClient(inner, time.Hour)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 10)
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _, err := client.ListTagsWithETag(context.Background(), "ghcr.io/org/app", nil, "")
+			errs <- err
+		}()
+	}
+	// Let every poll reach the cache before the first request returns
+	time.Sleep(50 * time.Millisecond)
+	close(inner.release)
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Errorf("ListTagsWithETag() error = %v", err)
+		}
+	}
+	if got := inner.requests(); got != 1 {
+		t.Errorf("expected 1 registry request for concurrent polls, got %d", got)
+	}
+}
+
+func TestSharedClient_SeparatesCredentials(t *testing.T) {
+	inner := newCountingClient()
+	inner.setTags("ghcr.io/org/app", "v1.0.0")
+	client := NewSharedClient(inner, time.Hour)
+	ctx := context.Background()
+
+	for _, auth := range []authn.Authenticator{
+		nil,
+		&authn.Basic{Username: "team-a", Password: "secret"},
+		&authn.Basic{Username: "team-b", Password: "secret"},
+		&authn.Basic{Username: "team-a", Password: "secret"},
+	} {
+		if _, _, err := client.ListTagsWithETag(ctx, "ghcr.io/org/app", auth, ""); err != nil {
+			t.Fatalf("ListTagsWithETag() error = %v", err)
+		}
+	}
+	if got := inner.requests(); got != 3 {
+		t.Errorf("expected one registry request per set of credentials (3), got %d", got)
+	}
+
+	// A list of the tags after a given tag is the bundle's own, and isn't cached
+	after := WithTagsAfter(ctx, "v1.0.0")
+	for range 2 {
+		if _, _, err := client.ListTagsWithETag(after, "ghcr.io/org/app", nil, ""); err != nil {
+			t.Fatalf("ListTagsWithETag() error = %v", err)
+		}
+	}
+	if got := inner.requests(); got != 5 {
+		t.Errorf("expected a registry request per list of the tags after v1.0.0, got %d requests", got)
+	}
+	if got := len(client.entries); got != 3 {
+		t.Errorf("expected 3 cached lists, got %d", got)
+	}
+}
+
+func TestSharedClient_DropsIdleLists(t *testing.T) {
+	inner := newCountingClient()
+	inner.setTags("ghcr.io/org/app", "v1.0.0")
+	client := NewSharedClient(inner, time.Hour)
+	ctx := WithMaxAge(context.Background(), time.Minute)
+
+	rotated := &authn.Basic{Username: "ci", Password: "old"}
+	if _, _, err := client.ListTagsWithETag(ctx, "ghcr.io/org/app", rotated, ""); err != nil {
+		t.Fatalf("ListTagsWithETag() error = %v", err)
+	}
+
+	// The credentials were rotated: the old list is never asked for again
+	client.mu.Lock()
+	for _, entry := range client.entries {
+		entry.expires = time.Now().Add(-time.Second)
+	}
+	client.nextSweep = time.Time{}
+	client.mu.Unlock()
+
+	current := &authn.Basic{Username: "ci", Password: "new"}
+	if _, _, err := client.ListTagsWithETag(ctx, "ghcr.io/org/app", current, ""); err != nil {
+		t.Fatalf("ListTagsWithETag() error = %v", err)
+	}
+	if got := len(client.entries); got != 1 {
+		t.Errorf("expected the idle list to be dropped, got %d cached lists", got)
+	}
+	for _, entry := range client.entries {
+		if wait := time.Until(entry.expires); wait <= 2*time.Minute || wait > 3*time.Minute {
+			t.Errorf("expected the list to be kept for 3 poll intervals, expires in %v", wait)
+		}
+	}
+}
+
+func TestSharedClient_InvalidateAndErrors(t *testing.T) {
+	inner := newCountingClient()
+	inner.setTags("ghcr.io/org/app", "v1.0.0")
+	client := NewSharedClient(inner, time.Hour)
+	ctx := context.Background()
+
+	if _, _, err := client.ListTagsWithETag(ctx, "ghcr.io/org/app", nil, ""); err != nil {
+		t.Fatalf("ListTagsWithETag() error = %v", err)
+	}
+
+	// A push notification drops the cached list
+	inner.setTags("ghcr.io/org/app", "v1.0.0", "v1.1.0")
+	client.Invalidate("ghcr.io/org/app")
+	tags, _, err := client.ListTagsWithETag(ctx, "ghcr.io/org/app", nil, "")
+	if err != nil || len(tags) != 2 {
+		t.Fatalf("expected the pushed tag after Invalidate, got %v, %v", tags, err)
+	}
+
+	// Errors are returned to the caller and not cached
+	client.Invalidate("ghcr.io/org/app")
+	inner.err = &NetworkError{Err: errors.New("connection refused")}
+	_, _, err = client.ListTagsWithETag(ctx, "ghcr.io/org/app", nil, "")
+	var networkErr *NetworkError
+	if !errors.As(err, &networkErr) {
+		t.Fatalf("expected NetworkError, got %v", err)
+	}
+	inner.err = nil
+	tags, _, err = client.ListTagsWithETag(ctx, "ghcr.io/org/app", nil, "")
+	if err != nil || len(tags) != 2 {
+		t.Errorf("expected the tag list after the error cleared, got %v, %v", tags, err)
+	}
+	if got := inner.requests(); got != 4 {
+		t.Errorf("expected 4 registry requests, got %d", got)
+	}
+}
+
+func TestNormalizeRepository(t *testing.T) {
+	tests := []struct {
+		a, b string
+	}{
+		{"docker.io/org/app", "index.docker.io/org/app"},
+		{"GHCR.io/Werf/Bundle", "ghcr.io/werf/bundle"},
+		{"registry.local:5000/app", "registry.local:5000/app"},
+	}
+	for _, tt := range tests {
+		a, okA := NormalizeRepository(tt.a)
+		b, okB := NormalizeRepository(tt.b)
+		if !okA || !okB || a != b {
+			t.Errorf("expected %q and %q to match, got %q and %q", tt.a, tt.b, a, b)
+		}
+	}
+
+	if _, ok := NormalizeRepository("not a repository!"); ok {
+		t.Error("expected invalid repository to be rejected")
+	}
+}
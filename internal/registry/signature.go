@@ -0,0 +1,269 @@
+// Cosign signature verification for bundles stored in OCI registries.
+package registry
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Cosign signature layout. Signatures for an image digest are stored in the same repository
+// under the tag sha256-<hex>.sig, one layer per signature: the layer blob is the signed
+// "simple signing" payload and the signature is in the layer annotation.
+const (
+	CosignSimpleSigningMediaType = "application/vnd.dev.cosign.simplesigning.v1+json"
+	CosignSignatureAnnotation    = "dev.cosignproject.cosign/signature"
+	CosignSignatureType          = "cosign container image signature"
+)
+
+// PublicKeySuffix marks the Secret keys that hold PEM-encoded cosign public keys
+// (e.g., cosign.pub as written by cosign generate-key-pair).
+const PublicKeySuffix = ".pub"
+
+// maxSignaturePayload bounds the size of a simple signing payload read from the registry.
+// Real payloads are a few hundred bytes; anything larger is not a cosign signature.
+const maxSignaturePayload = 1 << 20
+
+// VerificationError indicates the bundle signature could not be verified: there are no
+// signatures, none of them was made by a trusted key, or the verification keys are invalid.
+// Retrying won't help until the bundle is signed or the keys change.
+type VerificationError struct {
+	Err error
+}
+
+func (e *VerificationError) Error() string {
+	return fmt.Sprintf("signature verification failed: %v", e.Err)
+}
+
+func (e *VerificationError) Unwrap() error {
+	return e.Err
+}
+
+// simpleSigningPayload is the part of the cosign simple signing payload that binds
+// a signature to an image.
+type simpleSigningPayload struct {
+	Critical struct {
+		Image struct {
+			DockerManifestDigest string `json:"docker-manifest-digest"`
+		} `json:"image"`
+		Type string `json:"type"`
+	} `json:"critical"`
+}
+
+// PublicKeysFromSecret parses the cosign public keys in secret.
+// Every data key ending in ".pub" must hold a PEM-encoded public key (ECDSA, RSA or Ed25519).
+// Returns a VerificationError if the Secret has no such keys or one of them is invalid.
+func PublicKeysFromSecret(secret *corev1.Secret) ([]crypto.PublicKey, error) {
+	var names []string
+	for key := range secret.Data {
+		if strings.HasSuffix(key, PublicKeySuffix) {
+			names = append(names, key)
+		}
+	}
+	if len(names) == 0 {
+		return nil, &VerificationError{Err: fmt.Errorf("secret %q has no public keys (keys ending in %s)",
+			secret.Name, PublicKeySuffix)}
+	}
+	sort.Strings(names)
+
+	keys := make([]crypto.PublicKey, 0, len(names))
+	for _, key := range names {
+		block, _ := pem.Decode(secret.Data[key])
+		if block == nil {
+			return nil, &VerificationError{Err: fmt.Errorf("secret %q: %s is not PEM-encoded", secret.Name, key)}
+		}
+		pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, &VerificationError{Err: fmt.Errorf("secret %q: invalid public key %s: %w",
+				secret.Name, key, err)}
+		}
+		switch pub.(type) {
+		case *ecdsa.PublicKey, *rsa.PublicKey, ed25519.PublicKey:
+		default:
+			return nil, &VerificationError{Err: fmt.Errorf("secret %q: unsupported public key type %T in %s",
+				secret.Name, pub, key)}
+		}
+		keys = append(keys, pub)
+	}
+	return keys, nil
+}
+
+// CosignSignatureTag returns the tag cosign stores the signatures of digest under.
+func CosignSignatureTag(digest string) string {
+	return strings.Replace(digest, ":", "-", 1) + ".sig"
+}
+
+// VerifyCosignSignature checks that the manifest digest in repoURL carries a cosign signature
+// made by one of keys. auth is an optional authn.Authenticator; if nil, anonymous access is used.
+// The registry is reached with the TLS and proxy configuration of ctx (see WithTLS and
+// WithProxy), through the host's connection pool and rate limit.
+//
+// Returns a VerificationError if the digest isn't signed by a trusted key. Failures to reach
+// the registry are returned as registry errors (AuthError, NetworkError, ...) so they can be
+// retried like any other poll failure.
+func (c *OCIClient) VerifyCosignSignature(
+	ctx context.Context,
+	repoURL, digest string,
+	auth authn.Authenticator,
+	keys []crypto.PublicKey,
+) error {
+	if len(keys) == 0 {
+		return &VerificationError{Err: errors.New("no public keys configured")}
+	}
+
+	ref, err := name.NewTag(fmt.Sprintf("%s:%s", repoURL, CosignSignatureTag(digest)), tlsFrom(ctx).nameOptions()...)
+	if err != nil {
+		return fmt.Errorf("invalid signature reference: %w", err)
+	}
+
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	puller, release, err := c.puller(ctx, ref.Registry, auth)
+	if err != nil {
+		return fmt.Errorf("failed to fetch signatures: %w", classifyError(err))
+	}
+	err = verifyCosignSignature(ctx, puller, ref, digest, keys)
+	var verificationErr *VerificationError
+	if errors.As(err, &verificationErr) {
+		release(nil)
+		return err
+	}
+	release(err)
+	return err
+}
+
+// verifyCosignSignature downloads the signatures stored under ref and checks that one of them
+// is a signature of digest by one of keys.
+func verifyCosignSignature(
+	ctx context.Context,
+	puller *remote.Puller,
+	ref name.Tag,
+	digest string,
+	keys []crypto.PublicKey,
+) error {
+	desc, err := puller.Get(ctx, ref)
+	if err != nil {
+		classified := classifyError(err)
+		var notFound *NotFoundError
+		if errors.As(classified, &notFound) {
+			return &VerificationError{Err: fmt.Errorf("no signatures found for %s", digest)}
+		}
+		return fmt.Errorf("failed to fetch signatures: %w", classified)
+	}
+	img, err := desc.Image()
+	if err != nil {
+		return fmt.Errorf("failed to fetch signatures: %w", classifyError(err))
+	}
+	manifest, err := img.Manifest()
+	if err != nil {
+		return fmt.Errorf("failed to fetch signatures: %w", classifyError(err))
+	}
+
+	// Report why the last candidate was rejected if none verifies
+	lastErr := fmt.Errorf("no signatures found for %s", digest)
+	for _, desc := range manifest.Layers {
+		if desc.MediaType != CosignSimpleSigningMediaType {
+			continue
+		}
+		encoded, ok := desc.Annotations[CosignSignatureAnnotation]
+		if !ok {
+			continue
+		}
+		signature, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			lastErr = fmt.Errorf("malformed signature: %w", err)
+			continue
+		}
+
+		layer, err := puller.Layer(ctx, ref.Context().Digest(desc.Digest.String()))
+		if err != nil {
+			return fmt.Errorf("failed to fetch signature payload: %w", classifyError(err))
+		}
+		payload, err := readPayload(layer.Compressed, maxSignaturePayload)
+		if err != nil {
+			return fmt.Errorf("failed to fetch signature payload: %w", classifyError(err))
+		}
+
+		if !verifyWithAnyKey(keys, payload, signature) {
+			lastErr = errors.New("no signature matches the configured public keys")
+			continue
+		}
+
+		// A valid signature only counts if it was made for this digest
+		var claims simpleSigningPayload
+		if err := json.Unmarshal(payload, &claims); err != nil {
+			lastErr = fmt.Errorf("malformed signature payload: %w", err)
+			continue
+		}
+		if claims.Critical.Type != CosignSignatureType {
+			lastErr = fmt.Errorf("unexpected signature type %q", claims.Critical.Type)
+			continue
+		}
+		if claims.Critical.Image.DockerManifestDigest != digest {
+			lastErr = fmt.Errorf("signature is for %s, not %s", claims.Critical.Image.DockerManifestDigest, digest)
+			continue
+		}
+		return nil
+	}
+
+	return &VerificationError{Err: lastErr}
+}
+
+// readPayload reads a layer blob, refusing blobs over limit bytes.
+func readPayload(open func() (io.ReadCloser, error), limit int64) ([]byte, error) {
+	rc, err := open()
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rc.Close() }()
+
+	payload, err := io.ReadAll(io.LimitReader(rc, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(payload)) > limit {
+		return nil, fmt.Errorf("payload exceeds %d bytes", limit)
+	}
+	return payload, nil
+}
+
+// verifyWithAnyKey reports whether signature is a valid signature of payload by one of keys.
+// Uses the schemes cosign signs with: ECDSA and RSA PKCS#1 v1.5 over SHA-256, or pure Ed25519.
+func verifyWithAnyKey(keys []crypto.PublicKey, payload, signature []byte) bool {
+	digest := sha256.Sum256(payload)
+	for _, key := range keys {
+		switch pub := key.(type) {
+		case *ecdsa.PublicKey:
+			if ecdsa.VerifyASN1(pub, digest[:], signature) {
+				return true
+			}
+		case *rsa.PublicKey:
+			if rsa.VerifyPKCS1v15(pub, crypto.SHA256, digest[:], signature) == nil {
+				return true
+			}
+		case ed25519.PublicKey:
+			if ed25519.Verify(pub, payload, signature) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,283 @@
+package registry
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	testingutil "github.com/werf/k8s-werf-operator-go/internal/testing"
+)
+
+// pushImage pushes a random image to repoURL:tag and returns its digest.
+func pushImage(t *testing.T, repoURL, tag string, auth authn.Authenticator) string {
+	t.Helper()
+
+	img, err := random.Image(256, 1)
+	if err != nil {
+		t.Fatalf("failed to create random image: %v", err)
+	}
+	ref, err := name.ParseReference(repoURL + ":" + tag)
+	if err != nil {
+		t.Fatalf("failed to parse reference: %v", err)
+	}
+	if err := remote.Write(ref, img, remote.WithAuth(auth)); err != nil {
+		t.Fatalf("failed to push image: %v", err)
+	}
+	digest, err := img.Digest()
+	if err != nil {
+		t.Fatalf("failed to compute digest: %v", err)
+	}
+	return digest.String()
+}
+
+// generateKeys returns one signing key of each type supported by cosign.
+func generateKeys(t *testing.T) map[string]crypto.Signer {
+	t.Helper()
+
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate ECDSA key: %v", err)
+	}
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate RSA key: %v", err)
+	}
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate Ed25519 key: %v", err)
+	}
+	return map[string]crypto.Signer{"ecdsa": ecKey, "rsa": rsaKey, "ed25519": edKey}
+}
+
+// keySecret builds a Secret holding the PEM public keys of signers as <name>.pub entries.
+func keySecret(t *testing.T, signers map[string]crypto.Signer) *corev1.Secret {
+	t.Helper()
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "cosign-keys"},
+		Data:       map[string][]byte{},
+	}
+	for keyName, signer := range signers {
+		pub, err := testingutil.PublicKeyPEM(signer)
+		if err != nil {
+			t.Fatalf("failed to encode public key: %v", err)
+		}
+		secret.Data[keyName+PublicKeySuffix] = pub
+	}
+	return secret
+}
+
+func TestPublicKeysFromSecret(t *testing.T) {
+	keys, err := PublicKeysFromSecret(keySecret(t, generateKeys(t)))
+	if err != nil {
+		t.Fatalf("PublicKeysFromSecret() error = %v", err)
+	}
+	if len(keys) != 3 {
+		t.Errorf("expected 3 keys, got %d", len(keys))
+	}
+
+	tests := []struct {
+		name    string
+		data    map[string][]byte
+		wantErr string
+	}{
+		{
+			name:    "no public keys",
+			data:    map[string][]byte{"cosign.key": []byte("private")},
+			wantErr: "has no public keys",
+		},
+		{
+			name:    "not PEM",
+			data:    map[string][]byte{"cosign.pub": []byte("not a key")},
+			wantErr: "is not PEM-encoded",
+		},
+		{
+			name: "invalid key",
+			data: map[string][]byte{
+				"cosign.pub": []byte("-----BEGIN PUBLIC KEY-----\nAAAA\n-----END PUBLIC KEY-----\n"),
+			},
+			wantErr: "invalid public key cosign.pub",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "cosign-keys"}, Data: tt.data}
+			_, err := PublicKeysFromSecret(secret)
+			var verifyErr *VerificationError
+			if !errors.As(err, &verifyErr) {
+				t.Fatalf("expected VerificationError, got %v", err)
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %q", tt.wantErr, err.Error())
+			}
+		})
+	}
+}
+
+func TestCosignSignatureTag(t *testing.T) {
+	got := CosignSignatureTag("sha256:abc123")
+	if got != "sha256-abc123.sig" {
+		t.Errorf("CosignSignatureTag() = %q, want %q", got, "sha256-abc123.sig")
+	}
+}
+
+func TestVerifyCosignSignature_LocalRegistry(t *testing.T) {
+	ctx := context.Background()
+	host := startBasicAuthRegistry(t, "testuser", "testpass")
+	repoURL := host + "/test/signed"
+	auth := &authn.Basic{Username: "testuser", Password: "testpass"}
+	client := NewOCIClient()
+
+	signers := generateKeys(t)
+	for keyType, signer := range signers {
+		t.Run(keyType, func(t *testing.T) {
+			digest := pushImage(t, repoURL, keyType, auth)
+			keys, err := PublicKeysFromSecret(keySecret(t, map[string]crypto.Signer{keyType: signer}))
+			if err != nil {
+				t.Fatalf("PublicKeysFromSecret() error = %v", err)
+			}
+
+			// Unsigned bundles are rejected
+			err = client.VerifyCosignSignature(ctx, repoURL, digest, auth, keys)
+			var verifyErr *VerificationError
+			if !errors.As(err, &verifyErr) || !strings.Contains(err.Error(), "no signatures found") {
+				t.Fatalf("expected VerificationError for unsigned bundle, got %v", err)
+			}
+
+			if err := testingutil.SignBundle(ctx, repoURL, digest, signer, auth); err != nil {
+				t.Fatalf("SignBundle() error = %v", err)
+			}
+			if err := client.VerifyCosignSignature(ctx, repoURL, digest, auth, keys); err != nil {
+				t.Errorf("VerifyCosignSignature() error = %v", err)
+			}
+		})
+	}
+
+	t.Run("untrusted key", func(t *testing.T) {
+		digest := pushImage(t, repoURL, "untrusted", auth)
+		if err := testingutil.SignBundle(ctx, repoURL, digest, signers["ecdsa"], auth); err != nil {
+			t.Fatalf("SignBundle() error = %v", err)
+		}
+
+		keys, err := PublicKeysFromSecret(keySecret(t, map[string]crypto.Signer{"other": generateKeys(t)["ecdsa"]}))
+		if err != nil {
+			t.Fatalf("PublicKeysFromSecret() error = %v", err)
+		}
+		err = client.VerifyCosignSignature(ctx, repoURL, digest, auth, keys)
+		var verifyErr *VerificationError
+		if !errors.As(err, &verifyErr) || !strings.Contains(err.Error(), "no signature matches") {
+			t.Errorf("expected VerificationError for untrusted key, got %v", err)
+		}
+
+		// Any trusted key is enough
+		keys, err = PublicKeysFromSecret(keySecret(t, map[string]crypto.Signer{
+			"other": generateKeys(t)["ecdsa"],
+			"ci":    signers["ecdsa"],
+		}))
+		if err != nil {
+			t.Fatalf("PublicKeysFromSecret() error = %v", err)
+		}
+		if err := client.VerifyCosignSignature(ctx, repoURL, digest, auth, keys); err != nil {
+			t.Errorf("VerifyCosignSignature() with one trusted key error = %v", err)
+		}
+	})
+
+	t.Run("signature for another digest", func(t *testing.T) {
+		signed := pushImage(t, repoURL, "signed", auth)
+		unsigned := pushImage(t, repoURL, "unsigned", auth)
+		if err := testingutil.SignBundle(ctx, repoURL, signed, signers["ecdsa"], auth); err != nil {
+			t.Fatalf("SignBundle() error = %v", err)
+		}
+
+		// Copy a valid signature of one bundle to the signature tag of another
+		sigRef, _ := name.NewTag(repoURL + ":" + CosignSignatureTag(signed))
+		sigImg, err := remote.Image(sigRef, remote.WithAuth(auth))
+		if err != nil {
+			t.Fatalf("failed to fetch signature: %v", err)
+		}
+		copyRef, _ := name.NewTag(repoURL + ":" + CosignSignatureTag(unsigned))
+		if err := remote.Write(copyRef, sigImg, remote.WithAuth(auth)); err != nil {
+			t.Fatalf("failed to copy signature: %v", err)
+		}
+
+		keys, err := PublicKeysFromSecret(keySecret(t, map[string]crypto.Signer{"ci": signers["ecdsa"]}))
+		if err != nil {
+			t.Fatalf("PublicKeysFromSecret() error = %v", err)
+		}
+		err = client.VerifyCosignSignature(ctx, repoURL, unsigned, auth, keys)
+		var verifyErr *VerificationError
+		if !errors.As(err, &verifyErr) || !strings.Contains(err.Error(), "signature is for "+signed) {
+			t.Errorf("expected VerificationError for copied signature, got %v", err)
+		}
+	})
+
+	t.Run("registry auth failure", func(t *testing.T) {
+		keys, err := PublicKeysFromSecret(keySecret(t, map[string]crypto.Signer{"ci": signers["ecdsa"]}))
+		if err != nil {
+			t.Fatalf("PublicKeysFromSecret() error = %v", err)
+		}
+		err = client.VerifyCosignSignature(ctx, repoURL, "sha256:"+strings.Repeat("0", 64), nil, keys)
+		var authErr *AuthError
+		if !errors.As(err, &authErr) {
+			t.Errorf("expected AuthError without credentials, got %v", err)
+		}
+	})
+}
+
+func TestVerifyCosignSignature_SharesHostRateLimit(t *testing.T) {
+	var mu sync.Mutex
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v2/" {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		mu.Lock()
+		requests++
+		mu.Unlock()
+		w.Header().Set("Retry-After", "120")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	t.Cleanup(server.Close)
+	repoURL := strings.TrimPrefix(server.URL, "http://") + "/test/signed"
+
+	keys, err := PublicKeysFromSecret(keySecret(t, map[string]crypto.Signer{"ci": generateKeys(t)["ecdsa"]}))
+	if err != nil {
+		t.Fatalf("PublicKeysFromSecret() error = %v", err)
+	}
+	client := NewOCIClient()
+	err = client.VerifyCosignSignature(context.Background(), repoURL, "sha256:"+strings.Repeat("0", 64), nil, keys)
+	var rateLimited *RateLimitedError
+	if !errors.As(err, &rateLimited) || rateLimited.RetryAfter != 2*time.Minute {
+		t.Fatalf("expected RateLimitedError with RetryAfter 2m, got %v", err)
+	}
+
+	// Polls of the host are held back for the Retry-After of the signature request
+	if _, _, err := client.ListTagsWithETag(context.Background(), repoURL, nil, ""); !errors.As(err, &rateLimited) {
+		t.Errorf("expected the tag list to be held back, got %v", err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if requests != 1 {
+		t.Errorf("expected 1 request to reach the registry, got %d", requests)
+	}
+}
@@ -0,0 +1,412 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	werfv1alpha1 "github.com/werf/k8s-werf-operator-go/api/v1alpha1"
+	"github.com/werf/k8s-werf-operator-go/internal/version"
+)
+
+var (
+	// digitRuns matches the numeric segments compared by the calver sort policy.
+	digitRuns = regexp.MustCompile(`[0-9]+`)
+	// decimalNumber matches values accepted by the numerical sort policy.
+	decimalNumber = regexp.MustCompile(`^[0-9]+(\.[0-9]+)?$`)
+)
+
+// maxMetadataCandidates bounds the tags whose image metadata is read for one selection.
+// Each candidate costs registry requests; larger sets must be narrowed with tagFilter.
+const maxMetadataCandidates = 100
+
+// MetadataFunc returns the OCI metadata of the image tagged tag.
+type MetadataFunc func(ctx context.Context, tag string) (*ImageMetadata, error)
+
+// TagPolicy filters and orders the tags returned by ListTags to pick the one to deploy.
+// It sits between the registry client and the controller: the client lists tags,
+// the policy decides which of them (if any) should be deployed.
+type TagPolicy struct {
+	include    *regexp.Regexp
+	exclude    *regexp.Regexp
+	extract    int // index of the capture group in include, or -1 for the whole tag
+	sortPolicy string
+	versions   version.Options
+	// filtered is false when spec.registry.tagFilter is not set: tags are ordered by
+	// semver, and tags that aren't semver are ignored.
+	filtered bool
+	// orderBy and matchAnnotations are set from spec.registry.metadataSelection;
+	// orderBy is empty when tags are ordered by name.
+	orderBy          string
+	matchAnnotations map[string]string
+}
+
+// NewTagPolicy builds a TagPolicy from the registry spec.
+// Returns an error if a regular expression, the extract group, the sort policy or the
+// version constraint is invalid, or if incrementalTagList is set without lexical ordering. These are configuration errors: retrying won't help
+// until the spec changes.
+func NewTagPolicy(cfg *werfv1alpha1.RegistryConfig) (*TagPolicy, error) {
+	p := &TagPolicy{
+		extract:    -1,
+		sortPolicy: werfv1alpha1.SortPolicySemver,
+		versions: version.Options{
+			Constraint:        cfg.VersionConstraint,
+			IncludePrerelease: cfg.AllowPrerelease,
+		},
+	}
+
+	if filter := cfg.TagFilter; filter != nil {
+		p.filtered = true
+
+		if filter.Include != "" {
+			re, err := regexp.Compile(filter.Include)
+			if err != nil {
+				return nil, fmt.Errorf("invalid tagFilter.include: %w", err)
+			}
+			p.include = re
+		}
+		if filter.Exclude != "" {
+			re, err := regexp.Compile(filter.Exclude)
+			if err != nil {
+				return nil, fmt.Errorf("invalid tagFilter.exclude: %w", err)
+			}
+			p.exclude = re
+		}
+		if filter.Extract != "" {
+			if p.include == nil {
+				return nil, fmt.Errorf("tagFilter.extract requires tagFilter.include")
+			}
+			p.extract = p.include.SubexpIndex(filter.Extract)
+			if p.extract < 0 {
+				return nil, fmt.Errorf("tagFilter.include has no capture group named %q", filter.Extract)
+			}
+		}
+
+		switch filter.SortPolicy {
+		case "":
+		case werfv1alpha1.SortPolicyLexical, werfv1alpha1.SortPolicyNumerical,
+			werfv1alpha1.SortPolicySemver, werfv1alpha1.SortPolicyCalver:
+			p.sortPolicy = filter.SortPolicy
+		default:
+			return nil, fmt.Errorf("invalid tagFilter.sortPolicy %q: must be one of %s, %s, %s, %s",
+				filter.SortPolicy, werfv1alpha1.SortPolicyLexical, werfv1alpha1.SortPolicyNumerical,
+				werfv1alpha1.SortPolicySemver, werfv1alpha1.SortPolicyCalver)
+		}
+	}
+
+	if selection := cfg.MetadataSelection; selection != nil {
+		if cfg.VersionConstraint != "" {
+			return nil, fmt.Errorf("versionConstraint can't be used with metadataSelection")
+		}
+		p.orderBy = selection.OrderBy
+		if p.orderBy == "" {
+			p.orderBy = AnnotationCreated
+		}
+		p.matchAnnotations = selection.MatchAnnotations
+	}
+
+	if cfg.VersionConstraint != "" {
+		if p.sortPolicy != werfv1alpha1.SortPolicySemver {
+			return nil, fmt.Errorf("versionConstraint requires tagFilter.sortPolicy %s, got %s",
+				werfv1alpha1.SortPolicySemver, p.sortPolicy)
+		}
+		if _, err := version.ParseConstraint(cfg.VersionConstraint); err != nil {
+			return nil, err
+		}
+	}
+
+	// Registries list tags after "last" in lexical order: any other order would miss newer
+	// tags sorting before the applied one (e.g., v1.10.0 after v1.9.0)
+	if cfg.IncrementalTagList &&
+		(p.sortPolicy != werfv1alpha1.SortPolicyLexical || p.extract >= 0 || p.orderBy != "") {
+		order := "sortPolicy " + p.sortPolicy
+		switch {
+		case p.orderBy != "":
+			order = "metadataSelection"
+		case p.extract >= 0:
+			order = "tagFilter.extract"
+		}
+		return nil, fmt.Errorf("incrementalTagList requires tagFilter.sortPolicy %s of whole tags, "+
+			"as registries list tags in lexical order; got %s", werfv1alpha1.SortPolicyLexical, order)
+	}
+
+	return p, nil
+}
+
+// Select picks the tag to deploy from tags.
+// When no tag qualifies, the returned Selection has an empty Tag and a Reason explaining why.
+func (p *TagPolicy) Select(tags []string) (*version.Selection, error) {
+	if !p.filtered {
+		return version.SelectVersion(tags, p.versions)
+	}
+
+	if len(tags) == 0 {
+		return &version.Selection{Reason: "no tags found in registry"}, nil
+	}
+
+	candidates := p.filter(tags)
+	if len(candidates) == 0 {
+		return &version.Selection{
+			Reason: fmt.Sprintf("none of %d tags match tagFilter", len(tags)),
+		}, nil
+	}
+	matched := fmt.Sprintf("%d of %d tags match tagFilter", len(candidates), len(tags))
+
+	if p.sortPolicy == werfv1alpha1.SortPolicySemver {
+		selection, err := version.SelectCandidate(candidates, p.versions)
+		if err != nil {
+			return nil, err
+		}
+		selection.Reason = fmt.Sprintf("%s; %s", matched, selection.Reason)
+		return selection, nil
+	}
+
+	var (
+		best     *version.Candidate
+		unusable int
+	)
+	for i := range candidates {
+		c := &candidates[i]
+		if !p.parsable(c.Value) {
+			unusable++
+			continue
+		}
+		// Ties (equal values from different tags) go to the lexicographically greater tag
+		if best == nil {
+			best = c
+			continue
+		}
+		if cmp := p.compare(c.Value, best.Value); cmp > 0 || (cmp == 0 && c.Tag > best.Tag) {
+			best = c
+		}
+	}
+
+	if best == nil {
+		return &version.Selection{
+			Reason: fmt.Sprintf("%s; no %s values found", matched, p.sortPolicy),
+		}, nil
+	}
+
+	reason := fmt.Sprintf("%s; highest %s value %q", matched, p.sortPolicy, best.Value)
+	if unusable > 0 {
+		reason += fmt.Sprintf(" (ignored %d tags without a %s value)", unusable, p.sortPolicy)
+	}
+	return &version.Selection{Tag: best.Tag, Version: best.Value, Reason: reason}, nil
+}
+
+// UsesMetadata reports whether tags are selected by image metadata (spec.registry.metadataSelection),
+// in which case SelectByMetadata must be used instead of Select.
+func (p *TagPolicy) UsesMetadata() bool {
+	return p.orderBy != ""
+}
+
+// SelectByMetadata picks the tag to deploy from tags by the metadata of their images: tags
+// passing the tag filter whose metadata matches matchAnnotations are ordered by the orderBy
+// annotation. Ties go to the lexicographically greater tag.
+//
+// Tags deleted since they were listed are skipped. Other errors of lookup are returned
+// wrapped, so the caller can handle them like registry errors.
+func (p *TagPolicy) SelectByMetadata(ctx context.Context, tags []string, lookup MetadataFunc) (*version.Selection, error) {
+	if len(tags) == 0 {
+		return &version.Selection{Reason: "no tags found in registry"}, nil
+	}
+
+	candidates := tags
+	matched := fmt.Sprintf("%d tags", len(tags))
+	if p.filtered {
+		candidates = nil
+		for _, c := range p.filter(tags) {
+			candidates = append(candidates, c.Tag)
+		}
+		if len(candidates) == 0 {
+			return &version.Selection{
+				Reason: fmt.Sprintf("none of %d tags match tagFilter", len(tags)),
+			}, nil
+		}
+		matched = fmt.Sprintf("%d of %d tags match tagFilter", len(candidates), len(tags))
+	}
+	if len(candidates) > maxMetadataCandidates {
+		return &version.Selection{
+			Reason: fmt.Sprintf("%s; metadata is read for at most %d tags, narrow them with tagFilter",
+				matched, maxMetadataCandidates),
+		}, nil
+	}
+
+	var (
+		bestTag, bestValue  string
+		unmatched, unusable int
+	)
+	for _, tag := range candidates {
+		metadata, err := lookup(ctx, tag)
+		var notFound *NotFoundError
+		if errors.As(err, &notFound) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read metadata of tag %s: %w", tag, err)
+		}
+		if !p.matchesAnnotations(metadata) {
+			unmatched++
+			continue
+		}
+		value, ok := metadata.Value(p.orderBy)
+		if !ok || value == "" {
+			unusable++
+			continue
+		}
+		if bestTag == "" {
+			bestTag, bestValue = tag, value
+			continue
+		}
+		if cmp := compareMetadata(value, bestValue); cmp > 0 || (cmp == 0 && tag > bestTag) {
+			bestTag, bestValue = tag, value
+		}
+	}
+
+	var ignored []string
+	if unmatched > 0 {
+		ignored = append(ignored, fmt.Sprintf("%d not matching matchAnnotations", unmatched))
+	}
+	if unusable > 0 {
+		ignored = append(ignored, fmt.Sprintf("%d without %s", unusable, p.orderBy))
+	}
+	suffix := ""
+	if len(ignored) > 0 {
+		suffix = fmt.Sprintf(" (ignored %s)", strings.Join(ignored, ", "))
+	}
+
+	if bestTag == "" {
+		return &version.Selection{
+			Reason: fmt.Sprintf("%s; no image with a %s value%s", matched, p.orderBy, suffix),
+		}, nil
+	}
+	return &version.Selection{
+		Tag:     bestTag,
+		Version: bestValue,
+		Reason:  fmt.Sprintf("%s; highest %s %q%s", matched, p.orderBy, bestValue, suffix),
+	}, nil
+}
+
+// matchesAnnotations reports whether metadata has all the values of matchAnnotations.
+func (p *TagPolicy) matchesAnnotations(metadata *ImageMetadata) bool {
+	for key, want := range p.matchAnnotations {
+		if value, ok := metadata.Value(key); !ok || value != want {
+			return false
+		}
+	}
+	return true
+}
+
+// compareMetadata orders two metadata values: RFC 3339 timestamps by time, values with
+// digits by their numeric segments (like calver), anything else as plain strings.
+func compareMetadata(a, b string) int {
+	aTime, aErr := time.Parse(time.RFC3339Nano, a)
+	bTime, bErr := time.Parse(time.RFC3339Nano, b)
+	switch {
+	case aErr == nil && bErr == nil:
+		return aTime.Compare(bTime)
+	case digitRuns.MatchString(a) && digitRuns.MatchString(b):
+		return compareCalver(a, b)
+	default:
+		return strings.Compare(a, b)
+	}
+}
+
+// filter applies include/exclude and extracts the sortable value from each remaining tag.
+// Tags where the extract group didn't participate in the match are dropped.
+func (p *TagPolicy) filter(tags []string) []version.Candidate {
+	var candidates []version.Candidate
+	for _, tag := range tags {
+		if p.exclude != nil && p.exclude.MatchString(tag) {
+			continue
+		}
+
+		value := tag
+		if p.include != nil {
+			match := p.include.FindStringSubmatchIndex(tag)
+			if match == nil {
+				continue
+			}
+			if p.extract >= 0 {
+				start, end := match[2*p.extract], match[2*p.extract+1]
+				if start < 0 || start == end {
+					continue
+				}
+				value = tag[start:end]
+			}
+		}
+
+		candidates = append(candidates, version.Candidate{Tag: tag, Value: value})
+	}
+	return candidates
+}
+
+// parsable reports whether value can be ordered by the sort policy.
+func (p *TagPolicy) parsable(value string) bool {
+	switch p.sortPolicy {
+	case werfv1alpha1.SortPolicyNumerical:
+		return decimalNumber.MatchString(value)
+	case werfv1alpha1.SortPolicyCalver:
+		return digitRuns.MatchString(value)
+	default:
+		return true
+	}
+}
+
+// compare orders two parsable values by the sort policy, returning -1, 0 or 1.
+func (p *TagPolicy) compare(a, b string) int {
+	switch p.sortPolicy {
+	case werfv1alpha1.SortPolicyNumerical:
+		return compareDecimal(a, b)
+	case werfv1alpha1.SortPolicyCalver:
+		return compareCalver(a, b)
+	default:
+		return strings.Compare(a, b)
+	}
+}
+
+// compareDecimal compares two non-negative decimal numbers (e.g., "512", "3.14") exactly,
+// without the precision loss of float parsing for long build numbers.
+func compareDecimal(a, b string) int {
+	aInt, aFrac, _ := strings.Cut(a, ".")
+	bInt, bFrac, _ := strings.Cut(b, ".")
+	if cmp := compareDigits(aInt, bInt); cmp != 0 {
+		return cmp
+	}
+	// Fractions compare lexically once trailing zeros are dropped: "5" > "45", "5" == "50"
+	return strings.Compare(strings.TrimRight(aFrac, "0"), strings.TrimRight(bFrac, "0"))
+}
+
+// compareCalver compares the numeric segments of a and b left to right, so that
+// 2025.1.10 > 2025.1.9 and 20250102.0001 > 20250101.2359. Separators are ignored.
+// When one value is a prefix of the other, the longer one is greater (2025.1.1 > 2025.1).
+func compareCalver(a, b string) int {
+	as, bs := digitRuns.FindAllString(a, -1), digitRuns.FindAllString(b, -1)
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		if cmp := compareDigits(as[i], bs[i]); cmp != 0 {
+			return cmp
+		}
+	}
+	switch {
+	case len(as) < len(bs):
+		return -1
+	case len(as) > len(bs):
+		return 1
+	}
+	return 0
+}
+
+// compareDigits compares two non-negative decimal strings of any length numerically.
+func compareDigits(a, b string) int {
+	a, b = strings.TrimLeft(a, "0"), strings.TrimLeft(b, "0")
+	if len(a) != len(b) {
+		if len(a) < len(b) {
+			return -1
+		}
+		return 1
+	}
+	return strings.Compare(a, b)
+}
@@ -0,0 +1,380 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	werfv1alpha1 "github.com/werf/k8s-werf-operator-go/api/v1alpha1"
+)
+
+func TestTagPolicy_Select(t *testing.T) {
+	tests := []struct {
+		name        string
+		cfg         werfv1alpha1.RegistryConfig
+		tags        []string
+		wantTag     string
+		wantVersion string
+		wantReason  string
+	}{
+		{
+			name:        "no filter orders by semver",
+			tags:        []string{"latest", "v1.10.0", "v1.9.0"},
+			wantTag:     "v1.10.0",
+			wantVersion: "1.10.0",
+		},
+		{
+			name:       "no filter selects nothing without semver tags",
+			tags:       []string{"build-1", "build-2"},
+			wantReason: "no eligible semver versions (ignored 2 non-semver tags)",
+		},
+		{
+			name: "numerical with extract",
+			cfg: werfv1alpha1.RegistryConfig{TagFilter: &werfv1alpha1.TagFilter{
+				Include:    `^build-(?P<num>[0-9]+)$`,
+				Extract:    "num",
+				SortPolicy: werfv1alpha1.SortPolicyNumerical,
+			}},
+			tags:        []string{"build-99", "build-512", "build-1000", "build-abc", "latest"},
+			wantTag:     "build-1000",
+			wantVersion: "1000",
+			wantReason:  `3 of 5 tags match tagFilter; highest numerical value "1000"`,
+		},
+		{
+			name: "numerical compares decimals exactly",
+			cfg: werfv1alpha1.RegistryConfig{TagFilter: &werfv1alpha1.TagFilter{
+				SortPolicy: werfv1alpha1.SortPolicyNumerical,
+			}},
+			tags:        []string{"1.45", "1.5", "1.499999999999999999", "12345678901234567891", "12345678901234567890"},
+			wantTag:     "12345678901234567891",
+			wantVersion: "12345678901234567891",
+			wantReason:  "",
+		},
+		{
+			name: "numerical ignores values that are not numbers",
+			cfg: werfv1alpha1.RegistryConfig{TagFilter: &werfv1alpha1.TagFilter{
+				SortPolicy: werfv1alpha1.SortPolicyNumerical,
+			}},
+			tags:       []string{"1.45", "1.5", "latest", "0x10", "1e9"},
+			wantTag:    "1.5",
+			wantReason: "ignored 3 tags without a numerical value",
+		},
+		{
+			name: "calver timestamp with extract",
+			cfg: werfv1alpha1.RegistryConfig{TagFilter: &werfv1alpha1.TagFilter{
+				Include:    `^main-(?P<ts>[0-9]{8}\.[0-9]+)-[a-f0-9]+$`,
+				Extract:    "ts",
+				SortPolicy: werfv1alpha1.SortPolicyCalver,
+			}},
+			tags: []string{
+				"main-20250101.1234-abcdef",
+				"main-20250102.0001-123456",
+				"main-20241231.9999-fedcba",
+				"feature-20250103.0001-aaaaaa",
+			},
+			wantTag:     "main-20250102.0001-123456",
+			wantVersion: "20250102.0001",
+		},
+		{
+			name: "calver compares segments numerically",
+			cfg: werfv1alpha1.RegistryConfig{TagFilter: &werfv1alpha1.TagFilter{
+				SortPolicy: werfv1alpha1.SortPolicyCalver,
+			}},
+			tags:        []string{"2025.1.9", "2025.1.10", "2025.1", "2024.12.31"},
+			wantTag:     "2025.1.10",
+			wantVersion: "2025.1.10",
+		},
+		{
+			name: "lexical with include and exclude",
+			cfg: werfv1alpha1.RegistryConfig{TagFilter: &werfv1alpha1.TagFilter{
+				Include:    `^release-`,
+				Exclude:    `-rc$`,
+				SortPolicy: werfv1alpha1.SortPolicyLexical,
+			}},
+			tags:        []string{"release-b", "release-c-rc", "release-a", "zzz"},
+			wantTag:     "release-b",
+			wantVersion: "release-b",
+		},
+		{
+			name: "semver with extract and constraint",
+			cfg: werfv1alpha1.RegistryConfig{
+				VersionConstraint: "<2.0.0",
+				TagFilter: &werfv1alpha1.TagFilter{
+					Include: `^app-(?P<version>.+)$`,
+					Extract: "version",
+				},
+			},
+			tags:        []string{"app-1.2.0", "app-1.10.0", "app-2.0.0", "other-1.99.0"},
+			wantTag:     "app-1.10.0",
+			wantVersion: "1.10.0",
+			wantReason:  `3 of 4 tags match tagFilter; highest semver version 1.10.0 satisfying "<2.0.0"`,
+		},
+		{
+			name: "semver filter has no lexicographic fallback",
+			cfg: werfv1alpha1.RegistryConfig{TagFilter: &werfv1alpha1.TagFilter{
+				Exclude: `^latest$`,
+			}},
+			tags:       []string{"latest", "main", "dev"},
+			wantReason: "2 of 3 tags match tagFilter; no eligible semver versions",
+		},
+		{
+			name: "exclude only",
+			cfg: werfv1alpha1.RegistryConfig{TagFilter: &werfv1alpha1.TagFilter{
+				Exclude: `-debug$`,
+			}},
+			tags:        []string{"v1.0.0", "v1.1.0-debug", "v1.1.0"},
+			wantTag:     "v1.1.0",
+			wantVersion: "1.1.0",
+		},
+		{
+			name: "no tags match filter",
+			cfg: werfv1alpha1.RegistryConfig{TagFilter: &werfv1alpha1.TagFilter{
+				Include: `^release-`,
+			}},
+			tags:       []string{"v1.0.0", "main"},
+			wantReason: "none of 2 tags match tagFilter",
+		},
+		{
+			name: "optional extract group that did not match is skipped",
+			cfg: werfv1alpha1.RegistryConfig{TagFilter: &werfv1alpha1.TagFilter{
+				Include:    `^build(-(?P<num>[0-9]+))?$`,
+				Extract:    "num",
+				SortPolicy: werfv1alpha1.SortPolicyNumerical,
+			}},
+			tags:        []string{"build", "build-7"},
+			wantTag:     "build-7",
+			wantVersion: "7",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy, err := NewTagPolicy(&tt.cfg)
+			if err != nil {
+				t.Fatalf("NewTagPolicy() error = %v", err)
+			}
+			got, err := policy.Select(tt.tags)
+			if err != nil {
+				t.Fatalf("Select() error = %v", err)
+			}
+			if got.Tag != tt.wantTag {
+				t.Errorf("Tag = %q, want %q (reason: %s)", got.Tag, tt.wantTag, got.Reason)
+			}
+			if tt.wantVersion != "" && got.Version != tt.wantVersion {
+				t.Errorf("Version = %q, want %q", got.Version, tt.wantVersion)
+			}
+			if !strings.Contains(got.Reason, tt.wantReason) {
+				t.Errorf("Reason = %q, want it to contain %q", got.Reason, tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestNewTagPolicy_InvalidConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     werfv1alpha1.RegistryConfig
+		wantErr string
+	}{
+		{
+			name:    "invalid include",
+			cfg:     werfv1alpha1.RegistryConfig{TagFilter: &werfv1alpha1.TagFilter{Include: `(`}},
+			wantErr: "invalid tagFilter.include",
+		},
+		{
+			name:    "invalid exclude",
+			cfg:     werfv1alpha1.RegistryConfig{TagFilter: &werfv1alpha1.TagFilter{Exclude: `[`}},
+			wantErr: "invalid tagFilter.exclude",
+		},
+		{
+			name:    "extract without include",
+			cfg:     werfv1alpha1.RegistryConfig{TagFilter: &werfv1alpha1.TagFilter{Extract: "ts"}},
+			wantErr: "extract requires tagFilter.include",
+		},
+		{
+			name: "extract group missing from include",
+			cfg: werfv1alpha1.RegistryConfig{TagFilter: &werfv1alpha1.TagFilter{
+				Include: `^build-(?P<num>[0-9]+)$`,
+				Extract: "ts",
+			}},
+			wantErr: `no capture group named "ts"`,
+		},
+		{
+			name:    "unknown sort policy",
+			cfg:     werfv1alpha1.RegistryConfig{TagFilter: &werfv1alpha1.TagFilter{SortPolicy: "random"}},
+			wantErr: `invalid tagFilter.sortPolicy "random"`,
+		},
+		{
+			name: "version constraint with non-semver sort policy",
+			cfg: werfv1alpha1.RegistryConfig{
+				VersionConstraint: ">=1.0.0",
+				TagFilter:         &werfv1alpha1.TagFilter{SortPolicy: werfv1alpha1.SortPolicyNumerical},
+			},
+			wantErr: "versionConstraint requires tagFilter.sortPolicy semver",
+		},
+		{
+			name: "version constraint with metadata selection",
+			cfg: werfv1alpha1.RegistryConfig{
+				VersionConstraint: ">=1.0.0",
+				MetadataSelection: &werfv1alpha1.MetadataSelection{},
+			},
+			wantErr: "versionConstraint can't be used with metadataSelection",
+		},
+		{
+			name:    "invalid version constraint",
+			cfg:     werfv1alpha1.RegistryConfig{VersionConstraint: ">=banana"},
+			wantErr: "invalid version constraint",
+		},
+		{
+			name: "incremental tag list with the default semver order",
+			cfg:  werfv1alpha1.RegistryConfig{IncrementalTagList: true},
+			wantErr: "incrementalTagList requires tagFilter.sortPolicy lexical of whole tags, " +
+				"as registries list tags in lexical order; got sortPolicy semver",
+		},
+		{
+			name: "incremental tag list with an extracted value",
+			cfg: werfv1alpha1.RegistryConfig{IncrementalTagList: true, TagFilter: &werfv1alpha1.TagFilter{
+				Include:    `^(?P<ts>[0-9]+)-[a-z]+$`,
+				Extract:    "ts",
+				SortPolicy: werfv1alpha1.SortPolicyLexical,
+			}},
+			wantErr: "got tagFilter.extract",
+		},
+		{
+			name: "incremental tag list with metadata selection",
+			cfg: werfv1alpha1.RegistryConfig{
+				IncrementalTagList: true,
+				TagFilter:          &werfv1alpha1.TagFilter{SortPolicy: werfv1alpha1.SortPolicyLexical},
+				MetadataSelection:  &werfv1alpha1.MetadataSelection{},
+			},
+			wantErr: "got metadataSelection",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewTagPolicy(&tt.cfg)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("NewTagPolicy() error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestTagPolicy_SelectByMetadata(t *testing.T) {
+	images := map[string]map[string]string{
+		"main-a":   {AnnotationCreated: "2025-03-01T10:00:00Z", "env": "prod"},
+		"main-b":   {AnnotationCreated: "2025-03-01T12:00:00+01:00", "env": "prod"}, // 11:00 UTC
+		"main-c":   {AnnotationCreated: "2025-03-02T09:00:00Z", "env": "staging"},
+		"main-d":   {"env": "prod"},
+		"build-9":  {"build": "9"},
+		"build-10": {"build": "10"},
+	}
+	lookup := func(_ context.Context, tag string) (*ImageMetadata, error) {
+		annotations, ok := images[tag]
+		if !ok {
+			return nil, &NotFoundError{Err: fmt.Errorf("tag %s deleted", tag)}
+		}
+		return &ImageMetadata{Annotations: annotations}, nil
+	}
+
+	tests := []struct {
+		name       string
+		cfg        werfv1alpha1.RegistryConfig
+		tags       []string
+		wantTag    string
+		wantReason string
+	}{
+		{
+			name:       "orders by creation time",
+			cfg:        werfv1alpha1.RegistryConfig{MetadataSelection: &werfv1alpha1.MetadataSelection{}},
+			tags:       []string{"main-a", "main-b", "main-c"},
+			wantTag:    "main-c",
+			wantReason: `highest org.opencontainers.image.created "2025-03-02T09:00:00Z"`,
+		},
+		{
+			name: "filters by annotation",
+			cfg: werfv1alpha1.RegistryConfig{MetadataSelection: &werfv1alpha1.MetadataSelection{
+				MatchAnnotations: map[string]string{"env": "prod"},
+			}},
+			tags:       []string{"main-a", "main-b", "main-c", "main-d"},
+			wantTag:    "main-b",
+			wantReason: "ignored 1 not matching matchAnnotations, 1 without org.opencontainers.image.created",
+		},
+		{
+			name: "orders by a named annotation",
+			cfg: werfv1alpha1.RegistryConfig{MetadataSelection: &werfv1alpha1.MetadataSelection{
+				OrderBy: "build",
+			}},
+			tags:    []string{"build-9", "build-10", "main-a"},
+			wantTag: "build-10",
+		},
+		{
+			name: "narrowed by tag filter, deleted tags skipped",
+			cfg: werfv1alpha1.RegistryConfig{
+				TagFilter:         &werfv1alpha1.TagFilter{Include: `^main-`},
+				MetadataSelection: &werfv1alpha1.MetadataSelection{},
+			},
+			tags:       []string{"main-a", "main-gone", "build-9"},
+			wantTag:    "main-a",
+			wantReason: "2 of 3 tags match tagFilter",
+		},
+		{
+			name:       "no image has the annotation",
+			cfg:        werfv1alpha1.RegistryConfig{MetadataSelection: &werfv1alpha1.MetadataSelection{OrderBy: "missing"}},
+			tags:       []string{"main-a"},
+			wantReason: "no image with a missing value",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy, err := NewTagPolicy(&tt.cfg)
+			if err != nil {
+				t.Fatalf("NewTagPolicy() error = %v", err)
+			}
+			if !policy.UsesMetadata() {
+				t.Fatal("expected the policy to use metadata")
+			}
+			got, err := policy.SelectByMetadata(context.Background(), tt.tags, lookup)
+			if err != nil {
+				t.Fatalf("SelectByMetadata() error = %v", err)
+			}
+			if got.Tag != tt.wantTag {
+				t.Errorf("Tag = %q, want %q (reason: %s)", got.Tag, tt.wantTag, got.Reason)
+			}
+			if !strings.Contains(got.Reason, tt.wantReason) {
+				t.Errorf("Reason = %q, want it to contain %q", got.Reason, tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestTagPolicy_SelectByMetadata_Errors(t *testing.T) {
+	policy, err := NewTagPolicy(&werfv1alpha1.RegistryConfig{MetadataSelection: &werfv1alpha1.MetadataSelection{}})
+	if err != nil {
+		t.Fatalf("NewTagPolicy() error = %v", err)
+	}
+
+	// Registry errors are returned for the caller to retry
+	failing := func(context.Context, string) (*ImageMetadata, error) {
+		return nil, &NetworkError{Err: errors.New("connection refused")}
+	}
+	_, err = policy.SelectByMetadata(context.Background(), []string{"v1"}, failing)
+	var networkErr *NetworkError
+	if !errors.As(err, &networkErr) {
+		t.Errorf("expected NetworkError, got %v", err)
+	}
+
+	// Too many candidates: no registry requests, nothing selected
+	tags := make([]string, maxMetadataCandidates+1)
+	for i := range tags {
+		tags[i] = fmt.Sprintf("build-%d", i)
+	}
+	got, err := policy.SelectByMetadata(context.Background(), tags, failing)
+	if err != nil || got.Tag != "" || !strings.Contains(got.Reason, "narrow them with tagFilter") {
+		t.Errorf("expected no selection for too many candidates, got %+v, %v", got, err)
+	}
+}
@@ -0,0 +1,149 @@
+// TLS settings for registries using private CAs, client certificates or plain HTTP.
+package registry
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/google/go-containerregistry/pkg/name"
+)
+
+// DefaultCABundleKey is the data key of the CA certificates in a ConfigMap or Secret when
+// spec.registry.tls.caBundle.key isn't set.
+const DefaultCABundleKey = "ca.crt"
+
+// TLSError indicates the registry certificate could not be verified, or the configured
+// certificates are invalid. Retrying won't help until the certificates change.
+type TLSError struct {
+	Err error
+}
+
+func (e *TLSError) Error() string {
+	return fmt.Sprintf("TLS error: %v", e.Err)
+}
+
+func (e *TLSError) Unwrap() error {
+	return e.Err
+}
+
+// TLSConfig configures connections to a registry. The zero value uses the system roots.
+type TLSConfig struct {
+	// CACerts holds PEM-encoded CA certificates trusted in addition to the system roots.
+	CACerts []byte
+
+	// ClientCert and ClientKey are the PEM-encoded client certificate and key presented to
+	// registries requiring mutual TLS.
+	ClientCert []byte
+	ClientKey  []byte
+
+	// Insecure allows plain HTTP and skips verification of the registry certificate.
+	Insecure bool
+}
+
+// tlsKey is the context key for WithTLS.
+type tlsKey struct{}
+
+// WithTLS returns a context whose registry operations connect with cfg.
+func WithTLS(ctx context.Context, cfg *TLSConfig) context.Context {
+	return context.WithValue(ctx, tlsKey{}, cfg)
+}
+
+// tlsFrom returns the configuration set by WithTLS, or nil for the defaults.
+func tlsFrom(ctx context.Context) *TLSConfig {
+	cfg, _ := ctx.Value(tlsKey{}).(*TLSConfig)
+	return cfg
+}
+
+// Validate checks that the certificates and key can be parsed.
+// Returns a TLSError describing the first problem found.
+func (cfg *TLSConfig) Validate() error {
+	_, err := cfg.clientConfig()
+	return err
+}
+
+// key identifies the settings of cfg; "" for the defaults.
+func (cfg *TLSConfig) key() string {
+	if cfg == nil || (len(cfg.CACerts) == 0 && len(cfg.ClientCert) == 0 && !cfg.Insecure) {
+		return ""
+	}
+	h := sha256.New()
+	for _, data := range [][]byte{cfg.CACerts, cfg.ClientCert, cfg.ClientKey} {
+		_, _ = fmt.Fprintf(h, "%d:", len(data))
+		h.Write(data)
+	}
+	if cfg.Insecure {
+		h.Write([]byte("insecure"))
+	}
+	return hex.EncodeToString(h.Sum(nil)[:16])
+}
+
+// nameOptions returns the options for parsing references under cfg. Insecure registries
+// may be reached over plain HTTP.
+func (cfg *TLSConfig) nameOptions() []name.Option {
+	if cfg != nil && cfg.Insecure {
+		return []name.Option{name.Insecure}
+	}
+	return nil
+}
+
+// clientConfig builds the crypto/tls configuration of cfg; nil for the defaults.
+func (cfg *TLSConfig) clientConfig() (*tls.Config, error) {
+	if cfg.key() == "" {
+		return nil, nil
+	}
+
+	// nolint:gosec // skipping verification is explicitly requested by spec.registry.tls.insecure
+	config := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: cfg.Insecure,
+	}
+	if len(cfg.CACerts) > 0 {
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(cfg.CACerts) {
+			return nil, &TLSError{Err: errors.New("CA bundle contains no PEM-encoded certificates")}
+		}
+		config.RootCAs = pool
+	}
+	if len(cfg.ClientCert) > 0 || len(cfg.ClientKey) > 0 {
+		cert, err := tls.X509KeyPair(cfg.ClientCert, cfg.ClientKey)
+		if err != nil {
+			return nil, &TLSError{Err: fmt.Errorf("invalid client certificate: %w", err)}
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+	return config, nil
+}
+
+// newBaseTransport creates the HTTP transport to a registry host under cfg, connecting
+// through proxy.
+func newBaseTransport(cfg *TLSConfig, proxy *ProxyConfig) (*http.Transport, error) {
+	base := http.DefaultTransport.(*http.Transport).Clone()
+	base.Proxy = proxy.proxyFunc()
+	config, err := cfg.clientConfig()
+	if err != nil {
+		return nil, err
+	}
+	if config != nil {
+		base.TLSClientConfig = config
+	}
+	return base, nil
+}
+
+// isCertificateError reports whether err is a failure to verify the registry certificate.
+func isCertificateError(err error) bool {
+	var verifyErr *tls.CertificateVerificationError
+	var unknownAuthority x509.UnknownAuthorityError
+	var invalid x509.CertificateInvalidError
+	var hostname x509.HostnameError
+	return errors.As(err, &verifyErr) || errors.As(err, &unknownAuthority) ||
+		errors.As(err, &invalid) || errors.As(err, &hostname)
+}
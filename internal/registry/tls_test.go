@@ -0,0 +1,153 @@
+package registry
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"io"
+	"log"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	ggcrregistry "github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+
+	testingutil "github.com/werf/k8s-werf-operator-go/internal/testing"
+)
+
+// startTLSRegistry serves an in-process registry over TLS with a certificate issued by ca,
+// requiring client certificates issued by ca, and pushes an image tagged v1.0.0 to
+// test/tls. Returns the repository URL.
+func startTLSRegistry(t *testing.T, ca *testingutil.CertificateAuthority) string {
+	t.Helper()
+
+	handler := ggcrregistry.New(ggcrregistry.Logger(log.New(io.Discard, "", 0)))
+
+	// The same registry over plain HTTP, to push the test image
+	plain := httptest.NewServer(handler)
+	t.Cleanup(plain.Close)
+	ref, err := name.ParseReference(strings.TrimPrefix(plain.URL, "http://") + "/test/tls:v1.0.0")
+	if err != nil {
+		t.Fatalf("failed to parse reference: %v", err)
+	}
+	img, err := random.Image(256, 1)
+	if err != nil {
+		t.Fatalf("failed to create random image: %v", err)
+	}
+	if err := remote.Write(ref, img); err != nil {
+		t.Fatalf("failed to push image: %v", err)
+	}
+
+	server := httptest.NewUnstartedServer(handler)
+	server.TLS, err = ca.ServerTLSConfig(true)
+	if err != nil {
+		t.Fatalf("failed to create server TLS config: %v", err)
+	}
+	server.StartTLS()
+	t.Cleanup(server.Close)
+	return strings.TrimPrefix(server.URL, "https://") + "/test/tls"
+}
+
+func TestOCIClient_TLS(t *testing.T) {
+	ca, err := testingutil.NewCertificateAuthority()
+	if err != nil {
+		t.Fatalf("failed to create CA: %v", err)
+	}
+	clientCert, clientKey, err := ca.Issue("werf-operator", false)
+	if err != nil {
+		t.Fatalf("failed to issue client certificate: %v", err)
+	}
+	otherCA, err := testingutil.NewCertificateAuthority()
+	if err != nil {
+		t.Fatalf("failed to create CA: %v", err)
+	}
+	repoURL := startTLSRegistry(t, ca)
+
+	tests := []struct {
+		name    string
+		cfg     *TLSConfig
+		wantErr bool
+	}{
+		{name: "system roots only", cfg: nil, wantErr: true},
+		{name: "other CA", cfg: &TLSConfig{CACerts: otherCA.CertPEM, ClientCert: clientCert, ClientKey: clientKey}, wantErr: true},
+		{name: "no client certificate", cfg: &TLSConfig{CACerts: ca.CertPEM}, wantErr: true},
+		{name: "CA and client certificate", cfg: &TLSConfig{CACerts: ca.CertPEM, ClientCert: clientCert, ClientKey: clientKey}},
+		{name: "insecure", cfg: &TLSConfig{Insecure: true, ClientCert: clientCert, ClientKey: clientKey}},
+	}
+
+	// One client for all cases: each TLS configuration must get its own connections
+	client := NewOCIClient()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := WithTLS(context.Background(), tt.cfg)
+			tags, err := client.ListTags(ctx, repoURL, nil)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("expected an error, got tags %v", tags)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ListTags() error = %v", err)
+			}
+			if len(tags) != 1 || tags[0] != "v1.0.0" {
+				t.Errorf("ListTags() = %v, want [v1.0.0]", tags)
+			}
+			if _, err := client.ResolveDigest(ctx, repoURL, "v1.0.0", nil); err != nil {
+				t.Errorf("ResolveDigest() error = %v", err)
+			}
+		})
+	}
+}
+
+func TestTLSConfig_Validate(t *testing.T) {
+	ca, err := testingutil.NewCertificateAuthority()
+	if err != nil {
+		t.Fatalf("failed to create CA: %v", err)
+	}
+	cert, key, err := ca.Issue("werf-operator", false)
+	if err != nil {
+		t.Fatalf("failed to issue certificate: %v", err)
+	}
+	_, otherKey, err := ca.Issue("other", false)
+	if err != nil {
+		t.Fatalf("failed to issue certificate: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		cfg     *TLSConfig
+		wantErr bool
+	}{
+		{name: "nil", cfg: nil},
+		{name: "CA and client certificate", cfg: &TLSConfig{CACerts: ca.CertPEM, ClientCert: cert, ClientKey: key}},
+		{name: "insecure", cfg: &TLSConfig{Insecure: true}},
+		{name: "CA bundle without certificates", cfg: &TLSConfig{CACerts: []byte("not a certificate")}, wantErr: true},
+		{name: "client certificate without key", cfg: &TLSConfig{ClientCert: cert}, wantErr: true},
+		{name: "key of another certificate", cfg: &TLSConfig{ClientCert: cert, ClientKey: otherKey}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.cfg.Validate()
+			var tlsErr *TLSError
+			if tt.wantErr && !errors.As(err, &tlsErr) {
+				t.Errorf("expected TLSError, got %v", err)
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("Validate() error = %v", err)
+			}
+		})
+	}
+}
+
+func TestClassifyError_Certificate(t *testing.T) {
+	err := &url.Error{Op: "Get", URL: "https://registry.example.com/v2/", Err: x509.UnknownAuthorityError{}}
+	var tlsErr *TLSError
+	if !errors.As(classifyError(err), &tlsErr) {
+		t.Errorf("expected TLSError for an untrusted certificate, got %v", classifyError(err))
+	}
+}
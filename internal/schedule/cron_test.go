@@ -0,0 +1,97 @@
+package schedule
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseCron_Errors(t *testing.T) {
+	tests := []struct {
+		expr    string
+		wantErr string
+	}{
+		{expr: "0 22 * *", wantErr: "expected 5 fields"},
+		{expr: "60 22 * * *", wantErr: `minute: "60" is not a value from 0 to 59`},
+		{expr: "0 22 0 * *", wantErr: "day of month"},
+		{expr: "0 22 * 13 *", wantErr: "month"},
+		{expr: "0 22 * * 8", wantErr: "day of week"},
+		{expr: "0 22 * * FRI-MON", wantErr: "ends before it starts"},
+		{expr: "*/0 * * * *", wantErr: "invalid step"},
+		{expr: "0 x * * *", wantErr: "hour"},
+	}
+	for _, tt := range tests {
+		_, err := ParseCron(tt.expr)
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("ParseCron(%q) error = %v, want one containing %q", tt.expr, err, tt.wantErr)
+		}
+	}
+}
+
+func TestCron_Next(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatalf("failed to load time zone: %v", err)
+	}
+	// A Wednesday
+	after := time.Date(2026, 3, 4, 10, 30, 15, 0, time.UTC)
+
+	tests := []struct {
+		expr string
+		loc  *time.Location
+		want time.Time
+	}{
+		{expr: "* * * * *", loc: time.UTC, want: time.Date(2026, 3, 4, 10, 31, 0, 0, time.UTC)},
+		{expr: "0 22 * * *", loc: time.UTC, want: time.Date(2026, 3, 4, 22, 0, 0, 0, time.UTC)},
+		{expr: "30 10 * * *", loc: time.UTC, want: time.Date(2026, 3, 5, 10, 30, 0, 0, time.UTC)},
+		{expr: "*/20 * * * *", loc: time.UTC, want: time.Date(2026, 3, 4, 10, 40, 0, 0, time.UTC)},
+		{expr: "0 9-17/4 * * *", loc: time.UTC, want: time.Date(2026, 3, 4, 13, 0, 0, 0, time.UTC)},
+		{expr: "0 6 * * SAT,SUN", loc: time.UTC, want: time.Date(2026, 3, 7, 6, 0, 0, 0, time.UTC)},
+		{expr: "0 6 * * 7", loc: time.UTC, want: time.Date(2026, 3, 8, 6, 0, 0, 0, time.UTC)},
+		{expr: "0 0 1 * *", loc: time.UTC, want: time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)},
+		{expr: "0 0 1 jan *", loc: time.UTC, want: time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)},
+		// Both day fields restricted: either matches (the 10th, or the next Friday)
+		{expr: "0 0 10 * FRI", loc: time.UTC, want: time.Date(2026, 3, 6, 0, 0, 0, 0, time.UTC)},
+		// 22:00 in Berlin is 21:00 UTC in winter
+		{expr: "0 22 * * *", loc: berlin, want: time.Date(2026, 3, 4, 21, 0, 0, 0, time.UTC)},
+		{expr: "0 0 30 2 *", loc: time.UTC, want: time.Time{}},
+	}
+	for _, tt := range tests {
+		c, err := ParseCron(tt.expr)
+		if err != nil {
+			t.Fatalf("ParseCron(%q) error = %v", tt.expr, err)
+		}
+		if got := c.Next(after, tt.loc); !got.Equal(tt.want) {
+			t.Errorf("Next(%q) = %v, want %v", tt.expr, got, tt.want)
+		}
+	}
+}
+
+func TestCron_Next_DaylightSaving(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatalf("failed to load time zone: %v", err)
+	}
+	c, err := ParseCron("30 2 * * *")
+	if err != nil {
+		t.Fatalf("ParseCron() error = %v", err)
+	}
+
+	// 02:30 doesn't exist on 2026-03-29 in Berlin: the next match is the day after
+	after := time.Date(2026, 3, 28, 12, 0, 0, 0, berlin)
+	want := time.Date(2026, 3, 30, 2, 30, 0, 0, berlin)
+	if got := c.Next(after, berlin); !got.Equal(want) {
+		t.Errorf("Next() = %v, want %v", got, want)
+	}
+
+	// 02:30 happens twice on 2026-10-25: it matches once, in standard time
+	after = time.Date(2026, 10, 24, 12, 0, 0, 0, berlin)
+	want = time.Date(2026, 10, 25, 1, 30, 0, 0, time.UTC)
+	got := c.Next(after, berlin)
+	if !got.Equal(want) {
+		t.Errorf("Next() = %v, want %v", got, want)
+	}
+	if next := c.Next(got, berlin); !next.Equal(time.Date(2026, 10, 26, 2, 30, 0, 0, berlin)) {
+		t.Errorf("Next() = %v, expected the match on the following day", next)
+	}
+}
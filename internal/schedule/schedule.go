@@ -0,0 +1,168 @@
+// Package schedule decides when converge Jobs may start, from the deployment windows and
+// change freezes of a WerfBundle's spec.schedule.
+package schedule
+
+import (
+	"fmt"
+	"time"
+
+	// Embed the time zone database: window time zones must resolve in images without one
+	_ "time/tzdata"
+
+	werfv1alpha1 "github.com/werf/k8s-werf-operator-go/api/v1alpha1"
+)
+
+// maxSteps bounds the search for the next allowed time when windows and freezes keep
+// excluding each other.
+const maxSteps = 10000
+
+// Schedule is a parsed spec.schedule.
+type Schedule struct {
+	windows []window
+	freezes []Freeze
+}
+
+// window is a deployment window opening at each match of start, in loc, for duration.
+type window struct {
+	start    *Cron
+	duration time.Duration
+	loc      *time.Location
+}
+
+// Freeze is a period in which no converge starts.
+type Freeze struct {
+	Start, End time.Time
+	Reason     string
+}
+
+// Decision is the outcome of Check.
+type Decision struct {
+	// Allowed is true if a converge may start now.
+	Allowed bool
+
+	// Freeze is the change freeze holding the converge, or nil if it's allowed or only held
+	// for being outside the windows.
+	Freeze *Freeze
+
+	// Next is when a converge may start: now if Allowed, otherwise the start of the next
+	// window outside freezes. Zero if no window opens within five years.
+	Next time.Time
+}
+
+// New parses spec. Returns an error for an invalid cron expression, time zone, window
+// duration or freeze period.
+func New(spec *werfv1alpha1.ScheduleConfig) (*Schedule, error) {
+	s := &Schedule{}
+	if spec == nil {
+		return s, nil
+	}
+
+	defaultLoc, err := loadLocation(spec.TimeZone)
+	if err != nil {
+		return nil, err
+	}
+	for i, w := range spec.Windows {
+		start, err := ParseCron(w.Start)
+		if err != nil {
+			return nil, fmt.Errorf("window %d: %w", i, err)
+		}
+		if w.Duration.Duration <= 0 {
+			return nil, fmt.Errorf("window %d: duration must be positive, got %s", i, w.Duration.Duration)
+		}
+		loc := defaultLoc
+		if w.TimeZone != "" {
+			if loc, err = loadLocation(w.TimeZone); err != nil {
+				return nil, fmt.Errorf("window %d: %w", i, err)
+			}
+		}
+		s.windows = append(s.windows, window{start: start, duration: w.Duration.Duration, loc: loc})
+	}
+	for i, f := range spec.Freezes {
+		if !f.End.After(f.Start.Time) {
+			return nil, fmt.Errorf("freeze %d: end %s is not after start %s",
+				i, f.End.Format(time.RFC3339), f.Start.Format(time.RFC3339))
+		}
+		s.freezes = append(s.freezes, Freeze{Start: f.Start.Time, End: f.End.Time, Reason: f.Reason})
+	}
+	return s, nil
+}
+
+// loadLocation loads the IANA time zone name, UTC if empty.
+func loadLocation(name string) (*time.Location, error) {
+	if name == "" {
+		return time.UTC, nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("unknown time zone %q", name)
+	}
+	return loc, nil
+}
+
+// Check decides whether a converge may start at now, and if not, when it may.
+func (s *Schedule) Check(now time.Time) Decision {
+	freeze := s.freezeAt(now)
+	if freeze == nil && s.inWindow(now) {
+		return Decision{Allowed: true, Next: now}
+	}
+	return Decision{Freeze: freeze, Next: s.nextAllowed(now)}
+}
+
+// nextAllowed returns the first time from t that is inside a window and outside freezes,
+// or zero if there is none within five years.
+func (s *Schedule) nextAllowed(t time.Time) time.Time {
+	limit := t.Add(cronSearchLimit)
+	for range maxSteps {
+		if !t.Before(limit) {
+			break
+		}
+		if freeze := s.freezeAt(t); freeze != nil {
+			t = freeze.End
+			continue
+		}
+		if s.inWindow(t) {
+			return t
+		}
+		if t = s.nextWindowStart(t); t.IsZero() {
+			break
+		}
+	}
+	return time.Time{}
+}
+
+// freezeAt returns the freeze t is in, or nil.
+func (s *Schedule) freezeAt(t time.Time) *Freeze {
+	for i := range s.freezes {
+		if !t.Before(s.freezes[i].Start) && t.Before(s.freezes[i].End) {
+			return &s.freezes[i]
+		}
+	}
+	return nil
+}
+
+// inWindow reports whether a window is open at t. Without windows, any time is.
+func (s *Schedule) inWindow(t time.Time) bool {
+	if len(s.windows) == 0 {
+		return true
+	}
+	for _, w := range s.windows {
+		// The window is open if it started after t-duration, and not after t
+		start := w.start.Next(t.Add(-w.duration), w.loc)
+		if !start.IsZero() && !start.After(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// nextWindowStart returns the earliest window start after t, or zero if none.
+func (s *Schedule) nextWindowStart(t time.Time) time.Time {
+	var next time.Time
+	for _, w := range s.windows {
+		start := w.start.Next(t, w.loc)
+		if !start.IsZero() && (next.IsZero() || start.Before(next)) {
+			next = start
+		}
+	}
+	return next
+}
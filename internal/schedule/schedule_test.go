@@ -0,0 +1,181 @@
+package schedule
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	werfv1alpha1 "github.com/werf/k8s-werf-operator-go/api/v1alpha1"
+)
+
+func TestSchedule_Check(t *testing.T) {
+	at := func(day, hour, minute int) time.Time {
+		// March 2026: the 2nd is a Monday
+		return time.Date(2026, 3, day, hour, minute, 0, 0, time.UTC)
+	}
+	freeze := func(start, end time.Time, reason string) werfv1alpha1.FreezePeriod {
+		return werfv1alpha1.FreezePeriod{Start: metav1.NewTime(start), End: metav1.NewTime(end), Reason: reason}
+	}
+	weeknights := []werfv1alpha1.DeploymentWindow{
+		{Start: "0 22 * * MON-FRI", Duration: metav1.Duration{Duration: 2 * time.Hour}},
+	}
+
+	tests := []struct {
+		name       string
+		spec       *werfv1alpha1.ScheduleConfig
+		now        time.Time
+		wantAllow  bool
+		wantNext   time.Time
+		wantFrozen bool
+		wantFreeze string
+	}{
+		{
+			name:      "no schedule",
+			now:       at(4, 10, 0),
+			wantAllow: true,
+			wantNext:  at(4, 10, 0),
+		},
+		{
+			name:      "inside a window",
+			spec:      &werfv1alpha1.ScheduleConfig{Windows: weeknights},
+			now:       at(4, 23, 30),
+			wantAllow: true,
+			wantNext:  at(4, 23, 30),
+		},
+		{
+			name:     "before a window",
+			spec:     &werfv1alpha1.ScheduleConfig{Windows: weeknights},
+			now:      at(4, 10, 0),
+			wantNext: at(4, 22, 0),
+		},
+		{
+			name:     "window closed at its end",
+			spec:     &werfv1alpha1.ScheduleConfig{Windows: weeknights},
+			now:      at(5, 0, 0),
+			wantNext: at(5, 22, 0),
+		},
+		{
+			name:     "weekend",
+			spec:     &werfv1alpha1.ScheduleConfig{Windows: weeknights},
+			now:      at(7, 12, 0),
+			wantNext: at(9, 22, 0),
+		},
+		{
+			name: "schedule time zone",
+			spec: &werfv1alpha1.ScheduleConfig{TimeZone: "America/New_York", Windows: weeknights},
+			// 22:00 in New York is 03:00 UTC the next day
+			now:      at(4, 10, 0),
+			wantNext: at(5, 3, 0),
+		},
+		{
+			name: "window time zone overrides the schedule's",
+			spec: &werfv1alpha1.ScheduleConfig{TimeZone: "America/New_York", Windows: []werfv1alpha1.DeploymentWindow{
+				{Start: "0 22 * * MON-FRI", Duration: metav1.Duration{Duration: time.Hour}, TimeZone: "Asia/Tokyo"},
+			}},
+			// 22:00 in Tokyo is 13:00 UTC
+			now:       at(4, 13, 30),
+			wantAllow: true,
+			wantNext:  at(4, 13, 30),
+		},
+		{
+			name: "freeze without windows",
+			spec: &werfv1alpha1.ScheduleConfig{Freezes: []werfv1alpha1.FreezePeriod{
+				freeze(at(4, 0, 0), at(6, 0, 0), "release week"),
+			}},
+			now:        at(4, 10, 0),
+			wantNext:   at(6, 0, 0),
+			wantFrozen: true,
+			wantFreeze: "release week",
+		},
+		{
+			name: "freeze ending inside a window",
+			spec: &werfv1alpha1.ScheduleConfig{Windows: weeknights, Freezes: []werfv1alpha1.FreezePeriod{
+				freeze(at(4, 20, 0), at(4, 23, 0), ""),
+			}},
+			now:        at(4, 22, 30),
+			wantNext:   at(4, 23, 0),
+			wantFrozen: true,
+		},
+		{
+			name: "overlapping freezes covering windows",
+			spec: &werfv1alpha1.ScheduleConfig{Windows: weeknights, Freezes: []werfv1alpha1.FreezePeriod{
+				freeze(at(4, 0, 0), at(5, 12, 0), "first"),
+				freeze(at(5, 6, 0), at(7, 0, 0), "second"),
+			}},
+			now:        at(4, 10, 0),
+			wantNext:   at(9, 22, 0),
+			wantFrozen: true,
+			wantFreeze: "first",
+		},
+		{
+			name: "window that never opens",
+			spec: &werfv1alpha1.ScheduleConfig{Windows: []werfv1alpha1.DeploymentWindow{
+				{Start: "0 0 30 2 *", Duration: metav1.Duration{Duration: time.Hour}},
+			}},
+			now: at(4, 10, 0),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := New(tt.spec)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			decision := s.Check(tt.now)
+			if decision.Allowed != tt.wantAllow || !decision.Next.Equal(tt.wantNext) {
+				t.Errorf("Check() = allowed %v next %v, want allowed %v next %v",
+					decision.Allowed, decision.Next, tt.wantAllow, tt.wantNext)
+			}
+			if (decision.Freeze != nil) != tt.wantFrozen ||
+				(decision.Freeze != nil && decision.Freeze.Reason != tt.wantFreeze) {
+				t.Errorf("expected frozen %v with reason %q, got %+v", tt.wantFrozen, tt.wantFreeze, decision.Freeze)
+			}
+		})
+	}
+}
+
+func TestNew_Errors(t *testing.T) {
+	now := time.Date(2026, 3, 4, 10, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		spec    *werfv1alpha1.ScheduleConfig
+		wantErr string
+	}{
+		{
+			name:    "unknown time zone",
+			spec:    &werfv1alpha1.ScheduleConfig{TimeZone: "Mars/Olympus"},
+			wantErr: `unknown time zone "Mars/Olympus"`,
+		},
+		{
+			name: "invalid cron expression",
+			spec: &werfv1alpha1.ScheduleConfig{Windows: []werfv1alpha1.DeploymentWindow{
+				{Start: "0 25 * * *", Duration: metav1.Duration{Duration: time.Hour}},
+			}},
+			wantErr: "window 0: cron expression",
+		},
+		{
+			name: "zero duration",
+			spec: &werfv1alpha1.ScheduleConfig{Windows: []werfv1alpha1.DeploymentWindow{
+				{Start: "0 22 * * *"},
+			}},
+			wantErr: "window 0: duration must be positive",
+		},
+		{
+			name: "freeze ending before it starts",
+			spec: &werfv1alpha1.ScheduleConfig{Freezes: []werfv1alpha1.FreezePeriod{
+				{Start: metav1.NewTime(now), End: metav1.NewTime(now.Add(-time.Hour))},
+			}},
+			wantErr: "freeze 0: end",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(tt.spec)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("New() error = %v, want one containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
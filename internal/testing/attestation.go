@@ -0,0 +1,75 @@
+// This file contains helpers for attaching artifacts (SBOMs, provenance) to bundles as OCI
+// referrers, the way oras attach or cosign attest do.
+//
+// Example - Attach an SPDX SBOM to a bundle:
+//
+//	sbom := []byte(`{"spdxVersion":"SPDX-2.3"}`)
+//	if err := testing.AttachArtifact(ctx, repoURL, digest, "application/spdx+json", sbom, nil, auth); err != nil {
+//	    t.Fatalf("failed to attach SBOM: %v", err)
+//	}
+package testing
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/static"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+)
+
+// AttachArtifact pushes content as an artifact of artifactType whose subject is digest in
+// repoURL, with annotations on its manifest. The artifact type is the config media type, so
+// it's listed with the referrers API, or in the referrers tag (sha256-<hex>) of registries
+// without it. auth may be nil for anonymous access.
+func AttachArtifact(
+	ctx context.Context,
+	repoURL, digest, artifactType string,
+	content []byte,
+	annotations map[string]string,
+	auth authn.Authenticator,
+) error {
+	if auth == nil {
+		auth = authn.Anonymous
+	}
+	subjectRef, err := name.NewDigest(fmt.Sprintf("%s@%s", repoURL, digest))
+	if err != nil {
+		return fmt.Errorf("invalid subject reference: %w", err)
+	}
+	subject, err := remote.Head(subjectRef, remote.WithContext(ctx), remote.WithAuth(auth))
+	if err != nil {
+		return fmt.Errorf("failed to fetch subject: %w", err)
+	}
+
+	img, err := mutate.Append(empty.Image, mutate.Addendum{
+		Layer: static.NewLayer(content, types.MediaType(artifactType)),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to build artifact: %w", err)
+	}
+	img = mutate.MediaType(img, types.OCIManifestSchema1)
+	img = mutate.ConfigMediaType(img, types.MediaType(artifactType))
+	if len(annotations) > 0 {
+		img = mutate.Annotations(img, annotations).(v1.Image)
+	}
+	img = mutate.Subject(img, v1.Descriptor{
+		MediaType: subject.MediaType,
+		Digest:    subject.Digest,
+		Size:      subject.Size,
+	}).(v1.Image)
+
+	artifactDigest, err := img.Digest()
+	if err != nil {
+		return fmt.Errorf("failed to compute artifact digest: %w", err)
+	}
+	ref := subjectRef.Context().Digest(artifactDigest.String())
+	if err := remote.Write(ref, img, remote.WithContext(ctx), remote.WithAuth(auth)); err != nil {
+		return fmt.Errorf("failed to push artifact: %w", err)
+	}
+	return nil
+}
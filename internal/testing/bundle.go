@@ -0,0 +1,143 @@
+// This file contains helpers for building werf bundles, Helm charts in the OCI layout the way
+// werf bundle publish pushes them, so the operator's bundle checks can be tested against an
+// in-process registry.
+//
+// Example - Push a bundle for tag v1.0.0:
+//
+//	img, err := testing.BundleImage("app", "1.0.0")
+//	if err != nil {
+//	    t.Fatalf("failed to build bundle: %v", err)
+//	}
+//	if err := remote.Write(ref, img); err != nil {
+//	    t.Fatalf("failed to push bundle: %v", err)
+//	}
+package testing
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/static"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+)
+
+// Media types of a Helm chart in the OCI layout.
+const (
+	helmConfigMediaType       = "application/vnd.cncf.helm.config.v1+json"
+	helmChartContentMediaType = "application/vnd.cncf.helm.chart.content.v1.tar+gzip"
+)
+
+// BundleImage builds a werf bundle for the chart name and version: an OCI manifest with a Helm
+// chart config and a chart archive holding Chart.yaml. Bundles of different versions have
+// different digests.
+func BundleImage(name, version string) (v1.Image, error) {
+	config, err := json.Marshal(map[string]string{
+		"apiVersion": "v2",
+		"name":       name,
+		"version":    version,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode chart config: %w", err)
+	}
+
+	chartYAML := fmt.Sprintf("apiVersion: v2\nname: %s\nversion: %s\n", name, version)
+	var archive bytes.Buffer
+	gz := gzip.NewWriter(&archive)
+	tw := tar.NewWriter(gz)
+	if err := tw.WriteHeader(&tar.Header{
+		Name: name + "/Chart.yaml",
+		Mode: 0o644,
+		Size: int64(len(chartYAML)),
+	}); err != nil {
+		return nil, fmt.Errorf("failed to build chart archive: %w", err)
+	}
+	if _, err := tw.Write([]byte(chartYAML)); err != nil {
+		return nil, fmt.Errorf("failed to build chart archive: %w", err)
+	}
+	if err := tw.Close(); err != nil {
+		return nil, fmt.Errorf("failed to build chart archive: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return nil, fmt.Errorf("failed to build chart archive: %w", err)
+	}
+
+	img, err := mutate.Append(empty.Image, mutate.Addendum{
+		Layer: static.NewLayer(archive.Bytes(), helmChartContentMediaType),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to build bundle: %w", err)
+	}
+	img = mutate.MediaType(img, types.OCIManifestSchema1)
+	img = mutate.ConfigMediaType(img, helmConfigMediaType)
+	return withRawConfig(img, config), nil
+}
+
+// rawConfigImage is an image whose config blob is raw bytes rather than an image config.
+type rawConfigImage struct {
+	v1.Image
+	config []byte
+}
+
+// withRawConfig replaces the config blob of img, keeping its config media type.
+func withRawConfig(img v1.Image, config []byte) v1.Image {
+	return &rawConfigImage{Image: img, config: config}
+}
+
+func (i *rawConfigImage) RawConfigFile() ([]byte, error) {
+	return i.config, nil
+}
+
+func (i *rawConfigImage) ConfigName() (v1.Hash, error) {
+	hash, _, err := v1.SHA256(bytes.NewReader(i.config))
+	return hash, err
+}
+
+func (i *rawConfigImage) Manifest() (*v1.Manifest, error) {
+	manifest, err := i.Image.Manifest()
+	if err != nil {
+		return nil, err
+	}
+	manifest = manifest.DeepCopy()
+	hash, err := i.ConfigName()
+	if err != nil {
+		return nil, err
+	}
+	manifest.Config.Digest = hash
+	manifest.Config.Size = int64(len(i.config))
+	return manifest, nil
+}
+
+func (i *rawConfigImage) RawManifest() ([]byte, error) {
+	manifest, err := i.Manifest()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(manifest)
+}
+
+func (i *rawConfigImage) Digest() (v1.Hash, error) {
+	raw, err := i.RawManifest()
+	if err != nil {
+		return v1.Hash{}, err
+	}
+	hash, _, err := v1.SHA256(bytes.NewReader(raw))
+	return hash, err
+}
+
+func (i *rawConfigImage) Size() (int64, error) {
+	raw, err := i.RawManifest()
+	if err != nil {
+		return 0, err
+	}
+	return int64(len(raw)), nil
+}
+
+func (i *rawConfigImage) ConfigFile() (*v1.ConfigFile, error) {
+	return v1.ParseConfigFile(bytes.NewReader(i.config))
+}
@@ -0,0 +1,83 @@
+// This file contains helpers for testing exec credential provider plugins.
+//
+// Example - Provide credentials for a registry:
+//
+//	configPath, _ := testing.WriteFakeCredentialProvider(dir, "fake-provider",
+//		`{"kind":"CredentialProviderResponse","apiVersion":"credentialprovider.kubelet.k8s.io/v1",`+
+//			`"cacheKeyType":"Registry","auth":{"registry.example.com":{"username":"u","password":"p"}}}`,
+//		"registry.example.com")
+//	providers, _ := registry.LoadCredentialProviders(configPath, dir)
+//	images, _ := testing.CredentialProviderRequests(dir, "fake-provider")
+package testing
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// WriteFakeCredentialProvider writes a plugin named name to dir that answers every request with
+// response, or fails if response is empty, and a CredentialProviderConfig for it matching
+// matchImages. The plugin is a shell script that logs the requests it receives.
+// Returns the path of the config file.
+func WriteFakeCredentialProvider(dir, name, response string, matchImages ...string) (string, error) {
+	script := "#!/bin/sh\ncat >> \"$0.log\"\necho >> \"$0.log\"\n"
+	if response == "" {
+		script += "echo \"no credentials\" >&2\nexit 1\n"
+	} else {
+		script += "cat <<'EOF'\n" + response + "\nEOF\n"
+	}
+	// nolint:gosec
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(script), 0o755); err != nil {
+		return "", fmt.Errorf("failed to write plugin: %w", err)
+	}
+
+	config := map[string]any{
+		"apiVersion": "kubelet.config.k8s.io/v1",
+		"kind":       "CredentialProviderConfig",
+		"providers": []map[string]any{{
+			"name":        name,
+			"matchImages": matchImages,
+			"apiVersion":  "credentialprovider.kubelet.k8s.io/v1",
+		}},
+	}
+	data, err := json.Marshal(config)
+	if err != nil {
+		return "", err
+	}
+	configPath := filepath.Join(dir, name+"-config.json")
+	if err := os.WriteFile(configPath, data, 0o600); err != nil {
+		return "", fmt.Errorf("failed to write config: %w", err)
+	}
+	return configPath, nil
+}
+
+// CredentialProviderRequests returns the images of the requests received by the plugin written
+// by WriteFakeCredentialProvider, in order.
+func CredentialProviderRequests(dir, name string) ([]string, error) {
+	data, err := os.ReadFile(filepath.Join(dir, name+".log"))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var images []string
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		var request struct {
+			Kind  string `json:"kind"`
+			Image string `json:"image"`
+		}
+		if err := json.Unmarshal([]byte(line), &request); err != nil {
+			return nil, fmt.Errorf("invalid request %q: %w", line, err)
+		}
+		if request.Kind != "CredentialProviderRequest" {
+			return nil, fmt.Errorf("unexpected request kind %q", request.Kind)
+		}
+		images = append(images, request.Image)
+	}
+	return images, nil
+}
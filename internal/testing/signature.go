@@ -0,0 +1,101 @@
+// Package testing provides utilities for writing tests of the Werf operator.
+// This file contains helpers for signing bundles the way cosign does, so signature
+// verification can be tested against an in-process registry without the cosign CLI.
+//
+// Example - Sign a bundle and store the public key in a Secret:
+//
+//	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+//	if err := testing.SignBundle(ctx, repoURL, digest, key, auth); err != nil {
+//	    t.Fatalf("failed to sign bundle: %v", err)
+//	}
+//	pub, _ := testing.PublicKeyPEM(key)
+//	secret.Data = map[string][]byte{"cosign.pub": pub}
+package testing
+
+import (
+	"context"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/static"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+)
+
+// SignBundle signs digest in repoURL with key and pushes the signature to the registry
+// using the cosign layout: a manifest tagged sha256-<hex>.sig whose layer is the simple
+// signing payload, with the base64 signature in the layer annotation.
+// Signing again replaces earlier signatures for the digest.
+// key may be an ECDSA, RSA or Ed25519 private key; auth may be nil for anonymous access.
+func SignBundle(ctx context.Context, repoURL, digest string, key crypto.Signer, auth authn.Authenticator) error {
+	payload := []byte(fmt.Sprintf(
+		`{"critical":{"identity":{"docker-reference":%q},"image":{"docker-manifest-digest":%q},`+
+			`"type":"cosign container image signature"},"optional":null}`,
+		repoURL, digest))
+
+	var (
+		signature []byte
+		err       error
+	)
+	if _, ok := key.Public().(ed25519.PublicKey); ok {
+		signature, err = key.Sign(rand.Reader, payload, crypto.Hash(0))
+	} else {
+		hash := sha256.Sum256(payload)
+		signature, err = key.Sign(rand.Reader, hash[:], crypto.SHA256)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to sign payload: %w", err)
+	}
+
+	img, err := mutate.Append(empty.Image, mutate.Addendum{
+		Layer: static.NewLayer(payload, types.MediaType("application/vnd.dev.cosign.simplesigning.v1+json")),
+		Annotations: map[string]string{
+			"dev.cosignproject.cosign/signature": base64.StdEncoding.EncodeToString(signature),
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to build signature image: %w", err)
+	}
+	img = mutate.MediaType(img, types.OCIManifestSchema1)
+	img = mutate.ConfigMediaType(img, types.OCIConfigJSON)
+
+	return pushSignature(ctx, repoURL, digest, img, auth)
+}
+
+// pushSignature writes img to the cosign signature tag of digest.
+func pushSignature(ctx context.Context, repoURL, digest string, img v1.Image, auth authn.Authenticator) error {
+	tag := strings.Replace(digest, ":", "-", 1) + ".sig"
+	ref, err := name.NewTag(fmt.Sprintf("%s:%s", repoURL, tag))
+	if err != nil {
+		return fmt.Errorf("invalid signature reference: %w", err)
+	}
+	if auth == nil {
+		auth = authn.Anonymous
+	}
+	if err := remote.Write(ref, img, remote.WithContext(ctx), remote.WithAuth(auth)); err != nil {
+		return fmt.Errorf("failed to push signature: %w", err)
+	}
+	return nil
+}
+
+// PublicKeyPEM encodes the public half of key as a PEM "PUBLIC KEY" block,
+// the format of cosign.pub files.
+func PublicKeyPEM(key crypto.Signer) ([]byte, error) {
+	der, err := x509.MarshalPKIXPublicKey(key.Public())
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal public key: %w", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), nil
+}
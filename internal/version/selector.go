@@ -0,0 +1,171 @@
+// Package version selects which registry tag to deploy using semantic versioning.
+package version
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+// Options controls how a tag is selected from a registry tag list.
+type Options struct {
+	// Constraint is a semver constraint such as ">=1.2.0 <2.0.0" or "~1.4".
+	// Empty means any version.
+	Constraint string
+
+	// IncludePrerelease allows pre-release versions (e.g., 1.3.0-rc.1) to be selected.
+	// When false, pre-releases are skipped even if the constraint mentions one.
+	IncludePrerelease bool
+}
+
+// Selection is the outcome of SelectVersion.
+type Selection struct {
+	// Tag is the registry tag to deploy, or empty if no tag qualified.
+	Tag string
+
+	// Version is the semver version parsed from Tag (without "v" prefix),
+	// or empty if no tag qualified.
+	Version string
+
+	// Reason is a human-readable explanation of the choice, suitable for status.
+	Reason string
+}
+
+// Candidate is a registry tag and the value its version is parsed from.
+// Value differs from Tag when only part of the tag holds the version
+// (e.g., "1.2.3" extracted from "release-1.2.3-linux").
+type Candidate struct {
+	Tag   string
+	Value string
+}
+
+// SelectVersion picks the highest semver tag that satisfies opts.
+//
+// Tags are parsed as strict semver (MAJOR.MINOR.PATCH) with an optional "v" prefix;
+// other tags such as "latest" or "sha-abc" are ignored, even when no tag parses as semver:
+// ordering such tags as strings would deploy whichever sorts last (e.g., "latest" over
+// "build-2"), so bundles without semver tags must set a tagFilter.sortPolicy instead.
+//
+// Returns an error only if the constraint is invalid. When no tag qualifies, the returned
+// Selection has an empty Tag and a Reason explaining why.
+func SelectVersion(tags []string, opts Options) (*Selection, error) {
+	candidates := make([]Candidate, len(tags))
+	for i, tag := range tags {
+		candidates[i] = Candidate{Tag: tag, Value: tag}
+	}
+	return SelectCandidate(candidates, opts)
+}
+
+// SelectCandidate picks the candidate with the highest semver Value that satisfies opts.
+// Like SelectVersion, candidates whose Value isn't semver are ignored.
+func SelectCandidate(candidates []Candidate, opts Options) (*Selection, error) {
+	var constraint *semver.Constraints
+	if opts.Constraint != "" {
+		c, err := ParseConstraint(opts.Constraint)
+		if err != nil {
+			return nil, err
+		}
+		c.IncludePrerelease = opts.IncludePrerelease
+		constraint = c
+	}
+
+	if len(candidates) == 0 {
+		return &Selection{Reason: "no tags found in registry"}, nil
+	}
+
+	var (
+		bestTag    string
+		best       *semver.Version
+		semverTags int
+		skippedPre int
+		rejected   int
+	)
+	for _, c := range candidates {
+		v, ok := parseTag(c.Value)
+		if !ok {
+			continue
+		}
+		semverTags++
+
+		if v.Prerelease() != "" && !opts.IncludePrerelease {
+			skippedPre++
+			continue
+		}
+		if constraint != nil && !constraint.Check(v) {
+			rejected++
+			continue
+		}
+
+		// Equal precedence (e.g., v1.0.0 and 1.0.0, or differing build metadata):
+		// prefer the lexicographically greater tag so the choice is deterministic
+		if best == nil || v.GreaterThan(best) || (v.Equal(best) && c.Tag > bestTag) {
+			best = v
+			bestTag = c.Tag
+		}
+	}
+
+	ignored := len(candidates) - semverTags
+	details := describeSkipped(ignored, skippedPre, rejected)
+
+	if best != nil {
+		reason := fmt.Sprintf("highest semver version %s", best.String())
+		if constraint != nil {
+			reason += fmt.Sprintf(" satisfying %q", opts.Constraint)
+		}
+		return &Selection{Tag: bestTag, Version: best.String(), Reason: reason + details}, nil
+	}
+
+	if constraint != nil {
+		return &Selection{
+			Reason: fmt.Sprintf("no versions match constraint %q", opts.Constraint) + details,
+		}, nil
+	}
+	return &Selection{Reason: "no eligible semver versions" + details}, nil
+}
+
+// ParseConstraint parses a semver constraint, returning a descriptive error if it's invalid.
+func ParseConstraint(constraint string) (*semver.Constraints, error) {
+	c, err := semver.NewConstraint(constraint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid version constraint %q: %w", constraint, err)
+	}
+	return c, nil
+}
+
+// parseTag parses a tag as strict semver, allowing a single leading "v".
+// Strict parsing rejects partial versions such as "1" or "2024", which would otherwise
+// be coerced to 1.0.0 and 2024.0.0 and compete with real releases.
+func parseTag(tag string) (*semver.Version, bool) {
+	v, err := semver.StrictNewVersion(strings.TrimPrefix(tag, "v"))
+	if err != nil {
+		return nil, false
+	}
+	return v, true
+}
+
+// describeSkipped summarises tags that were not considered, e.g. " (ignored 2 non-semver tags)".
+func describeSkipped(nonSemver, prerelease, rejected int) string {
+	var parts []string
+	if nonSemver > 0 {
+		parts = append(parts, plural(nonSemver, "non-semver tag"))
+	}
+	if prerelease > 0 {
+		parts = append(parts, plural(prerelease, "pre-release"))
+	}
+	if rejected > 0 {
+		parts = append(parts, plural(rejected, "version")+" outside constraint")
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return " (ignored " + strings.Join(parts, ", ") + ")"
+}
+
+// plural formats n with noun, adding "s" when n != 1.
+func plural(n int, noun string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, noun)
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
@@ -0,0 +1,165 @@
+package version
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSelectVersion(t *testing.T) {
+	tests := []struct {
+		name        string
+		tags        []string
+		opts        Options
+		wantTag     string
+		wantVersion string
+		wantReason  string
+	}{
+		{
+			name:        "semver ordering beats lexicographic ordering",
+			tags:        []string{"v1.10.0", "v1.9.0", "v1.2.0"},
+			wantTag:     "v1.10.0",
+			wantVersion: "1.10.0",
+			wantReason:  "highest semver version 1.10.0",
+		},
+		{
+			name:        "non-semver tags are ignored",
+			tags:        []string{"latest", "sha-abc123", "v1.0.0", "v1.1.0"},
+			wantTag:     "v1.1.0",
+			wantVersion: "1.1.0",
+			wantReason:  "ignored 2 non-semver tags",
+		},
+		{
+			name:        "partial versions are not semver",
+			tags:        []string{"2024", "1", "v1.0.0"},
+			wantTag:     "v1.0.0",
+			wantVersion: "1.0.0",
+		},
+		{
+			name:        "tags without v prefix",
+			tags:        []string{"1.2.0", "1.3.0"},
+			wantTag:     "1.3.0",
+			wantVersion: "1.3.0",
+		},
+		{
+			name:        "range constraint",
+			tags:        []string{"v0.9.0", "v1.2.0", "v1.9.9", "v2.0.0", "v2.1.0"},
+			opts:        Options{Constraint: ">=1.2.0 <2.0.0"},
+			wantTag:     "v1.9.9",
+			wantVersion: "1.9.9",
+			wantReason:  `satisfying ">=1.2.0 <2.0.0" (ignored 3 versions outside constraint)`,
+		},
+		{
+			name:        "tilde constraint",
+			tags:        []string{"v1.3.9", "v1.4.0", "v1.4.7", "v1.5.0"},
+			opts:        Options{Constraint: "~1.4"},
+			wantTag:     "v1.4.7",
+			wantVersion: "1.4.7",
+		},
+		{
+			name:        "caret constraint",
+			tags:        []string{"v1.0.0", "v1.8.2", "v2.0.0"},
+			opts:        Options{Constraint: "^1.0.0"},
+			wantTag:     "v1.8.2",
+			wantVersion: "1.8.2",
+		},
+		{
+			name:        "pre-releases skipped by default",
+			tags:        []string{"v1.0.0", "v1.1.0-rc.1"},
+			wantTag:     "v1.0.0",
+			wantVersion: "1.0.0",
+			wantReason:  "ignored 1 pre-release",
+		},
+		{
+			name:       "pre-releases skipped even when constraint mentions one",
+			tags:       []string{"v1.0.0", "v1.1.0-rc.1"},
+			opts:       Options{Constraint: ">=1.1.0-rc.0"},
+			wantReason: "no versions match constraint",
+		},
+		{
+			name:        "pre-releases included when allowed",
+			tags:        []string{"v1.0.0", "v1.1.0-rc.1"},
+			opts:        Options{IncludePrerelease: true},
+			wantTag:     "v1.1.0-rc.1",
+			wantVersion: "1.1.0-rc.1",
+		},
+		{
+			name:        "pre-releases included with constraint when allowed",
+			tags:        []string{"v1.0.0", "v1.1.0-rc.1", "v2.0.0-rc.1"},
+			opts:        Options{Constraint: "<2.0.0-0", IncludePrerelease: true},
+			wantTag:     "v1.1.0-rc.1",
+			wantVersion: "1.1.0-rc.1",
+		},
+		{
+			name:        "release beats its own pre-release",
+			tags:        []string{"v1.1.0-rc.1", "v1.1.0"},
+			opts:        Options{IncludePrerelease: true},
+			wantTag:     "v1.1.0",
+			wantVersion: "1.1.0",
+		},
+		{
+			name:        "equal versions pick lexicographically greater tag",
+			tags:        []string{"1.0.0", "v1.0.0"},
+			wantTag:     "v1.0.0",
+			wantVersion: "1.0.0",
+		},
+		{
+			name:       "no versions match constraint",
+			tags:       []string{"v1.0.0", "v1.1.0"},
+			opts:       Options{Constraint: ">=2.0.0"},
+			wantReason: `no versions match constraint ">=2.0.0" (ignored 2 versions outside constraint)`,
+		},
+		{
+			name:       "constraint with only non-semver tags selects nothing",
+			tags:       []string{"latest", "main"},
+			opts:       Options{Constraint: ">=1.0.0"},
+			wantReason: "no versions match constraint",
+		},
+		{
+			name:       "no constraint and no semver tags selects nothing",
+			tags:       []string{"build-1", "build-2", "latest"},
+			wantReason: "no eligible semver versions (ignored 3 non-semver tags)",
+		},
+		{
+			name:       "only pre-releases without opt-in selects nothing",
+			tags:       []string{"v1.0.0-alpha", "v1.0.0-beta"},
+			wantReason: "no eligible semver versions (ignored 2 pre-releases)",
+		},
+		{
+			name:       "empty tag list",
+			tags:       nil,
+			opts:       Options{Constraint: ">=1.0.0"},
+			wantReason: "no tags found in registry",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := SelectVersion(tt.tags, tt.opts)
+			if err != nil {
+				t.Fatalf("SelectVersion() error = %v", err)
+			}
+			if got.Tag != tt.wantTag {
+				t.Errorf("Tag = %q, want %q (reason: %s)", got.Tag, tt.wantTag, got.Reason)
+			}
+			if got.Version != tt.wantVersion {
+				t.Errorf("Version = %q, want %q", got.Version, tt.wantVersion)
+			}
+			if got.Reason == "" {
+				t.Error("Reason is empty")
+			}
+			if !strings.Contains(got.Reason, tt.wantReason) {
+				t.Errorf("Reason = %q, want it to contain %q", got.Reason, tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestSelectVersion_InvalidConstraint(t *testing.T) {
+	_, err := SelectVersion([]string{"v1.0.0"}, Options{Constraint: ">=not-a-version"})
+	if err == nil {
+		t.Fatal("expected error for invalid constraint")
+	}
+	if !strings.Contains(err.Error(), ">=not-a-version") {
+		t.Errorf("expected error to include the constraint, got %v", err)
+	}
+}